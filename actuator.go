@@ -0,0 +1,77 @@
+package pidctrl
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Actuator applies controller outputs, e.g. a PWM driver or a valve. Write
+// returns the output actually applied, which differs from the commanded one
+// when the hardware clamps, quantizes or rate limits it.
+type Actuator interface {
+	Write(output float64) (actual float64, err error)
+}
+
+// ActuatorFunc adapts a function to the Actuator interface.
+type ActuatorFunc func(output float64) (actual float64, err error)
+
+// Write implements Actuator.
+func (f ActuatorFunc) Write(output float64) (float64, error) {
+	return f(output)
+}
+
+// Applied reports the output the actuator actually applied after the last
+// update. If it differs from the commanded output, the integral is corrected
+// by back-calculation so that the last update would have produced it, which
+// stops windup against limits the controller does not know about, and the
+// applied value becomes the held output for bumpless transfer and the
+// disturbance observer. Non-finite values are ignored.
+func (c *PIDController) Applied(actual float64) *PIDController {
+	if actual == c.output || math.IsNaN(actual) || math.IsInf(actual, 0) {
+		return c
+	}
+	c.track(actual)
+	c.output = actual
+	return c
+}
+
+// RunActuator is like Run, but writes the output to a and reports the
+// applied value back with Applied. It returns the first error of a, or
+// ctx.Err().
+func (c *PIDController) RunActuator(ctx context.Context, interval time.Duration, read func() float64, a Actuator) error {
+	return runActuator(ctx, interval, read, a, c.UpdateDuration, func(v float64) { c.Applied(v) })
+}
+
+// Applied reports the output the actuator actually applied after the last
+// update.
+func (s *SafePIDController) Applied(actual float64) *SafePIDController {
+	s.mu.Lock()
+	s.c.Applied(actual)
+	s.mu.Unlock()
+	return s
+}
+
+// RunActuator is the locked counterpart of PIDController.RunActuator.
+func (s *SafePIDController) RunActuator(ctx context.Context, interval time.Duration, read func() float64, a Actuator) error {
+	return runActuator(ctx, interval, read, a, s.UpdateDuration, func(v float64) { s.Applied(v) })
+}
+
+func runActuator(ctx context.Context, interval time.Duration, read func() float64, a Actuator, update func(float64, time.Duration) float64, applied func(float64)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var werr error
+	err := tick(ctx, interval, func(dt time.Duration) {
+		actual, err := a.Write(update(read(), dt))
+		if err != nil {
+			werr = err
+			cancel()
+			return
+		}
+		applied(actual)
+	})
+	if werr != nil {
+		return werr
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+package pidctrl
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPIDController_Applied(t *testing.T) {
+	// the actuator saturates at 2, unknown to the controller
+	c := NewPIDController(1, 1, 0).Set(10)
+	out := c.UpdateDuration(5, time.Second)
+	if out != 10 {
+		t.Fatalf("unexpected output %v", out)
+	}
+	c.Applied(2)
+	if c.Output() != 2 || c.Integral() != -3 {
+		t.Errorf("integral not back-calculated: output %v integral %v", c.Output(), c.Integral())
+	}
+	c.Applied(math.NaN())
+	if c.Output() != 2 {
+		t.Error("non-finite applied value accepted")
+	}
+
+	// once the process reaches the setpoint the output drops immediately
+	// instead of unwinding an integral accumulated against the hidden limit
+	if out := c.UpdateDuration(10, time.Second); out != -3 {
+		t.Errorf("output after back-calculation: %v", out)
+	}
+}
+
+func TestPIDController_RunActuator(t *testing.T) {
+	c := NewPIDController(0, 1, 0).Set(1)
+	var (
+		writes int
+		last   float64
+	)
+	a := ActuatorFunc(func(v float64) (float64, error) {
+		writes++
+		// each update integrates whole intervals on top of the applied value
+		if ticks := (v - last) / 0.001; ticks < 0.999 || math.Abs(ticks-math.Round(ticks)) > 1e-6 {
+			t.Errorf("write %d: integral %v did not start from the applied value %v", writes, v, last)
+		}
+		if writes == 5 {
+			return 0, errors.New("bus error")
+		}
+		last = v / 2
+		return last, nil
+	})
+	err := c.RunActuator(context.Background(), time.Millisecond, func() float64 { return 0 }, a)
+	if err == nil || err.Error() != "bus error" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if writes != 5 {
+		t.Errorf("writes after error: %d", writes)
+	}
+}
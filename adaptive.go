@@ -0,0 +1,121 @@
+package pidctrl
+
+import (
+	"math"
+	"time"
+)
+
+// AdaptiveTuner retunes a PI controller online. It estimates a first order
+// process model y[k] = a·y[k-1] + b·u[k-1] + c from the controller output
+// and the process value with recursive least squares and moves the gains
+// towards the lambda tuning of the model, Kp = τ/(K·λ) and Ki = Kp/τ, where
+// K is the process gain, τ its time constant and λ the desired closed-loop
+// time constant. Gains change by at most MaxChange per minute, so noise and
+// poor excitation cannot upset the loop.
+type AdaptiveTuner struct {
+	Controller *PIDController
+	ClosedLoop time.Duration // desired closed-loop time constant λ
+	// Forgetting is the RLS forgetting factor in (0, 1]; smaller values
+	// follow drifting dynamics faster. The default is 0.99.
+	Forgetting float64
+	// MaxChange is the maximum relative change of each gain per minute, the
+	// default is 0.1.
+	MaxChange float64
+	Enabled   bool // false only estimates the model
+
+	theta   [3]float64    // a, b, c
+	cov     [3][3]float64 // parameter covariance
+	prevY   float64
+	prevU   float64
+	started bool
+}
+
+// NewAdaptiveTuner returns an enabled AdaptiveTuner for c.
+func NewAdaptiveTuner(c *PIDController, closedLoop time.Duration) *AdaptiveTuner {
+	t := &AdaptiveTuner{Controller: c, ClosedLoop: closedLoop, Forgetting: 0.99, MaxChange: 0.1, Enabled: true}
+	t.Reset()
+	return t
+}
+
+// Reset discards the model estimate.
+func (t *AdaptiveTuner) Reset() *AdaptiveTuner {
+	t.theta, t.cov, t.started = [3]float64{}, [3][3]float64{}, false
+	for i := range t.cov {
+		t.cov[i][i] = 1e4
+	}
+	return t
+}
+
+// Model returns the gain and time constant of the estimated process model
+// for updates of duration dt, and false while the estimate is not a stable
+// first order process.
+func (t *AdaptiveTuner) Model(dt time.Duration) (gain float64, tau time.Duration, ok bool) {
+	a, b := t.theta[0], t.theta[1]
+	if !(a > 0 && a < 1) || b == 0 {
+		return 0, 0, false
+	}
+	return b / (1 - a), time.Duration(-dt.Seconds() / math.Log(a) * float64(time.Second)), true
+}
+
+// UpdateDuration refines the model, retunes the controller if enabled and
+// returns its output for value.
+func (t *AdaptiveTuner) UpdateDuration(value float64, duration time.Duration) float64 {
+	if t.started && duration > 0 {
+		t.estimate(value)
+		if t.Enabled {
+			t.retune(duration)
+		}
+	}
+	out := t.Controller.UpdateDuration(value, duration)
+	t.prevY, t.prevU, t.started = value, out, true
+	return out
+}
+
+// estimate performs a recursive least squares step for the measurement y.
+func (t *AdaptiveTuner) estimate(y float64) {
+	phi := [3]float64{t.prevY, t.prevU, 1}
+	var pphi [3]float64
+	denom := t.Forgetting
+	for i := range phi {
+		for j := range phi {
+			pphi[i] += t.cov[i][j] * phi[j]
+		}
+		denom += phi[i] * pphi[i]
+	}
+	e := y
+	for i := range phi {
+		e -= t.theta[i] * phi[i]
+	}
+	for i := range phi {
+		t.theta[i] += pphi[i] / denom * e
+	}
+	for i := range phi {
+		for j := range phi {
+			t.cov[i][j] = (t.cov[i][j] - pphi[i]*pphi[j]/denom) / t.Forgetting
+		}
+	}
+}
+
+// retune moves the controller gains towards the lambda tuning of the model.
+func (t *AdaptiveTuner) retune(duration time.Duration) {
+	gain, tau, ok := t.Model(duration)
+	if !ok || gain <= 0 || t.ClosedLoop <= 0 {
+		return
+	}
+	kp := tau.Seconds() / (gain * t.ClosedLoop.Seconds())
+	ki := kp / tau.Seconds()
+	max := t.MaxChange * duration.Minutes()
+	p, i, d := t.Controller.PID()
+	t.Controller.SetPID(approachGain(p, kp, max), approachGain(i, ki, max), d)
+}
+
+// approachGain moves the gain g towards target by at most the fraction max
+// of g, or of target for a zero gain.
+func approachGain(g, target, max float64) float64 {
+	base := math.Abs(g)
+	if base == 0 {
+		base = math.Abs(target)
+	}
+	step := max * base
+	return g + math.Max(-step, math.Min(step, target-g))
+}
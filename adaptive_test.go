@@ -0,0 +1,50 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveTuner(t *testing.T) {
+	const (
+		gain = 2.0
+		tau  = 20.0
+		dt   = time.Second
+	)
+	c := NewPIDController(0.2, 0.01, 0).SetOutputLimits(0, 100)
+	tuner := NewAdaptiveTuner(c, 10*time.Second)
+	tuner.MaxChange = 1
+	var y float64
+	for k := 0; k < 3600; k++ {
+		// excite the loop with setpoint steps
+		c.Set(float64(20 + 10*(k/300%2)))
+		u := tuner.UpdateDuration(y, dt)
+		y += (gain*u - y) * dt.Seconds() / tau
+	}
+	g, tc, ok := tuner.Model(dt)
+	if !ok || math.Abs(g-gain) > 0.05 || math.Abs(tc.Seconds()-tau) > 1 {
+		t.Errorf("model gain %v, tau %v, ok %v", g, tc, ok)
+	}
+	p, i, _ := c.PID()
+	if math.Abs(p-tc.Seconds()/(g*10)) > 0.01 || math.Abs(i-p/tc.Seconds()) > 0.001 {
+		t.Errorf("gains %v %v", p, i)
+	}
+}
+
+func TestAdaptiveTuner_RateLimit(t *testing.T) {
+	c := NewPIDController(1, 0.1, 0)
+	tuner := NewAdaptiveTuner(c, time.Second)
+	tuner.theta = [3]float64{0.5, 1, 0}
+	tuner.retune(time.Minute / 10)
+	if p, _, _ := c.PID(); math.Abs(p-1.01) > 1e-12 {
+		t.Errorf("gain changed by %v in 6s", p-1)
+	}
+	tuner.Enabled = false
+	c.SetPID(1, 0.1, 0)
+	tuner.started = true
+	tuner.UpdateDuration(0, time.Second)
+	if p, _, _ := c.PID(); p != 1 {
+		t.Errorf("disabled tuner changed the gain to %v", p)
+	}
+}
@@ -0,0 +1,53 @@
+package pidctrl
+
+import "math"
+
+// SetApproachGains enables a two-stage approach mode: while the absolute
+// error exceeds band the controller uses the given aggressive gains, inside
+// the band it uses the normal gains set with SetPID. Switching between the
+// two sets is bumpless, the integral absorbs the difference in the P and D
+// terms. A band of 0 disables the approach mode.
+func (c *PIDController) SetApproachGains(p, i, d, band float64) *PIDController {
+	c.approach = [3]float64{p, i, d}
+	c.band = math.Abs(band)
+	if c.band == 0 {
+		c.approaching = false
+	}
+	return c
+}
+
+// ApproachGains returns the aggressive gains and the band of the approach
+// mode.
+func (c *PIDController) ApproachGains() (p, i, d, band float64) {
+	return c.approach[0], c.approach[1], c.approach[2], c.band
+}
+
+// Approaching returns true if the aggressive approach gains were used on the
+// last update.
+func (c *PIDController) Approaching() bool {
+	return c.approaching
+}
+
+// gains returns the gains to use for the given error, proportional error and
+// derivative input, compensating the integral when the gain set changes.
+func (c *PIDController) gains(err, pErr, d float64) (kp, ki, kd float64) {
+	if c.band == 0 {
+		return c.normalGains(err, pErr, d)
+	}
+	approaching := math.Abs(err) > c.band
+	if approaching != c.approaching {
+		p, _, kd := c.normalGains(err, pErr, d)
+		oldP, oldD, newP, newD := p, kd, c.approach[0], c.approach[2]
+		if c.approaching {
+			oldP, oldD, newP, newD = newP, newD, oldP, oldD
+		}
+		if c.started {
+			c.integral += (oldP-newP)*pErr + (oldD-newD)*d
+		}
+		c.approaching = approaching
+	}
+	if approaching {
+		return c.approach[0], c.approach[1], c.approach[2]
+	}
+	return c.normalGains(err, pErr, d)
+}
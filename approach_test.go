@@ -0,0 +1,21 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApproachGains(t *testing.T) {
+	c := NewPIDController(1, 0, 0).SetApproachGains(4, 0, 0, 5).Set(20)
+	if out := c.UpdateDuration(10, time.Second); out != 40 || !c.Approaching() {
+		t.Errorf("aggressive stage: %f != 40", out)
+	}
+	// entering the band keeps the output continuous at the switch
+	if out := c.UpdateDuration(16, time.Second); out != 16 || c.Approaching() {
+		t.Errorf("switch to conservative stage: %f != 16", out)
+	}
+	// afterwards the conservative gain acts on error changes
+	if out := c.UpdateDuration(17, time.Second); out != 15 {
+		t.Errorf("conservative stage: %f != 15", out)
+	}
+}
@@ -0,0 +1,150 @@
+package pidctrl
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrUnknownSource is returned by SetpointArbiter.Command for sources that
+// were not registered.
+var ErrUnknownSource = errors.New("unknown setpoint source")
+
+// SetpointSource is a source of setpoint commands of a SetpointArbiter, e.g.
+// the local API, MQTT, a schedule or a safety override.
+type SetpointSource struct {
+	Name     string
+	Priority int // the current source with the highest priority wins
+	// Timeout expires a command that was not renewed within it, so that a
+	// source losing connectivity falls back to lower priority ones. 0 keeps
+	// commands until they are released.
+	Timeout time.Duration
+	// Func optionally polls the source on every Apply instead of receiving
+	// commands; it returns false while the source has no setpoint. E.g. a
+	// Scheduler's Setpoint.
+	Func func() (float64, bool)
+}
+
+// SetpointArbiter sets the setpoint of a controller from the highest
+// priority of several sources with a current command. Commands may be sent
+// from any goroutine, Apply is called from the control loop.
+type SetpointArbiter struct {
+	Controller *PIDController
+
+	mu       sync.Mutex
+	sources  []SetpointSource
+	commands map[string]setpointCommand
+	clock    Clock
+}
+
+type setpointCommand struct {
+	setpoint float64
+	at       time.Time
+}
+
+// NewSetpointArbiter returns a SetpointArbiter for the controller c. Sources
+// of equal priority are preferred in the given order.
+func NewSetpointArbiter(c *PIDController, sources ...SetpointSource) *SetpointArbiter {
+	return &SetpointArbiter{Controller: c, sources: sources, commands: map[string]setpointCommand{}}
+}
+
+// SetClock changes the time source used for command timeouts. Passing nil
+// restores the real clock.
+func (a *SetpointArbiter) SetClock(clock Clock) *SetpointArbiter {
+	a.mu.Lock()
+	a.clock = clock
+	a.mu.Unlock()
+	return a
+}
+
+func (a *SetpointArbiter) now() time.Time {
+	if a.clock != nil {
+		return a.clock.Now()
+	}
+	return time.Now()
+}
+
+// Command sets or renews the setpoint commanded by the named source.
+func (a *SetpointArbiter) Command(source string, setpoint float64) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.find(source); !ok {
+		return ErrUnknownSource
+	}
+	a.commands[source] = setpointCommand{setpoint: setpoint, at: a.now()}
+	return nil
+}
+
+// Release withdraws the command of the named source, e.g. when a safety
+// override ends.
+func (a *SetpointArbiter) Release(source string) {
+	a.mu.Lock()
+	delete(a.commands, source)
+	a.mu.Unlock()
+}
+
+// Active returns the winning source and its setpoint, and false if no
+// source has a current command.
+func (a *SetpointArbiter) Active() (source string, setpoint float64, ok bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.active()
+}
+
+// Apply sets the setpoint of the controller to that of the winning source
+// and returns the setpoint and whether it was changed. Without any current
+// command the setpoint is left alone.
+func (a *SetpointArbiter) Apply() (float64, bool) {
+	a.mu.Lock()
+	_, setpoint, ok := a.active()
+	a.mu.Unlock()
+	if !ok || setpoint == a.Controller.Get() {
+		return a.Controller.Get(), false
+	}
+	a.Controller.Set(setpoint)
+	return setpoint, true
+}
+
+func (a *SetpointArbiter) find(name string) (SetpointSource, bool) {
+	for _, s := range a.sources {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return SetpointSource{}, false
+}
+
+// active returns the winning source. Expired commands are dropped.
+func (a *SetpointArbiter) active() (string, float64, bool) {
+	var (
+		best     *SetpointSource
+		setpoint float64
+		now      = a.now()
+	)
+	for i := range a.sources {
+		s := &a.sources[i]
+		if best != nil && s.Priority <= best.Priority {
+			continue
+		}
+		var v float64
+		var ok bool
+		if s.Func != nil {
+			v, ok = s.Func()
+		} else {
+			var cmd setpointCommand
+			cmd, ok = a.commands[s.Name]
+			if ok && s.Timeout > 0 && now.Sub(cmd.at) > s.Timeout {
+				delete(a.commands, s.Name)
+				ok = false
+			}
+			v = cmd.setpoint
+		}
+		if ok {
+			best, setpoint = s, v
+		}
+	}
+	if best == nil {
+		return "", 0, false
+	}
+	return best.Name, setpoint, true
+}
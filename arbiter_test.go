@@ -0,0 +1,58 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetpointArbiter(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	schedule := 18.0
+	c := NewPIDController(1, 0, 0).Set(15)
+	a := NewSetpointArbiter(c,
+		SetpointSource{Name: "schedule", Func: func() (float64, bool) { return schedule, true }},
+		SetpointSource{Name: "local", Priority: 1},
+		SetpointSource{Name: "mqtt", Priority: 1, Timeout: time.Minute},
+		SetpointSource{Name: "safety", Priority: 10},
+	).SetClock(clock)
+	check := func(source string, setpoint float64) {
+		t.Helper()
+		if got, changed := a.Apply(); got != setpoint || c.Get() != setpoint {
+			t.Errorf("setpoint %v (changed %v), want %v", got, changed, setpoint)
+		}
+		if s, _, _ := a.Active(); s != source {
+			t.Errorf("active source %q, want %q", s, source)
+		}
+	}
+	check("schedule", 18)
+	if err := a.Command("mqtt", 21); err != nil {
+		t.Fatal(err)
+	}
+	check("mqtt", 21)
+	a.Command("local", 20)
+	check("local", 20) // registered first
+	a.Release("local")
+	check("mqtt", 21)
+	a.Command("safety", 5)
+	check("safety", 5)
+	a.Release("safety")
+	clock.Advance(2 * time.Minute)
+	check("schedule", 18) // mqtt command expired
+	if _, changed := a.Apply(); changed {
+		t.Error("unchanged setpoint reported as changed")
+	}
+	if err := a.Command("modbus", 1); err != ErrUnknownSource {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestSetpointArbiter_NoCommand(t *testing.T) {
+	c := NewPIDController(1, 0, 0).Set(15)
+	a := NewSetpointArbiter(c, SetpointSource{Name: "local"})
+	if setpoint, changed := a.Apply(); setpoint != 15 || changed {
+		t.Errorf("Apply() = %v, %v without commands", setpoint, changed)
+	}
+	if _, _, ok := a.Active(); ok {
+		t.Error("active source without commands")
+	}
+}
@@ -0,0 +1,88 @@
+package pidctrl
+
+import (
+	"math"
+	"time"
+)
+
+// ControllerArray is a packed structure-of-arrays of plain PID controllers
+// for simulating thousands of loops, e.g. in a digital twin. Each field is a
+// contiguous slice indexed by loop, and UpdateAll walks them in tight loops
+// the compiler can keep in registers and vectorize. The loops behave like a
+// PIDController with only gains, setpoint and output limits configured.
+//
+// The exported slices hold the configuration and may be written directly;
+// their length must not change.
+type ControllerArray struct {
+	P, I, D        []float64
+	Setpoint       []float64
+	OutMin, OutMax []float64
+
+	integral  []float64
+	prevValue []float64
+}
+
+// NewControllerArray returns an array of n loops with zero gains and
+// unbounded outputs.
+func NewControllerArray(n int) *ControllerArray {
+	a := &ControllerArray{
+		P: make([]float64, n), I: make([]float64, n), D: make([]float64, n),
+		Setpoint: make([]float64, n),
+		OutMin:   make([]float64, n), OutMax: make([]float64, n),
+		integral: make([]float64, n), prevValue: make([]float64, n),
+	}
+	for k := range a.OutMin {
+		a.OutMin[k], a.OutMax[k] = math.Inf(-1), math.Inf(1)
+	}
+	return a
+}
+
+// Len returns the number of loops.
+func (a *ControllerArray) Len() int {
+	return len(a.P)
+}
+
+// Integral returns the integral of loop k.
+func (a *ControllerArray) Integral(k int) float64 {
+	return a.integral[k]
+}
+
+// Reset clears the state of all loops.
+func (a *ControllerArray) Reset() *ControllerArray {
+	for k := range a.integral {
+		a.integral[k], a.prevValue[k] = 0, 0
+	}
+	return a
+}
+
+// UpdateAll updates loop k with values[k] and the given duration and
+// writes its output to out[k]. It panics if values or out are shorter than
+// the array.
+func (a *ControllerArray) UpdateAll(values []float64, duration time.Duration, out []float64) {
+	n := len(a.P)
+	var (
+		dt       = duration.Seconds()
+		p, i, d  = a.P[:n], a.I[:n], a.D[:n]
+		sp       = a.Setpoint[:n]
+		min, max = a.OutMin[:n], a.OutMax[:n]
+		integral = a.integral[:n]
+		prev     = a.prevValue[:n]
+		v, o     = values[:n], out[:n]
+	)
+	for k := range integral {
+		integral[k] = math.Max(min[k], math.Min(max[k], integral[k]+(sp[k]-v[k])*dt*i[k]))
+	}
+	if dt > 0 {
+		for k := range o {
+			o[k] = -(v[k] - prev[k]) / dt * d[k]
+		}
+	} else {
+		for k := range o {
+			o[k] = 0
+		}
+	}
+	for k := range o {
+		o[k] = math.Max(min[k], math.Min(max[k], p[k]*(sp[k]-v[k])+integral[k]+o[k]))
+	}
+	copy(prev, v)
+}
@@ -0,0 +1,52 @@
+package pidctrl
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestControllerArray(t *testing.T) {
+	const n = 16
+	rng := rand.New(rand.NewSource(1))
+	a := NewControllerArray(n)
+	ref := make([]*PIDController, n)
+	for k := 0; k < n; k++ {
+		a.P[k], a.I[k], a.D[k] = rng.Float64(), rng.Float64(), rng.Float64()
+		a.Setpoint[k] = rng.Float64() * 100
+		ref[k] = NewPIDController(a.P[k], a.I[k], a.D[k]).Set(a.Setpoint[k])
+		if k%2 == 0 {
+			a.OutMin[k], a.OutMax[k] = 0, 50
+			ref[k].SetOutputLimits(0, 50)
+		}
+	}
+	values, out := make([]float64, n), make([]float64, n)
+	for step := 0; step < 50; step++ {
+		for k := range values {
+			values[k] = rng.Float64() * 100
+		}
+		a.UpdateAll(values, 100*time.Millisecond, out)
+		for k, c := range ref {
+			if want := c.UpdateDuration(values[k], 100*time.Millisecond); out[k] != want {
+				t.Fatalf("step %d, loop %d: %v != %v", step, k, out[k], want)
+			}
+		}
+	}
+	if allocs := testing.AllocsPerRun(10, func() { a.UpdateAll(values, time.Millisecond, out) }); allocs != 0 {
+		t.Errorf("%v allocations per update", allocs)
+	}
+}
+
+func BenchmarkControllerArray(b *testing.B) {
+	const n = 4096
+	a := NewControllerArray(n)
+	values, out := make([]float64, n), make([]float64, n)
+	for k := range values {
+		a.P[k], a.I[k], a.D[k], a.Setpoint[k] = 0.5, 0.25, 0.1, 42
+		values[k] = float64(k % 100)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		a.UpdateAll(values, time.Millisecond, out)
+	}
+}
@@ -0,0 +1,48 @@
+package pidctrl
+
+// SetNegativeGains enables asymmetric gains: while the error is negative,
+// i.e. the process value is above the setpoint, the controller uses the
+// given gains instead of the ones set with SetPID. A chamber that heats fast
+// but cools slowly can so be tuned for both directions. Switching between
+// the two sets is bumpless like the approach mode.
+func (c *PIDController) SetNegativeGains(p, i, d float64) *PIDController {
+	c.negGains = [3]float64{p, i, d}
+	c.asymmetric = true
+	return c
+}
+
+// ClearNegativeGains uses the gains set with SetPID for both error signs
+// again.
+func (c *PIDController) ClearNegativeGains() *PIDController {
+	c.asymmetric, c.negative = false, false
+	return c
+}
+
+// NegativeGains returns the gains used for negative errors and whether they
+// are enabled.
+func (c *PIDController) NegativeGains() (p, i, d float64, ok bool) {
+	return c.negGains[0], c.negGains[1], c.negGains[2], c.asymmetric
+}
+
+// normalGains returns the gains set for the sign of err, compensating the
+// integral when the set changes.
+func (c *PIDController) normalGains(err, pErr, d float64) (kp, ki, kd float64) {
+	if !c.asymmetric {
+		return c.p, c.i, c.d
+	}
+	negative := err < 0 || (err == 0 && c.negative)
+	if negative != c.negative {
+		old, cur := [3]float64{c.p, c.i, c.d}, c.negGains
+		if c.negative {
+			old, cur = cur, old
+		}
+		if c.started && !c.approaching {
+			c.integral += (old[0]-cur[0])*pErr + (old[2]-cur[2])*d
+		}
+		c.negative = negative
+	}
+	if negative {
+		return c.negGains[0], c.negGains[1], c.negGains[2]
+	}
+	return c.p, c.i, c.d
+}
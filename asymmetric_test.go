@@ -0,0 +1,24 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNegativeGains(t *testing.T) {
+	c := NewPIDController(2, 0, 0).SetNegativeGains(0.5, 0, 0).Set(10)
+	if out := c.UpdateDuration(5, time.Second); out != 10 {
+		t.Errorf("positive error: output %v != 10", out)
+	}
+	// the switch to the negative set is bumpless, the integral absorbs the
+	// difference of the P terms
+	if out := c.UpdateDuration(14, time.Second); out != -8 {
+		t.Errorf("negative error: output %v != -8", out)
+	}
+	if out := c.UpdateDuration(18, time.Second); out != -4-6 {
+		t.Errorf("negative error: output %v != -10", out)
+	}
+	if _, _, _, ok := c.ClearNegativeGains().NegativeGains(); ok {
+		t.Error("negative gains still enabled")
+	}
+}
@@ -0,0 +1,71 @@
+package pidctrl
+
+import "time"
+
+// ControllerBank manages many independent loops with a shared clock, e.g.
+// the zone temperature loops of a building. The controllers are stored
+// contiguously, so batch updates walk memory linearly.
+type ControllerBank struct {
+	controllers []PIDController
+	clock       Clock
+	lastUpdate  time.Time
+}
+
+// NewControllerBank returns a bank of n controllers using the given gain
+// values. Controllers are configured individually through Controller.
+func NewControllerBank(n int, p, i, d float64) *ControllerBank {
+	b := &ControllerBank{controllers: make([]PIDController, n)}
+	for k := range b.controllers {
+		b.controllers[k] = *NewPIDController(p, i, d)
+	}
+	return b
+}
+
+// Len returns the number of controllers.
+func (b *ControllerBank) Len() int {
+	return len(b.controllers)
+}
+
+// Controller returns the controller at index i. The pointer stays valid for
+// the lifetime of the bank.
+func (b *ControllerBank) Controller(i int) *PIDController {
+	return &b.controllers[i]
+}
+
+// SetClock changes the time source used by UpdateAll. Passing nil restores
+// the real clock.
+func (b *ControllerBank) SetClock(clock Clock) *ControllerBank {
+	b.clock = clock
+	return b
+}
+
+// UpdateAll is identical to UpdateAllDuration, but automatically keeps track
+// of the duration between updates of the whole bank. It returns a new slice
+// of outputs.
+func (b *ControllerBank) UpdateAll(values []float64) []float64 {
+	now := time.Now()
+	if b.clock != nil {
+		now = b.clock.Now()
+	}
+	var duration time.Duration
+	if !b.lastUpdate.IsZero() {
+		duration = now.Sub(b.lastUpdate)
+	}
+	b.lastUpdate = now
+	return b.UpdateAllDuration(values, duration, nil)
+}
+
+// UpdateAllDuration updates controller k with values[k] and the given
+// duration and returns the outputs appended to out[:0], which avoids
+// allocations when out has enough capacity. It panics if the number of
+// values does not match the number of controllers.
+func (b *ControllerBank) UpdateAllDuration(values []float64, duration time.Duration, out []float64) []float64 {
+	if len(values) != len(b.controllers) {
+		panic("pidctrl: number of values does not match the number of controllers")
+	}
+	out = out[:0]
+	for k := range b.controllers {
+		out = append(out, b.controllers[k].UpdateDuration(values[k], duration))
+	}
+	return out
+}
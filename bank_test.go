@@ -0,0 +1,41 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestControllerBank(t *testing.T) {
+	clock := NewManualClock(time.Date(2016, 3, 2, 12, 0, 0, 0, time.UTC))
+	b := NewControllerBank(3, 1, 1, 0).SetClock(clock)
+	for k := 0; k < b.Len(); k++ {
+		b.Controller(k).Set(float64(10 * (k + 1)))
+	}
+	b.UpdateAll([]float64{0, 0, 0})
+	clock.Advance(time.Second)
+	out := b.UpdateAll([]float64{5, 10, 15})
+	ref := NewPIDController(1, 1, 0)
+	for k, v := range out {
+		ref.Reset().Set(float64(10 * (k + 1)))
+		ref.UpdateDuration(0, 0)
+		if want := ref.UpdateDuration(float64(5*(k+1)), time.Second); v != want {
+			t.Errorf("controller %d: output %v != %v", k, v, want)
+		}
+	}
+
+	buf := make([]float64, 0, 3)
+	if allocs := testing.AllocsPerRun(10, func() {
+		buf = b.UpdateAllDuration([]float64{1, 2, 3}, time.Second, buf)
+	}); allocs != 0 {
+		t.Errorf("%v allocations per batch update", allocs)
+	}
+}
+
+func TestControllerBank_Mismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for mismatched values")
+		}
+	}()
+	NewControllerBank(2, 1, 0, 0).UpdateAll([]float64{1})
+}
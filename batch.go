@@ -0,0 +1,44 @@
+package pidctrl
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrBatchLength is returned by UpdateBatch for columns of different length.
+var ErrBatchLength = errors.New("batch columns differ in length")
+
+// UpdateBatch runs the columns of a log through the controller in one call,
+// e.g. for the offline analysis of large CSV logs. times are the offsets of
+// the samples from the start of the log, values the process values and
+// setpoints the setpoints; a nil setpoints column keeps the current
+// setpoint. The outputs are written to outputs and, unless diags is nil,
+// the details of each update to diags. All non-nil columns must have the
+// same length. UpdateBatch doesn't allocate, so buffers can be reused for
+// logs processed in chunks, which continue from the state of the previous
+// chunk. Non-increasing times are treated as zero durations.
+func (c *PIDController) UpdateBatch(times []time.Duration, setpoints, values, outputs []float64, diags []Diagnostics) error {
+	n := len(values)
+	if len(times) != n || len(outputs) != n || (setpoints != nil && len(setpoints) != n) || (diags != nil && len(diags) != n) {
+		return ErrBatchLength
+	}
+	for i, value := range values {
+		if setpoints != nil && setpoints[i] != c.target {
+			c.Set(setpoints[i])
+		}
+		var dt time.Duration
+		if i > 0 && times[i] > times[i-1] {
+			dt = times[i] - times[i-1]
+		} else if i == 0 && c.batchStarted && times[0] > c.batchLast {
+			dt = times[0] - c.batchLast
+		}
+		outputs[i] = c.UpdateDuration(value, dt)
+		if diags != nil {
+			diags[i] = c.diagnostics()
+		}
+	}
+	if n > 0 {
+		c.batchLast, c.batchStarted = times[n-1], true
+	}
+	return nil
+}
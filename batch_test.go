@@ -0,0 +1,55 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPIDController_UpdateBatch(t *testing.T) {
+	times := []time.Duration{0, time.Second, 2 * time.Second, 3 * time.Second}
+	setpoints := []float64{10, 10, 20, 20}
+	values := []float64{0, 2, 4, 6}
+	outputs := make([]float64, len(values))
+	diags := make([]Diagnostics, len(values))
+	if err := NewPIDController(1, 1, 0).UpdateBatch(times, setpoints, values, outputs, diags); err != nil {
+		t.Fatal(err)
+	}
+	c := NewPIDController(1, 1, 0)
+	for i := range values {
+		var dt time.Duration
+		if i > 0 {
+			dt = times[i] - times[i-1]
+		}
+		if out := c.Set(setpoints[i]).UpdateDuration(values[i], dt); outputs[i] != out || diags[i].Error != setpoints[i]-values[i] {
+			t.Errorf("sample %d: output %v, diagnostics %+v, want %v", i, outputs[i], diags[i], out)
+		}
+	}
+
+	// a log processed in two chunks
+	chunked := NewPIDController(1, 1, 0)
+	for _, r := range [][2]int{{0, 2}, {2, 4}} {
+		if err := chunked.UpdateBatch(times[r[0]:r[1]], setpoints[r[0]:r[1]], values[r[0]:r[1]], outputs[r[0]:r[1]], nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if outputs[3] != diags[3].Terms.P+diags[3].Terms.I {
+		t.Errorf("chunked output %v, want %v", outputs[3], diags[3].Terms.P+diags[3].Terms.I)
+	}
+	if err := c.UpdateBatch(times, nil, values[:2], outputs, nil); err != ErrBatchLength {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestPIDController_UpdateBatchAllocs(t *testing.T) {
+	c := NewPIDController(0.5, 0.25, 0.1).SetOutputLimits(0, 100).Set(42)
+	times := make([]time.Duration, 1000)
+	values := make([]float64, len(times))
+	outputs := make([]float64, len(times))
+	for i := range times {
+		times[i] = time.Duration(i) * 100 * time.Millisecond
+		values[i] = float64(i % 50)
+	}
+	if allocs := testing.AllocsPerRun(10, func() { c.UpdateBatch(times, nil, values, outputs, nil) }); allocs != 0 {
+		t.Errorf("%v allocations per batch", allocs)
+	}
+}
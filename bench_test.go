@@ -0,0 +1,114 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+// observedInfo keeps the observer in hotPathController from being optimized
+// away.
+var observedInfo UpdateInfo
+
+// hotPathController returns a controller with most update features enabled.
+func hotPathController() *PIDController {
+	return NewPIDController(0.5, 0.25, 0.1).
+		SetOutputLimits(0, 100).
+		SetIntegralLimits(0, 50).
+		SetSetpointRamp(1).
+		SetApproachGains(1, 0, 0.2, 10).
+		SetNegativeGains(0.4, 0.2, 0.1).
+		SetDiscretization(Discretization{Integral: IntegralTrapezoidal, Derivative: DerivativeTustin, DerivativeFilter: time.Second}).
+		SetOutputSmoothing(time.Second).
+		SetOutputQuantization(0.5, 0.1).
+		SetOscillationDetection(1, time.Minute, 4).
+		SetSaturationFault(time.Minute, nil).
+		SetStaleTimeout(time.Minute, StaleHold, 0, nil).
+		SetObserver(func(info UpdateInfo) { observedInfo = info }).
+		Set(42)
+}
+
+func TestUpdateDuration_Allocs(t *testing.T) {
+	for name, c := range map[string]*PIDController{
+		"plain":    NewPIDController(0.5, 0.25, 0.1).Set(42),
+		"features": hotPathController(),
+	} {
+		// a hunting loop: the error changes sign on every update. The run
+		// does many updates, so occasional allocations are not averaged
+		// away.
+		v := 50.0
+		if allocs := testing.AllocsPerRun(1, func() {
+			for n := 0; n < 10000; n++ {
+				v = -v
+				c.UpdateDuration(v, time.Millisecond)
+			}
+		}); allocs != 0 {
+			t.Errorf("%s: %v allocations per 10000 updates", name, allocs)
+		}
+		if name == "features" && !c.Oscillating() {
+			t.Errorf("%s: oscillation not detected", name)
+		}
+	}
+	i := NewIntegerPIDController(500, 250, 100).Set(42)
+	if allocs := testing.AllocsPerRun(100, func() { i.UpdateTicks(40, 1000) }); allocs != 0 {
+		t.Errorf("integer: %v allocations per update", allocs)
+	}
+}
+
+func BenchmarkUpdateDuration(b *testing.B) {
+	c := NewPIDController(0.5, 0.25, 0.1).Set(42)
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		c.UpdateDuration(float64(n%100), time.Millisecond)
+	}
+}
+
+func BenchmarkUpdateDuration_Features(b *testing.B) {
+	c := hotPathController()
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		c.UpdateDuration(float64(n%100), time.Millisecond)
+	}
+}
+
+func BenchmarkUpdate(b *testing.B) {
+	clock := NewManualClock(time.Unix(0, 0))
+	c := NewPIDController(0.5, 0.25, 0.1).Set(42).SetClock(clock)
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		clock.Advance(time.Millisecond)
+		c.Update(float64(n % 100))
+	}
+}
+
+func BenchmarkSafeUpdateDuration(b *testing.B) {
+	c := NewSafePIDController(0.5, 0.25, 0.1).Set(42)
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		c.UpdateDuration(float64(n%100), time.Millisecond)
+	}
+}
+
+func BenchmarkIntegerUpdateTicks(b *testing.B) {
+	c := NewIntegerPIDController(500, 250, 100).Set(42)
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		c.UpdateTicks(int64(n%100), 1000)
+	}
+}
+
+func BenchmarkUpdateBatch(b *testing.B) {
+	c := hotPathController()
+	times := make([]time.Duration, 10000)
+	values := make([]float64, len(times))
+	outputs := make([]float64, len(times))
+	diags := make([]Diagnostics, len(times))
+	for i := range times {
+		times[i] = time.Duration(i) * 100 * time.Millisecond
+		values[i] = float64(i % 50)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Reset()
+		c.UpdateBatch(times, nil, values, outputs, diags)
+	}
+}
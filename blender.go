@@ -0,0 +1,108 @@
+package pidctrl
+
+import (
+	"math"
+	"time"
+)
+
+// Blender crossfades the output between two controllers over Duration, e.g.
+// from an aggressive startup tuning to a steady-state one. Both controllers
+// are updated with the same process value. The controller not in control is
+// fed the blended output as tracking signal, see SetTrackingSignal, so it
+// does not wind up, and its integral is aligned to the blended output when a
+// crossfade towards it starts, so the transition is bumpless on both sides.
+type Blender struct {
+	Controllers [2]*PIDController
+	Duration    time.Duration // crossfade duration, 0 switches at once
+
+	weight  float64 // weight of Controllers[1]
+	target  int     // controller faded towards
+	output  float64
+	started bool
+}
+
+// NewBlender returns a Blender starting with controller a in control.
+func NewBlender(a, b *PIDController, duration time.Duration) *Blender {
+	return &Blender{Controllers: [2]*PIDController{a, b}, Duration: duration}
+}
+
+// Set changes the setpoint of both controllers.
+func (b *Blender) Set(setpoint float64) *Blender {
+	b.Controllers[0].Set(setpoint)
+	b.Controllers[1].Set(setpoint)
+	return b
+}
+
+// Switch starts a crossfade towards controller i, 0 or 1. Switching back
+// during a crossfade reverses it from the current blend. It panics for other
+// indexes.
+func (b *Blender) Switch(i int) *Blender {
+	if i != 0 && i != 1 {
+		panic("pidctrl: blender controller index must be 0 or 1")
+	}
+	if i != b.target && b.started && b.idle(i) {
+		b.Controllers[i].track(b.output)
+	}
+	b.target = i
+	return b
+}
+
+// UpdateDuration updates both controllers with value, advances the crossfade
+// and returns the blended output.
+func (b *Blender) UpdateDuration(value float64, duration time.Duration) float64 {
+	step := 1.0
+	if b.Duration > 0 {
+		step = duration.Seconds() / b.Duration.Seconds()
+	}
+	if b.target == 1 {
+		b.weight = math.Min(1, b.weight+step)
+	} else {
+		b.weight = math.Max(0, b.weight-step)
+	}
+	var outs [2]float64
+	for i, c := range b.Controllers {
+		if b.started && b.idle(i) {
+			c.SetTrackingSignal(b.output)
+		} else {
+			c.ClearTrackingSignal()
+		}
+		outs[i] = c.UpdateDuration(value, duration)
+	}
+	b.output = (1-b.weight)*outs[0] + b.weight*outs[1]
+	b.started = true
+	return b.output
+}
+
+// idle returns true if controller i has no weight and is not faded towards.
+func (b *Blender) idle(i int) bool {
+	if i == 0 {
+		return b.weight == 1 && b.target == 1
+	}
+	return b.weight == 0 && b.target == 0
+}
+
+// Weight returns the weight of the second controller in the blended output,
+// from 0 to 1.
+func (b *Blender) Weight() float64 {
+	return b.weight
+}
+
+// Blending returns true while a crossfade is in progress.
+func (b *Blender) Blending() bool {
+	return b.weight != float64(b.target)
+}
+
+// Output returns the output of the last update.
+func (b *Blender) Output() float64 {
+	return b.output
+}
+
+// Reset resets both controllers and clears their tracking signals. The
+// controller switched to last stays in control.
+func (b *Blender) Reset() *Blender {
+	for _, c := range b.Controllers {
+		c.Reset().ClearTrackingSignal()
+	}
+	b.weight, b.output, b.started = float64(b.target), 0, false
+	return b
+}
@@ -0,0 +1,49 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBlender(t *testing.T) {
+	// without I gains the output only changes by bumps
+	startup := NewPIDController(4, 0, 0).SetOutputLimits(0, 100)
+	steady := NewPIDController(1, 0, 0).SetOutputLimits(0, 100)
+	b := NewBlender(startup, steady, 4*time.Second).Set(50)
+	var out float64
+	for i := 0; i < 5; i++ {
+		out = b.UpdateDuration(45, time.Second)
+	}
+	if b.Weight() != 0 || out != 20 {
+		t.Fatalf("output %v not that of the first controller", out)
+	}
+	b.Switch(1)
+	for i := 0; i < 4; i++ {
+		if !b.Blending() {
+			t.Fatalf("update %d: not blending", i)
+		}
+		if out := b.UpdateDuration(45, time.Second); math.Abs(out-20) > 1e-9 {
+			t.Errorf("update %d: output bumped to %v", i, out)
+		}
+	}
+	if b.Blending() || b.Weight() != 1 {
+		t.Errorf("crossfade not completed, weight %v", b.Weight())
+	}
+	if out := b.UpdateDuration(40, 0); out != steady.Output() || math.Abs(out-25) > 1e-9 {
+		t.Errorf("output %v not that of the second controller %v", out, steady.Output())
+	}
+	b.Reset()
+	if b.Weight() != 1 || b.Output() != 0 {
+		t.Error("reset changed the controller in control")
+	}
+}
+
+func TestBlender_Switch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for an invalid index")
+		}
+	}()
+	NewBlender(NewPIDController(1, 0, 0), NewPIDController(1, 0, 0), 0).Switch(2)
+}
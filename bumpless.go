@@ -0,0 +1,51 @@
+package pidctrl
+
+import "math"
+
+// SetBumpless enables bumpless retuning: after SetPID changes the gains of a
+// running controller, the next update adjusts the integral so that the
+// changed P and D gains do not step the output. The integral is kept in
+// output units, so changes of the I gain never step the output.
+//
+// SetOutputLimits on a running bumpless controller re-derives the integral
+// from the last output clamped to the new limits, so tightened limits take
+// effect without excess integral and relaxed limits release a saturated
+// output gradually instead of stepping to the unclamped value.
+func (c *PIDController) SetBumpless(on bool) *PIDController {
+	c.bumpless = on
+	return c
+}
+
+// Bumpless returns true if bumpless retuning is enabled.
+func (c *PIDController) Bumpless() bool {
+	return c.bumpless
+}
+
+// retune marks a gain change, so the next update can compensate it.
+func (c *PIDController) retune() {
+	if c.bumpless && c.started {
+		c.retuning = true
+	}
+}
+
+// compensateRetune moves the difference between the P and D terms of the
+// effective gains of the last update and kp and kd into the integral. After
+// a gain change, this replaces the compensation of switches between gain
+// sets in the same update, so integral is the integral before selecting the
+// gains. Changed gains that are not in effect, e.g. of disabled terms, do
+// not move the integral.
+func (c *PIDController) compensateRetune(integral, kp, kd, pErr, d float64) {
+	if c.retuning {
+		c.integral = integral + (c.effGains[0]-kp)*pErr + (c.effGains[1]-kd)*d
+		c.retuning = false
+	}
+	c.effGains = [2]float64{kp, kd}
+}
+
+// rebaseLimits re-derives the integral after a change of the output limits
+// so that the last update would have produced its output clamped to the new
+// limits.
+func (c *PIDController) rebaseLimits() {
+	c.output = math.Max(c.outMin, math.Min(c.outMax, c.output))
+	c.track(c.output)
+}
@@ -0,0 +1,79 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBumpless(t *testing.T) {
+	for _, test := range []struct {
+		bumpless bool
+		output   float64
+	}{
+		{false, 30},
+		{true, 10},
+	} {
+		c := NewPIDController(1, 0, 0).Set(10).SetBumpless(test.bumpless)
+		c.UpdateDuration(0, time.Second)
+		c.SetPID(2, 0, 0).SetPID(3, 0, 0)
+		if out := c.UpdateDuration(0, time.Second); out != test.output {
+			t.Errorf("bumpless %v: output after retune %v != %v", test.bumpless, out, test.output)
+		}
+		if c.Terms().P != 30 {
+			t.Errorf("bumpless %v: new gain not in effect, P %v", test.bumpless, c.Terms().P)
+		}
+	}
+}
+
+func TestBumpless_NegativeGains(t *testing.T) {
+	// the gains set with SetPID are not in effect while the error is negative
+	c := NewPIDController(1, 0, 0).SetBumpless(true).SetNegativeGains(2, 0, 0).Set(10)
+	c.UpdateDuration(12, time.Second)
+	c.SetPID(5, 0, 0)
+	if out := c.UpdateDuration(12, time.Second); out != -4 {
+		t.Errorf("output after retune %v != -4", out)
+	}
+	// the switch to the retuned gains is bumpless as well
+	if out := c.UpdateDuration(9.5, time.Second); out != 1 {
+		t.Errorf("output after the switch %v != 1", out)
+	}
+	if out := c.UpdateDuration(9.5, time.Second); out != 1 || c.Terms().P != 2.5 {
+		t.Errorf("output %v, P %v after the switch", out, c.Terms().P)
+	}
+}
+
+func TestBumpless_DisabledTerms(t *testing.T) {
+	c := NewPIDController(1, 0, 0).SetBumpless(true).SetTermsEnabled(false, true, true).Set(10)
+	c.UpdateDuration(8, time.Second)
+	c.SetPID(5, 0, 0)
+	if out := c.UpdateDuration(8, time.Second); out != 0 {
+		t.Errorf("output after retuning a disabled P %v != 0", out)
+	}
+	if c.Integral() != 0 {
+		t.Errorf("integral %v moved", c.Integral())
+	}
+}
+
+func TestBumpless_OutputLimits(t *testing.T) {
+	for _, test := range []struct {
+		bumpless bool
+		relaxed  float64 // output after relaxing the limits to ±100
+	}{
+		{false, 80},
+		{true, 50},
+	} {
+		c := NewPIDController(1, 1, 0).SetOutputLimits(-50, 50).Set(40).SetBumpless(test.bumpless)
+		c.SetIntegral(40).UpdateDuration(0, 0)
+		c.SetOutputLimits(-100, 100)
+		if out := c.UpdateDuration(0, 0); out != test.relaxed {
+			t.Errorf("bumpless %v: output after relaxing %v != %v", test.bumpless, out, test.relaxed)
+		}
+	}
+
+	c := NewPIDController(1, 1, 0).Set(10).SetBumpless(true)
+	c.SetIntegral(20).UpdateDuration(0, 0)
+	c.SetOutputLimits(0, 25)
+	if c.Output() != 25 || c.Integral() != 15 {
+		t.Errorf("tightened: output %v, integral %v", c.Output(), c.Integral())
+	}
+}
@@ -0,0 +1,64 @@
+package pidctrl
+
+import "time"
+
+// Cascade runs two loops in cascade, the output of the outer loop being the
+// setpoint of the inner loop, at different sample rates: the inner loop is
+// updated on every call, the outer loop once per OuterInterval with the
+// accumulated duration, e.g. a 100 Hz flow loop below a 1 Hz level loop.
+type Cascade struct {
+	Outer, Inner *PIDController
+	// OuterInterval is the sample time of the outer loop. 0 updates the
+	// outer loop on every call.
+	OuterInterval time.Duration
+	// Interpolate spreads each change of the inner setpoint linearly over
+	// the following outer interval instead of stepping it, which avoids
+	// proportional kicks of the inner loop at every outer update at the cost
+	// of delaying the outer loop by one interval.
+	Interpolate bool
+
+	pending    time.Duration // time since the last outer update
+	from, to   float64       // inner setpoint before and after the last outer update
+	outerDue   bool          // outer loop updated on the last call
+	outerStart bool          // outer loop updated at least once
+}
+
+// NewCascade returns a new Cascade.
+func NewCascade(outer, inner *PIDController, outerInterval time.Duration) *Cascade {
+	return &Cascade{Outer: outer, Inner: inner, OuterInterval: outerInterval}
+}
+
+// UpdateDuration updates the inner loop with innerValue and, when it is
+// due, the outer loop with outerValue, and returns the inner output. The
+// outer value is only read on calls that update the outer loop. The first
+// call always updates the outer loop.
+func (c *Cascade) UpdateDuration(outerValue, innerValue float64, duration time.Duration) float64 {
+	c.pending += duration
+	c.outerDue = !c.outerStart || c.pending >= c.OuterInterval
+	if c.outerDue {
+		c.from, c.to = c.to, c.Outer.UpdateDuration(outerValue, c.pending)
+		if !c.outerStart {
+			c.from = c.to
+		}
+		c.pending, c.outerStart = 0, true
+	}
+	setpoint := c.to
+	if c.Interpolate && c.OuterInterval > 0 && c.pending < c.OuterInterval {
+		setpoint = c.from + (c.to-c.from)*float64(c.pending)/float64(c.OuterInterval)
+	}
+	c.Inner.Set(setpoint)
+	return c.Inner.UpdateDuration(innerValue, duration)
+}
+
+// OuterUpdated returns true if the last call updated the outer loop.
+func (c *Cascade) OuterUpdated() bool {
+	return c.outerDue
+}
+
+// Reset resets both loops and restarts the outer interval.
+func (c *Cascade) Reset() *Cascade {
+	c.Outer.Reset()
+	c.Inner.Reset()
+	c.pending, c.from, c.to, c.outerDue, c.outerStart = 0, 0, 0, false, false
+	return c
+}
@@ -0,0 +1,49 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCascade_Rates(t *testing.T) {
+	var outerDts []time.Duration
+	outer := NewPIDController(1, 0, 0).Set(10)
+	outer.SetObserver(func(info UpdateInfo) { outerDts = append(outerDts, info.Dt) })
+	inner := NewPIDController(2, 0, 0)
+	c := NewCascade(outer, inner, time.Second)
+	for i := 0; i < 250; i++ {
+		c.UpdateDuration(float64(i)/100, 0, 10*time.Millisecond)
+		if due := i%100 == 0; c.OuterUpdated() != due {
+			t.Fatalf("call %d: outer updated %v", i, c.OuterUpdated())
+		}
+	}
+	if len(outerDts) != 3 || outerDts[0] != 10*time.Millisecond || outerDts[1] != time.Second || outerDts[2] != time.Second {
+		t.Errorf("outer durations %v", outerDts)
+	}
+	if sp := inner.Get(); sp != 10-2 {
+		t.Errorf("inner setpoint %v != 8", sp)
+	}
+}
+
+func TestCascade_Interpolate(t *testing.T) {
+	outer := NewPIDController(1, 0, 0).Set(10)
+	inner := NewPIDController(1, 0, 0)
+	c := NewCascade(outer, inner, 100*time.Millisecond)
+	c.Interpolate = true
+	var setpoints []float64
+	for _, v := range []float64{0, 0, 0, 0, 0, 5, 5, 5, 5, 5, 5} {
+		c.UpdateDuration(v, 0, 20*time.Millisecond)
+		setpoints = append(setpoints, inner.Get())
+	}
+	want := []float64{10, 10, 10, 10, 10, 10, 9, 8, 7, 6, 5}
+	for n := range want {
+		if math.Abs(setpoints[n]-want[n]) > 1e-9 {
+			t.Fatalf("inner setpoints %v != %v", setpoints, want)
+		}
+	}
+	c.Reset()
+	if c.UpdateDuration(2, 0, time.Millisecond); inner.Get() != 8 {
+		t.Errorf("inner setpoint %v != 8 after reset", inner.Get())
+	}
+}
@@ -0,0 +1,274 @@
+package pidctrl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrChangeTooSoon is returned by ChangeGuard.Apply for changes within the
+// minimum interval after the last accepted one.
+var ErrChangeTooSoon = errors.New("change too soon after the last one")
+
+// ErrNoHistory is returned by ChangeGuard.Rollback when there is no change
+// to roll back.
+var ErrNoHistory = errors.New("no change to roll back")
+
+// ChangeLimitError is returned by ChangeGuard.Apply for changes exceeding
+// the limits.
+type ChangeLimitError struct {
+	Setting       string
+	Change, Limit float64
+}
+
+func (e ChangeLimitError) Error() string {
+	if e.Limit == 0 {
+		return fmt.Sprintf("%s changes not allowed", e.Setting)
+	}
+	return fmt.Sprintf("%s change %v exceeds the limit %v", e.Setting, e.Change, e.Limit)
+}
+
+// ChangeLimits bound the changes accepted by a ChangeGuard. Zero deltas do
+// not limit the respective setting.
+type ChangeLimits struct {
+	MaxGainDelta     Gains         // maximum absolute change of each gain
+	MaxSetpointDelta float64       // maximum absolute setpoint change
+	MinInterval      time.Duration // minimum time between accepted changes
+	AllowLimits      bool          // accept output limit changes
+	AllowOccupancy   bool          // accept occupancy changes
+	AllowMode        bool          // accept operating mode changes
+}
+
+// AuditEntry records a change requested from a ChangeGuard.
+type AuditEntry struct {
+	Time     time.Time
+	Who      string   // requester passed to Apply or Rollback
+	Old      Settings // complete settings before the change
+	Change   Settings // requested change, the restored settings of a rollback
+	Rollback bool
+	Err      error // reason of a rejected change, nil if applied
+}
+
+// ChangeGuard applies remote gain and setpoint changes to a controller
+// within limits, records every request in an audit callback and can roll
+// back accepted changes, so tuning can be exposed over the network. It is
+// safe for concurrent use.
+type ChangeGuard struct {
+	Controller *SafePIDController
+	Limits     ChangeLimits
+	// Audit is called with every requested change, applied or not. It may
+	// be nil.
+	Audit func(AuditEntry)
+	// History is the number of changes that can be rolled back.
+	History int
+	// Store persists the audit entries in NamespaceAudit if it is not nil,
+	// see LoadAudit. A failure to store the entry of an otherwise
+	// successful request is returned by Apply and Rollback; the change
+	// stays applied.
+	Store Store
+
+	mu      sync.Mutex
+	clock   Clock
+	last    time.Time
+	history []Settings
+	seq     int // sequence number of the next stored audit entry
+}
+
+// NewChangeGuard returns a ChangeGuard for c keeping the last 10 changes for
+// rollbacks.
+func NewChangeGuard(c *SafePIDController, limits ChangeLimits, audit func(AuditEntry)) *ChangeGuard {
+	return &ChangeGuard{Controller: c, Limits: limits, Audit: audit, History: 10}
+}
+
+// SetClock changes the time source of the audit log and the minimum
+// interval. Passing nil restores the real clock.
+func (g *ChangeGuard) SetClock(clock Clock) *ChangeGuard {
+	g.mu.Lock()
+	g.clock = clock
+	g.mu.Unlock()
+	return g
+}
+
+// Apply applies the change s requested by who if it is within the limits.
+func (g *ChangeGuard) Apply(who string, s Settings) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	old := g.Controller.Settings()
+	err := g.check(now, old, s)
+	if err == nil {
+		err = g.Controller.ApplySettings(s)
+	}
+	if err == nil {
+		g.last = now
+		g.history = append(g.history, old)
+		if n := len(g.history) - g.History; n > 0 {
+			g.history = append(g.history[:0], g.history[n:]...)
+		}
+	}
+	if serr := g.audit(AuditEntry{Time: now, Who: who, Old: old, Change: s.clone(), Err: err}); err == nil {
+		err = serr
+	}
+	return err
+}
+
+// Rollback restores the settings before the last accepted change that was
+// not rolled back yet. Rollbacks are not subject to the limits.
+func (g *ChangeGuard) Rollback(who string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	old := g.Controller.Settings()
+	var (
+		prev Settings
+		err  = ErrNoHistory
+	)
+	if n := len(g.history); n > 0 {
+		prev = g.history[n-1]
+		if err = g.Controller.ApplySettings(prev); err == nil {
+			g.history = g.history[:n-1]
+		}
+	}
+	if serr := g.audit(AuditEntry{Time: now, Who: who, Old: old, Change: prev, Rollback: true, Err: err}); err == nil {
+		err = serr
+	}
+	return err
+}
+
+// check returns why the change s of the settings old is not accepted at now.
+func (g *ChangeGuard) check(now time.Time, old, s Settings) error {
+	l := g.Limits
+	if !g.last.IsZero() && now.Sub(g.last) < l.MinInterval {
+		return ErrChangeTooSoon
+	}
+	if s.Gains != nil {
+		for _, c := range []struct {
+			name          string
+			old, new, max float64
+		}{
+			{"p gain", old.Gains.P, s.Gains.P, l.MaxGainDelta.P},
+			{"i gain", old.Gains.I, s.Gains.I, l.MaxGainDelta.I},
+			{"d gain", old.Gains.D, s.Gains.D, l.MaxGainDelta.D},
+		} {
+			if d := math.Abs(c.new - c.old); c.max > 0 && !(d <= c.max) {
+				return ChangeLimitError{Setting: c.name, Change: d, Limit: c.max}
+			}
+		}
+	}
+	if s.Setpoint != nil {
+		if d := math.Abs(*s.Setpoint - *old.Setpoint); l.MaxSetpointDelta > 0 && !(d <= l.MaxSetpointDelta) {
+			return ChangeLimitError{Setting: "setpoint", Change: d, Limit: l.MaxSetpointDelta}
+		}
+	}
+	if s.OutputLimits != nil && !l.AllowLimits {
+		return ChangeLimitError{Setting: "output limits"}
+	}
+	if s.Occupancy != nil && !l.AllowOccupancy {
+		return ChangeLimitError{Setting: "occupancy"}
+	}
+	if s.Mode != nil && !l.AllowMode {
+		return ChangeLimitError{Setting: "mode"}
+	}
+	return nil
+}
+
+// clone returns a copy of s not sharing the values of the caller.
+func (s Settings) clone() Settings {
+	if s.Gains != nil {
+		g := *s.Gains
+		s.Gains = &g
+	}
+	if s.Setpoint != nil {
+		v := *s.Setpoint
+		s.Setpoint = &v
+	}
+	if s.OutputLimits != nil {
+		l := *s.OutputLimits
+		if l.Min != nil {
+			v := *l.Min
+			l.Min = &v
+		}
+		if l.Max != nil {
+			v := *l.Max
+			l.Max = &v
+		}
+		s.OutputLimits = &l
+	}
+	if s.Occupancy != nil {
+		o := *s.Occupancy
+		s.Occupancy = &o
+	}
+	if s.Mode != nil {
+		m := *s.Mode
+		s.Mode = &m
+	}
+	return s
+}
+
+func (g *ChangeGuard) now() time.Time {
+	if g.clock != nil {
+		return g.clock.Now()
+	}
+	return time.Now()
+}
+
+// audit reports e to the callback and returns the error of storing it.
+func (g *ChangeGuard) audit(e AuditEntry) error {
+	if g.Audit != nil {
+		g.Audit(e)
+	}
+	if g.Store == nil {
+		return nil
+	}
+	j := auditJSON{Time: e.Time, Who: e.Who, Old: e.Old, Change: e.Change, Rollback: e.Rollback}
+	if e.Err != nil {
+		j.Err = e.Err.Error()
+	}
+	data, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+	key := fmt.Sprintf("%s-%06d", e.Time.UTC().Format("20060102T150405.000000000Z"), g.seq)
+	g.seq++
+	return g.Store.Put(NamespaceAudit, key, data)
+}
+
+// auditJSON is the stored form of an AuditEntry.
+type auditJSON struct {
+	Time     time.Time `json:"time"`
+	Who      string    `json:"who"`
+	Old      Settings  `json:"old"`
+	Change   Settings  `json:"change"`
+	Rollback bool      `json:"rollback,omitempty"`
+	Err      string    `json:"error,omitempty"`
+}
+
+// LoadAudit returns the audit entries persisted by ChangeGuards in the audit
+// namespace of s, oldest first. Errors of rejected changes are restored as
+// plain errors with the original message.
+func LoadAudit(s Store) ([]AuditEntry, error) {
+	keys, err := s.List(NamespaceAudit)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]AuditEntry, 0, len(keys))
+	for _, key := range keys {
+		data, err := s.Get(NamespaceAudit, key)
+		if err != nil {
+			return nil, err
+		}
+		var j auditJSON
+		if err := json.Unmarshal(data, &j); err != nil {
+			return nil, err
+		}
+		e := AuditEntry{Time: j.Time, Who: j.Who, Old: j.Old, Change: j.Change, Rollback: j.Rollback}
+		if j.Err != "" {
+			e.Err = errors.New(j.Err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
@@ -0,0 +1,118 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChangeGuard(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	c := NewSafePIDController(1, 0.1, 0).Set(50)
+	var log []AuditEntry
+	g := NewChangeGuard(c, ChangeLimits{
+		MaxGainDelta:     Gains{P: 0.5},
+		MaxSetpointDelta: 10,
+		MinInterval:      time.Minute,
+	}, func(e AuditEntry) { log = append(log, e) }).SetClock(clock)
+
+	gains, setpoint := Gains{P: 1.5, I: 0.2}, 55.0
+	if err := g.Apply("alice", Settings{Gains: &gains, Setpoint: &setpoint}); err != nil {
+		t.Fatal(err)
+	}
+	if c.Get() != 55 || c.Settings().Gains.P != 1.5 {
+		t.Errorf("change not applied: %+v", c.Settings())
+	}
+	setpoint = 56
+	if err := g.Apply("bob", Settings{Setpoint: &setpoint}); err != ErrChangeTooSoon {
+		t.Errorf("unexpected error %v", err)
+	}
+	clock.Advance(time.Minute)
+	gains.P = 3
+	if err := g.Apply("bob", Settings{Gains: &gains}); err != (ChangeLimitError{Setting: "p gain", Change: 1.5, Limit: 0.5}) {
+		t.Errorf("unexpected error %v", err)
+	}
+	setpoint = 80
+	if err := g.Apply("bob", Settings{Setpoint: &setpoint}); err == nil {
+		t.Error("setpoint change beyond the limit applied")
+	}
+	if err := g.Apply("bob", Settings{OutputLimits: &Limits{}}); err == nil {
+		t.Error("output limit change applied")
+	}
+	manual := ModeManual
+	if err := g.Apply("bob", Settings{Mode: &manual}); err != (ChangeLimitError{Setting: "mode"}) {
+		t.Errorf("mode change: unexpected error %v", err)
+	}
+	if c.Get() != 55 {
+		t.Errorf("rejected changes changed the setpoint to %v", c.Get())
+	}
+
+	if err := g.Rollback("carol"); err != nil {
+		t.Fatal(err)
+	}
+	if c.Get() != 50 || c.Settings().Gains.P != 1 {
+		t.Errorf("rollback restored %+v", c.Settings())
+	}
+	if err := g.Rollback("carol"); err != ErrNoHistory {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	if len(log) != 8 {
+		t.Fatalf("%d audit entries, want 8", len(log))
+	}
+	if e := log[0]; e.Who != "alice" || e.Err != nil || *e.Old.Setpoint != 50 || *e.Change.Setpoint != 55 || !e.Time.Equal(time.Unix(0, 0)) {
+		t.Errorf("unexpected audit entry %+v", e)
+	}
+	if e := log[6]; e.Who != "carol" || !e.Rollback || *e.Change.Setpoint != 50 {
+		t.Errorf("unexpected rollback entry %+v", e)
+	}
+}
+
+func TestChangeGuard_History(t *testing.T) {
+	c := NewSafePIDController(1, 0, 0)
+	g := NewChangeGuard(c, ChangeLimits{}, nil)
+	g.History = 2
+	for i := 1; i <= 3; i++ {
+		setpoint := float64(i)
+		g.Apply("", Settings{Setpoint: &setpoint})
+	}
+	g.Rollback("")
+	g.Rollback("")
+	if err := g.Rollback(""); err != ErrNoHistory || c.Get() != 1 {
+		t.Errorf("setpoint %v after rolling back the history, error %v", c.Get(), err)
+	}
+}
+
+func TestChangeGuard_Store(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	st := NewMemStore()
+	g := NewChangeGuard(NewSafePIDController(1, 0, 0).Set(50), ChangeLimits{MaxSetpointDelta: 10}, nil).SetClock(clock)
+	g.Store = st
+
+	setpoint := 55.0
+	if err := g.Apply("alice", Settings{Setpoint: &setpoint}); err != nil {
+		t.Fatal(err)
+	}
+	setpoint = 80
+	if err := g.Apply("bob", Settings{Setpoint: &setpoint}); err == nil {
+		t.Fatal("expected limit error")
+	}
+	if err := g.Rollback("alice"); err != nil {
+		t.Fatal(err)
+	}
+	log, err := LoadAudit(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(log) != 3 {
+		t.Fatalf("expected three entries, got %+v", log)
+	}
+	if e := log[0]; e.Who != "alice" || *e.Change.Setpoint != 55 || *e.Old.Setpoint != 50 || e.Err != nil || !e.Time.Equal(clock.Now()) {
+		t.Errorf("applied entry %+v", e)
+	}
+	if e := log[1]; e.Who != "bob" || e.Err == nil || e.Err.Error() != (ChangeLimitError{"setpoint", 25, 10}).Error() {
+		t.Errorf("rejected entry %+v", e)
+	}
+	if e := log[2]; !e.Rollback || *e.Change.Setpoint != 50 {
+		t.Errorf("rollback entry %+v", e)
+	}
+}
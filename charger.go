@@ -0,0 +1,87 @@
+package pidctrl
+
+import (
+	"math"
+	"time"
+)
+
+// ChargeMode is the state of a Charger.
+type ChargeMode int
+
+// Charger modes.
+const (
+	ChargeCC   ChargeMode = iota // constant current
+	ChargeCV                     // constant voltage, current tapering off
+	ChargeDone                   // current fell below the taper threshold
+)
+
+func (m ChargeMode) String() string {
+	switch m {
+	case ChargeCC:
+		return "cc"
+	case ChargeCV:
+		return "cv"
+	case ChargeDone:
+		return "done"
+	}
+	return "unknown"
+}
+
+// Charger implements constant-current/constant-voltage battery charging: the
+// Current controller regulates the charge current, the Voltage controller
+// the terminal voltage, and the lower of both outputs drives the converter.
+// The controller that is not selected tracks the selected output, so the
+// hand-over between the modes is bumpless. Charging terminates once the
+// current in CV mode falls below the taper current.
+type Charger struct {
+	Current *PIDController
+	Voltage *PIDController
+	// TaperCurrent terminates charging, 0 charges indefinitely (float
+	// charging).
+	TaperCurrent float64
+
+	mode ChargeMode
+}
+
+// NewCharger returns a Charger using the given controllers, whose setpoints
+// are the charge current and the charge voltage.
+func NewCharger(current, voltage *PIDController, taperCurrent float64) *Charger {
+	return &Charger{Current: current, Voltage: voltage, TaperCurrent: taperCurrent}
+}
+
+// Mode returns the current charge mode.
+func (ch *Charger) Mode() ChargeMode {
+	return ch.mode
+}
+
+// Reset restarts charging in CC mode.
+func (ch *Charger) Reset() *Charger {
+	ch.Current.Reset()
+	ch.Voltage.Reset()
+	ch.mode = ChargeCC
+	return ch
+}
+
+// UpdateDuration updates both controllers with the measured current and
+// voltage and returns the converter command, or the lower output limit of
+// the current controller once charging is done.
+func (ch *Charger) UpdateDuration(current, voltage float64, duration time.Duration) float64 {
+	if ch.mode == ChargeDone {
+		min, _ := ch.Current.OutputLimits()
+		return math.Max(min, 0)
+	}
+	outI := ch.Current.UpdateDuration(current, duration)
+	outV := ch.Voltage.UpdateDuration(voltage, duration)
+	out := math.Min(outI, outV)
+	if outV < outI {
+		ch.Current.track(out)
+		ch.mode = ChargeCV
+	} else {
+		ch.Voltage.track(out)
+		ch.mode = ChargeCC
+	}
+	if ch.mode == ChargeCV && ch.TaperCurrent > 0 && current < ch.TaperCurrent {
+		ch.mode = ChargeDone
+	}
+	return out
+}
@@ -0,0 +1,47 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCharger(t *testing.T) {
+	ch := NewCharger(
+		NewPIDController(0.05, 0.5, 0).SetOutputLimits(0, 15).Set(2),
+		NewPIDController(0.5, 2, 0).SetOutputLimits(0, 15).Set(4.2),
+		0.1,
+	)
+	// a battery with an open circuit voltage rising with charge and an
+	// internal resistance, fed from a converter output voltage
+	var (
+		ocv, current, voltage = 3.0, 0.0, 3.0
+		modes                 = map[ChargeMode]bool{}
+		maxVoltage            float64
+		dt                    = 100 * time.Millisecond
+	)
+	for i := 0; i < 100000 && ch.Mode() != ChargeDone; i++ {
+		out := ch.UpdateDuration(current, voltage, dt)
+		modes[ch.Mode()] = true
+		current = (out - ocv) / 0.1
+		if current < 0 {
+			current = 0
+		}
+		voltage = ocv + current*0.1
+		ocv += current * dt.Seconds() * 0.001
+		if i > 100 && voltage > maxVoltage {
+			maxVoltage = voltage
+		}
+	}
+	if !modes[ChargeCC] || !modes[ChargeCV] || ch.Mode() != ChargeDone {
+		t.Fatalf("modes %v, final %v", modes, ch.Mode())
+	}
+	if maxVoltage > 4.25 {
+		t.Errorf("voltage overshoot to %v", maxVoltage)
+	}
+	if out := ch.UpdateDuration(0, 4.2, dt); out != 0 {
+		t.Errorf("output %v after charging finished", out)
+	}
+	if ch.Reset().Mode() != ChargeCC {
+		t.Error("reset did not restart charging")
+	}
+}
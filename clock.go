@@ -0,0 +1,57 @@
+package pidctrl
+
+import "time"
+
+// Clock provides the current time to a controller. Simulations and tests can
+// install their own Clock to drive time deterministically.
+type Clock interface {
+	Now() time.Time
+}
+
+// realClock is the Clock backed by time.Now.
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+// RealClock is the default Clock using the system time.
+var RealClock Clock = realClock{}
+
+// SetClock changes the time source used by Update. Passing nil restores the
+// real clock.
+func (c *PIDController) SetClock(clock Clock) *PIDController {
+	c.clock = clock
+	return c
+}
+
+// now returns the current time according to the controller's clock.
+func (c *PIDController) now() time.Time {
+	if c.clock == nil {
+		return time.Now()
+	}
+	return c.clock.Now()
+}
+
+// ManualClock is a Clock that only advances when told to.
+type ManualClock struct {
+	t time.Time
+}
+
+// NewManualClock returns a ManualClock set to the given time.
+func NewManualClock(t time.Time) *ManualClock {
+	return &ManualClock{t: t}
+}
+
+// Now implements Clock.
+func (m *ManualClock) Now() time.Time {
+	return m.t
+}
+
+// Advance moves the clock forward by d.
+func (m *ManualClock) Advance(d time.Duration) {
+	m.t = m.t.Add(d)
+}
+
+// Set moves the clock to t.
+func (m *ManualClock) Set(t time.Time) {
+	m.t = t
+}
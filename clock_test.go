@@ -0,0 +1,23 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPIDController_SetClock(t *testing.T) {
+	clock := NewManualClock(time.Date(2016, 3, 2, 0, 0, 0, 0, time.UTC))
+	c := NewPIDController(0, 0.5, 0).SetClock(clock).Set(10)
+	// the first update has no previous update to measure from
+	if out := c.Update(5); out != 0 {
+		t.Errorf("first update: %f != 0", out)
+	}
+	clock.Advance(2 * time.Second)
+	if out := c.Update(5); out != 5 {
+		t.Errorf("after 2s: %f != 5", out)
+	}
+	clock.Advance(time.Second)
+	if out := c.Update(5); out != 7.5 {
+		t.Errorf("after 3s: %f != 7.5", out)
+	}
+}
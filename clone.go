@@ -0,0 +1,56 @@
+package pidctrl
+
+import "time"
+
+// EstimatorCloner is implemented by estimators that can be copied along with
+// a controller by Clone.
+type EstimatorCloner interface {
+	Estimator
+	CloneEstimator() Estimator
+}
+
+// CloneEstimator implements EstimatorCloner.
+func (k *KalmanFilter) CloneEstimator() Estimator {
+	cp := *k
+	return &cp
+}
+
+// Clone returns an independent copy of the controller with the same
+// configuration and state. Estimators implementing EstimatorCloner and the
+// disturbance observer are copied; other estimators, the clock, the
+// observer function, the event bus and the logger are shared with the
+// original.
+func (c *PIDController) Clone() *PIDController {
+	cp := *c
+	if c.profiles != nil {
+		cp.profiles = make(map[Occupancy]OccupancyProfile, len(c.profiles))
+		for k, v := range c.profiles {
+			cp.profiles[k] = v
+		}
+	}
+	if e, ok := c.estimator.(EstimatorCloner); ok {
+		cp.estimator = e.CloneEstimator()
+	}
+	if c.dob != nil {
+		dob := *c.dob
+		cp.dob = &dob
+	}
+	cp.osc.times = append([]time.Duration(nil), c.osc.times...)
+	return &cp
+}
+
+// CloneWith returns a copy of the controller like Clone with the given
+// options applied on top of its configuration, e.g. to derive per-zone
+// controllers from a tuned template. The options see the configuration of
+// the original as their starting point, so only the fields they change
+// differ. An invalid resulting configuration returns an error.
+func (c *PIDController) CloneWith(opts ...Option) (*PIDController, error) {
+	o := c.options()
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	return c.Clone().applyOptions(o), nil
+}
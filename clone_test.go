@@ -0,0 +1,59 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClone(t *testing.T) {
+	c := NewPIDController(0.5, 0.25, 0.1).SetOutputLimits(0, 100).Set(42).
+		SetEstimator(NewKalmanFilter(0.01, 0.25)).
+		SetOccupancyProfiles(map[Occupancy]OccupancyProfile{OccupancyAway: {SetpointOffset: -4}})
+	c.UpdateDuration(40, time.Second)
+	cp := c.Clone()
+	for _, v := range []float64{41, 41.5, 41.8} {
+		if a, b := c.UpdateDuration(v, time.Second), cp.UpdateDuration(v, time.Second); a != b {
+			t.Errorf("clone diverged: %v != %v", b, a)
+		}
+	}
+	cp.profiles[OccupancyAway] = OccupancyProfile{}
+	if p := c.OccupancyProfiles()[OccupancyAway]; p.SetpointOffset != -4 {
+		t.Errorf("original profiles changed: %+v", p)
+	}
+	cp.Set(10).SetPID(1, 1, 1)
+	if c.Get() != 42 || c.Gains() != (Gains{0.5, 0.25, 0.1}) {
+		t.Error("original changed with the clone")
+	}
+
+	c = NewPIDController(1, 0, 0).SetOscillationDetection(0, time.Minute, 2)
+	cp = c.Clone()
+	for _, v := range []float64{1, -1, 1} {
+		cp.UpdateDuration(v, time.Second)
+	}
+	if c.osc.times[0] != 0 || c.osc.times[1] != 0 {
+		t.Errorf("original sign changes changed: %v", c.osc.times)
+	}
+}
+
+func TestCloneWith(t *testing.T) {
+	template := NewPIDController(0.5, 0.25, 0.1).SetOutputLimits(0, 100).SetIntegralLimits(0, 20).Set(21)
+	zone, err := template.CloneWith(WithSetpoint(19), WithDirection(Reverse))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if zone.Get() != 19 || zone.Direction() != Reverse || zone.Gains() != template.Gains() {
+		t.Errorf("zone %v %v %+v", zone.Get(), zone.Direction(), zone.Gains())
+	}
+	if min, max := zone.OutputLimits(); min != 0 || max != 100 {
+		t.Errorf("output limits %v %v", min, max)
+	}
+	if min, max := zone.IntegralLimits(); min != 0 || max != 20 {
+		t.Errorf("integral limits %v %v", min, max)
+	}
+	if template.Get() != 21 || template.Direction() != Direct {
+		t.Error("template changed")
+	}
+	if _, err := template.CloneWith(WithOutputLimits(1, 0)); err == nil {
+		t.Error("expected error for invalid limits")
+	}
+}
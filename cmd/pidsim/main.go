@@ -0,0 +1,165 @@
+// Command pidsim runs a controller from a config file against a simulated
+// first order plus dead time plant from the sim package, prints the step
+// response metrics of sim.Harness and writes the trajectory as CSV. With
+// -plot the trajectory is also rendered as an SVG or PNG chart. With -tune
+// the gains of the config are replaced by those of a tuning rule applied to
+// the plant model, accounting for the simulation step as sample period.
+//
+// Usage:
+//
+//	pidsim -config loops.json -loop boiler -gain 2 -tau 60s -delay 5s -duration 10m > run.csv
+//	pidsim -config loops.json -loop boiler -csv "" -plot run.svg
+//	pidsim -config loops.json -gain 2 -tau 60s -delay 5s -tune simc -tune-variant conservative
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/felixge/pidctrl"
+	"github.com/felixge/pidctrl/config"
+	"github.com/felixge/pidctrl/plot"
+	"github.com/felixge/pidctrl/sim"
+	"github.com/felixge/pidctrl/tuning"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "pidsim:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("pidsim", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var (
+		configPath = fs.String("config", "", "controller config file (required)")
+		loopName   = fs.String("loop", "", "loop to simulate, may be omitted if the config has one loop")
+		gain       = fs.Float64("gain", 1, "plant gain")
+		tau        = fs.Duration("tau", time.Minute, "plant time constant")
+		delay      = fs.Duration("delay", 0, "plant dead time")
+		initial    = fs.Float64("initial", 0, "initial process value")
+		noise      = fs.Float64("noise", 0, "standard deviation of the measurement noise")
+		seed       = fs.Int64("seed", 1, "random seed of the measurement noise")
+		duration   = fs.Duration("duration", 10*time.Minute, "simulated duration")
+		step       = fs.Duration("dt", 0, "simulation step, defaults to the loop sample time or 1s")
+		csvPath    = fs.String("csv", "-", "CSV output file, - for stdout, empty to disable")
+		plotPath   = fs.String("plot", "", "chart output file, PNG if it ends in .png, SVG otherwise")
+		tune       = fs.String("tune", "", "tuning rule replacing the configured gains: ziegler-nichols, cohen-coon, imc, simc, amigo or takahashi")
+		tuneKind   = fs.String("tune-kind", "pi", "controller kind of the tuning rule: pi or pid")
+		variant    = fs.String("tune-variant", "normal", "tuning rule variant: aggressive, normal or conservative")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *configPath == "" {
+		return fmt.Errorf("-config is required")
+	}
+	cfg, err := config.LoadFile(*configPath)
+	if err != nil {
+		return err
+	}
+	name := *loopName
+	if name == "" {
+		if len(cfg.Loops) != 1 {
+			return fmt.Errorf("-loop is required, the config has loops %s", strings.Join(cfg.Names(), ", "))
+		}
+		name = cfg.Names()[0]
+	}
+	loop, ok := cfg.Loops[name]
+	if !ok {
+		return fmt.Errorf("unknown loop %q", name)
+	}
+	c, err := loop.NewController()
+	if err != nil {
+		return err
+	}
+	dt := *step
+	if dt == 0 {
+		dt = time.Duration(loop.SampleTime)
+	}
+	if dt <= 0 {
+		dt = time.Second
+	}
+	if *tune != "" {
+		g, err := tuneGains(tuning.FOPDT{Gain: *gain, Tau: *tau, DeadTime: *delay}, *tune, *tuneKind, *variant, dt)
+		if err != nil {
+			return err
+		}
+		c.SetGains(g)
+		fmt.Fprintf(stderr, "gains: p=%.4g i=%.4g d=%.4g\n", g.P, g.I, g.D)
+	}
+
+	steps := int(*duration / dt)
+	var p sim.Plant = &sim.Delay{Plant: sim.NewFirstOrder(*gain, *tau).SetValue(*initial), Delay: *delay}
+	if *noise > 0 {
+		p = sim.NewNoise(p, *noise, *seed)
+	}
+	rec := pidctrl.NewRecorder(steps + 1)
+	h := &sim.Harness{
+		Controller: c,
+		Plant:      p,
+		Duration:   *duration,
+		Dt:         dt,
+		Recorder:   rec,
+		Clock:      pidctrl.NewManualClock(time.Unix(0, 0).UTC()),
+	}
+	r := h.Run()
+	fmt.Fprintf(stderr, "rise_time: %v\n", r.RiseTime)
+	fmt.Fprintf(stderr, "overshoot_percent: %.4g\n", r.Overshoot)
+	fmt.Fprintf(stderr, "settling_time: %v\n", r.SettlingTime)
+	fmt.Fprintf(stderr, "iae: %.4g\n", r.IAE)
+	fmt.Fprintf(stderr, "ise: %.4g\n", r.ISE)
+	fmt.Fprintf(stderr, "itae: %.4g\n", r.ITAE)
+	fmt.Fprintf(stderr, "steady_state_error: %.4g\n", r.SteadyStateError)
+	fmt.Fprintf(stderr, "saturated_fraction: %.4g\n", r.Saturated)
+
+	if *plotPath != "" {
+		if err := plot.File(*plotPath, rec.Records(), plot.Options{Title: name}); err != nil {
+			return err
+		}
+	}
+	switch *csvPath {
+	case "":
+		return nil
+	case "-":
+		return rec.WriteCSV(stdout)
+	}
+	f, err := os.Create(*csvPath)
+	if err != nil {
+		return err
+	}
+	if err := rec.WriteCSV(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// tuneGains applies the named tuning rule to the plant model sampled with
+// the given period.
+func tuneGains(m tuning.FOPDT, rule, kind, variant string, period time.Duration) (pidctrl.Gains, error) {
+	r, err := tuning.ParseRule(rule)
+	if err != nil {
+		return pidctrl.Gains{}, err
+	}
+	v, err := tuning.ParseVariant(variant)
+	if err != nil {
+		return pidctrl.Gains{}, err
+	}
+	var k tuning.Kind
+	switch kind {
+	case "pi":
+		k = tuning.PI
+	case "pid":
+		k = tuning.PID
+	default:
+		return pidctrl.Gains{}, fmt.Errorf("unknown controller kind %q", kind)
+	}
+	return m.TuneSampled(r, k, v, period)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "loops.json")
+	os.WriteFile(path, []byte(`{"loops": {"tank": {
+		"gains": {"p": 0.8, "i": 0.05, "d": 0},
+		"output_limits": {"min": 0, "max": 100},
+		"setpoint": 50,
+		"sample_time": "1s"
+	}}}`), 0644)
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-config", path, "-gain", "1", "-tau", "20s", "-duration", "5m"}, &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(stdout.String(), "\n"); lines != 302 {
+		t.Errorf("%d CSV lines, expected header and 301 records", lines)
+	}
+	var finalError float64
+	for _, line := range strings.Split(stderr.String(), "\n") {
+		fmt.Sscanf(line, "steady_state_error: %g", &finalError)
+	}
+	if !strings.Contains(stderr.String(), "steady_state_error: ") || math.Abs(finalError) > 0.1 {
+		t.Errorf("loop did not settle:\n%s", stderr.String())
+	}
+
+	plotPath := filepath.Join(t.TempDir(), "run.svg")
+	if err := run([]string{"-config", path, "-duration", "1m", "-csv", "", "-plot", plotPath}, &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(plotPath); err != nil || !bytes.HasPrefix(data, []byte("<svg")) {
+		t.Errorf("no chart written: %v", err)
+	}
+
+	if err := run([]string{"-config", path, "-loop", "boiler"}, &stdout, &stderr); err == nil {
+		t.Error("expected error for unknown loop")
+	}
+}
+
+func TestRunTune(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "loops.json")
+	os.WriteFile(path, []byte(`{"loops": {"tank": {
+		"gains": {"p": 0, "i": 0, "d": 0},
+		"output_limits": {"min": 0, "max": 100},
+		"setpoint": 50
+	}}}`), 0644)
+	var stdout, stderr bytes.Buffer
+	args := []string{"-config", path, "-gain", "1", "-tau", "20s", "-delay", "2s", "-duration", "5m", "-csv", "", "-tune", "simc"}
+	if err := run(args, &stdout, &stderr); err != nil {
+		t.Fatal(err)
+	}
+	var finalError float64
+	for _, line := range strings.Split(stderr.String(), "\n") {
+		fmt.Sscanf(line, "steady_state_error: %g", &finalError)
+	}
+	// the 1s step adds half a second of dead time: p = 20/(2·2.5)
+	if !strings.Contains(stderr.String(), "gains: p=4 ") || math.Abs(finalError) > 0.1 {
+		t.Errorf("tuned loop did not settle:\n%s", stderr.String())
+	}
+	if err := run(append(args, "-tune-kind", "pd"), &stdout, &stderr); err == nil {
+		t.Error("expected error for unknown controller kind")
+	}
+}
@@ -0,0 +1,304 @@
+// Package config constructs controllers from declarative configuration
+// files, so tunings can be changed without redeploying.
+//
+// Configuration files are JSON documents holding any number of named loops:
+//
+//	{
+//	  "loops": {
+//	    "mash": {
+//	      "gains": {"p": 40, "i": 0.5, "d": 12},
+//	      "output_limits": {"min": 0, "max": 255},
+//	      "integral_limits": {"min": 0, "max": 100},
+//	      "anti_windup": "conditional",
+//	      "direction": "direct",
+//	      "deadband": 0.2,
+//	      "setpoint": 66,
+//	      "setpoint_ramp": 1,
+//	      "ramp_integral_factor": 0,
+//	      "filter": {"kalman": {"process_noise": 0.01, "measurement_noise": 0.25}},
+//	      "sample_time": "1s"
+//	    }
+//	  }
+//	}
+//
+// A Watcher applies changes of a configuration file to a running controller.
+//
+// YAML and TOML are not supported to keep the package free of dependencies;
+// documents in those formats can be converted to JSON, all field names are
+// the same.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+// Config is a configuration file.
+type Config struct {
+	Loops map[string]Loop `json:"loops"`
+}
+
+// Loop is the configuration of a single controller.
+type Loop struct {
+	Gains              pidctrl.Gains   `json:"gains"`
+	OutputLimits       *pidctrl.Limits `json:"output_limits,omitempty"`
+	Setpoint           float64         `json:"setpoint"`
+	SetpointRamp       float64         `json:"setpoint_ramp,omitempty"`
+	RampIntegralFactor *float64        `json:"ramp_integral_factor,omitempty"`
+	Approach           *Approach       `json:"approach,omitempty"`
+	OutputExponent     float64         `json:"output_exponent,omitempty"`
+	Filter             *Filter         `json:"filter,omitempty"`
+	// IntegralLimits bound the integral, nil bounds it by the output
+	// limits.
+	IntegralLimits *pidctrl.Limits `json:"integral_limits,omitempty"`
+	// AntiWindup is "clamp", the default, or "conditional".
+	AntiWindup string `json:"anti_windup,omitempty"`
+	// Direction is "direct", the default, or "reverse".
+	Direction string  `json:"direction,omitempty"`
+	Deadband  float64 `json:"deadband,omitempty"`
+	// SampleTime is the minimum time between output computations, see
+	// PIDController.SetSampleTime, and the interval the loop should be run
+	// at, e.g. with PIDController.Run.
+	SampleTime Duration `json:"sample_time,omitempty"`
+}
+
+var antiWindups = map[string]pidctrl.AntiWindup{
+	"":            pidctrl.AntiWindupClamp,
+	"clamp":       pidctrl.AntiWindupClamp,
+	"conditional": pidctrl.AntiWindupConditional,
+}
+
+var directions = map[string]pidctrl.Direction{
+	"":        pidctrl.Direct,
+	"direct":  pidctrl.Direct,
+	"reverse": pidctrl.Reverse,
+}
+
+// Approach configures staged approach gains.
+type Approach struct {
+	Gains pidctrl.Gains `json:"gains"`
+	Band  float64       `json:"band"`
+}
+
+// Filter configures the process value estimator.
+type Filter struct {
+	Kalman *Kalman `json:"kalman,omitempty"`
+}
+
+// Kalman configures a pidctrl.KalmanFilter.
+type Kalman struct {
+	ProcessNoise     float64 `json:"process_noise"`
+	MeasurementNoise float64 `json:"measurement_noise"`
+}
+
+// Duration is a time.Duration encoded as a string like "1.5s" in JSON.
+type Duration time.Duration
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"1s\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Error is a configuration error of a particular loop.
+type Error struct {
+	Loop string
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("config: loop %q: %v", e.Loop, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Load reads and validates a configuration from r. Unknown fields are
+// rejected to catch typos.
+func Load(r io.Reader) (*Config, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	var c Config
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// LoadFile reads and validates a configuration file. Only JSON files are
+// supported.
+func LoadFile(path string) (*Config, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+	case ".yaml", ".yml", ".toml":
+		return nil, fmt.Errorf("config: %s: %s files are not supported, convert to JSON", path, ext)
+	default:
+		return nil, fmt.Errorf("config: %s: unknown file type %q", path, ext)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Load(bytes.NewReader(data))
+}
+
+// Names returns the names of all loops in sorted order.
+func (c *Config) Names() []string {
+	names := make([]string, 0, len(c.Loops))
+	for name := range c.Loops {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Validate checks all loops.
+func (c *Config) Validate() error {
+	for _, name := range c.Names() {
+		if err := c.Loops[name].Validate(); err != nil {
+			return &Error{Loop: name, Err: err}
+		}
+	}
+	return nil
+}
+
+// Controllers constructs the controllers of all loops.
+func (c *Config) Controllers() (map[string]*pidctrl.PIDController, error) {
+	controllers := make(map[string]*pidctrl.PIDController, len(c.Loops))
+	for _, name := range c.Names() {
+		pc, err := c.Loops[name].NewController()
+		if err != nil {
+			return nil, &Error{Loop: name, Err: err}
+		}
+		controllers[name] = pc
+	}
+	return controllers, nil
+}
+
+// Validate checks the loop configuration for invalid values.
+func (l Loop) Validate() error {
+	min, max := l.limits()
+	if min > max {
+		return fmt.Errorf("output_limits: min %v is greater than max %v", min, max)
+	}
+	if l.OutputExponent < 0 {
+		return fmt.Errorf("output_exponent: %v is negative", l.OutputExponent)
+	}
+	if l.SampleTime < 0 {
+		return fmt.Errorf("sample_time: %v is negative", time.Duration(l.SampleTime))
+	}
+	if min, max := l.integralLimits(); min > max {
+		return fmt.Errorf("integral_limits: min %v is greater than max %v", min, max)
+	}
+	if _, ok := antiWindups[l.AntiWindup]; !ok {
+		return fmt.Errorf("anti_windup: unknown mode %q", l.AntiWindup)
+	}
+	if _, ok := directions[l.Direction]; !ok {
+		return fmt.Errorf("direction: unknown direction %q", l.Direction)
+	}
+	if l.Deadband < 0 {
+		return fmt.Errorf("deadband: %v is negative", l.Deadband)
+	}
+	if l.Filter != nil && l.Filter.Kalman != nil && l.Filter.Kalman.MeasurementNoise <= 0 {
+		return fmt.Errorf("filter.kalman.measurement_noise: must be positive")
+	}
+	return nil
+}
+
+// NewController constructs a controller from the loop configuration.
+func (l Loop) NewController() (*pidctrl.PIDController, error) {
+	c := pidctrl.NewPIDController(0, 0, 0)
+	if err := l.Apply(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Apply changes an existing controller to match the loop configuration,
+// e.g. after the configuration file changed. Nothing is changed if the
+// configuration is invalid. The integrator state is kept.
+func (l Loop) Apply(c *pidctrl.PIDController) error {
+	if err := l.Validate(); err != nil {
+		return err
+	}
+	c.SetGains(l.Gains)
+	c.SetOutputLimits(l.limits())
+	c.SetSetpointRamp(l.SetpointRamp)
+	c.Set(l.Setpoint)
+	if l.RampIntegralFactor != nil {
+		c.SetRampIntegralFactor(*l.RampIntegralFactor)
+	} else {
+		c.SetRampIntegralFactor(1)
+	}
+	if l.Approach != nil {
+		c.SetApproachGains(l.Approach.Gains.P, l.Approach.Gains.I, l.Approach.Gains.D, l.Approach.Band)
+	} else {
+		c.SetApproachGains(0, 0, 0, 0)
+	}
+	c.SetOutputExponent(l.OutputExponent)
+	if l.IntegralLimits != nil {
+		c.SetIntegralLimits(l.integralLimits())
+	} else {
+		c.ClearIntegralLimits()
+	}
+	c.SetAntiWindup(antiWindups[l.AntiWindup])
+	c.SetDirection(directions[l.Direction])
+	c.SetDeadband(l.Deadband)
+	c.SetSampleTime(time.Duration(l.SampleTime))
+	if l.Filter != nil && l.Filter.Kalman != nil {
+		c.SetEstimator(pidctrl.NewKalmanFilter(l.Filter.Kalman.ProcessNoise, l.Filter.Kalman.MeasurementNoise))
+	} else {
+		c.SetEstimator(nil)
+	}
+	return nil
+}
+
+func (l Loop) limits() (min, max float64) {
+	return bounds(l.OutputLimits)
+}
+
+func (l Loop) integralLimits() (min, max float64) {
+	return bounds(l.IntegralLimits)
+}
+
+// bounds returns the bounds of limits, unbounded if it or its fields are
+// nil.
+func bounds(limits *pidctrl.Limits) (min, max float64) {
+	min, max = math.Inf(-1), math.Inf(0)
+	if limits != nil {
+		if limits.Min != nil {
+			min = *limits.Min
+		}
+		if limits.Max != nil {
+			max = *limits.Max
+		}
+	}
+	return min, max
+}
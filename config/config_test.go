@@ -0,0 +1,132 @@
+package config
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+const example = `{
+  "loops": {
+    "mash": {
+      "gains": {"p": 40, "i": 0.5, "d": 12},
+      "output_limits": {"min": 0, "max": 255},
+      "integral_limits": {"min": 0, "max": 100},
+      "anti_windup": "conditional",
+      "deadband": 0.2,
+      "setpoint": 66,
+      "setpoint_ramp": 1,
+      "ramp_integral_factor": 0,
+      "filter": {"kalman": {"process_noise": 0.01, "measurement_noise": 0.25}},
+      "sample_time": "1s"
+    },
+    "boil": {
+      "gains": {"p": 1},
+      "direction": "reverse",
+      "approach": {"gains": {"p": 4}, "band": 5}
+    }
+  }
+}`
+
+func TestLoad(t *testing.T) {
+	c, err := Load(strings.NewReader(example))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names := c.Names(); len(names) != 2 || names[0] != "boil" || names[1] != "mash" {
+		t.Errorf("unexpected loops: %v", names)
+	}
+	if c.Loops["mash"].SampleTime != Duration(time.Second) {
+		t.Errorf("sample time %v", time.Duration(c.Loops["mash"].SampleTime))
+	}
+	controllers, err := c.Controllers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mash := controllers["mash"]
+	if p, i, d := mash.PID(); p != 40 || i != 0.5 || d != 12 {
+		t.Errorf("mash gains %v %v %v", p, i, d)
+	}
+	if min, max := mash.OutputLimits(); min != 0 || max != 255 {
+		t.Errorf("mash limits %v %v", min, max)
+	}
+	if mash.Get() != 66 || mash.SetpointRamp() != 1 || mash.RampIntegralFactor() != 0 {
+		t.Errorf("mash setpoint %v ramp %v factor %v", mash.Get(), mash.SetpointRamp(), mash.RampIntegralFactor())
+	}
+	if min, max := mash.IntegralLimits(); min != 0 || max != 100 {
+		t.Errorf("mash integral limits %v %v", min, max)
+	}
+	if mash.AntiWindup() != pidctrl.AntiWindupConditional || mash.Deadband() != 0.2 || mash.SampleTime() != time.Second {
+		t.Errorf("mash anti-windup %v deadband %v sample time %v", mash.AntiWindup(), mash.Deadband(), mash.SampleTime())
+	}
+	if mash.Direction() != pidctrl.Direct {
+		t.Errorf("mash direction %v", mash.Direction())
+	}
+	boil := controllers["boil"]
+	if p, _, _, band := boil.ApproachGains(); p != 4 || band != 5 {
+		t.Errorf("boil approach %v %v", p, band)
+	}
+	if boil.Direction() != pidctrl.Reverse || boil.AntiWindup() != pidctrl.AntiWindupClamp || boil.SampleTime() != 0 {
+		t.Errorf("boil direction %v anti-windup %v sample time %v", boil.Direction(), boil.AntiWindup(), boil.SampleTime())
+	}
+	// without integral limits the unbounded output limits apply
+	if min, max := boil.IntegralLimits(); !math.IsInf(min, -1) || !math.IsInf(max, 1) {
+		t.Errorf("boil integral limits %v %v", min, max)
+	}
+}
+
+func TestLoad_invalid(t *testing.T) {
+	for _, doc := range []string{
+		`{"loops": {"mash": {"gains": {"p": 1}, "output_limits": {"min": 5, "max": 1}}}}`,
+		`{"loops": {"mash": {"gain": {"p": 1}}}}`,
+		`{"loops": {"mash": {"sample_time": 5}}}`,
+		`{"loops": {"mash": {"integral_limits": {"min": 5, "max": 1}}}}`,
+		`{"loops": {"mash": {"anti_windup": "back-calculation"}}}`,
+		`{"loops": {"mash": {"direction": "sideways"}}}`,
+		`{"loops": {"mash": {"deadband": -1}}}`,
+	} {
+		if _, err := Load(strings.NewReader(doc)); err == nil {
+			t.Errorf("%s: expected error", doc)
+		}
+	}
+	_, err := Load(strings.NewReader(`{"loops": {"mash": {"output_exponent": -1}}}`))
+	var cerr *Error
+	if !errors.As(err, &cerr) || cerr.Loop != "mash" {
+		t.Errorf("expected error for loop mash, got %v", err)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "loops.json")
+	if err := os.WriteFile(path, []byte(example), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFile(path); err != nil {
+		t.Error(err)
+	}
+	if _, err := LoadFile(filepath.Join(dir, "loops.yaml")); err == nil || !strings.Contains(err.Error(), "convert to JSON") {
+		t.Errorf("expected unsupported format error, got %v", err)
+	}
+}
+
+func FuzzLoad(f *testing.F) {
+	f.Add([]byte(example))
+	f.Add([]byte(`{"loops": {"x": {"output_limits": {"min": 5, "max": 1}}}}`))
+	f.Add([]byte(`{"loops": {"x": {"integral_limits": {"min": -1}, "anti_windup": "clamp", "direction": "reverse", "deadband": 1, "sample_time": "2s"}}}`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		c, err := Load(strings.NewReader(string(data)))
+		if err != nil {
+			return
+		}
+		if _, err := c.Controllers(); err != nil {
+			t.Fatalf("validated config failed to construct controllers: %v", err)
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+// Watcher reloads a configuration file when it changes and applies the
+// configuration of one loop to a running controller. Gains and limits are
+// applied bumplessly, and invalid files are reported instead of applied, so
+// editing the file never disturbs the control loop.
+type Watcher struct {
+	Path       string
+	Loop       string
+	Controller *pidctrl.SafePIDController
+	// Interval is the time between checks of the file, 1s if 0.
+	Interval time.Duration
+	// OnError, if set, is called when the file cannot be read, is invalid
+	// or lacks the loop. The controller keeps the last applied
+	// configuration.
+	OnError func(error)
+	// OnApply, if set, is called with every applied loop configuration.
+	OnApply func(Loop)
+
+	modTime time.Time
+	size    int64
+	applied *Loop
+}
+
+// NewWatcher returns a Watcher applying the named loop of the file at path
+// to c.
+func NewWatcher(path, loop string, c *pidctrl.SafePIDController) *Watcher {
+	return &Watcher{Path: path, Loop: loop, Controller: c}
+}
+
+// Run checks the file at the configured interval until ctx is cancelled and
+// returns ctx.Err(). The file is checked once immediately.
+func (w *Watcher) Run(ctx context.Context) error {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := w.Check(); err != nil && w.OnError != nil {
+			w.OnError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check reloads the file if its modification time or size changed since the
+// last check and applies the loop configuration if it differs from the last
+// applied one. It reports whether the configuration was applied. The first
+// check always loads the file.
+func (w *Watcher) Check() (bool, error) {
+	fi, err := os.Stat(w.Path)
+	if err != nil {
+		return false, err
+	}
+	if fi.ModTime().Equal(w.modTime) && fi.Size() == w.size {
+		return false, nil
+	}
+	cfg, err := LoadFile(w.Path)
+	if err != nil {
+		return false, err
+	}
+	// only remember the file once it loaded, so that a file caught while
+	// being written is read again
+	w.modTime, w.size = fi.ModTime(), fi.Size()
+	loop, ok := cfg.Loops[w.Loop]
+	if !ok {
+		return false, &Error{Loop: w.Loop, Err: fmt.Errorf("not found in %s", w.Path)}
+	}
+	if w.applied != nil && equalLoops(*w.applied, loop) {
+		return false, nil
+	}
+	keepFilter := w.applied != nil && reflect.DeepEqual(w.applied.Filter, loop.Filter)
+	w.Controller.Do(func(c *pidctrl.PIDController) {
+		bumpless, estimator := c.Bumpless(), c.Estimator()
+		c.SetBumpless(true)
+		err = loop.Apply(c)
+		c.SetBumpless(bumpless)
+		if err == nil && keepFilter {
+			// keep the state of an unchanged filter
+			c.SetEstimator(estimator)
+		}
+	})
+	if err != nil {
+		return false, &Error{Loop: w.Loop, Err: err}
+	}
+	w.applied = &loop
+	if w.OnApply != nil {
+		w.OnApply(loop)
+	}
+	return true, nil
+}
+
+func equalLoops(a, b Loop) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(ja) == string(jb)
+}
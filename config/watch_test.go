@@ -0,0 +1,113 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+func writeConfig(t *testing.T, path, data string, mod time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mod, mod); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "loops.json")
+	mod := time.Date(2016, 3, 2, 0, 0, 0, 0, time.UTC)
+	writeConfig(t, path, `{"loops": {"mash": {"gains": {"p": 2, "i": 0.1}, "setpoint": 10, "output_limits": {"min": -100, "max": 100}}}}`, mod)
+
+	c := pidctrl.NewSafePIDController(0, 0, 0)
+	w := NewWatcher(path, "mash", c)
+	var applied int
+	w.OnApply = func(Loop) { applied++ }
+	if ok, err := w.Check(); !ok || err != nil {
+		t.Fatalf("initial check: %v %v", ok, err)
+	}
+	for i := 0; i < 10; i++ {
+		c.UpdateDuration(5, time.Second)
+	}
+	before := c.UpdateDuration(5, time.Second)
+
+	// unchanged file, and touched file with the same content
+	if ok, err := w.Check(); ok || err != nil {
+		t.Errorf("unchanged file applied: %v %v", ok, err)
+	}
+	mod = mod.Add(time.Second)
+	writeConfig(t, path, `{"loops": {"mash": {"gains": {"p": 2, "i": 0.1}, "setpoint": 10, "output_limits": {"min": -100, "max": 100}}}}`, mod)
+	if ok, err := w.Check(); ok || err != nil {
+		t.Errorf("touched file applied: %v %v", ok, err)
+	}
+
+	// new gains are applied without a bump
+	mod = mod.Add(time.Second)
+	writeConfig(t, path, `{"loops": {"mash": {"gains": {"p": 8, "i": 0.1}, "setpoint": 10, "output_limits": {"min": -100, "max": 100}}}}`, mod)
+	if ok, err := w.Check(); !ok || err != nil {
+		t.Fatalf("changed file: %v %v", ok, err)
+	}
+	if g := c.Settings().Gains; g.P != 8 {
+		t.Errorf("gains not applied: %+v", g)
+	}
+	if after := c.UpdateDuration(5, time.Second); math.Abs(after-before) > 1 {
+		t.Errorf("output bumped from %v to %v", before, after)
+	}
+	if applied != 2 {
+		t.Errorf("OnApply called %d times", applied)
+	}
+
+	// invalid files and missing loops are reported and not applied
+	for _, data := range []string{
+		`{"loops": {"mash": {"gains": {"p": 1}, "output_limits": {"min": 5, "max": 1}}}}`,
+		`{"loops": {"boil": {"gains": {"p": 1}}}}`,
+		`{"loops": `,
+	} {
+		mod = mod.Add(time.Second)
+		writeConfig(t, path, data, mod)
+		var cfgErr *Error
+		if ok, err := w.Check(); ok || !errors.As(err, &cfgErr) && err == nil {
+			t.Errorf("%s: %v %v", data, ok, err)
+		}
+		if g := c.Settings().Gains; g.P != 8 {
+			t.Errorf("%s: gains changed: %+v", data, g)
+		}
+	}
+}
+
+func TestWatcher_Run(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "loops.json")
+	c := pidctrl.NewSafePIDController(0, 0, 0)
+	w := NewWatcher(path, "mash", c)
+	w.Interval = time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errs := make(chan error, 1)
+	w.OnError = func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	}
+	w.OnApply = func(Loop) { cancel() }
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+	if err := <-errs; !os.IsNotExist(err) {
+		t.Errorf("missing file: %v", err)
+	}
+	writeConfig(t, path, `{"loops": {"mash": {"gains": {"p": 3}}}}`, time.Now())
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Run returned %v", err)
+	}
+	if g := c.Settings().Gains; g.P != 3 {
+		t.Errorf("gains not applied: %+v", g)
+	}
+}
@@ -0,0 +1,54 @@
+package pidctrl
+
+import (
+	"math"
+	"time"
+)
+
+// CrossLimiter is a cross-limited pair of loops as used for burner air/fuel
+// control: the setpoint of each loop is constrained by the measured value of
+// the other, so that during demand changes the Lead loop (air) always leads
+// on increases and the Lag loop (fuel) always leads on decreases. The process
+// never passes through an unsafe combination, such as excess fuel, even when
+// the loops respond at different speeds.
+//
+// Demand is given in Lag units. Ratio converts Lag units to Lead units, e.g.
+// the stoichiometric air per unit of fuel, and Margin is the fraction by
+// which the values may deviate from that ratio before the other loop is
+// held back.
+type CrossLimiter struct {
+	Lead   *PIDController
+	Lag    *PIDController
+	Ratio  float64
+	Margin float64
+
+	demand float64
+}
+
+// NewCrossLimiter returns a CrossLimiter for the given loops.
+func NewCrossLimiter(lead, lag *PIDController, ratio, margin float64) *CrossLimiter {
+	return &CrossLimiter{Lead: lead, Lag: lag, Ratio: ratio, Margin: margin}
+}
+
+// Set changes the demand, in Lag units.
+func (x *CrossLimiter) Set(demand float64) *CrossLimiter {
+	x.demand = demand
+	return x
+}
+
+// Get returns the demand.
+func (x *CrossLimiter) Get() float64 {
+	return x.demand
+}
+
+// UpdateDuration limits the setpoints of both loops by the measured value of
+// the other, updates them and returns their outputs.
+func (x *CrossLimiter) UpdateDuration(lead, lag float64, duration time.Duration) (leadOut, lagOut float64) {
+	// the lag loop may not exceed what the lead loop supports
+	lagSP := math.Min(x.demand, lead/x.Ratio*(1+x.Margin))
+	// the lead loop may not fall below what the lag loop needs
+	leadSP := math.Max(x.demand*x.Ratio, lag*x.Ratio*(1-x.Margin))
+	leadOut = x.Lead.Set(leadSP).UpdateDuration(lead, duration)
+	lagOut = x.Lag.Set(lagSP).UpdateDuration(lag, duration)
+	return leadOut, lagOut
+}
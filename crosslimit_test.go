@@ -0,0 +1,37 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCrossLimiter(t *testing.T) {
+	// fast fuel, slow air at 10 units of air per unit of fuel
+	x := NewCrossLimiter(
+		NewPIDController(1, 0.05, 0).SetOutputLimits(0, 200),
+		NewPIDController(0.5, 2, 0).SetOutputLimits(0, 20),
+		10, 0.02,
+	)
+	var air, fuel float64
+	dt := 100 * time.Millisecond
+	step := func(demand float64, n int) {
+		x.Set(demand)
+		for i := 0; i < n; i++ {
+			airOut, fuelOut := x.UpdateDuration(air, fuel, dt)
+			air += (airOut - air) * 0.02
+			fuel += (fuelOut - fuel) * 0.5
+			if fuel*10 > air*1.03 {
+				t.Fatalf("demand %v, update %d: fuel %v exceeds air %v", demand, i, fuel, air)
+			}
+		}
+	}
+	step(1, 200)
+	step(5, 3000)
+	if fuel < 4.9 || air < 49 {
+		t.Errorf("did not reach the increased demand: fuel %v air %v", fuel, air)
+	}
+	step(2, 3000)
+	if fuel > 2.1 || air > 21 {
+		t.Errorf("did not reach the decreased demand: fuel %v air %v", fuel, air)
+	}
+}
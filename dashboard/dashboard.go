@@ -0,0 +1,244 @@
+// Package dashboard serves a small web page with live charts of setpoint,
+// process value, output and the P, I and D terms of one or more controllers.
+// Updates are streamed to the browser with server-sent events, and a bounded
+// history of recent samples per loop is served at /history so charts are
+// filled on page load.
+//
+// Controllers are connected by installing the function returned by Observer
+// as their observer:
+//
+//	d := dashboard.New()
+//	c.SetObserver(d.Observer("mash"))
+//	http.Handle("/dashboard/", http.StripPrefix("/dashboard", d))
+package dashboard
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+//go:embed index.html
+var index []byte
+
+// Sample is a single update as sent to the browser.
+type Sample struct {
+	Loop     string  `json:"loop"`
+	Time     int64   `json:"t"` // milliseconds since the Unix epoch
+	Setpoint float64 `json:"sp"`
+	Value    float64 `json:"pv"`
+	Output   float64 `json:"out"`
+	P        float64 `json:"p"`
+	I        float64 `json:"i"`
+	D        float64 `json:"d"`
+}
+
+// DefaultHistorySize is the number of samples per loop kept by New.
+const DefaultHistorySize = 3600
+
+// Dashboard is an http.Handler serving the dashboard page at /, the event
+// stream at /events and the sample history at /history.
+type Dashboard struct {
+	mu          sync.Mutex
+	subscribers map[chan []byte]struct{}
+	history     map[string]*pidctrl.Recorder
+
+	// Now returns the time samples are stamped with, time.Now by default.
+	Now func() time.Time
+	// HistorySize is the number of samples kept per loop, 0 disables the
+	// history. Changes apply to loops published for the first time.
+	HistorySize int
+}
+
+// New returns a new Dashboard.
+func New() *Dashboard {
+	return &Dashboard{
+		subscribers: map[chan []byte]struct{}{},
+		history:     map[string]*pidctrl.Recorder{},
+		Now:         time.Now,
+		HistorySize: DefaultHistorySize,
+	}
+}
+
+// Observer returns an observer function for the controller with the given
+// name, to be installed with PIDController.SetObserver.
+func (d *Dashboard) Observer(name string) func(pidctrl.UpdateInfo) {
+	return func(info pidctrl.UpdateInfo) {
+		d.Publish(Sample{
+			Loop:     name,
+			Time:     d.Now().UnixMilli(),
+			Setpoint: info.Setpoint,
+			Value:    info.Value,
+			Output:   info.Output,
+			P:        info.Terms.P,
+			I:        info.Terms.I,
+			D:        info.Terms.D,
+		})
+	}
+}
+
+// Publish adds a sample to the history of its loop and sends it to all
+// connected browsers. Browsers that can't keep up miss samples instead of
+// blocking the control loop.
+func (d *Dashboard) Publish(s Sample) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if r := d.recorder(s.Loop); r != nil {
+		r.Add(s.record())
+	}
+	for ch := range d.subscribers {
+		select {
+		case ch <- data:
+		default:
+		}
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (d *Dashboard) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/", "":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(index)
+	case "/events":
+		d.serveEvents(w, r)
+	case "/history":
+		d.serveHistory(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (d *Dashboard) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	ch := make(chan []byte, 64)
+	d.mu.Lock()
+	d.subscribers[ch] = struct{}{}
+	d.mu.Unlock()
+	defer func() {
+		d.mu.Lock()
+		delete(d.subscribers, ch)
+		d.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case data := <-ch:
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// History returns the held samples of the named loop with from <= Time < to,
+// oldest first. A zero from or to leaves that end of the range open.
+func (d *Dashboard) History(loop string, from, to time.Time) []Sample {
+	d.mu.Lock()
+	r := d.history[loop]
+	d.mu.Unlock()
+	if r == nil {
+		return nil
+	}
+	recs := r.Range(from, to)
+	samples := make([]Sample, len(recs))
+	for i, rec := range recs {
+		samples[i] = sampleOf(loop, rec)
+	}
+	return samples
+}
+
+// recorder returns the history of the named loop, creating it on first use.
+// d.mu must be held.
+func (d *Dashboard) recorder(loop string) *pidctrl.Recorder {
+	r, ok := d.history[loop]
+	if !ok && d.HistorySize > 0 {
+		r = pidctrl.NewRecorder(d.HistorySize)
+		d.history[loop] = r
+	}
+	return r
+}
+
+func (s Sample) record() pidctrl.Record {
+	return pidctrl.Record{
+		Time:     time.UnixMilli(s.Time),
+		Setpoint: s.Setpoint,
+		Value:    s.Value,
+		Output:   s.Output,
+		Terms:    pidctrl.Terms{P: s.P, I: s.I, D: s.D},
+	}
+}
+
+func sampleOf(loop string, rec pidctrl.Record) Sample {
+	return Sample{
+		Loop:     loop,
+		Time:     rec.Time.UnixMilli(),
+		Setpoint: rec.Setpoint,
+		Value:    rec.Value,
+		Output:   rec.Output,
+		P:        rec.Terms.P,
+		I:        rec.Terms.I,
+		D:        rec.Terms.D,
+	}
+}
+
+// serveHistory writes the held samples as a JSON array. The optional loop
+// parameter selects a single loop, from and to bound the range in
+// milliseconds since the Unix epoch.
+func (d *Dashboard) serveHistory(w http.ResponseWriter, r *http.Request) {
+	var from, to time.Time
+	for _, p := range []struct {
+		name string
+		t    *time.Time
+	}{{"from", &from}, {"to", &to}} {
+		v := r.URL.Query().Get(p.name)
+		if v == "" {
+			continue
+		}
+		ms, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			http.Error(w, "invalid "+p.name, http.StatusBadRequest)
+			return
+		}
+		*p.t = time.UnixMilli(ms)
+	}
+	var loops []string
+	if loop := r.URL.Query().Get("loop"); loop != "" {
+		loops = []string{loop}
+	} else {
+		d.mu.Lock()
+		for loop := range d.history {
+			loops = append(loops, loop)
+		}
+		d.mu.Unlock()
+		sort.Strings(loops)
+	}
+	samples := []Sample{}
+	for _, loop := range loops {
+		samples = append(samples, d.History(loop, from, to)...)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(samples)
+}
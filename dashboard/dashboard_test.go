@@ -0,0 +1,110 @@
+package dashboard
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+func TestDashboard(t *testing.T) {
+	d := New()
+	d.Now = func() time.Time { return time.UnixMilli(1456876800000) }
+	srv := httptest.NewServer(d)
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || !strings.HasPrefix(res.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("page: %d %s", res.StatusCode, res.Header.Get("Content-Type"))
+	}
+
+	res, err = http.Get(srv.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	// wait for the subscription before publishing
+	for {
+		d.mu.Lock()
+		n := len(d.subscribers)
+		d.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	c := pidctrl.NewPIDController(2, 0, 0).SetObserver(d.Observer("mash")).Set(66)
+	c.UpdateDuration(65, time.Second)
+
+	line, err := bufio.NewReader(res.Body).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Sample
+	if !strings.HasPrefix(line, "data: ") || json.Unmarshal([]byte(line[6:]), &got) != nil {
+		t.Fatalf("malformed event: %q", line)
+	}
+	want := Sample{Loop: "mash", Time: 1456876800000, Setpoint: 66, Value: 65, Output: 2, P: 2}
+	if got != want {
+		t.Errorf("event:\n%+v\n%+v", got, want)
+	}
+}
+
+func TestDashboard_History(t *testing.T) {
+	d := New()
+	d.HistorySize = 3
+	for i := int64(0); i < 5; i++ {
+		d.Publish(Sample{Loop: "mash", Time: 1000 * i, Value: float64(i)})
+	}
+	d.Publish(Sample{Loop: "boil", Time: 2500, Value: 99})
+
+	if got := d.History("mash", time.Time{}, time.Time{}); len(got) != 3 || got[0].Value != 2 {
+		t.Errorf("history not bounded: %+v", got)
+	}
+	if got := d.History("mash", time.UnixMilli(3000), time.UnixMilli(4000)); len(got) != 1 || got[0].Value != 3 {
+		t.Errorf("unexpected range: %+v", got)
+	}
+	if got := d.History("hlt", time.Time{}, time.Time{}); got != nil {
+		t.Errorf("unknown loop returned %+v", got)
+	}
+
+	srv := httptest.NewServer(d)
+	defer srv.Close()
+	res, err := http.Get(srv.URL + "/history?from=2500")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var got []Sample
+	if err := json.NewDecoder(res.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	want := []Sample{{Loop: "boil", Time: 2500, Value: 99}, {Loop: "mash", Time: 3000, Value: 3}, {Loop: "mash", Time: 4000, Value: 4}}
+	if len(got) != len(want) {
+		t.Fatalf("history:\n%+v\n%+v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("sample %d: %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	res, err = http.Get(srv.URL + "/history?to=x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid range: status %d", res.StatusCode)
+	}
+}
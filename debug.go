@@ -0,0 +1,50 @@
+package pidctrl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// String returns a one-line summary of gains, setpoint and last output.
+func (c *PIDController) String() string {
+	return fmt.Sprintf("PID(p=%g i=%g d=%g setpoint=%g output=%g)", c.p, c.i, c.d, c.target, c.output)
+}
+
+// DebugString returns the complete configuration and internal state of the
+// controller, one field per line, for error reports.
+func (c *PIDController) DebugString() string {
+	var b strings.Builder
+	field := func(name string, v interface{}) { fmt.Fprintf(&b, "%s: %v\n", name, v) }
+	field("gains", fmt.Sprintf("p=%g i=%g d=%g", c.p, c.i, c.d))
+	field("setpoint", c.target)
+	field("working_setpoint", c.setpoint)
+	field("output_limits", fmt.Sprintf("[%g, %g]", c.outMin, c.outMax))
+	imin, imax := c.IntegralLimits()
+	field("integral_limits", fmt.Sprintf("[%g, %g]", imin, imax))
+	field("direction", map[Direction]string{Direct: "direct", Reverse: "reverse"}[c.Direction()])
+	field("deadband", c.deadband)
+	field("bias", c.bias)
+	field("feed_forward", c.feedForward())
+	field("sample_time", c.sampleTime)
+	field("integral", c.integral)
+	field("prev_value", c.prevValue)
+	field("prev_error", c.prevErr)
+	field("derivative_filter_state", c.dFilt)
+	field("terms", fmt.Sprintf("p=%g i=%g d=%g ff=%g clamp=%v", c.terms.P, c.terms.I, c.terms.D, c.terms.FeedForward, c.terms.Clamp))
+	field("output", c.output)
+	field("saturated", c.saturated)
+	field("windup", c.windup)
+	field("approaching", c.approaching)
+	field("guarding", c.guarding)
+	field("oscillating", c.osc.active)
+	field("stale", c.stale.active)
+	field("saturation_fault", c.satFault.active)
+	field("started", c.started)
+	field("last_update", c.lastUpdate)
+	return b.String()
+}
+
+// String returns a one-line summary of gains, setpoint and limits.
+func (c *IntegerPIDController) String() string {
+	return fmt.Sprintf("IntegerPID(p=%d i=%d d=%d setpoint=%d limits=[%d, %d])", c.p, c.i, c.d, c.setpoint, c.outMin, c.outMax)
+}
@@ -0,0 +1,25 @@
+package pidctrl
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestString(t *testing.T) {
+	c := NewPIDController(0.5, 0.25, 0).Set(42)
+	c.UpdateDuration(40, time.Second)
+	if s := fmt.Sprint(c); s != "PID(p=0.5 i=0.25 d=0 setpoint=42 output=1.5)" {
+		t.Errorf("String() = %q", s)
+	}
+	if s := NewIntegerPIDController(500, 0, 0).Set(3).String(); s != "IntegerPID(p=500 i=0 d=0 setpoint=3 limits=[-9223372036854775808, 9223372036854775807])" {
+		t.Errorf("String() = %q", s)
+	}
+	d := c.DebugString()
+	for _, want := range []string{"integral: 0.5\n", "output_limits: [-Inf, +Inf]\n", "direction: direct\n", "started: true\n"} {
+		if !strings.Contains(d, want) {
+			t.Errorf("DebugString() lacks %q:\n%s", want, d)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package pidctrl
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrSingular is returned by NewInverseDecoupler for process gain matrices
+// without an inverse.
+var ErrSingular = errors.New("singular matrix")
+
+// ErrMatrixShape is returned for matrices that are not rectangular or whose
+// dimensions do not fit the operation.
+var ErrMatrixShape = errors.New("invalid matrix shape")
+
+// Decoupler combines the outputs of interacting loops, such as temperature
+// and humidity of a climate chamber, through a static gain matrix before
+// they drive the actuators: actuator i receives the sum of Matrix[i][j]
+// times the output of controller j.
+type Decoupler struct {
+	Matrix [][]float64
+}
+
+// NewDecoupler returns a Decoupler for the given matrix, with one row per
+// actuator and one column per controller.
+func NewDecoupler(matrix [][]float64) (*Decoupler, error) {
+	for _, row := range matrix {
+		if len(row) != len(matrix[0]) {
+			return nil, ErrMatrixShape
+		}
+	}
+	return &Decoupler{Matrix: matrix}, nil
+}
+
+// NewInverseDecoupler returns the ideal static Decoupler for the square
+// steady-state process gain matrix gains, where gains[i][j] is the change of
+// process value i per unit of actuator j. With it each controller only sees
+// its own process value.
+func NewInverseDecoupler(gains [][]float64) (*Decoupler, error) {
+	n := len(gains)
+	// Gauss-Jordan elimination with partial pivoting on [gains | I]
+	m := make([][]float64, n)
+	for i, row := range gains {
+		if len(row) != n {
+			return nil, ErrMatrixShape
+		}
+		m[i] = make([]float64, 2*n)
+		copy(m[i], row)
+		m[i][n+i] = 1
+	}
+	for col := 0; col < n; col++ {
+		pivot := col
+		for r := col + 1; r < n; r++ {
+			if math.Abs(m[r][col]) > math.Abs(m[pivot][col]) {
+				pivot = r
+			}
+		}
+		if math.Abs(m[pivot][col]) < 1e-12 {
+			return nil, ErrSingular
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+		for k, p := 0, m[col][col]; k < 2*n; k++ {
+			m[col][k] /= p
+		}
+		for r := 0; r < n; r++ {
+			if r == col || m[r][col] == 0 {
+				continue
+			}
+			for k, f := 0, m[r][col]; k < 2*n; k++ {
+				m[r][k] -= f * m[col][k]
+			}
+		}
+	}
+	for i := range m {
+		m[i] = m[i][n:]
+	}
+	return &Decoupler{Matrix: m}, nil
+}
+
+// Apply returns the actuator values for the controller outputs appended to
+// out[:0]. It returns ErrMatrixShape if the number of outputs does not
+// match the matrix.
+func (d *Decoupler) Apply(outputs []float64, out []float64) ([]float64, error) {
+	out = out[:0]
+	for _, row := range d.Matrix {
+		if len(row) != len(outputs) {
+			return nil, ErrMatrixShape
+		}
+		var sum float64
+		for j, g := range row {
+			sum += g * outputs[j]
+		}
+		out = append(out, sum)
+	}
+	return out, nil
+}
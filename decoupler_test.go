@@ -0,0 +1,46 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDecoupler(t *testing.T) {
+	d, err := NewDecoupler([][]float64{{1, 0.5}, {0, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := d.Apply([]float64{2, 4}, nil)
+	if err != nil || out[0] != 4 || out[1] != 4 {
+		t.Errorf("actuators %v %v", out, err)
+	}
+	if _, err := d.Apply([]float64{1}, nil); err != ErrMatrixShape {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := NewDecoupler([][]float64{{1, 2}, {3}}); err != ErrMatrixShape {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestInverseDecoupler(t *testing.T) {
+	// heater raises temperature and lowers humidity, humidifier the inverse
+	gains := [][]float64{{2, -0.5}, {-1, 3}}
+	d, err := NewInverseDecoupler(gains)
+	if err != nil {
+		t.Fatal(err)
+	}
+	act, _ := d.Apply([]float64{1, 0}, nil)
+	// the process then only moves the first process value
+	for i, row := range gains {
+		var pv float64
+		for j, g := range row {
+			pv += g * act[j]
+		}
+		if want := []float64{1, 0}[i]; math.Abs(pv-want) > 1e-12 {
+			t.Errorf("process value %d: %v != %v", i, pv, want)
+		}
+	}
+	if _, err := NewInverseDecoupler([][]float64{{1, 2}, {2, 4}}); err != ErrSingular {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+// Package demo runs a controller against a simulated first order plus dead
+// time plant one step at a time, for interactive tuning demos and teaching.
+// It has no file or network dependencies, so it also runs in the browser;
+// the demo/wasm command exposes it to JavaScript.
+//
+// Demos are configured with JSON documents combining a config.Loop with the
+// plant model:
+//
+//	{
+//	  "loop": {"gains": {"p": 2, "i": 0.1}, "output_limits": {"min": 0, "max": 100}, "setpoint": 50},
+//	  "plant": {"gain": 1, "tau": "20s", "dead_time": "2s"},
+//	  "dt": "500ms"
+//	}
+package demo
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/felixge/pidctrl"
+	"github.com/felixge/pidctrl/config"
+	"github.com/felixge/pidctrl/sim"
+)
+
+// Plant configures the simulated first order plus dead time plant.
+type Plant struct {
+	Gain     float64         `json:"gain"`
+	Tau      config.Duration `json:"tau"`
+	DeadTime config.Duration `json:"dead_time,omitempty"`
+	Initial  float64         `json:"initial,omitempty"` // initial process value
+	Noise    float64         `json:"noise,omitempty"`   // measurement noise standard deviation
+}
+
+// Config configures a Demo.
+type Config struct {
+	Loop  config.Loop     `json:"loop"`
+	Plant Plant           `json:"plant"`
+	Dt    config.Duration `json:"dt,omitempty"` // simulation step, 1s if 0
+}
+
+// ParseConfig decodes a JSON configuration.
+func ParseConfig(data []byte) (Config, error) {
+	var cfg Config
+	err := json.Unmarshal(data, &cfg)
+	return cfg, err
+}
+
+// Frame is the state of the loop after a simulation step.
+type Frame struct {
+	Time     float64 `json:"t"` // simulated seconds since the start
+	Setpoint float64 `json:"setpoint"`
+	Value    float64 `json:"value"`
+	Output   float64 `json:"output"`
+}
+
+// Demo is a controller in closed loop with a simulated plant. It is not safe
+// for concurrent use.
+type Demo struct {
+	cfg   Config
+	c     *pidctrl.PIDController
+	plant sim.Plant
+	dt    time.Duration
+	now   time.Duration
+	load  float64
+	value float64
+}
+
+// New returns a demo at time 0 with the plant at its initial value.
+func New(cfg Config) (*Demo, error) {
+	if cfg.Plant.Gain == 0 || cfg.Plant.Tau < 0 || cfg.Plant.DeadTime < 0 {
+		return nil, errors.New("demo: plant gain must be nonzero and times not negative")
+	}
+	c, err := cfg.Loop.NewController()
+	if err != nil {
+		return nil, err
+	}
+	d := &Demo{cfg: cfg, c: c, dt: time.Duration(cfg.Dt)}
+	if d.dt <= 0 {
+		d.dt = time.Second
+	}
+	d.Reset()
+	return d, nil
+}
+
+// Reset restarts the demo at time 0 with the plant at its initial value and
+// a reset controller, keeping tuning changes made since New.
+func (d *Demo) Reset() {
+	p := d.cfg.Plant
+	var plant sim.Plant = &sim.Delay{
+		Plant: sim.NewFirstOrder(p.Gain, time.Duration(p.Tau)).SetValue(p.Initial),
+		Delay: time.Duration(p.DeadTime),
+	}
+	plant = sim.NewDisturbance(plant, func(time.Duration) float64 { return d.load })
+	if p.Noise > 0 {
+		plant = sim.NewNoise(plant, p.Noise, 1)
+	}
+	d.plant, d.now, d.load, d.value = plant, 0, 0, p.Initial
+	d.c.Reset()
+}
+
+// Controller returns the controller of the demo, e.g. to tune it.
+func (d *Demo) Controller() *pidctrl.PIDController {
+	return d.c
+}
+
+// Apply changes the gains, setpoint or limits of the controller, see
+// pidctrl.Settings.
+func (d *Demo) Apply(s pidctrl.Settings) error {
+	return d.c.ApplySettings(s)
+}
+
+// Disturb sets a load disturbance added to the plant input, in output
+// units, to demonstrate disturbance rejection.
+func (d *Demo) Disturb(load float64) {
+	d.load = load
+}
+
+// Step advances the simulation by n steps and returns their frames.
+func (d *Demo) Step(n int) []Frame {
+	frames := make([]Frame, 0, n)
+	for i := 0; i < n; i++ {
+		out := d.c.UpdateDuration(d.value, d.dt)
+		d.value = d.plant.Update(out, d.dt)
+		d.now += d.dt
+		frames = append(frames, Frame{
+			Time:     d.now.Seconds(),
+			Setpoint: d.c.WorkingSetpoint(),
+			Value:    d.value,
+			Output:   out,
+		})
+	}
+	return frames
+}
+
+// Time returns the simulated time since the start.
+func (d *Demo) Time() time.Duration {
+	return d.now
+}
@@ -0,0 +1,54 @@
+package demo
+
+import (
+	"math"
+	"testing"
+
+	"github.com/felixge/pidctrl"
+)
+
+const testConfig = `{
+	"loop": {"gains": {"p": 2, "i": 0.2}, "output_limits": {"min": 0, "max": 100}, "setpoint": 50},
+	"plant": {"gain": 1, "tau": "20s", "dead_time": "2s"},
+	"dt": "500ms"
+}`
+
+func TestDemo(t *testing.T) {
+	cfg, err := ParseConfig([]byte(testConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames := d.Step(600)
+	if len(frames) != 600 || frames[0].Time != 0.5 || d.Time().Seconds() != 300 {
+		t.Fatalf("%d frames, first at %v, time %v", len(frames), frames[0].Time, d.Time())
+	}
+	if last := frames[len(frames)-1]; math.Abs(last.Value-50) > 0.5 || last.Setpoint != 50 {
+		t.Errorf("loop did not settle: %+v", last)
+	}
+	before := frames[len(frames)-1].Output
+	d.Disturb(10)
+	frames = d.Step(600)
+	if last := frames[len(frames)-1]; math.Abs(last.Value-50) > 0.5 || math.Abs(last.Output-(before-10)) > 0.5 {
+		t.Errorf("load disturbance not rejected: %+v, output before %v", last, before)
+	}
+	setpoint := 30.0
+	if err := d.Apply(pidctrl.Settings{Setpoint: &setpoint}); err != nil {
+		t.Fatal(err)
+	}
+	d.Reset()
+	if d.Time() != 0 || d.Step(1)[0].Setpoint != 30 {
+		t.Error("reset did not restart with the applied setpoint")
+	}
+}
+
+func TestNew_InvalidPlant(t *testing.T) {
+	cfg, _ := ParseConfig([]byte(testConfig))
+	cfg.Plant.Gain = 0
+	if _, err := New(cfg); err == nil {
+		t.Error("expected error for a plant without gain")
+	}
+}
@@ -0,0 +1,93 @@
+//go:build js && wasm
+
+// Command wasm exposes the demo package to JavaScript when compiled to
+// WebAssembly:
+//
+//	GOOS=js GOARCH=wasm go build -o demo.wasm ./demo/wasm
+//	cp "$(go env GOROOT)/misc/wasm/wasm_exec.js" .
+//
+// The module defines a global function pidctrlDemo(config) taking a demo
+// configuration as JSON string and returning an object with the methods
+//
+//	step(n)         advances n steps and returns the frames as JSON string
+//	apply(settings) applies pidctrl.Settings given as JSON string
+//	disturb(load)   sets the load disturbance
+//	reset()         restarts the simulation
+//	time()          returns the simulated seconds
+//
+// Errors are thrown as JavaScript exceptions. index.html is a small tuning
+// page using the module.
+package main
+
+import (
+	"encoding/json"
+	"syscall/js"
+
+	"github.com/felixge/pidctrl"
+	"github.com/felixge/pidctrl/demo"
+)
+
+func main() {
+	js.Global().Set("pidctrlDemo", js.FuncOf(newDemo))
+	select {}
+}
+
+// newDemo implements pidctrlDemo.
+func newDemo(_ js.Value, args []js.Value) interface{} {
+	if len(args) != 1 {
+		throw("pidctrlDemo: expected one configuration argument")
+	}
+	cfg, err := demo.ParseConfig([]byte(args[0].String()))
+	if err != nil {
+		throw(err.Error())
+	}
+	d, err := demo.New(cfg)
+	if err != nil {
+		throw(err.Error())
+	}
+	return js.ValueOf(map[string]interface{}{
+		"step": js.FuncOf(func(_ js.Value, args []js.Value) interface{} {
+			n := 1
+			if len(args) > 0 {
+				n = args[0].Int()
+			}
+			data, err := json.Marshal(d.Step(n))
+			if err != nil {
+				throw(err.Error())
+			}
+			return string(data)
+		}),
+		"apply": js.FuncOf(func(_ js.Value, args []js.Value) interface{} {
+			var s pidctrl.Settings
+			if len(args) != 1 {
+				throw("apply: expected one settings argument")
+			}
+			if err := json.Unmarshal([]byte(args[0].String()), &s); err != nil {
+				throw(err.Error())
+			}
+			if err := d.Apply(s); err != nil {
+				throw(err.Error())
+			}
+			return nil
+		}),
+		"disturb": js.FuncOf(func(_ js.Value, args []js.Value) interface{} {
+			if len(args) != 1 {
+				throw("disturb: expected one load argument")
+			}
+			d.Disturb(args[0].Float())
+			return nil
+		}),
+		"reset": js.FuncOf(func(js.Value, []js.Value) interface{} {
+			d.Reset()
+			return nil
+		}),
+		"time": js.FuncOf(func(js.Value, []js.Value) interface{} {
+			return d.Time().Seconds()
+		}),
+	})
+}
+
+// throw raises a JavaScript exception with the given message.
+func throw(msg string) {
+	panic(js.Global().Get("Error").New(msg))
+}
@@ -0,0 +1,41 @@
+package pidctrl
+
+import "time"
+
+// Diagnostics describes the computation of a single update.
+type Diagnostics struct {
+	Computed bool          // false if the update returned the held output, e.g. while paused or within the sample time
+	Error    float64       // setpoint minus value, after direction, transform and deadband
+	Dt       time.Duration // duration used, including skipped sample intervals
+	Terms    Terms         // term contributions and clamp cause
+	Clamped  bool          // output clamped to the output limits
+	Windup   bool          // integral clamped by anti-windup
+}
+
+// UpdateWithDiagnostics is like UpdateDuration, but additionally returns the
+// details of the computation. If the update did not compute a new output,
+// diag.Computed is false and the other fields describe the last computation.
+func (c *PIDController) UpdateWithDiagnostics(value float64, duration time.Duration) (output float64, diag Diagnostics) {
+	output = c.UpdateDuration(value, duration)
+	return output, c.diagnostics()
+}
+
+// diagnostics returns the details of the last call to UpdateDuration.
+func (c *PIDController) diagnostics() Diagnostics {
+	return Diagnostics{
+		Computed: c.computed,
+		Error:    c.prevErr,
+		Dt:       c.dt,
+		Terms:    c.terms,
+		Clamped:  c.saturated,
+		Windup:   c.windup,
+	}
+}
+
+// UpdateWithDiagnostics is like UpdateDuration, but additionally returns the
+// details of the computation.
+func (s *SafePIDController) UpdateWithDiagnostics(value float64, duration time.Duration) (float64, Diagnostics) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.c.UpdateWithDiagnostics(value, duration)
+}
@@ -0,0 +1,43 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPIDController_UpdateWithDiagnostics(t *testing.T) {
+	c := NewPIDController(1, 1, 0).SetOutputLimits(0, 5).Set(10)
+	out, diag := c.UpdateWithDiagnostics(8, time.Second)
+	want := Diagnostics{
+		Computed: true,
+		Error:    2,
+		Dt:       time.Second,
+		Terms:    Terms{P: 2, I: 2},
+	}
+	if out != 4 || diag != want {
+		t.Errorf("got %v %+v, want 4 %+v", out, diag, want)
+	}
+
+	out, diag = c.UpdateWithDiagnostics(0, time.Second)
+	if out != 5 || !diag.Computed || !diag.Clamped || !diag.Windup || diag.Terms.Clamp != ClampP {
+		t.Errorf("saturated update: %v %+v", out, diag)
+	}
+
+	c.Pause()
+	out, diag = c.UpdateWithDiagnostics(3, time.Second)
+	if out != 5 || diag.Computed || diag.Error != 10 {
+		t.Errorf("paused update: %v %+v", out, diag)
+	}
+	c.Resume()
+	if _, diag = c.UpdateWithDiagnostics(9, time.Second); !diag.Computed || diag.Dt != 0 {
+		t.Errorf("first update after resume: %+v", diag)
+	}
+
+	c.SetSampleTime(2 * time.Second)
+	if _, diag = c.UpdateWithDiagnostics(9, time.Second); diag.Computed {
+		t.Errorf("update within the sample time computed: %+v", diag)
+	}
+	if _, diag = c.UpdateWithDiagnostics(9, time.Second); !diag.Computed || diag.Dt != 2*time.Second {
+		t.Errorf("update after the sample time: %+v", diag)
+	}
+}
@@ -0,0 +1,79 @@
+package pidctrl
+
+import "time"
+
+// IntegralMethod selects how the integral is discretized.
+type IntegralMethod int
+
+const (
+	// IntegralRectangular integrates the current error over the step
+	// (backward Euler), the default.
+	IntegralRectangular IntegralMethod = iota
+	// IntegralTrapezoidal integrates the mean of the previous and the
+	// current error, which is more accurate at slow sample rates.
+	IntegralTrapezoidal
+)
+
+// DerivativeMethod selects how the filtered derivative is discretized.
+type DerivativeMethod int
+
+const (
+	// DerivativeBackward uses backward differences, the default.
+	DerivativeBackward DerivativeMethod = iota
+	// DerivativeTustin uses the bilinear (Tustin) transform of the
+	// derivative filter, which preserves its phase better at slow sample
+	// rates. It requires a filter time constant and falls back to
+	// backward differences without one.
+	DerivativeTustin
+)
+
+// Discretization configures the discretization of the integral and
+// derivative terms.
+type Discretization struct {
+	Integral   IntegralMethod   `json:"integral"`
+	Derivative DerivativeMethod `json:"derivative"`
+	// DerivativeFilter is the time constant of the first order low-pass
+	// filter of the derivative term, 0 disables the filter.
+	DerivativeFilter time.Duration `json:"derivative_filter,omitempty"`
+}
+
+// SetDiscretization changes the discretization methods.
+func (c *PIDController) SetDiscretization(d Discretization) *PIDController {
+	c.disc = d
+	return c
+}
+
+// Discretization returns the discretization methods.
+func (c *PIDController) Discretization() Discretization {
+	return c.disc
+}
+
+// integrand returns the error to integrate over the current step.
+func (c *PIDController) integrand(err float64) float64 {
+	if c.disc.Integral == IntegralTrapezoidal && c.started {
+		return (err + c.prevErr) / 2
+	}
+	return err
+}
+
+// filterDerivative applies the derivative filter to the derivative input d
+// of a step of dt seconds.
+func (c *PIDController) filterDerivative(d, dt float64) float64 {
+	tf := c.disc.DerivativeFilter.Seconds()
+	if tf == 0 || dt <= 0 {
+		if tf == 0 {
+			c.dFilt = d
+		}
+		return c.dFilt
+	}
+	if !c.started {
+		c.dFilt = 0
+	}
+	if c.disc.Derivative == DerivativeTustin {
+		// d·dt is the change of the derivative input over the step
+		c.dFilt = (2*tf-dt)/(2*tf+dt)*c.dFilt + 2/(2*tf+dt)*d*dt
+	} else {
+		c.dFilt = (tf*c.dFilt + d*dt) / (tf + dt)
+	}
+	return c.dFilt
+}
@@ -0,0 +1,47 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTrapezoidalIntegral(t *testing.T) {
+	c := NewPIDController(0, 1, 0).SetDiscretization(Discretization{Integral: IntegralTrapezoidal})
+	// a ramping error integrates exactly: ∫t dt from 0 to 4 = 8
+	var out float64
+	for i := 0; i <= 4; i++ {
+		out = c.Set(float64(i)).UpdateDuration(0, time.Second)
+	}
+	if out != 8 {
+		t.Errorf("trapezoidal integral %v != 8", out)
+	}
+	c = NewPIDController(0, 1, 0)
+	for i := 0; i <= 4; i++ {
+		out = c.Set(float64(i)).UpdateDuration(0, time.Second)
+	}
+	if out != 10 {
+		t.Errorf("rectangular integral %v != 10", out)
+	}
+}
+
+func TestDerivativeFilter(t *testing.T) {
+	for _, m := range []DerivativeMethod{DerivativeBackward, DerivativeTustin} {
+		c := NewPIDController(0, 0, 1).SetDiscretization(Discretization{Derivative: m, DerivativeFilter: time.Second})
+		c.UpdateDuration(0, 100*time.Millisecond)
+		// a step of the measurement gives a decaying derivative instead of
+		// a single spike of -10/0.1s
+		first := c.UpdateDuration(-1, 100*time.Millisecond)
+		if first <= 0 || first > 2 {
+			t.Errorf("method %v: filtered kick %v", m, first)
+		}
+		var sum float64 = first
+		for i := 0; i < 200; i++ {
+			sum += c.UpdateDuration(-1, 100*time.Millisecond)
+		}
+		// the area under the derivative is preserved, sum·dt = 1
+		if math.Abs(sum*0.1-1) > 0.01 {
+			t.Errorf("method %v: derivative area %v != 1", m, sum*0.1)
+		}
+	}
+}
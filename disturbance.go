@@ -0,0 +1,64 @@
+package pidctrl
+
+import "time"
+
+// DisturbanceObserver estimates the unmeasured load acting on a process from
+// a nominal first order model with the given gain and time constant. The
+// estimate is the input-referred disturbance, the difference between the
+// input the model needs to explain the measured process value and the
+// actual input, low-pass filtered with the time constant Filter.
+type DisturbanceObserver struct {
+	Gain   float64
+	Tau    time.Duration
+	Filter time.Duration
+
+	estimate float64
+	prevY    float64
+	started  bool
+}
+
+// NewDisturbanceObserver returns a DisturbanceObserver for the nominal model.
+func NewDisturbanceObserver(gain float64, tau, filter time.Duration) *DisturbanceObserver {
+	return &DisturbanceObserver{Gain: gain, Tau: tau, Filter: filter}
+}
+
+// Update feeds the process value and the process input of the last step
+// into the observer and returns the disturbance estimate.
+func (o *DisturbanceObserver) Update(value, input float64, duration time.Duration) float64 {
+	if dt := duration.Seconds(); o.started && dt > 0 && o.Gain != 0 {
+		raw := (o.Tau.Seconds()*(value-o.prevY)/dt+value)/o.Gain - input
+		o.estimate += dt / (o.Filter.Seconds() + dt) * (raw - o.estimate)
+	}
+	o.prevY, o.started = value, true
+	return o.estimate
+}
+
+// Estimate returns the disturbance estimate in process input units.
+func (o *DisturbanceObserver) Estimate() float64 {
+	return o.estimate
+}
+
+// Reset clears the estimate.
+func (o *DisturbanceObserver) Reset() *DisturbanceObserver {
+	o.estimate, o.prevY, o.started = 0, 0, false
+	return o
+}
+
+// SetDisturbanceObserver installs a DisturbanceObserver whose estimate is
+// fed forward with the opposite sign on every update, rejecting loads such
+// as an opened incubator door before they show up as error. The controller
+// output is taken as process input, so the observer must match the units of
+// the output. Pass nil to remove it.
+func (c *PIDController) SetDisturbanceObserver(o *DisturbanceObserver) *PIDController {
+	c.dob = o
+	return c
+}
+
+// Disturbance returns the current disturbance estimate, 0 without an
+// observer.
+func (c *PIDController) Disturbance() float64 {
+	if c.dob == nil {
+		return 0
+	}
+	return c.dob.Estimate()
+}
@@ -0,0 +1,54 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDisturbanceObserver(t *testing.T) {
+	const (
+		gain = 0.5
+		tau  = 10.0
+		dt   = 100 * time.Millisecond
+	)
+	o := NewDisturbanceObserver(gain, 10*time.Second, time.Second)
+	var y float64
+	for k := 0; k < 600; k++ {
+		u, load := 20.0, -8.0
+		o.Update(y, u, dt)
+		y += (gain*(u+load) - y) * dt.Seconds() / tau
+	}
+	if d := o.Estimate(); math.Abs(d+8) > 0.01 {
+		t.Errorf("estimate %v != -8", d)
+	}
+}
+
+func TestSetDisturbanceObserver(t *testing.T) {
+	const (
+		gain = 0.5
+		tau  = 10.0
+		dt   = 100 * time.Millisecond
+	)
+	var last UpdateInfo
+	c := NewPIDController(4, 0, 0).Set(10).
+		SetDisturbanceObserver(NewDisturbanceObserver(gain, 10*time.Second, time.Second)).
+		SetObserver(func(info UpdateInfo) { last = info })
+	var y float64
+	for k := 0; k < 2000; k++ {
+		u := c.UpdateDuration(y, dt)
+		load := 0.0
+		if k > 1000 {
+			load = -8
+		}
+		y += (gain*(u+load) - y) * dt.Seconds() / tau
+	}
+	// without integral action the observer removes the offset caused by the
+	// load, leaving only the proportional droop of 2/(1+2)
+	if math.Abs(c.Disturbance()) < 7 || last.Disturbance != c.Disturbance() {
+		t.Errorf("disturbance %v, observed %v", c.Disturbance(), last.Disturbance)
+	}
+	if math.Abs(y-20.0/3) > 0.1 {
+		t.Errorf("process value %v", y)
+	}
+}
@@ -0,0 +1,73 @@
+package pidctrl
+
+import (
+	"math"
+	"time"
+)
+
+// Doser doses a batch amount: it totalizes the measured flow (in units per
+// second) while the Flow controller regulates the flow rate, and closes the
+// actuator once the total reaches the target minus the pre-act amount, which
+// anticipates the material still in flight after the valve closes.
+type Doser struct {
+	Flow   *PIDController
+	Target float64 // batch amount
+	Preact float64 // amount before the target at which the actuator closes
+	// Dribble optionally slows the flow to DribbleFlow for the last Dribble
+	// amount of the batch, for better accuracy.
+	Dribble     float64
+	DribbleFlow float64
+
+	flow  float64 // flow setpoint at the start of the batch
+	total float64
+	done  bool
+}
+
+// NewDoser returns a Doser for a batch of target using the flow controller,
+// whose setpoint is the dosing flow rate.
+func NewDoser(flow *PIDController, target, preact float64) *Doser {
+	return &Doser{Flow: flow, Target: target, Preact: preact, flow: flow.Get()}
+}
+
+// Start resets the total and starts a new batch of target.
+func (d *Doser) Start(target float64) *Doser {
+	if d.total != 0 || d.done {
+		d.Flow.Set(d.flow)
+	}
+	d.Target, d.total, d.done = target, 0, false
+	d.Flow.Reset()
+	return d
+}
+
+// Total returns the dosed amount.
+func (d *Doser) Total() float64 {
+	return d.total
+}
+
+// Done returns true once the actuator was closed for the batch.
+func (d *Doser) Done() bool {
+	return d.done
+}
+
+// UpdateDuration totalizes the measured flow and returns the actuator
+// output. After the cutoff the output is the lower output limit of the flow
+// controller, or 0 if it is unbounded. The total keeps counting the
+// material still flowing after the cutoff.
+func (d *Doser) UpdateDuration(flow float64, duration time.Duration) float64 {
+	d.total += flow * duration.Seconds()
+	remaining := d.Target - d.total
+	if !d.done && remaining <= d.Preact {
+		d.done = true
+	}
+	if d.done {
+		min, _ := d.Flow.OutputLimits()
+		if math.IsInf(min, -1) {
+			min = 0
+		}
+		return min
+	}
+	if d.Dribble > 0 && remaining <= d.Dribble+d.Preact {
+		d.Flow.Set(d.DribbleFlow)
+	}
+	return d.Flow.UpdateDuration(flow, duration)
+}
@@ -0,0 +1,35 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDoser(t *testing.T) {
+	d := NewDoser(NewPIDController(0.5, 2, 0).SetOutputLimits(0, 100).Set(10), 100, 1.2)
+	d.Dribble, d.DribbleFlow = 10, 2
+	// a valve with 0.2 flow units per percent opening and some lag
+	var flow float64
+	dt := 100 * time.Millisecond
+	for i := 0; i < 1000; i++ {
+		out := d.UpdateDuration(flow, dt)
+		flow += (0.2*out - flow) * 0.5
+		if d.Done() && flow < 1e-3 {
+			break
+		}
+	}
+	if !d.Done() {
+		t.Fatal("batch not finished")
+	}
+	if total := d.Total(); math.Abs(total-100) > 0.2 {
+		t.Errorf("dosed %v, expected 100 with pre-act", total)
+	}
+	if d.Flow.Get() != 2 {
+		t.Errorf("flow setpoint %v, expected dribble flow", d.Flow.Get())
+	}
+	d.Start(50)
+	if d.Total() != 0 || d.Done() || d.Flow.Get() != 10 {
+		t.Errorf("start did not reset the batch: %v %v %v", d.Total(), d.Done(), d.Flow.Get())
+	}
+}
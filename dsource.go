@@ -0,0 +1,42 @@
+package pidctrl
+
+// DerivativeSource selects the signal the derivative term differentiates.
+type DerivativeSource int
+
+const (
+	// DerivativeOnMeasurement differentiates the negated process value,
+	// avoiding derivative kick on setpoint changes. This is the default of
+	// both controllers.
+	DerivativeOnMeasurement DerivativeSource = iota
+	// DerivativeOnError differentiates the error.
+	DerivativeOnError
+)
+
+// SetDerivativeSource selects the signal of the derivative term. It is a
+// shorthand for a derivative setpoint weight of 0 or 1.
+func (c *PIDController) SetDerivativeSource(s DerivativeSource) *PIDController {
+	if s == DerivativeOnError {
+		return c.SetDerivativeSetpointWeight(1)
+	}
+	return c.SetDerivativeSetpointWeight(0)
+}
+
+// DerivativeSource returns the signal of the derivative term. Partial
+// setpoint weights count as DerivativeOnMeasurement.
+func (c *PIDController) DerivativeSource() DerivativeSource {
+	if c.dWeight == 1 {
+		return DerivativeOnError
+	}
+	return DerivativeOnMeasurement
+}
+
+// SetDerivativeSource selects the signal of the derivative term.
+func (c *IntegerPIDController) SetDerivativeSource(s DerivativeSource) *IntegerPIDController {
+	c.dSource = s
+	return c
+}
+
+// DerivativeSource returns the signal of the derivative term.
+func (c *IntegerPIDController) DerivativeSource() DerivativeSource {
+	return c.dSource
+}
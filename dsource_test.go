@@ -0,0 +1,25 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDerivativeSource(t *testing.T) {
+	for _, s := range []DerivativeSource{DerivativeOnMeasurement, DerivativeOnError} {
+		f := NewPIDController(0, 0, 1).SetDerivativeSource(s)
+		i := NewIntegerPIDController(0, 0, INTPID_SCALE).SetDerivativeSource(s)
+		if f.DerivativeSource() != s || i.DerivativeSource() != s {
+			t.Errorf("source %v not applied", s)
+		}
+		f.UpdateDuration(0, time.Second)
+		i.UpdateDuration(0, time.Second)
+		f.Set(10)
+		i.Set(10)
+		for n, v := range []float64{0, 4, 7} {
+			if a, b := f.UpdateDuration(v, time.Second), i.UpdateDuration(int64(v), time.Second); a != float64(b) {
+				t.Errorf("source %v, update %d: float %v != integer %v", s, n, a, b)
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package pidctrl
+
+import "time"
+
+// DtAction selects how UpdateDuration handles a pathological duration.
+type DtAction int
+
+const (
+	// DtAccept uses the duration as is, the default.
+	DtAccept DtAction = iota
+	// DtReject skips the update and returns the previous output.
+	DtReject
+	// DtClamp limits the duration to the range [0, MaxDt].
+	DtClamp
+	// DtRestart treats the update as a fresh start: the integral is kept,
+	// but the update neither integrates nor differentiates across the gap.
+	DtRestart
+)
+
+// DtPolicy configures the handling of negative durations, e.g. after a
+// clock adjustment, zero durations and gaps longer than MaxDt, e.g. after
+// the loop was paused.
+type DtPolicy struct {
+	Negative DtAction
+	Zero     DtAction
+	Gap      DtAction
+	MaxDt    time.Duration // 0 disables gap detection
+}
+
+// SetDtPolicy changes the handling of pathological durations.
+func (c *PIDController) SetDtPolicy(p DtPolicy) *PIDController {
+	c.dtPolicy = p
+	return c
+}
+
+// DtPolicy returns the handling of pathological durations.
+func (c *PIDController) DtPolicy() DtPolicy {
+	return c.dtPolicy
+}
+
+// checkDt applies the dt policy to duration and returns the duration to use
+// and false if the update must be skipped.
+func (c *PIDController) checkDt(value float64, duration time.Duration) (time.Duration, bool) {
+	p := c.dtPolicy
+	var action DtAction
+	switch {
+	case duration < 0:
+		action = p.Negative
+	case duration == 0:
+		action = p.Zero
+	case p.MaxDt > 0 && duration > p.MaxDt:
+		action = p.Gap
+	default:
+		return duration, true
+	}
+	switch action {
+	case DtReject:
+		return duration, false
+	case DtClamp:
+		if duration < 0 {
+			return 0, true
+		}
+		if p.MaxDt > 0 && duration > p.MaxDt {
+			return p.MaxDt, true
+		}
+	case DtRestart:
+		c.restart(value)
+		return 0, true
+	}
+	return duration, true
+}
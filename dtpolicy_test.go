@@ -0,0 +1,51 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDtPolicy(t *testing.T) {
+	policy := DtPolicy{Negative: DtReject, Zero: DtReject, Gap: DtRestart, MaxDt: 10 * time.Second}
+	c := NewPIDController(1, 1, 1).Set(10).SetDtPolicy(policy)
+	if out := c.UpdateDuration(0, time.Second); out != 20 {
+		t.Fatalf("output %v", out)
+	}
+	for _, d := range []time.Duration{-time.Second, 0} {
+		if out := c.UpdateDuration(5, d); out != 20 {
+			t.Errorf("duration %v: output %v, want previous output", d, out)
+		}
+	}
+	// restart after a gap: no integration, no derivative kick
+	if out := c.UpdateDuration(5, time.Hour); out != 5+10 {
+		t.Errorf("output after gap %v", out)
+	}
+	if c.integral != 10 {
+		t.Errorf("integral after gap %v", c.integral)
+	}
+}
+
+func TestDtPolicy_Clamp(t *testing.T) {
+	c := NewPIDController(0, 1, 0).Set(1).SetDtPolicy(DtPolicy{Negative: DtClamp, Gap: DtClamp, MaxDt: 2 * time.Second})
+	c.UpdateDuration(0, time.Hour)
+	if c.integral != 2 {
+		t.Errorf("integral after clamped gap %v", c.integral)
+	}
+	c.UpdateDuration(0, -time.Hour)
+	if c.integral != 2 {
+		t.Errorf("integral after negative duration %v", c.integral)
+	}
+}
+
+func TestWithMaxDt(t *testing.T) {
+	if _, err := NewPIDControllerWithOptions(WithMaxDt(-time.Second, DtClamp)); err == nil {
+		t.Error("expected error for negative max dt")
+	}
+	c, err := NewPIDControllerWithOptions(WithMaxDt(time.Second, DtReject))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := c.DtPolicy(); p.MaxDt != time.Second || p.Gap != DtReject {
+		t.Errorf("policy %+v", p)
+	}
+}
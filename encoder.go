@@ -0,0 +1,82 @@
+package pidctrl
+
+import "time"
+
+// EncoderServo is a cascaded position and velocity servo fed by a quadrature
+// encoder counter, the most common motor control setup in robotics. The
+// Position controller turns the position error into a velocity setpoint and
+// the Velocity loop turns that into a signed PWM duty cycle. Positions are
+// in units of CountsPerUnit encoder counts, velocities in units per second.
+type EncoderServo struct {
+	Position *PIDController
+	Velocity *MotorLoop
+	// CountsPerUnit converts encoder counts, e.g. counts per revolution for
+	// positions in revolutions.
+	CountsPerUnit float64
+	// Bits is the width of the hardware counter, which wraps around at
+	// 1<<Bits. 0 means a 64 bit counter.
+	Bits uint
+
+	count    int64   // last encoder count
+	seen     bool    // count valid
+	position float64 // accumulated position, in units
+	velocity float64 // measured velocity of the last update
+}
+
+// NewEncoderServo returns an EncoderServo using the given controllers. The
+// output limits of the position controller bound the velocity setpoint.
+func NewEncoderServo(position *PIDController, velocity *MotorLoop, countsPerUnit float64, bits uint) *EncoderServo {
+	return &EncoderServo{Position: position, Velocity: velocity, CountsPerUnit: countsPerUnit, Bits: bits}
+}
+
+// Set changes the target position.
+func (s *EncoderServo) Set(target float64) *EncoderServo {
+	s.Position.Set(target)
+	return s
+}
+
+// Get returns the target position.
+func (s *EncoderServo) Get() float64 {
+	return s.Position.Get()
+}
+
+// Home defines the current position, e.g. after a homing run.
+func (s *EncoderServo) Home(position float64) *EncoderServo {
+	s.position = position
+	return s
+}
+
+// MeasuredPosition returns the measured position.
+func (s *EncoderServo) MeasuredPosition() float64 {
+	return s.position
+}
+
+// MeasuredVelocity returns the measured velocity of the last update.
+func (s *EncoderServo) MeasuredVelocity() float64 {
+	return s.velocity
+}
+
+// UpdateDuration accumulates the raw encoder count, runs both loops and
+// returns the duty cycle in [-1, 1] for the given bus voltage, see
+// MotorLoop.Update.
+func (s *EncoderServo) UpdateDuration(count int64, bus float64, duration time.Duration) float64 {
+	if s.seen {
+		delta := s.unwrap(count - s.count)
+		s.position += float64(delta) / s.CountsPerUnit
+		if dt := duration.Seconds(); dt > 0 {
+			s.velocity = float64(delta) / s.CountsPerUnit / dt
+		}
+	}
+	s.count, s.seen = count, true
+	velocity := s.Position.UpdateDuration(s.position, duration)
+	return s.Velocity.Update(velocity, 0, s.velocity, bus, duration)
+}
+
+// unwrap sign-extends a count difference of a Bits wide counter.
+func (s *EncoderServo) unwrap(delta int64) int64 {
+	if s.Bits == 0 || s.Bits >= 64 {
+		return delta
+	}
+	shift := 64 - s.Bits
+	return delta << shift >> shift
+}
@@ -0,0 +1,51 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEncoderServo_Wraparound(t *testing.T) {
+	s := NewEncoderServo(NewPIDController(0, 0, 0), NewMotorLoop(NewPIDController(0, 0, 0), 0, 0, 0), 100, 16)
+	s.Velocity.NominalVoltage = 12
+	for _, count := range []int64{65500, 65535, 0, 30} {
+		s.UpdateDuration(count, 0, time.Second)
+	}
+	if p := s.MeasuredPosition(); math.Abs(p-0.66) > 1e-9 {
+		t.Errorf("position %v != 0.66", p)
+	}
+	if v := s.MeasuredVelocity(); math.Abs(v-0.3) > 1e-9 {
+		t.Errorf("velocity %v != 0.3", v)
+	}
+	s.UpdateDuration(65500, 0, time.Second)
+	if p := s.MeasuredPosition(); math.Abs(p) > 1e-9 {
+		t.Errorf("position after reversing through zero %v != 0", p)
+	}
+}
+
+func TestEncoderServo(t *testing.T) {
+	const countsPerRev = 2048
+	s := NewEncoderServo(
+		NewPIDController(5, 0, 0).SetOutputLimits(-10, 10),
+		NewMotorLoop(NewPIDController(0.5, 2, 0), 1, 0, 0),
+		countsPerRev, 16,
+	).Set(3)
+	var (
+		count    float64
+		velocity float64
+		dt       = time.Millisecond
+	)
+	// the motor reaches 1 rev/s per volt with a 50ms time constant
+	for i := 0; i < 5000; i++ {
+		duty := s.UpdateDuration(int64(count)&0xffff, 12, dt)
+		if duty < -1 || duty > 1 {
+			t.Fatalf("duty %v out of range", duty)
+		}
+		velocity += (duty*12 - velocity) * dt.Seconds() / 0.05
+		count += velocity * countsPerRev * dt.Seconds()
+	}
+	if p := s.MeasuredPosition(); math.Abs(p-3) > 0.01 {
+		t.Errorf("position %v != 3", p)
+	}
+}
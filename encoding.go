@@ -0,0 +1,195 @@
+package pidctrl
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// stateVersion is the current version of the controller encoding. Version 1,
+// encoded without a version, lacks the fields marked as version 2; decoding
+// it keeps their current values.
+const stateVersion = 2
+
+// UnsupportedStateError is returned when decoding a controller encoded by a
+// newer version of the package.
+type UnsupportedStateError struct {
+	Version int
+}
+
+func (e UnsupportedStateError) Error() string {
+	return fmt.Sprintf("unsupported controller encoding version %d", e.Version)
+}
+
+// controllerState is the serialized form of a PIDController. Unbounded
+// output limits are stored as nil since JSON has no representation for
+// infinity.
+type controllerState struct {
+	Version         int       `json:"version,omitempty"`
+	P               float64   `json:"p"`
+	I               float64   `json:"i"`
+	D               float64   `json:"d"`
+	OutMin          *float64  `json:"out_min,omitempty"`
+	OutMax          *float64  `json:"out_max,omitempty"`
+	Setpoint        float64   `json:"setpoint"`
+	WorkingSetpoint float64   `json:"working_setpoint"`
+	RampRate        float64   `json:"ramp_rate,omitempty"`
+	RampIntegral    float64   `json:"ramp_integral_factor"`
+	Integral        float64   `json:"integral"`
+	PrevValue       float64   `json:"prev_value"`
+	LastUpdate      time.Time `json:"last_update"`
+
+	// version 2
+	IntegralLimits *limitsState   `json:"integral_limits"` // nil without integral limits
+	Bias           float64        `json:"bias"`
+	Reverse        bool           `json:"reverse"`
+	Deadband       float64        `json:"deadband"`
+	AntiWindup     AntiWindup     `json:"anti_windup"`
+	Mode           ControlMode    `json:"mode"`
+	ManualOutput   float64        `json:"manual_output"`
+	FailsafeOutput float64        `json:"failsafe_output"`
+	Discretization Discretization `json:"discretization"`
+	DFilt          float64        `json:"d_filt"`
+	PrevSetpoint   float64        `json:"prev_setpoint"`
+	PrevErr        float64        `json:"prev_err"`
+	Smoothing      time.Duration  `json:"smoothing"`
+	SmoothOut      float64        `json:"smooth_out"`
+	Smoothed       bool           `json:"smoothed"`
+	Output         float64        `json:"output"`
+	Started        bool           `json:"started"`
+}
+
+// limitsState is the serialized form of a pair of limits.
+type limitsState struct {
+	Min *float64 `json:"min"`
+	Max *float64 `json:"max"`
+}
+
+func finiteOrNil(v float64) *float64 {
+	if math.IsInf(v, 0) {
+		return nil
+	}
+	return &v
+}
+
+func (c *PIDController) state() controllerState {
+	s := controllerState{
+		Version:         stateVersion,
+		P:               c.p,
+		I:               c.i,
+		D:               c.d,
+		OutMin:          finiteOrNil(c.outMin),
+		OutMax:          finiteOrNil(c.outMax),
+		Setpoint:        c.target,
+		WorkingSetpoint: c.setpoint,
+		RampRate:        c.rampRate,
+		RampIntegral:    c.rampIntegral,
+		Integral:        c.integral,
+		PrevValue:       c.prevValue,
+		LastUpdate:      c.lastUpdate,
+		Bias:            c.bias,
+		Reverse:         c.reverse,
+		Deadband:        c.deadband,
+		AntiWindup:      c.antiWindup,
+		Mode:            c.mode,
+		ManualOutput:    c.manual,
+		FailsafeOutput:  c.failsafe,
+		Discretization:  c.disc,
+		DFilt:           c.dFilt,
+		PrevSetpoint:    c.prevSetpoint,
+		PrevErr:         c.prevErr,
+		Smoothing:       c.smoothing,
+		SmoothOut:       c.smoothOut,
+		Smoothed:        c.smoothed,
+		Output:          c.output,
+		Started:         c.started,
+	}
+	if c.iLimits {
+		s.IntegralLimits = &limitsState{Min: finiteOrNil(c.iMin), Max: finiteOrNil(c.iMax)}
+	}
+	return s
+}
+
+// limits returns the limits, unbounded where nil.
+func (l limitsState) limits() (min, max float64, err error) {
+	min, max = math.Inf(-1), math.Inf(0)
+	if l.Min != nil {
+		min = *l.Min
+	}
+	if l.Max != nil {
+		max = *l.Max
+	}
+	if min > max {
+		return 0, 0, MinMaxError{min, max}
+	}
+	return min, max, nil
+}
+
+func (c *PIDController) setState(s controllerState) error {
+	if s.Version > stateVersion {
+		return UnsupportedStateError{s.Version}
+	}
+	if s.Mode < 0 || int(s.Mode) >= len(modeTransitions) {
+		return fmt.Errorf("invalid mode %d", int(s.Mode))
+	}
+	min, max, err := limitsState{s.OutMin, s.OutMax}.limits()
+	if err != nil {
+		return err
+	}
+	var iMin, iMax float64
+	if s.IntegralLimits != nil {
+		if iMin, iMax, err = s.IntegralLimits.limits(); err != nil {
+			return err
+		}
+	}
+	c.p, c.i, c.d = s.P, s.I, s.D
+	c.outMin, c.outMax = min, max
+	c.iMin, c.iMax, c.iLimits = iMin, iMax, s.IntegralLimits != nil
+	c.target, c.setpoint = s.Setpoint, s.WorkingSetpoint
+	c.rampRate, c.rampIntegral = s.RampRate, s.RampIntegral
+	c.integral, c.prevValue, c.lastUpdate = s.Integral, s.PrevValue, s.LastUpdate
+	c.bias, c.reverse, c.deadband, c.antiWindup = s.Bias, s.Reverse, s.Deadband, s.AntiWindup
+	c.mode, c.manual, c.failsafe = s.Mode, s.ManualOutput, s.FailsafeOutput
+	c.disc, c.dFilt, c.prevSetpoint, c.prevErr = s.Discretization, s.DFilt, s.PrevSetpoint, s.PrevErr
+	c.smoothing, c.smoothOut, c.smoothed = s.Smoothing, s.SmoothOut, s.Smoothed
+	c.output, c.started = s.Output, s.Started
+	c.clampIntegral()
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler. The encoding covers gains, output
+// and integral limits, setpoints and ramp, bias, direction, deadband,
+// anti-windup and operating modes with their outputs, the discretization,
+// output smoothing, and the integrator, derivative filter and smoothing
+// state. It does
+// not cover the other options, such as gain schedules, occupancy profiles,
+// setpoint weights, transforms and alarm detectors, nor attached
+// estimators, observers, event buses, loggers and clocks; configure those
+// again on the decoding controller. Decoding clamps the integral to the
+// restored limits.
+func (c *PIDController) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.state())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (c *PIDController) UnmarshalJSON(data []byte) error {
+	s := c.state()
+	s.Version = 0
+	s.OutMin, s.OutMax = nil, nil
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return c.setState(s)
+}
+
+// GobEncode implements gob.GobEncoder using the JSON encoding, which keeps
+// unbounded limits distinguishable from zero ones.
+func (c *PIDController) GobEncode() ([]byte, error) {
+	return c.MarshalJSON()
+}
+
+// GobDecode implements gob.GobDecoder.
+func (c *PIDController) GobDecode(data []byte) error {
+	return c.UnmarshalJSON(data)
+}
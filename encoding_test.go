@@ -0,0 +1,155 @@
+package pidctrl
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+)
+
+func encodingTestController() *PIDController {
+	c := NewPIDController(0.5, 0.25, 0.1).SetOutputLimits(0, 100).Set(42)
+	c.lastUpdate = time.Date(2016, 3, 2, 12, 0, 0, 0, time.UTC)
+	c.UpdateDuration(40, time.Second)
+	c.UpdateDuration(41, time.Second)
+	return c
+}
+
+func TestPIDController_JSON(t *testing.T) {
+	c := encodingTestController()
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := NewPIDController(0, 0, 0)
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatal(err)
+	}
+	if !reflectStateEqual(c, restored) {
+		t.Errorf("restored state differs:\n%#v\n%#v", c.state(), restored.state())
+	}
+	if a, b := c.UpdateDuration(41.5, time.Second), restored.UpdateDuration(41.5, time.Second); a != b {
+		t.Errorf("restored controller diverged: %f != %f", a, b)
+	}
+}
+
+func TestPIDController_JSONUnbounded(t *testing.T) {
+	data, err := json.Marshal(NewPIDController(1, 2, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewPIDController(0, 0, 0).SetOutputLimits(-1, 1)
+	if err := json.Unmarshal(data, c); err != nil {
+		t.Fatal(err)
+	}
+	if min, max := c.OutputLimits(); !math.IsInf(min, -1) || !math.IsInf(max, 1) {
+		t.Errorf("limits not restored as unbounded: %v %v", min, max)
+	}
+	if err := json.Unmarshal([]byte(`{"out_min": 5, "out_max": 1}`), c); err == nil {
+		t.Error("expected error for swapped limits")
+	}
+}
+
+func TestPIDController_Gob(t *testing.T) {
+	c := encodingTestController()
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(c); err != nil {
+		t.Fatal(err)
+	}
+	restored := NewPIDController(0, 0, 0)
+	if err := gob.NewDecoder(&buf).Decode(restored); err != nil {
+		t.Fatal(err)
+	}
+	if !reflectStateEqual(c, restored) {
+		t.Errorf("restored state differs:\n%#v\n%#v", c.state(), restored.state())
+	}
+}
+
+func reflectStateEqual(a, b *PIDController) bool {
+	ja, _ := json.Marshal(a)
+	jb, _ := json.Marshal(b)
+	return bytes.Equal(ja, jb) && a.lastUpdate.Equal(b.lastUpdate)
+}
+
+func TestPIDController_JSONOptions(t *testing.T) {
+	c := NewPIDController(0.5, 0.25, 0.1).SetOutputLimits(0, 100).Set(42).
+		SetIntegralLimits(-10, 20).SetBias(5).SetDirection(Reverse).SetDeadband(0.5).
+		SetAntiWindup(AntiWindupConditional).SetOutputSmoothing(time.Second).
+		SetDiscretization(Discretization{DerivativeFilter: time.Second}).SetFailsafeOutput(7)
+	c.UpdateDuration(40, time.Second)
+	c.UpdateDuration(45, time.Second)
+	if err := c.SetMode(ModeManual); err != nil {
+		t.Fatal(err)
+	}
+	c.SetManualOutput(30)
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := NewPIDController(0, 0, 0)
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatal(err)
+	}
+	if !reflectStateEqual(c, restored) {
+		t.Errorf("restored state differs:\n%#v\n%#v", c.state(), restored.state())
+	}
+	if min, max := restored.IntegralLimits(); min != -10 || max != 20 {
+		t.Errorf("integral limits %v %v", min, max)
+	}
+	if restored.Bias() != 5 || restored.Direction() != Reverse || restored.Deadband() != 0.5 ||
+		restored.AntiWindup() != AntiWindupConditional || restored.Mode() != ModeManual ||
+		restored.ManualOutput() != 30 || restored.FailsafeOutput() != 7 {
+		t.Errorf("options not restored: %+v", restored.state())
+	}
+	if err := c.SetMode(ModeAuto); err != nil {
+		t.Fatal(err)
+	}
+	if err := restored.SetMode(ModeAuto); err != nil {
+		t.Fatal(err)
+	}
+	for i, v := range []float64{44, 43, 42.5} {
+		if a, b := c.UpdateDuration(v, time.Second), restored.UpdateDuration(v, time.Second); a != b {
+			t.Errorf("update %d: restored controller diverged: %f != %f", i, a, b)
+		}
+	}
+
+	// unbounded integral limits and cleared ones
+	c = NewPIDController(1, 1, 0).SetIntegralLimits(math.Inf(-1), 5)
+	data, _ = json.Marshal(c)
+	restored = NewPIDController(0, 0, 0).SetIntegralLimits(-1, 1)
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatal(err)
+	}
+	if min, max := restored.IntegralLimits(); !math.IsInf(min, -1) || max != 5 {
+		t.Errorf("integral limits %v %v", min, max)
+	}
+	data, _ = json.Marshal(NewPIDController(1, 1, 0))
+	if err := json.Unmarshal(data, restored); err != nil {
+		t.Fatal(err)
+	}
+	if min, max := restored.IntegralLimits(); !math.IsInf(min, -1) || !math.IsInf(max, 1) {
+		t.Errorf("integral limits not cleared: %v %v", min, max)
+	}
+}
+
+func TestPIDController_JSONVersions(t *testing.T) {
+	// version 1 keeps the options of the decoding controller
+	c := NewPIDController(0, 0, 0).SetBias(3).SetIntegralLimits(-1, 1)
+	if err := json.Unmarshal([]byte(`{"p": 1, "integral": 5, "ramp_integral_factor": 1}`), c); err != nil {
+		t.Fatal(err)
+	}
+	if p, _, _ := c.PID(); p != 1 || c.Bias() != 3 {
+		t.Errorf("version 1: p %v, bias %v", p, c.Bias())
+	}
+	if c.Integral() != 1 {
+		t.Errorf("integral %v not clamped to the limits", c.Integral())
+	}
+	if err := json.Unmarshal([]byte(`{"version": 3}`), c); err != (UnsupportedStateError{3}) {
+		t.Errorf("unexpected error for a newer version: %v", err)
+	}
+	if err := json.Unmarshal([]byte(`{"version": 2, "mode": 42}`), c); err == nil {
+		t.Error("expected error for an invalid mode")
+	}
+}
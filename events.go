@@ -0,0 +1,212 @@
+package pidctrl
+
+import (
+	"sync"
+	"time"
+)
+
+// EventKind identifies the kind of an Event.
+type EventKind int
+
+const (
+	// EventSetpoint is published when the requested setpoint changes. Old
+	// and New hold the previous and new setpoint.
+	EventSetpoint EventKind = iota + 1
+	// EventMode is published when the occupancy or operating mode changes
+	// or the controller is paused or resumed. Mode holds the occupancy mode,
+	// Control the operating mode and Active is true while paused.
+	EventMode
+	// EventSaturation is published when the output enters (Active) or
+	// leaves saturation. New holds the output.
+	EventSaturation
+	// EventOscillation is published when the oscillation alarm is raised
+	// (Active) or cleared.
+	EventOscillation
+	// EventStale is published when the measurement becomes stale (Active)
+	// or changes again.
+	EventStale
+	// EventWatchdog is published when a Watchdog created with
+	// EventBus.Watchdog expires.
+	EventWatchdog
+	// EventRate is published when the rate-of-change alarm is raised
+	// (Active) or cleared. New holds the rate of the process value.
+	EventRate
+)
+
+var eventKindNames = [...]string{
+	EventSetpoint:    "setpoint",
+	EventMode:        "mode",
+	EventSaturation:  "saturation",
+	EventOscillation: "oscillation",
+	EventStale:       "stale",
+	EventWatchdog:    "watchdog",
+	EventRate:        "rate",
+}
+
+func (k EventKind) String() string {
+	if k > 0 && int(k) < len(eventKindNames) {
+		return eventKindNames[k]
+	}
+	return "unknown"
+}
+
+// Event is a controller event published on an EventBus.
+type Event struct {
+	Kind     EventKind
+	Time     time.Time   // controller clock when the event occurred
+	Active   bool        // condition raised, see the kinds
+	Old, New float64     // values before and after the change, see the kinds
+	Mode     Occupancy   // occupancy mode of EventMode
+	Control  ControlMode // operating mode of EventMode
+}
+
+// EventBus distributes controller events to subscribers, so that
+// applications can react to alarms and changes without polling. Install it
+// with SetEventBus; one bus may serve several controllers. It is safe for
+// concurrent use.
+type EventBus struct {
+	mu   sync.Mutex
+	subs map[*subscription]struct{}
+}
+
+type subscription struct {
+	mask  uint
+	f     func(Event)
+	mu    sync.Mutex // guards ch against a concurrent cancel
+	ch    chan Event
+	ended bool
+}
+
+// NewEventBus returns a new EventBus without subscribers.
+func NewEventBus() *EventBus {
+	return &EventBus{subs: map[*subscription]struct{}{}}
+}
+
+// Subscribe calls f for every published event of the given kinds, or of all
+// kinds if none are given. f is called synchronously from the publishing
+// goroutine, usually the control loop, so it should return quickly. The
+// returned function cancels the subscription.
+func (b *EventBus) Subscribe(f func(Event), kinds ...EventKind) (cancel func()) {
+	return b.add(&subscription{mask: eventMask(kinds), f: f})
+}
+
+// Channel returns a channel receiving the published events of the given
+// kinds, or of all kinds if none are given. Events are dropped while the
+// channel buffer of the given size is full, so a slow reader never blocks
+// the control loop. The returned function cancels the subscription and
+// closes the channel.
+func (b *EventBus) Channel(size int, kinds ...EventKind) (<-chan Event, func()) {
+	s := &subscription{mask: eventMask(kinds), ch: make(chan Event, size)}
+	return s.ch, b.add(s)
+}
+
+func (b *EventBus) add(s *subscription) func() {
+	b.mu.Lock()
+	b.subs[s] = struct{}{}
+	b.mu.Unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, s)
+			b.mu.Unlock()
+			if s.ch != nil {
+				s.mu.Lock()
+				s.ended = true
+				close(s.ch)
+				s.mu.Unlock()
+			}
+		})
+	}
+}
+
+// Publish delivers e to all subscribers of its kind.
+func (b *EventBus) Publish(e Event) {
+	bit := uint(1) << uint(e.Kind)
+	b.mu.Lock()
+	subs := make([]*subscription, 0, len(b.subs))
+	for s := range b.subs {
+		if s.mask&bit != 0 {
+			subs = append(subs, s)
+		}
+	}
+	b.mu.Unlock()
+	for _, s := range subs {
+		if s.f != nil {
+			s.f(e)
+			continue
+		}
+		s.mu.Lock()
+		if !s.ended {
+			select {
+			case s.ch <- e:
+			default:
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// Watchdog returns a running Watchdog publishing an EventWatchdog when it is
+// not kicked within timeout.
+func (b *EventBus) Watchdog(timeout time.Duration) *Watchdog {
+	return NewWatchdog(timeout, func() {
+		b.Publish(Event{Kind: EventWatchdog, Time: time.Now(), Active: true})
+	})
+}
+
+func eventMask(kinds []EventKind) uint {
+	if len(kinds) == 0 {
+		return ^uint(0)
+	}
+	var mask uint
+	for _, k := range kinds {
+		mask |= 1 << uint(k)
+	}
+	return mask
+}
+
+// SetEventBus installs a bus the controller publishes its events on. Events
+// are stamped with the controller's clock. Pass nil to stop publishing.
+func (c *PIDController) SetEventBus(b *EventBus) *PIDController {
+	c.events = b
+	return c
+}
+
+// EventBus returns the installed event bus, or nil.
+func (c *PIDController) EventBus() *EventBus {
+	return c.events
+}
+
+// emit publishes an event if a bus is installed.
+func (c *PIDController) emit(e Event) {
+	if c.events != nil {
+		e.Time = c.now()
+		c.events.Publish(e)
+	}
+}
+
+// emitUpdate publishes the condition changes of an update given the states
+// before it.
+func (c *PIDController) emitUpdate(wasSaturated, wasOscillating, wasStale, wasRate bool) {
+	if c.saturated != wasSaturated {
+		c.emit(Event{Kind: EventSaturation, Active: c.saturated, New: c.output})
+	}
+	if c.osc.active != wasOscillating {
+		c.emit(Event{Kind: EventOscillation, Active: c.osc.active})
+	}
+	if c.stale.active != wasStale {
+		c.emit(Event{Kind: EventStale, Active: c.stale.active})
+	}
+	if c.rateAlarm.active != wasRate {
+		c.emit(Event{Kind: EventRate, Active: c.rateAlarm.active, New: c.rateAlarm.rate})
+	}
+}
+
+// SetEventBus installs a bus the controller publishes its events on.
+func (s *SafePIDController) SetEventBus(b *EventBus) *SafePIDController {
+	s.mu.Lock()
+	s.c.SetEventBus(b)
+	s.mu.Unlock()
+	return s
+}
@@ -0,0 +1,110 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventBus(t *testing.T) {
+	clock := NewManualClock(time.Date(2016, 3, 2, 0, 0, 0, 0, time.UTC))
+	bus := NewEventBus()
+	var got []Event
+	cancel := bus.Subscribe(func(e Event) { got = append(got, e) })
+	ch, stop := bus.Channel(1, EventSaturation)
+
+	c := NewPIDController(1, 0, 0).SetClock(clock).SetOutputLimits(-1, 1).SetEventBus(bus)
+	c.SetOccupancyProfiles(map[Occupancy]OccupancyProfile{"away": {SetpointOffset: -2}})
+	c.Set(10)
+	c.Set(10) // unchanged, no event
+	c.UpdateDuration(0, time.Second)
+	c.UpdateDuration(9.5, time.Second)
+	if err := c.SetOccupancy("away"); err != nil {
+		t.Fatal(err)
+	}
+	c.Pause().Resume()
+
+	want := []Event{
+		{Kind: EventSetpoint, Old: 0, New: 10},
+		{Kind: EventSaturation, Active: true, New: 1},
+		{Kind: EventSaturation, New: 0.5},
+		{Kind: EventMode, Mode: "away"},
+		{Kind: EventMode, Mode: "away", Active: true},
+		{Kind: EventMode, Mode: "away"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(got), len(want), got)
+	}
+	for i, e := range got {
+		if !e.Time.Equal(clock.Now()) {
+			t.Errorf("event %d not stamped with the controller clock: %v", i, e.Time)
+		}
+		e.Time = time.Time{}
+		if e != want[i] {
+			t.Errorf("event %d: %+v, want %+v", i, e, want[i])
+		}
+	}
+
+	// the channel holds one event, the second saturation event was dropped
+	if e := <-ch; e.Kind != EventSaturation || !e.Active {
+		t.Errorf("unexpected channel event: %+v", e)
+	}
+	stop()
+	if _, ok := <-ch; ok {
+		t.Error("channel not closed")
+	}
+	stop()
+
+	cancel()
+	c.Set(20)
+	if len(got) != len(want) {
+		t.Errorf("event delivered after cancel: %+v", got[len(want):])
+	}
+}
+
+func TestEventBus_Conditions(t *testing.T) {
+	bus := NewEventBus()
+	var got []Event
+	bus.Subscribe(func(e Event) { got = append(got, e) }, EventOscillation, EventStale)
+	c := NewPIDController(1, 0, 0).Set(0).SetEventBus(bus)
+	c.SetOscillationDetection(0.5, 10*time.Second, 2)
+	c.SetStaleTimeout(2*time.Second, StaleHold, 0, nil)
+	for _, v := range []float64{1, -1, 1, 1, 1, 2} {
+		c.UpdateDuration(v, time.Second)
+	}
+	var kinds []EventKind
+	var active []bool
+	for _, e := range got {
+		kinds = append(kinds, e.Kind)
+		active = append(active, e.Active)
+	}
+	wantKinds := []EventKind{EventOscillation, EventStale, EventStale}
+	wantActive := []bool{true, true, false}
+	if len(kinds) != len(wantKinds) {
+		t.Fatalf("events: %v %v", kinds, active)
+	}
+	for i := range kinds {
+		if kinds[i] != wantKinds[i] || active[i] != wantActive[i] {
+			t.Errorf("events: %v %v, want %v %v", kinds, active, wantKinds, wantActive)
+			break
+		}
+	}
+	if EventWatchdog.String() != "watchdog" || EventKind(0).String() != "unknown" {
+		t.Error("unexpected kind names")
+	}
+}
+
+func TestEventBus_Watchdog(t *testing.T) {
+	bus := NewEventBus()
+	ch, stop := bus.Channel(1, EventWatchdog)
+	defer stop()
+	w := bus.Watchdog(time.Millisecond)
+	defer w.Stop()
+	select {
+	case e := <-ch:
+		if !e.Active {
+			t.Errorf("unexpected event: %+v", e)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("watchdog did not trip")
+	}
+}
@@ -0,0 +1,24 @@
+package pidctrl
+
+import "time"
+
+// UpdateWithRate is like UpdateDuration, but takes the rate of change of the
+// process value, in units per second, from the caller instead of
+// differentiating the process value, e.g. from a gyro, a tachometer or an
+// external Kalman filter. The rate replaces that of an installed estimator
+// and feeds the derivative term and the rate-of-change alarm. A non-finite
+// rate is ignored and the rate computed as usual.
+func (c *PIDController) UpdateWithRate(value, rate float64, duration time.Duration) float64 {
+	c.extRate, c.hasExtRate = rate, !nonFinite(rate)
+	output := c.UpdateDuration(value, duration)
+	c.hasExtRate = false
+	return output
+}
+
+// UpdateWithRate is like UpdateDuration, but takes the rate of change of the
+// process value from the caller.
+func (s *SafePIDController) UpdateWithRate(value, rate float64, duration time.Duration) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.c.UpdateWithRate(value, rate, duration)
+}
@@ -0,0 +1,22 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPIDController_UpdateWithRate(t *testing.T) {
+	c := NewPIDController(0, 0, 2)
+	c.UpdateWithRate(0, 0, time.Second)
+	// the value jumps, but the supplied rate is used
+	if out := c.UpdateWithRate(100, 3, time.Second); out != -6 {
+		t.Errorf("output %v, want -6", out)
+	}
+	if out := c.UpdateWithRate(110, math.NaN(), time.Second); out != -20 {
+		t.Errorf("output %v with a NaN rate, want the differentiated -20", out)
+	}
+	if out := c.UpdateDuration(110, time.Second); out != 0 {
+		t.Errorf("supplied rate used by UpdateDuration, output %v", out)
+	}
+}
@@ -0,0 +1,68 @@
+package pidctrl
+
+import (
+	"math"
+	"time"
+)
+
+// saturationFault detects an output pinned at a limit without progress.
+type saturationFault struct {
+	after    time.Duration // saturation duration raising the fault, 0 disables
+	callback func(bool)    // optional, called when the fault is raised or cleared
+	open     bool          // saturated since the last update
+	since    time.Duration // saturated time without progress
+	refErr   float64       // absolute error when the current window started
+	active   bool          // fault raised
+}
+
+// saturationProgress is the fraction by which the error has to shrink to
+// count as progress while saturated.
+const saturationProgress = 0.1
+
+// SetSaturationFault enables detection of persistent saturation: a fault is
+// raised when the output stayed clamped at a limit for longer than after
+// while the absolute error did not shrink by at least 10%, indicating an
+// undersized or failed actuator. f is called with true when the fault is
+// raised and with false when the output leaves the limit again; it may be
+// nil. An after of 0 disables detection.
+func (c *PIDController) SetSaturationFault(after time.Duration, f func(fault bool)) *PIDController {
+	c.satFault = saturationFault{after: after, callback: f}
+	return c
+}
+
+// SaturationFault returns true while a persistent saturation fault is
+// raised.
+func (c *PIDController) SaturationFault() bool {
+	return c.satFault.active
+}
+
+// update feeds an update into the detector.
+func (f *saturationFault) update(saturated bool, err float64, duration time.Duration) {
+	if f.after == 0 {
+		return
+	}
+	err = math.Abs(err)
+	if !saturated {
+		f.open = false
+		f.set(false)
+		return
+	}
+	if !f.open || err < f.refErr*(1-saturationProgress) {
+		f.open, f.since, f.refErr = true, 0, err
+	} else {
+		f.since += duration
+	}
+	if f.since > f.after {
+		f.set(true)
+	}
+}
+
+func (f *saturationFault) set(active bool) {
+	if active == f.active {
+		return
+	}
+	f.active = active
+	if f.callback != nil {
+		f.callback(active)
+	}
+}
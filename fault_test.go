@@ -0,0 +1,42 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSaturationFault(t *testing.T) {
+	var events []bool
+	c := NewPIDController(1, 0, 0).SetOutputLimits(0, 10).Set(100).
+		SetSaturationFault(time.Minute, func(fault bool) { events = append(events, fault) })
+
+	// the error shrinks by more than 10% per minute, no fault
+	value := 0.0
+	for i := 0; i < 180; i++ {
+		c.UpdateDuration(value, time.Second)
+		value += 0.25
+	}
+	if c.SaturationFault() {
+		t.Fatal("fault raised while making progress")
+	}
+	// a stuck actuator raises the fault after a minute
+	for i := 0; i < 61; i++ {
+		c.UpdateDuration(value, time.Second)
+	}
+	if !c.SaturationFault() || !c.LoopStatus().Alarm {
+		t.Fatal("fault not raised")
+	}
+	var info UpdateInfo
+	c.SetObserver(func(i UpdateInfo) { info = i })
+	c.UpdateDuration(value, time.Second)
+	if !info.Fault {
+		t.Error("fault not reported to the observer")
+	}
+	c.UpdateDuration(99, time.Second)
+	if c.SaturationFault() {
+		t.Error("fault not cleared after leaving the limit")
+	}
+	if want := []bool{true, false}; len(events) != 2 || events[0] != want[0] || events[1] != want[1] {
+		t.Errorf("events %v != %v", events, want)
+	}
+}
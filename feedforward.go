@@ -0,0 +1,61 @@
+package pidctrl
+
+// SetAmbientFeedForward configures feed-forward of an auxiliary ambient input
+// such as the enclosure or cold-junction temperature. Deviations of the value
+// passed to SetAmbient from reference are multiplied by gain and added to the
+// output, so ambient swings are compensated before they show up as error.
+// For a heater the gain is usually negative. A gain of 0 disables it.
+func (c *PIDController) SetAmbientFeedForward(gain, reference float64) *PIDController {
+	c.ambientGain = gain
+	c.ambientRef = reference
+	c.ambient = reference
+	return c
+}
+
+// AmbientFeedForward returns the ambient feed-forward gain and reference.
+func (c *PIDController) AmbientFeedForward() (gain, reference float64) {
+	return c.ambientGain, c.ambientRef
+}
+
+// SetAmbient updates the ambient input used by the ambient feed-forward.
+func (c *PIDController) SetAmbient(value float64) *PIDController {
+	c.ambient = value
+	return c
+}
+
+// Ambient returns the current ambient input.
+func (c *PIDController) Ambient() float64 {
+	return c.ambient
+}
+
+// SetFeedForward sets a feed-forward value that is added to the output of
+// the following updates, e.g. from a model of the process. Unlike adding it
+// to the output afterwards, it is subject to the output limits and
+// anti-windup.
+func (c *PIDController) SetFeedForward(value float64) *PIDController {
+	c.ff = value
+	return c
+}
+
+// FeedForward returns the feed-forward value set with SetFeedForward.
+func (c *PIDController) FeedForward() float64 {
+	return c.ff
+}
+
+// SetBias sets a constant offset added to the output before clamping, also
+// known as manual reset. It lets P and PD controllers hold a nonzero output
+// at the setpoint and PI controllers start near a known operating point.
+func (c *PIDController) SetBias(bias float64) *PIDController {
+	c.bias = bias
+	return c
+}
+
+// Bias returns the output bias.
+func (c *PIDController) Bias() float64 {
+	return c.bias
+}
+
+// feedForward returns the sum of all feed-forward contributions and the bias.
+func (c *PIDController) feedForward() float64 {
+	return c.bias + c.ff + c.ambientGain*(c.ambient-c.ambientRef) - c.Disturbance()
+}
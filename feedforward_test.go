@@ -0,0 +1,43 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAmbientFeedForward(t *testing.T) {
+	c := NewPIDController(1, 0, 0).SetAmbientFeedForward(-0.5, 20).Set(37)
+	if out := c.UpdateDuration(37, time.Second); out != 0 {
+		t.Errorf("at reference ambient: %f != 0", out)
+	}
+	c.SetAmbient(10)
+	if out := c.UpdateDuration(37, time.Second); out != 5 {
+		t.Errorf("colder ambient: %f != 5", out)
+	}
+}
+
+func TestFeedForward(t *testing.T) {
+	c := NewPIDController(1, 0, 0).SetOutputLimits(0, 10).SetFeedForward(3)
+	if out := c.Set(5).UpdateDuration(4, time.Second); out != 4 {
+		t.Errorf("output %v != 4", out)
+	}
+	if ff := c.Terms().FeedForward; ff != 3 {
+		t.Errorf("feed-forward term %v", ff)
+	}
+	if out := c.SetFeedForward(20).UpdateDuration(4, time.Second); out != 10 {
+		t.Errorf("feed-forward not limited: %v", out)
+	}
+}
+
+func TestBias(t *testing.T) {
+	c := NewPIDController(1, 0, 0).SetOutputLimits(0, 35).Set(10).SetBias(30)
+	if out := c.UpdateDuration(10, time.Second); out != 30 {
+		t.Errorf("output at setpoint %v != 30", out)
+	}
+	if out := c.UpdateDuration(0, time.Second); out != 35 {
+		t.Errorf("output %v not clamped", out)
+	}
+	if c.Terms().Clamp != ClampFeedForward {
+		t.Errorf("clamp cause %v", c.Terms().Clamp)
+	}
+}
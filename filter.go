@@ -0,0 +1,57 @@
+package pidctrl
+
+import (
+	"math"
+	"time"
+)
+
+// ComplementaryFilter fuses a fast but noisy process value source with a slow
+// but accurate one. The fast source is high-pass filtered and the slow source
+// low-pass filtered around the same crossover frequency before both are
+// summed, so the result follows quick changes of the fast sensor while
+// converging on the absolute value of the slow one.
+//
+// see http://en.wikipedia.org/wiki/Complementary_filter
+type ComplementaryFilter struct {
+	tau      float64 // time constant derived from the crossover frequency
+	estimate float64 // current fused value
+	prevFast float64 // last fast sensor value
+	started  bool    // true after the first update
+}
+
+// NewComplementaryFilter returns a new ComplementaryFilter with the given
+// crossover frequency in Hz. Changes faster than the crossover frequency are
+// taken from the fast source, slower changes from the slow source.
+func NewComplementaryFilter(crossover float64) *ComplementaryFilter {
+	if crossover <= 0 {
+		panic("pidctrl: crossover frequency must be positive")
+	}
+	return &ComplementaryFilter{tau: 1 / (2 * math.Pi * crossover)}
+}
+
+// Update feeds a new pair of readings taken duration after the previous pair
+// into the filter and returns the fused value. The first update initializes
+// the filter to the slow reading.
+func (f *ComplementaryFilter) Update(fast, slow float64, duration time.Duration) float64 {
+	if !f.started {
+		f.estimate = slow
+		f.prevFast = fast
+		f.started = true
+		return f.estimate
+	}
+	dt := duration.Seconds()
+	alpha := f.tau / (f.tau + dt)
+	f.estimate = alpha*(f.estimate+fast-f.prevFast) + (1-alpha)*slow
+	f.prevFast = fast
+	return f.estimate
+}
+
+// Value returns the current fused value.
+func (f *ComplementaryFilter) Value() float64 {
+	return f.estimate
+}
+
+// Reset discards the filter state, the next update starts over.
+func (f *ComplementaryFilter) Reset() {
+	f.estimate, f.prevFast, f.started = 0, 0, false
+}
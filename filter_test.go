@@ -0,0 +1,26 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestComplementaryFilter(t *testing.T) {
+	f := NewComplementaryFilter(0.1)
+	if v := f.Update(12, 10, time.Second); v != 10 {
+		t.Fatalf("first update: %f != 10", v)
+	}
+	// a fast step passes through almost unattenuated
+	v := f.Update(22, 10, 10*time.Millisecond)
+	if math.Abs(v-20) > 0.1 {
+		t.Errorf("fast step: %f, expected ~20", v)
+	}
+	// and decays towards the slow source over time
+	for i := 0; i < 10000; i++ {
+		v = f.Update(22, 10, 10*time.Millisecond)
+	}
+	if math.Abs(v-10) > 0.01 {
+		t.Errorf("steady state: %f, expected ~10", v)
+	}
+}
@@ -0,0 +1,130 @@
+package pidctrl
+
+import (
+	"math"
+	"time"
+)
+
+// StandardGains converts gains in the standard (ISA, ideal) form
+// Kp·(e + 1/Ti·∫e + Td·de/dt) into the parallel form used by the
+// controller. A Ti of 0 disables integral action.
+func StandardGains(kp float64, ti, td time.Duration) Gains {
+	g := Gains{P: kp, D: kp * td.Seconds()}
+	if ti > 0 {
+		g.I = kp / ti.Seconds()
+	}
+	return g
+}
+
+// Standard returns the gains in standard form. Ti is 0 without integral
+// action. Both times are 0 if P is 0, as the standard form cannot represent
+// pure I or D action.
+func (g Gains) Standard() (kp float64, ti, td time.Duration) {
+	if g.P == 0 {
+		return 0, 0, 0
+	}
+	if g.I != 0 {
+		ti = seconds(g.P / g.I)
+	}
+	return g.P, ti, seconds(g.D / g.P)
+}
+
+// SeriesGains converts gains in the series (interacting) form
+// Kc·(1 + 1/(Ti·s))·(1 + Td·s), found in many older and pneumatic
+// controllers and their tuning tables, into the parallel form. A Ti of 0
+// disables integral action.
+func SeriesGains(kc float64, ti, td time.Duration) Gains {
+	if ti <= 0 {
+		return Gains{P: kc, D: kc * td.Seconds()}
+	}
+	return Gains{P: kc * (1 + td.Seconds()/ti.Seconds()), I: kc / ti.Seconds(), D: kc * td.Seconds()}
+}
+
+// Series returns the gains in series form. ok is false if the gains have no
+// series representation, which is the case when the standard form Ti is
+// less than four times Td.
+func (g Gains) Series() (kc float64, ti, td time.Duration, ok bool) {
+	if g.I == 0 {
+		if g.P == 0 {
+			return 0, 0, 0, g.D == 0
+		}
+		return g.P, 0, seconds(g.D / g.P), true
+	}
+	// Kc/Ti = I, Kc·Td = D and Kc·(1+Td/Ti) = P give Kc² - P·Kc + I·D = 0
+	disc := g.P*g.P - 4*g.I*g.D
+	if disc < 0 || g.P <= 0 {
+		return 0, 0, 0, false
+	}
+	kc = (g.P + math.Sqrt(disc)) / 2
+	return kc, seconds(kc / g.I), seconds(g.D / kc), true
+}
+
+// TimeBase is the time unit of the integral and derivative gains of
+// parallel form gains. The controller works per second; tunings ported from
+// systems working per minute have an integral gain 60 times too large and a
+// derivative gain 60 times too small if they are not converted.
+type TimeBase time.Duration
+
+// Time bases for GainsPer and Gains.Per.
+const (
+	PerSecond = TimeBase(time.Second)
+	PerMinute = TimeBase(time.Minute)
+	PerHour   = TimeBase(time.Hour)
+)
+
+// GainsPer converts parallel form gains with an integral gain per base and a
+// derivative gain in base units into the per second gains of the controller.
+func GainsPer(p, i, d float64, base TimeBase) Gains {
+	s := time.Duration(base).Seconds()
+	return Gains{P: p, I: i / s, D: d * s}
+}
+
+// Per returns the parallel form gains in the given time base.
+func (g Gains) Per(base TimeBase) (p, i, d float64) {
+	s := time.Duration(base).Seconds()
+	return g.P, g.I * s, g.D / s
+}
+
+// RepeatsPerMinute converts an integral (reset) rate in repeats per minute,
+// as used by many industrial controllers, into the integral time of the
+// standard form. A rate of 0 gives 0, which disables integral action.
+func RepeatsPerMinute(r float64) time.Duration {
+	if r == 0 {
+		return 0
+	}
+	return seconds(60 / r)
+}
+
+// MinutesPerRepeat converts an integral time in minutes per repeat into the
+// integral time of the standard form.
+func MinutesPerRepeat(m float64) time.Duration {
+	return seconds(m * 60)
+}
+
+// Repeats returns the integral rate in repeats per minute of a standard form
+// integral time, or 0 for a ti of 0.
+func Repeats(ti time.Duration) float64 {
+	if ti == 0 {
+		return 0
+	}
+	return time.Minute.Seconds() / ti.Seconds()
+}
+
+// ProportionalBandGain converts a proportional band, the percentage of the
+// process value span that moves the output across its full span, into a
+// proportional gain. inSpan and outSpan are the process value and output
+// ranges in controller units, e.g. 0..400 °C and 0..100 % give 400 and 100.
+func ProportionalBandGain(pb, inSpan, outSpan float64) float64 {
+	return 100 / pb * outSpan / inSpan
+}
+
+// ProportionalBand returns the proportional band in percent matching the
+// proportional gain for the given spans, see ProportionalBandGain. It is
+// +Inf for a P of 0.
+func (g Gains) ProportionalBand(inSpan, outSpan float64) float64 {
+	return 100 / g.P * outSpan / inSpan
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Round(s * float64(time.Second)))
+}
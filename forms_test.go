@@ -0,0 +1,79 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestStandardGains(t *testing.T) {
+	g := StandardGains(2, 4*time.Second, 500*time.Millisecond)
+	if g != (Gains{P: 2, I: 0.5, D: 1}) {
+		t.Errorf("parallel gains %+v", g)
+	}
+	if kp, ti, td := g.Standard(); kp != 2 || ti != 4*time.Second || td != 500*time.Millisecond {
+		t.Errorf("standard form %v %v %v", kp, ti, td)
+	}
+	if g := StandardGains(2, 0, 0); g.I != 0 {
+		t.Errorf("Ti of 0 gives integral gain %v", g.I)
+	}
+}
+
+func TestSeriesGains(t *testing.T) {
+	g := SeriesGains(2, 4*time.Second, time.Second)
+	if want := (Gains{P: 2.5, I: 0.5, D: 2}); math.Abs(g.P-want.P)+math.Abs(g.I-want.I)+math.Abs(g.D-want.D) > 1e-12 {
+		t.Errorf("parallel gains %+v != %+v", g, want)
+	}
+	kc, ti, td, ok := g.Series()
+	if !ok || math.Abs(kc-2) > 1e-12 || ti != 4*time.Second || td != time.Second {
+		t.Errorf("series form %v %v %v %v", kc, ti, td, ok)
+	}
+	// standard form Ti < 4Td has no series equivalent
+	if _, _, _, ok := StandardGains(1, time.Second, time.Second).Series(); ok {
+		t.Error("expected no series representation")
+	}
+}
+
+func TestGainsPer(t *testing.T) {
+	// Ki in 1/min and Kd in min
+	g := GainsPer(2, 3, 0.5, PerMinute)
+	if want := (Gains{P: 2, I: 0.05, D: 30}); math.Abs(g.I-want.I)+math.Abs(g.D-want.D) > 1e-12 || g.P != want.P {
+		t.Errorf("per second gains %+v != %+v", g, want)
+	}
+	if p, i, d := g.Per(PerMinute); p != 2 || math.Abs(i-3) > 1e-12 || math.Abs(d-0.5) > 1e-12 {
+		t.Errorf("per minute gains %v %v %v", p, i, d)
+	}
+	if g := GainsPer(2, 3, 0.5, PerSecond); g != (Gains{P: 2, I: 3, D: 0.5}) {
+		t.Errorf("per second base changed gains %+v", g)
+	}
+}
+
+func TestRepeatsPerMinute(t *testing.T) {
+	if ti := RepeatsPerMinute(4); ti != 15*time.Second {
+		t.Errorf("4 repeats/min: %v", ti)
+	}
+	if ti := RepeatsPerMinute(0); ti != 0 {
+		t.Errorf("0 repeats/min: %v", ti)
+	}
+	if ti := MinutesPerRepeat(2.5); ti != 150*time.Second {
+		t.Errorf("2.5 min/repeat: %v", ti)
+	}
+	if r := Repeats(15 * time.Second); r != 4 {
+		t.Errorf("repeats of 15s: %v", r)
+	}
+	// with the standard form
+	if g := StandardGains(2, RepeatsPerMinute(6), 0); math.Abs(g.I-0.2) > 1e-12 {
+		t.Errorf("integral gain %v", g.I)
+	}
+}
+
+func TestProportionalBand(t *testing.T) {
+	// 25 % of a 0..400 °C span drives 0..100 % output
+	kp := ProportionalBandGain(25, 400, 100)
+	if kp != 1 {
+		t.Errorf("gain %v != 1", kp)
+	}
+	if pb := (Gains{P: 2}).ProportionalBand(400, 100); pb != 12.5 {
+		t.Errorf("band %v != 12.5", pb)
+	}
+}
@@ -0,0 +1,49 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// FuzzUnmarshalJSON checks that arbitrary persisted state is either
+// rejected or restores a controller with consistent limits.
+func FuzzUnmarshalJSON(f *testing.F) {
+	f.Add([]byte(`{"p":1,"i":0.5,"d":0.1,"out_min":0,"out_max":100,"setpoint":42,"integral":3}`))
+	f.Add([]byte(`{"out_min":5,"out_max":1}`))
+	f.Add([]byte(`{}`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		c := NewPIDController(0, 0, 0)
+		if err := c.UnmarshalJSON(data); err != nil {
+			return
+		}
+		if min, max := c.OutputLimits(); min > max {
+			t.Fatalf("accepted swapped limits %v > %v", min, max)
+		}
+		c.UpdateDuration(0, time.Second)
+	})
+}
+
+// FuzzUpdateDuration checks that finite inputs keep the output finite and
+// inside the output limits.
+func FuzzUpdateDuration(f *testing.F) {
+	f.Add(1.0, 0.5, 0.1, 10.0, 5.0, int64(time.Second))
+	f.Add(40.0, 0.0, 12.0, 90.0, 22.0, int64(time.Millisecond))
+	f.Fuzz(func(t *testing.T, p, i, d, setpoint, value float64, duration int64) {
+		for _, v := range []float64{p, i, d, setpoint, value} {
+			if math.IsNaN(v) || math.Abs(v) > 1e6 {
+				return
+			}
+		}
+		if duration < 0 || duration > int64(time.Hour) {
+			return
+		}
+		c := NewPIDController(p, i, d).SetOutputLimits(-1000, 1000).Set(setpoint)
+		for n := 0; n < 3; n++ {
+			out := c.UpdateDuration(value, time.Duration(duration))
+			if math.IsNaN(out) || out < -1000 || out > 1000 {
+				t.Fatalf("output %v out of limits", out)
+			}
+		}
+	})
+}
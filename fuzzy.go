@@ -0,0 +1,136 @@
+package pidctrl
+
+import (
+	"math"
+	"time"
+)
+
+// FuzzyRule is the consequence of a fuzzy rule: the change of each gain as a
+// fraction of the gain range, in [-1, 1].
+type FuzzyRule struct {
+	P, I, D float64
+}
+
+// FuzzyRules is a fuzzy rule table indexed by the linguistic levels of the
+// error and the error rate, from negative big (0) over zero (3) to positive
+// big (6).
+type FuzzyRules [7][7]FuzzyRule
+
+// linguistic levels of the rule consequences
+const (
+	nb = -1.0
+	nm = -2.0 / 3
+	ns = -1.0 / 3
+	zo = 0.0
+	ps = 1.0 / 3
+	pm = 2.0 / 3
+	pb = 1.0
+)
+
+// DefaultFuzzyRules returns the rule table common in fuzzy PID literature:
+// large errors raise the proportional and lower the integral gain, the
+// derivative gain counteracts fast changes near the setpoint.
+func DefaultFuzzyRules() FuzzyRules {
+	p := [7][7]float64{
+		{pb, pb, pm, pm, ps, zo, zo},
+		{pb, pb, pm, ps, ps, zo, ns},
+		{pm, pm, pm, ps, zo, ns, ns},
+		{pm, pm, ps, zo, ns, nm, nm},
+		{ps, ps, zo, ns, ns, nm, nm},
+		{ps, zo, ns, nm, nm, nm, nb},
+		{zo, zo, nm, nm, nm, nb, nb},
+	}
+	i := [7][7]float64{
+		{nb, nb, nm, nm, ns, zo, zo},
+		{nb, nb, nm, ns, ns, zo, zo},
+		{nb, nm, ns, ns, zo, ps, ps},
+		{nm, nm, ns, zo, ps, pm, pm},
+		{nm, ns, zo, ps, ps, pm, pb},
+		{zo, zo, ps, ps, pm, pb, pb},
+		{zo, zo, ps, pm, pm, pb, pb},
+	}
+	d := [7][7]float64{
+		{ps, ns, nb, nb, nb, nm, ps},
+		{ps, ns, nb, nm, nm, ns, zo},
+		{zo, ns, nm, nm, ns, ns, zo},
+		{zo, ns, ns, ns, ns, ns, zo},
+		{zo, zo, zo, zo, zo, zo, zo},
+		{pb, ns, ps, ps, ps, ps, pb},
+		{pb, pm, pm, pm, ps, ps, pb},
+	}
+	var r FuzzyRules
+	for e := range r {
+		for de := range r[e] {
+			r[e][de] = FuzzyRule{P: p[e][de], I: i[e][de], D: d[e][de]}
+		}
+	}
+	return r
+}
+
+// FuzzyGainAdjuster is a fuzzy supervisor that adjusts the gains of a
+// controller online from the error and its rate of change. Both inputs are
+// normalized by their scale and fuzzified with triangular membership
+// functions; the rule consequences are weighted by the product of the
+// memberships and scaled by Range.
+type FuzzyGainAdjuster struct {
+	Base       Gains   // gains for a zero rule output
+	Range      Gains   // gain change at a rule output of ±1
+	ErrorScale float64 // error of positive big
+	RateScale  float64 // error rate of positive big, per second
+	Rules      FuzzyRules
+
+	prevErr float64
+	started bool
+}
+
+// NewFuzzyGainAdjuster returns a FuzzyGainAdjuster using the default rules.
+func NewFuzzyGainAdjuster(base, gainRange Gains, errorScale, rateScale float64) *FuzzyGainAdjuster {
+	return &FuzzyGainAdjuster{Base: base, Range: gainRange, ErrorScale: errorScale, RateScale: rateScale, Rules: DefaultFuzzyRules()}
+}
+
+// Adjust returns the gains for the given error and error rate.
+func (f *FuzzyGainAdjuster) Adjust(err, rate float64) Gains {
+	me, mr := memberships(err/f.ErrorScale), memberships(rate/f.RateScale)
+	var out FuzzyRule
+	for e, we := range me {
+		for r, wr := range mr {
+			if w := we * wr; w != 0 {
+				rule := f.Rules[e][r]
+				out.P += w * rule.P
+				out.I += w * rule.I
+				out.D += w * rule.D
+			}
+		}
+	}
+	return Gains{
+		P: math.Max(0, f.Base.P+out.P*f.Range.P),
+		I: math.Max(0, f.Base.I+out.I*f.Range.I),
+		D: math.Max(0, f.Base.D+out.D*f.Range.D),
+	}
+}
+
+// UpdateDuration adjusts the gains of c from its error and updates it.
+func (f *FuzzyGainAdjuster) UpdateDuration(c *PIDController, value float64, duration time.Duration) float64 {
+	err := c.WorkingSetpoint() - value
+	var rate float64
+	if dt := duration.Seconds(); f.started && dt > 0 {
+		rate = (err - f.prevErr) / dt
+	}
+	f.prevErr, f.started = err, true
+	return c.SetGains(f.Adjust(err, rate)).UpdateDuration(value, duration)
+}
+
+// memberships returns the triangular memberships of x, clamped to [-1, 1],
+// in the seven levels. They sum to 1.
+func memberships(x float64) (m [7]float64) {
+	if math.IsNaN(x) {
+		m[3] = 1
+		return m
+	}
+	pos := (math.Max(-1, math.Min(1, x)) + 1) * 3
+	k := math.Min(5, math.Floor(pos))
+	frac := pos - k
+	m[int(k)] = 1 - frac
+	m[int(k)+1] = frac
+	return m
+}
@@ -0,0 +1,51 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMemberships(t *testing.T) {
+	for x, want := range map[float64][7]float64{
+		0:    {0, 0, 0, 1, 0, 0, 0},
+		1:    {0, 0, 0, 0, 0, 0, 1},
+		-5:   {1, 0, 0, 0, 0, 0, 0},
+		0.5:  {0, 0, 0, 0, 0.5, 0.5, 0},
+		-0.5: {0, 0.5, 0.5, 0, 0, 0, 0},
+	} {
+		m := memberships(x)
+		for k := range m {
+			if math.Abs(m[k]-want[k]) > 1e-12 {
+				t.Errorf("memberships(%v) = %v, want %v", x, m, want)
+				break
+			}
+		}
+	}
+}
+
+func TestFuzzyGainAdjuster(t *testing.T) {
+	f := NewFuzzyGainAdjuster(Gains{P: 2, I: 1, D: 0.5}, Gains{P: 1, I: 0.5, D: 0.25}, 10, 5)
+	// at the setpoint only the derivative gain is lowered a little
+	if g := f.Adjust(0, 0); !gainsClose(g, Gains{P: 2, I: 1, D: 0.5 - 0.25/3}) {
+		t.Errorf("gains at the setpoint %+v", g)
+	}
+	// far below the setpoint and not moving: positive big error, zero rate
+	if g := f.Adjust(20, 0); !gainsClose(g, Gains{P: 2 - 2.0/3, I: 1 + 0.5*2/3, D: 0.5 + 0.25*2/3}) {
+		t.Errorf("gains for a large error %+v", g)
+	}
+	f.Rules[6][3] = FuzzyRule{P: 1}
+	if g := f.Adjust(20, 0); !gainsClose(g, Gains{P: 3, I: 1, D: 0.5}) {
+		t.Errorf("gains with custom rule %+v", g)
+	}
+
+	c := NewPIDController(0, 0, 0).Set(10)
+	f.UpdateDuration(c, 10, time.Second)
+	if !gainsClose(c.Gains(), f.Adjust(0, 0)) {
+		t.Errorf("controller gains %+v", c.Gains())
+	}
+}
+
+func gainsClose(a, b Gains) bool {
+	return math.Abs(a.P-b.P) < 1e-12 && math.Abs(a.I-b.I) < 1e-12 && math.Abs(a.D-b.D) < 1e-12
+}
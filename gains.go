@@ -0,0 +1,18 @@
+package pidctrl
+
+// Gains is a set of P, I, and D constants.
+type Gains struct {
+	P float64 `json:"p"` // proportional gain
+	I float64 `json:"i"` // integral gain
+	D float64 `json:"d"` // derivative gain
+}
+
+// SetGains changes the P, I, and D constants
+func (c *PIDController) SetGains(g Gains) *PIDController {
+	return c.SetPID(g.P, g.I, g.D)
+}
+
+// Gains returns the P, I, and D constants
+func (c *PIDController) Gains() Gains {
+	return Gains{P: c.p, I: c.i, D: c.d}
+}
@@ -0,0 +1,120 @@
+package pidctrl
+
+import "sort"
+
+// ScheduleSource selects the scheduling variable of a gain schedule.
+type ScheduleSource int
+
+const (
+	// ScheduleValue schedules on the process value.
+	ScheduleValue ScheduleSource = iota
+	// ScheduleSetpoint schedules on the working setpoint.
+	ScheduleSetpoint
+	// ScheduleExternal schedules on a variable set with
+	// SetSchedulingVariable or returned by the function set with
+	// SetSchedulingFunc, e.g. airspeed or extruder throughput.
+	ScheduleExternal
+)
+
+// GainPoint is a breakpoint of a gain schedule: the gains in effect when
+// the scheduling variable is At.
+type GainPoint struct {
+	At    float64 `json:"at"`
+	Gains Gains   `json:"gains"`
+}
+
+// gainSchedule interpolates gains from a scheduling variable.
+type gainSchedule struct {
+	source   ScheduleSource
+	points   []GainPoint    // sorted by At, empty disables scheduling
+	variable float64        // external scheduling variable
+	f        func() float64 // optional external scheduling function
+}
+
+// SetGainSchedule enables gain scheduling: before every update the gains are
+// interpolated linearly between the breakpoints from the scheduling variable
+// of the given source, and held at the first or last breakpoint beyond
+// them. Scheduled gains replace those set with SetPID and change bumplessly,
+// like with SetBumpless. Call it without points to disable scheduling; the
+// last scheduled gains stay in effect.
+func (c *PIDController) SetGainSchedule(source ScheduleSource, points ...GainPoint) *PIDController {
+	points = append([]GainPoint(nil), points...)
+	sort.SliceStable(points, func(i, j int) bool { return points[i].At < points[j].At })
+	c.schedule.source, c.schedule.points = source, points
+	return c
+}
+
+// GainSchedule returns the source and breakpoints of the gain schedule.
+func (c *PIDController) GainSchedule() (ScheduleSource, []GainPoint) {
+	return c.schedule.source, append([]GainPoint(nil), c.schedule.points...)
+}
+
+// SetSchedulingVariable sets the external scheduling variable used by
+// ScheduleExternal schedules. It takes effect on the next update.
+func (c *PIDController) SetSchedulingVariable(v float64) *PIDController {
+	c.schedule.variable = v
+	return c
+}
+
+// SetSchedulingFunc installs a function returning the external scheduling
+// variable, which is then called before every update instead of using the
+// variable set with SetSchedulingVariable. Pass nil to remove it.
+func (c *PIDController) SetSchedulingFunc(f func() float64) *PIDController {
+	c.schedule.f = f
+	return c
+}
+
+// gains returns the interpolated gains at the scheduling variable v.
+func (s *gainSchedule) gains(v float64) Gains {
+	p := s.points
+	n := sort.Search(len(p), func(i int) bool { return p[i].At > v })
+	switch {
+	case n == 0:
+		return p[0].Gains
+	case n == len(p):
+		return p[n-1].Gains
+	}
+	a, b := p[n-1], p[n]
+	f := (v - a.At) / (b.At - a.At)
+	return Gains{
+		P: a.Gains.P + f*(b.Gains.P-a.Gains.P),
+		I: a.Gains.I + f*(b.Gains.I-a.Gains.I),
+		D: a.Gains.D + f*(b.Gains.D-a.Gains.D),
+	}
+}
+
+// applySchedule sets the scheduled gains for an update with the given
+// process value.
+func (c *PIDController) applySchedule(value float64) {
+	if len(c.schedule.points) == 0 {
+		return
+	}
+	var v float64
+	switch c.schedule.source {
+	case ScheduleValue:
+		v = value
+	case ScheduleSetpoint:
+		v = c.setpoint
+	case ScheduleExternal:
+		v = c.schedule.variable
+		if c.schedule.f != nil {
+			v = c.schedule.f()
+		}
+	}
+	g := c.schedule.gains(v)
+	if g == c.Gains() || c.rejectGains(g.P, g.I, g.D) {
+		return
+	}
+	if c.started {
+		c.retuning = true
+	}
+	c.p, c.i, c.d = g.P, g.I, g.D
+}
+
+// SetSchedulingVariable sets the external scheduling variable.
+func (s *SafePIDController) SetSchedulingVariable(v float64) *SafePIDController {
+	s.mu.Lock()
+	s.c.SetSchedulingVariable(v)
+	s.mu.Unlock()
+	return s
+}
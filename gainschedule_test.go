@@ -0,0 +1,68 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPIDController_GainScheduleExternal(t *testing.T) {
+	points := []GainPoint{
+		{At: 200, Gains: Gains{P: 1}},
+		{At: 100, Gains: Gains{P: 3}},
+	}
+	for _, tt := range []struct {
+		airspeed, want float64
+	}{
+		{50, 30},  // held at the first breakpoint
+		{150, 20}, // interpolated
+		{300, 10}, // held at the last breakpoint
+	} {
+		c := NewPIDController(0, 0, 0).Set(10).SetGainSchedule(ScheduleExternal, points...)
+		c.SetSchedulingVariable(tt.airspeed)
+		if out := c.UpdateDuration(0, time.Second); math.Abs(out-tt.want) > 1e-9 {
+			t.Errorf("airspeed %v: output %v, want %v", tt.airspeed, out, tt.want)
+		}
+	}
+	throughput := 100.0
+	c := NewPIDController(0, 0, 0).SetGainSchedule(ScheduleExternal, points...)
+	c.SetSchedulingVariable(200).SetSchedulingFunc(func() float64 { return throughput })
+	c.UpdateDuration(0, time.Second)
+	if g := c.Gains(); g.P != 3 {
+		t.Errorf("gains from the scheduling function %v, want P 3", g)
+	}
+}
+
+func TestPIDController_GainScheduleBumpless(t *testing.T) {
+	c := NewPIDController(1, 1, 0).Set(10).SetGainSchedule(ScheduleExternal,
+		GainPoint{At: 0, Gains: Gains{P: 1, I: 1}},
+		GainPoint{At: 1, Gains: Gains{P: 2, I: 1}},
+	)
+	before := c.UpdateDuration(5, time.Second)
+	c.SetSchedulingVariable(1)
+	if out := c.UpdateDuration(5, 0); math.Abs(out-before) > 1e-9 {
+		t.Errorf("output %v after the gain change, want %v", out, before)
+	}
+}
+
+func TestPIDController_GainScheduleSources(t *testing.T) {
+	points := []GainPoint{{At: 0, Gains: Gains{P: 1}}, {At: 10, Gains: Gains{P: 2}}}
+	c := NewPIDController(0, 0, 0).Set(10).SetGainSchedule(ScheduleValue, points...)
+	c.UpdateDuration(5, time.Second)
+	if g := c.Gains(); g.P != 1.5 {
+		t.Errorf("value scheduled P %v, want 1.5", g.P)
+	}
+	c.SetGainSchedule(ScheduleSetpoint, points...)
+	c.UpdateDuration(5, time.Second)
+	if g := c.Gains(); g.P != 2 {
+		t.Errorf("setpoint scheduled P %v, want 2", g.P)
+	}
+	c.SetGainSchedule(ScheduleValue)
+	c.UpdateDuration(0, time.Second)
+	if g := c.Gains(); g.P != 2 {
+		t.Errorf("disabled schedule changed P to %v", g.P)
+	}
+	if src, pts := c.GainSchedule(); src != ScheduleValue || len(pts) != 0 {
+		t.Errorf("GainSchedule() = %v, %v", src, pts)
+	}
+}
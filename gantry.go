@@ -0,0 +1,75 @@
+package pidctrl
+
+import (
+	"math"
+	"time"
+)
+
+// Gantry controls one axis driven by two actuators, as in gantry style CNC
+// machines and 3D printers: the Axis controller positions the mean of both
+// actuator positions at the target, the Skew controller holds the
+// difference between them at zero. The common mode output of Axis and the
+// differential output of Skew are mixed into one output per actuator.
+type Gantry struct {
+	Axis *PIDController
+	Skew *PIDController
+
+	outMin, outMax float64
+	lastUpdate     time.Time
+}
+
+// NewGantry returns a Gantry using the given axis and skew controllers. The
+// setpoint of the skew controller is reset to 0.
+func NewGantry(axis, skew *PIDController) *Gantry {
+	skew.Set(0)
+	return &Gantry{Axis: axis, Skew: skew, outMin: math.Inf(-1), outMax: math.Inf(0)}
+}
+
+// Set changes the axis target.
+func (g *Gantry) Set(target float64) *Gantry {
+	g.Axis.Set(target)
+	return g
+}
+
+// Get returns the axis target.
+func (g *Gantry) Get() float64 {
+	return g.Axis.Get()
+}
+
+// SetOutputLimits sets the min and max of each mixed actuator output.
+func (g *Gantry) SetOutputLimits(min, max float64) *Gantry {
+	if min > max {
+		panic(MinMaxError{min, max})
+	}
+	g.outMin, g.outMax = min, max
+	return g
+}
+
+// OutputLimits returns the min and max of each mixed actuator output.
+func (g *Gantry) OutputLimits() (min, max float64) {
+	return g.outMin, g.outMax
+}
+
+// Update is identical to UpdateDuration, but automatically keeps track of the
+// durations between updates using the clock of the axis controller.
+func (g *Gantry) Update(a, b float64) (outA, outB float64) {
+	var (
+		duration time.Duration
+		now      = g.Axis.now()
+	)
+	if !g.lastUpdate.IsZero() {
+		duration = now.Sub(g.lastUpdate)
+	}
+	g.lastUpdate = now
+	return g.UpdateDuration(a, b, duration)
+}
+
+// UpdateDuration updates both controllers with the actuator positions a and
+// b and returns the outputs of the two actuators.
+func (g *Gantry) UpdateDuration(a, b float64, duration time.Duration) (outA, outB float64) {
+	common := g.Axis.UpdateDuration((a+b)/2, duration)
+	diff := g.Skew.UpdateDuration(a-b, duration)
+	outA = math.Max(g.outMin, math.Min(g.outMax, common+diff))
+	outB = math.Max(g.outMin, math.Min(g.outMax, common-diff))
+	return outA, outB
+}
@@ -0,0 +1,32 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestGantry(t *testing.T) {
+	g := NewGantry(NewPIDController(1, 0, 0), NewPIDController(2, 0, 0)).Set(10)
+	a, b := g.UpdateDuration(1, 3, time.Second)
+	// common mode 10-2 = 8, skew correction 2*(0-(1-3)) = 4
+	if a != 12 || b != 4 {
+		t.Errorf("outputs %v %v, expected 12 4", a, b)
+	}
+	g.SetOutputLimits(0, 10)
+	if a, b = g.UpdateDuration(1, 3, time.Second); a != 10 || b != 4 {
+		t.Errorf("limited outputs %v %v, expected 10 4", a, b)
+	}
+
+	// a simulated axis with unequal actuators converges without skew
+	g = NewGantry(NewPIDController(0.5, 0.05, 0), NewPIDController(2, 0.5, 0)).Set(100)
+	posA, posB := 0.0, 0.0
+	for i := 0; i < 500; i++ {
+		outA, outB := g.UpdateDuration(posA, posB, 100*time.Millisecond)
+		posA += outA * 0.1
+		posB += outB * 0.07
+	}
+	if math.Abs(posA-100) > 0.5 || math.Abs(posB-100) > 0.5 {
+		t.Errorf("positions %v %v, expected both at 100", posA, posB)
+	}
+}
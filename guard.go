@@ -0,0 +1,41 @@
+package pidctrl
+
+import "time"
+
+// SetOvershootGuard enables a predictive overshoot guard. On every update the
+// process value is extrapolated horizon into the future using its current
+// rate of change, and while that prediction lies beyond the setpoint in the
+// direction the process is approaching it from, the output is replaced with
+// cutOutput (still subject to the output limits). A horizon of 0 disables the
+// guard.
+func (c *PIDController) SetOvershootGuard(horizon time.Duration, cutOutput float64) *PIDController {
+	c.guardHorizon = horizon
+	c.guardOutput = cutOutput
+	if horizon <= 0 {
+		c.guardHorizon = 0
+		c.guarding = false
+	}
+	return c
+}
+
+// OvershootGuard returns the overshoot guard horizon and cut output.
+func (c *PIDController) OvershootGuard() (horizon time.Duration, cutOutput float64) {
+	return c.guardHorizon, c.guardOutput
+}
+
+// Guarding returns true if the overshoot guard cut the output on the last
+// update.
+func (c *PIDController) Guarding() bool {
+	return c.guarding
+}
+
+// guard reports whether the predicted process value overshoots the setpoint.
+func (c *PIDController) guard(value, rate, err float64) bool {
+	c.guarding = false
+	if c.guardHorizon == 0 {
+		return false
+	}
+	predicted := value + rate*c.guardHorizon.Seconds()
+	c.guarding = (err > 0 && predicted > c.setpoint) || (err < 0 && predicted < c.setpoint)
+	return c.guarding
+}
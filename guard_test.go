@@ -0,0 +1,25 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOvershootGuard(t *testing.T) {
+	c := NewPIDController(1, 0, 0).SetOvershootGuard(5*time.Second, 0).Set(100)
+	c.UpdateDuration(80, time.Second)
+	// rising by 2/s, predicted 92 stays below the setpoint
+	if out := c.UpdateDuration(82, time.Second); out != 18 || c.Guarding() {
+		t.Errorf("unexpected cut: %f", out)
+	}
+	// rising by 5/s, predicted 112 overshoots
+	if out := c.UpdateDuration(87, time.Second); out != 0 || !c.Guarding() {
+		t.Errorf("expected cut output, got %f", out)
+	}
+	// approaching from above
+	c.Set(50)
+	c.UpdateDuration(60, time.Second)
+	if out := c.UpdateDuration(55, time.Second); out != 0 || !c.Guarding() {
+		t.Errorf("expected cut output from above, got %f", out)
+	}
+}
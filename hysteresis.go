@@ -0,0 +1,39 @@
+package pidctrl
+
+import "math"
+
+// SetOutputHysteresis holds the output until the computed output differs
+// from the last emitted one by more than band, to reduce the wear of
+// mechanical actuators like valves and dampers by suppressing small
+// corrections. It applies after output smoothing and quantization; outputs
+// at the output limits are always emitted, so the actuator can fully open
+// and close. A band of 0 disables the hysteresis.
+func (c *PIDController) SetOutputHysteresis(band float64) *PIDController {
+	c.outHyst = math.Abs(band)
+	return c
+}
+
+// OutputHysteresis returns the output hysteresis band.
+func (c *PIDController) OutputHysteresis() float64 {
+	return c.outHyst
+}
+
+// hysteresis returns the output to emit for output.
+func (c *PIDController) hysteresis(output float64) float64 {
+	if c.outHyst == 0 {
+		return output
+	}
+	if c.hystValid && math.Abs(output-c.hystOut) <= c.outHyst && output != c.outMin && output != c.outMax {
+		return c.hystOut
+	}
+	c.hystOut, c.hystValid = output, true
+	return output
+}
+
+// SetOutputHysteresis holds the output until it changes by more than band.
+func (s *SafePIDController) SetOutputHysteresis(band float64) *SafePIDController {
+	s.mu.Lock()
+	s.c.SetOutputHysteresis(band)
+	s.mu.Unlock()
+	return s
+}
@@ -0,0 +1,31 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPIDController_OutputHysteresis(t *testing.T) {
+	c := NewPIDController(1, 0, 0).SetOutputLimits(0, 100).Set(50).SetOutputHysteresis(2)
+	for i, tt := range []struct {
+		value, want float64
+	}{
+		{0, 50},
+		{1, 50}, // change of 1
+		{2, 50}, // change of 2
+		{3, 47},
+		{45, 5},
+		{46, 5},
+		{50, 0}, // limit
+		{-48, 98},
+		{-50, 100}, // limit
+	} {
+		if out := c.UpdateDuration(tt.value, time.Second); out != tt.want {
+			t.Errorf("update %d: output %v, want %v", i, out, tt.want)
+		}
+	}
+	c.Reset()
+	if out := c.UpdateDuration(49, time.Second); out != 1 {
+		t.Errorf("output %v held across reset", out)
+	}
+}
@@ -0,0 +1,72 @@
+package pidctrl
+
+import "math"
+
+// SetIntegral sets the integral term in output units, clamped to the
+// integral limits. Preloading it with the output that previously held the
+// process at the setpoint gives a bumpless start.
+func (c *PIDController) SetIntegral(integral float64) *PIDController {
+	c.integral = integral
+	c.clampIntegral()
+	return c
+}
+
+// Integral returns the integral term in output units.
+func (c *PIDController) Integral() float64 {
+	return c.integral
+}
+
+// PreloadFromModel sets the integral to the output expected to hold the
+// process at the current setpoint according to a static process model, in
+// which the process settles at ambient plus processGain times the output,
+// e.g. a heater raising the temperature by processGain °C per percent over
+// room temperature. Feed-forward contributions are accounted for. Started
+// that way, the loop reaches the setpoint without waiting for the integral
+// to wind up. The gain is negative for processes whose value falls with the
+// output, like a cooler. A zero or non-finite gain leaves the integral
+// unchanged.
+func (c *PIDController) PreloadFromModel(processGain, ambient float64) *PIDController {
+	output := (c.goal() - ambient) / processGain
+	if processGain == 0 || math.IsNaN(output) || math.IsInf(output, 0) {
+		return c
+	}
+	return c.SetIntegral(output - c.feedForward())
+}
+
+// SetIntegral sets the integral term in output units, clamped to the output
+// limits.
+func (c *IntegerPIDController) SetIntegral(integral int64) *IntegerPIDController {
+	c.integral = c.clampIntegral(scaleSaturating(integral))
+	c.iRem = 0
+	return c
+}
+
+// Integral returns the integral term in output units, truncated like the
+// output.
+func (c *IntegerPIDController) Integral() int64 {
+	return c.integral / INTPID_SCALE
+}
+
+// SetIntegral sets the integral term in output units.
+func (s *SafePIDController) SetIntegral(integral float64) *SafePIDController {
+	s.mu.Lock()
+	s.c.SetIntegral(integral)
+	s.mu.Unlock()
+	return s
+}
+
+// Integral returns the integral term in output units.
+func (s *SafePIDController) Integral() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.c.Integral()
+}
+
+// PreloadFromModel sets the integral to the output expected to hold the
+// process at the current setpoint according to a static process model.
+func (s *SafePIDController) PreloadFromModel(processGain, ambient float64) *SafePIDController {
+	s.mu.Lock()
+	s.c.PreloadFromModel(processGain, ambient)
+	s.mu.Unlock()
+	return s
+}
@@ -0,0 +1,49 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSetIntegral(t *testing.T) {
+	c := NewPIDController(1, 0.1, 0).SetOutputLimits(0, 100).Set(20)
+	if out := c.SetIntegral(40).UpdateDuration(20, time.Second); out != 40 {
+		t.Errorf("preloaded output %v != 40", out)
+	}
+	if c.SetIntegral(500).Integral() != 100 {
+		t.Errorf("integral not clamped: %v", c.Integral())
+	}
+
+	i := NewIntegerPIDController(INTPID_SCALE, 100, 0).SetOutputLimits(0, 100).Set(20)
+	if out := i.SetIntegral(40).UpdateDuration(20, time.Second); out != 40 {
+		t.Errorf("preloaded integer output %v != 40", out)
+	}
+	if i.SetIntegral(500).Integral() != 100 {
+		t.Errorf("integer integral not clamped: %v", i.Integral())
+	}
+
+	if s := NewSafePIDController(0, 1, 0).SetIntegral(3); s.Integral() != 3 {
+		t.Errorf("safe integral %v", s.Integral())
+	}
+}
+
+func TestPreloadFromModel(t *testing.T) {
+	// 0.5 °C per percent over 20 °C ambient holds 60 °C at 80%
+	c := NewPIDController(2, 0.1, 0).SetOutputLimits(0, 100).Set(60).SetBias(5)
+	if out := c.PreloadFromModel(0.5, 20).UpdateDuration(60, time.Second); out != 80 {
+		t.Errorf("preloaded output %v != 80", out)
+	}
+	// a cooler lowers the temperature with the output
+	c = NewPIDController(2, 0.1, 0).SetOutputLimits(0, 100).Set(5)
+	if out := c.PreloadFromModel(-0.25, 20).UpdateDuration(5, time.Second); out != 60 {
+		t.Errorf("preloaded cooler output %v != 60", out)
+	}
+	c.SetIntegral(1).PreloadFromModel(0, 20).PreloadFromModel(math.NaN(), 20)
+	if c.Integral() != 1 {
+		t.Errorf("invalid model changed the integral: %v", c.Integral())
+	}
+	if s := NewSafePIDController(0, 1, 0).Set(30).PreloadFromModel(2, 10); s.Integral() != 10 {
+		t.Errorf("safe preload %v", s.Integral())
+	}
+}
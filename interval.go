@@ -0,0 +1,108 @@
+package pidctrl
+
+import "time"
+
+// SetInterval sets the fixed update interval used by UpdateConstInterval.
+func (c *PIDController) SetInterval(interval time.Duration) *PIDController {
+	c.interval = interval
+	return c
+}
+
+// Interval returns the fixed update interval.
+func (c *PIDController) Interval() time.Duration {
+	return c.interval
+}
+
+// UpdateConstInterval is identical to UpdateDuration using the interval set
+// with SetInterval, for loops driven by a fixed rate timer. With jitter
+// compensation enabled, the measured period is used instead when it deviates
+// too much from the interval.
+func (c *PIDController) UpdateConstInterval(value float64) float64 {
+	if c.jitterThreshold > 0 {
+		return c.UpdateDuration(value, c.measureJitter())
+	}
+	return c.UpdateDuration(value, c.interval)
+}
+
+// JitterStats describes the timing of UpdateConstInterval calls measured by
+// the jitter compensation.
+type JitterStats struct {
+	Last        time.Duration // deviation of the last period from the interval
+	Max         time.Duration // largest absolute deviation
+	Compensated int           // updates that used the measured period
+}
+
+// SetJitterCompensation makes UpdateConstInterval measure the actual period
+// between calls with the controller clock. Periods deviating from the
+// interval by more than threshold, e.g. because a timer tick was delayed,
+// are used instead of the interval, so the integral and derivative are
+// scaled by the time that really passed. A threshold of 0 disables the
+// compensation and trusts the timer.
+func (c *PIDController) SetJitterCompensation(threshold time.Duration) *PIDController {
+	c.jitterThreshold = threshold
+	c.jitterLast = time.Time{}
+	c.jitter = JitterStats{}
+	return c
+}
+
+// Jitter returns the jitter measured by the jitter compensation.
+func (c *PIDController) Jitter() JitterStats {
+	return c.jitter
+}
+
+// measureJitter returns the duration of an UpdateConstInterval call. The
+// first call after a reset, a resume or a mode change uses the interval.
+func (c *PIDController) measureJitter() time.Duration {
+	now := c.now()
+	last := c.jitterLast
+	c.jitterLast = now
+	if last.IsZero() || c.paused {
+		return c.interval
+	}
+	period := now.Sub(last)
+	dev := period - c.interval
+	c.jitter.Last = dev
+	if dev < 0 {
+		dev = -dev
+	}
+	if dev > c.jitter.Max {
+		c.jitter.Max = dev
+	}
+	if dev <= c.jitterThreshold {
+		return c.interval
+	}
+	c.jitter.Compensated++
+	return period
+}
+
+// SetInterval sets the fixed update interval used by UpdateConstInterval.
+func (c *IntegerPIDController) SetInterval(interval time.Duration) *IntegerPIDController {
+	c.interval = interval
+	return c
+}
+
+// Interval returns the fixed update interval.
+func (c *IntegerPIDController) Interval() time.Duration {
+	return c.interval
+}
+
+// UpdateConstInterval is identical to UpdateDuration using the interval set
+// with SetInterval, for loops driven by a fixed rate timer.
+func (c *IntegerPIDController) UpdateConstInterval(value int64) int64 {
+	return c.UpdateDuration(value, c.interval)
+}
+
+// SetClock changes the time source used by Update. Passing nil restores the
+// real clock.
+func (c *IntegerPIDController) SetClock(clock Clock) *IntegerPIDController {
+	c.clock = clock
+	return c
+}
+
+// now returns the current time according to the controller's clock.
+func (c *IntegerPIDController) now() time.Time {
+	if c.clock == nil {
+		return time.Now()
+	}
+	return c.clock.Now()
+}
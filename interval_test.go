@@ -0,0 +1,77 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestUpdateConstInterval(t *testing.T) {
+	clock := NewManualClock(time.Date(2016, 3, 2, 12, 0, 0, 0, time.UTC))
+	var (
+		a = NewPIDController(0.5, 0.5, 0.5).Set(10).SetInterval(time.Second)
+		b = NewPIDController(0.5, 0.5, 0.5).Set(10).SetClock(clock)
+		c = NewPIDController(0.5, 0.5, 0.5).Set(10)
+		x = NewIntegerPIDController(500, 500, 500).Set(10).SetInterval(time.Second)
+		y = NewIntegerPIDController(500, 500, 500).Set(10).SetClock(clock)
+		z = NewIntegerPIDController(500, 500, 500).Set(10)
+	)
+	b.Update(0)
+	y.Update(0)
+	a.UpdateDuration(0, 0)
+	c.UpdateDuration(0, 0)
+	x.UpdateDuration(0, 0)
+	z.UpdateDuration(0, 0)
+	for i, v := range []float64{5, 10, 15, 8} {
+		clock.Advance(time.Second)
+		if oa, ob, oc := a.UpdateConstInterval(v), b.Update(v), c.UpdateDuration(v, time.Second); oa != oc || ob != oc {
+			t.Errorf("update %d: float outputs differ: %v %v %v", i, oa, ob, oc)
+		}
+		if ox, oy, oz := x.UpdateConstInterval(int64(v)), y.Update(int64(v)), z.UpdateDuration(int64(v), time.Second); ox != oz || oy != oz {
+			t.Errorf("update %d: integer outputs differ: %v %v %v", i, ox, oy, oz)
+		}
+	}
+}
+
+func TestPIDController_JitterCompensation(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	c := NewPIDController(0, 1, 0).Set(1).SetInterval(100 * time.Millisecond).SetClock(clock).SetJitterCompensation(10 * time.Millisecond)
+	c.UpdateConstInterval(0) // 0.1
+	for i, tt := range []struct {
+		period time.Duration
+		want   float64
+	}{
+		{105 * time.Millisecond, 0.2}, // within the threshold
+		{300 * time.Millisecond, 0.5}, // delayed tick
+		{95 * time.Millisecond, 0.6},
+	} {
+		clock.Advance(tt.period)
+		c.UpdateConstInterval(0)
+		if math.Abs(c.Integral()-tt.want) > 1e-9 {
+			t.Errorf("update %d: integral %v, want %v", i, c.Integral(), tt.want)
+		}
+	}
+	if j := c.Jitter(); j.Last != -5*time.Millisecond || j.Max != 200*time.Millisecond || j.Compensated != 1 {
+		t.Errorf("Jitter() = %+v", j)
+	}
+
+	// the time spent paused or in another mode is not a delayed tick
+	c.Pause()
+	clock.Advance(time.Minute)
+	c.Resume()
+	c.UpdateConstInterval(0)
+	if j := c.Jitter(); j.Compensated != 1 || j.Max != 200*time.Millisecond {
+		t.Errorf("after resume: Jitter() = %+v", j)
+	}
+	if err := c.SetMode(ModeManual); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Minute)
+	if err := c.SetMode(ModeAuto); err != nil {
+		t.Fatal(err)
+	}
+	c.UpdateConstInterval(0)
+	if j := c.Jitter(); j.Compensated != 1 || j.Max != 200*time.Millisecond {
+		t.Errorf("after mode change: Jitter() = %+v", j)
+	}
+}
@@ -0,0 +1,51 @@
+package pidctrl
+
+import "math"
+
+// SetIntegralLimits bounds the integral independently of the output limits,
+// e.g. to ±20% of the output span. By default the integral is bounded by the
+// output limits; ClearIntegralLimits restores that.
+func (c *PIDController) SetIntegralLimits(min, max float64) *PIDController {
+	if min > max {
+		panic(MinMaxError{min, max})
+	}
+	c.iMin, c.iMax, c.iLimits = min, max, true
+	c.clampIntegral()
+	return c
+}
+
+// SetIntegralLimitsE is like SetIntegralLimits, but returns a MinMaxError
+// instead of panicking when min is greater than max.
+func (c *PIDController) SetIntegralLimitsE(min, max float64) error {
+	if min > max {
+		return MinMaxError{min, max}
+	}
+	c.SetIntegralLimits(min, max)
+	return nil
+}
+
+// ClearIntegralLimits bounds the integral by the output limits again.
+func (c *PIDController) ClearIntegralLimits() *PIDController {
+	c.iLimits = false
+	c.clampIntegral()
+	return c
+}
+
+// IntegralLimits returns the limits of the integral in effect.
+func (c *PIDController) IntegralLimits() (min, max float64) {
+	if c.iLimits {
+		return c.iMin, c.iMax
+	}
+	return c.outMin, c.outMax
+}
+
+// clampIntegral limits the integral and reports whether it was clamped.
+func (c *PIDController) clampIntegral() bool {
+	min, max := c.IntegralLimits()
+	clamped := math.Max(min, math.Min(c.soft.limit(max), c.integral))
+	if clamped == c.integral {
+		return false
+	}
+	c.integral = clamped
+	return true
+}
@@ -0,0 +1,30 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIntegralLimits(t *testing.T) {
+	c := NewPIDController(1, 1, 0).SetOutputLimits(0, 100).SetIntegralLimits(-5, 20).Set(50)
+	for i := 0; i < 10; i++ {
+		c.UpdateDuration(40, time.Second)
+	}
+	if i := c.Terms().I; i != 20 {
+		t.Errorf("integral %v not clamped to 20", i)
+	}
+	if out := c.Output(); out != 30 {
+		t.Errorf("output %v != 30", out)
+	}
+	if min, max := c.IntegralLimits(); min != -5 || max != 20 {
+		t.Errorf("integral limits %v %v", min, max)
+	}
+	c.SetIntegralLimits(-5, 10)
+	if out := c.UpdateDuration(40, 0); out != 20 {
+		t.Errorf("integral not clamped to the new limits: %v", out)
+	}
+	c.ClearIntegralLimits()
+	if min, max := c.IntegralLimits(); min != 0 || max != 100 {
+		t.Errorf("cleared integral limits %v %v", min, max)
+	}
+}
@@ -0,0 +1,216 @@
+package pidctrl
+
+import (
+	"math"
+	"time"
+)
+
+// INTPID_SCALE is the fixed-point scale of IntegerPIDController gains: a gain
+// of INTPID_SCALE corresponds to a float gain of 1.
+const INTPID_SCALE int64 = 1000
+
+// NewIntegerPIDController returns a new IntegerPIDController using the given
+// gain values, scaled by INTPID_SCALE.
+func NewIntegerPIDController(p, i, d int64) *IntegerPIDController {
+	return &IntegerPIDController{p: p, i: i, d: d, outMin: math.MinInt64, outMax: math.MaxInt64}
+}
+
+// IntegerPIDController implements a PID controller using integer arithmetic
+// only, for targets without (fast) floating point support. Gains are fixed
+// point values scaled by INTPID_SCALE, process values and outputs are plain
+// integers, e.g. sensor and actuator counts.
+//
+// Updates are bit-exact reproducible: UpdateDuration, UpdateTicks and
+// UpdateConstInterval use integer arithmetic only, whose results, including
+// saturation and the truncation of divisions, are defined by the Go
+// specification independently of the architecture. Identical sequences of
+// gains, limits, setpoints, values and durations therefore yield identical
+// outputs on every platform, unlike float64 arithmetic, which compilers may
+// fuse into multiply-add instructions on some architectures. Update is
+// reproducible given a reproducible Clock. Only IntegerScaling, which
+// converts tunings before they are applied, uses floating point.
+type IntegerPIDController struct {
+	p          int64            // proportional gain, scaled
+	i          int64            // integral gain, scaled
+	d          int64            // derrivate gain, scaled
+	setpoint   int64            // current setpoint
+	prevValue  int64            // last process value
+	integral   int64            // integral sum, scaled
+	lastUpdate time.Time        // time of last update
+	outMin     int64            // Output Min
+	outMax     int64            // Output Max
+	clock      Clock            // time source for Update, nil means the real clock
+	interval   time.Duration    // interval of UpdateConstInterval
+	dSource    DerivativeSource // signal of the derivative term
+	prevErr    int64            // error of the last update
+	subMicros  time.Duration    // sub-microsecond remainder of UpdateDuration
+	iRem       int64            // remainder of the integral increments, scaled by 1e6
+}
+
+// Set changes the setpoint of the controller.
+func (c *IntegerPIDController) Set(setpoint int64) *IntegerPIDController {
+	c.setpoint = setpoint
+	return c
+}
+
+// Get returns the setpoint of the controller.
+func (c *IntegerPIDController) Get() int64 {
+	return c.setpoint
+}
+
+// SetPID changes the P, I, and D constants
+func (c *IntegerPIDController) SetPID(p, i, d int64) *IntegerPIDController {
+	c.p = p
+	c.i = i
+	c.d = d
+	return c
+}
+
+// PID returns the P, I, and D constants
+func (c *IntegerPIDController) PID() (p, i, d int64) {
+	return c.p, c.i, c.d
+}
+
+// SetOutputLimits sets the min and max output values
+func (c *IntegerPIDController) SetOutputLimits(min, max int64) *IntegerPIDController {
+	if min > max {
+		panic(MinMaxError{float64(min), float64(max)})
+	}
+	c.outMin = min
+	c.outMax = max
+	c.integral = c.clampIntegral(c.integral)
+	return c
+}
+
+// SetOutputLimitsE is like SetOutputLimits, but returns a MinMaxError instead
+// of panicking when min is greater than max.
+func (c *IntegerPIDController) SetOutputLimitsE(min, max int64) error {
+	if min > max {
+		return MinMaxError{float64(min), float64(max)}
+	}
+	c.SetOutputLimits(min, max)
+	return nil
+}
+
+// OutputLimits returns the min and max output values
+func (c *IntegerPIDController) OutputLimits() (min, max int64) {
+	return c.outMin, c.outMax
+}
+
+// Update is identical to UpdateDuration, but automatically keeps track of the
+// durations between updates.
+func (c *IntegerPIDController) Update(value int64) int64 {
+	var duration time.Duration
+	now := c.now()
+	if !c.lastUpdate.IsZero() {
+		duration = now.Sub(c.lastUpdate)
+	}
+	c.lastUpdate = now
+	return c.UpdateDuration(value, duration)
+}
+
+// UpdateDuration updates the controller with the given value and duration since
+// the last update. It returns the new output. The duration is used with
+// microsecond resolution; the sub-microsecond remainder is carried over to
+// the next update, so that no time is lost over many short updates.
+func (c *IntegerPIDController) UpdateDuration(value int64, duration time.Duration) int64 {
+	duration += c.subMicros
+	c.subMicros = duration % time.Microsecond
+	return c.UpdateTicks(value, int64(duration/time.Microsecond))
+}
+
+// UpdateTicks updates the controller with the given value and the number of
+// microseconds since the last update, e.g. from a monotonic hardware timer.
+// It returns the new output and uses integer arithmetic only. Intermediate
+// results saturate instead of overflowing, so any output limits, including
+// the unbounded default and negative-only ranges, clamp correctly. The
+// remainder of each integral increment below the integral resolution is
+// carried over to the next update, so that small errors over many short
+// ticks still integrate.
+func (c *IntegerPIDController) UpdateTicks(value int64, dtMicros int64) int64 {
+	var (
+		dt  = dtMicros
+		err = subSaturating(c.setpoint, value)
+		d   int64
+	)
+	inc := addSaturating(mulSaturating(mulSaturating(err, c.i), dt), c.iRem)
+	c.iRem = inc % 1e6
+	if integral := addSaturating(c.integral, inc/1e6); integral != c.clampIntegral(integral) {
+		c.integral, c.iRem = c.clampIntegral(integral), 0
+	} else {
+		c.integral = integral
+	}
+	if dt > 0 && c.dSource == DerivativeOnError {
+		d = mulSaturating(mulSaturating(subSaturating(err, c.prevErr), c.d), 1e6) / dt
+	} else if dt > 0 {
+		d = -(mulSaturating(mulSaturating(subSaturating(value, c.prevValue), c.d), 1e6) / dt)
+	}
+	c.prevValue = value
+	c.prevErr = err
+	output := addSaturating(addSaturating(mulSaturating(c.p, err), c.integral), d) / INTPID_SCALE
+
+	if output > c.outMax {
+		output = c.outMax
+	} else if output < c.outMin {
+		output = c.outMin
+	}
+	return output
+}
+
+// clampIntegral limits the scaled integral to the scaled output limits.
+func (c *IntegerPIDController) clampIntegral(integral int64) int64 {
+	if max := scaleSaturating(c.outMax); integral > max {
+		return max
+	} else if min := scaleSaturating(c.outMin); integral < min {
+		return min
+	}
+	return integral
+}
+
+// scaleSaturating multiplies v by INTPID_SCALE, saturating instead of
+// overflowing.
+func scaleSaturating(v int64) int64 {
+	if v > math.MaxInt64/INTPID_SCALE {
+		return math.MaxInt64
+	} else if v < math.MinInt64/INTPID_SCALE {
+		return math.MinInt64
+	}
+	return v * INTPID_SCALE
+}
+
+// addSaturating returns a+b, saturating instead of overflowing.
+func addSaturating(a, b int64) int64 {
+	s := a + b
+	if b > 0 && s < a {
+		return math.MaxInt64
+	} else if b < 0 && s > a {
+		return math.MinInt64
+	}
+	return s
+}
+
+// subSaturating returns a-b, saturating instead of overflowing.
+func subSaturating(a, b int64) int64 {
+	if b == math.MinInt64 {
+		if a >= 0 {
+			return math.MaxInt64
+		}
+		return a - b
+	}
+	return addSaturating(a, -b)
+}
+
+// mulSaturating returns a*b, saturating instead of overflowing.
+func mulSaturating(a, b int64) int64 {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	p := a * b
+	if p/b == a && !(a == -1 && b == math.MinInt64) && !(b == -1 && a == math.MinInt64) {
+		return p
+	}
+	if (a < 0) != (b < 0) {
+		return math.MinInt64
+	}
+	return math.MaxInt64
+}
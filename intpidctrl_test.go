@@ -0,0 +1,186 @@
+package pidctrl
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestIntegerPIDController(t *testing.T) {
+	// same as the pid controller test case, with gains scaled by INTPID_SCALE
+	c := NewIntegerPIDController(500, 500, 500)
+	for i, u := range []struct {
+		setpoint, input, output int64
+	}{
+		{10, 5, 2},
+		{0, 10, 0},
+		{0, 15, -5},
+		{0, 100, -132},
+		{1, 0, 6},
+	} {
+		if u.setpoint != 0 {
+			c.Set(u.setpoint)
+		}
+		if out := c.UpdateDuration(u.input, time.Second); out != u.output {
+			t.Errorf("update %d: %d != %d", i, out, u.output)
+		}
+	}
+}
+
+func TestIntegerPIDController_limits(t *testing.T) {
+	// the default limits must not overflow when scaled
+	c := NewIntegerPIDController(0, INTPID_SCALE, 0).Set(10)
+	if out := c.UpdateDuration(0, time.Second); out != 10 {
+		t.Errorf("default limits: %d != 10", out)
+	}
+	c.SetOutputLimits(-20, -5)
+	if out := c.UpdateDuration(0, time.Second); out != -5 {
+		t.Errorf("negative-only limits: %d != -5", out)
+	}
+}
+
+func TestIntegerPIDController_overflow(t *testing.T) {
+	// a wound-up integral plus a large proportional term must saturate
+	// instead of wrapping around
+	c := NewIntegerPIDController(INTPID_SCALE, INTPID_SCALE, 0).Set(math.MaxInt64 / 2)
+	c.SetIntegral(math.MaxInt64)
+	if out := c.UpdateDuration(0, time.Second); out != math.MaxInt64/INTPID_SCALE {
+		t.Errorf("positive overflow: %d", out)
+	}
+	c.Set(math.MinInt64 / 2).SetIntegral(math.MinInt64)
+	if out := c.UpdateDuration(math.MaxInt64, time.Second); out != math.MinInt64/INTPID_SCALE {
+		t.Errorf("negative overflow: %d", out)
+	}
+
+	// braking-only actuator
+	c = NewIntegerPIDController(INTPID_SCALE, INTPID_SCALE, 0).SetOutputLimits(-1000, 0).Set(0)
+	for i, want := range []int64{-1000, -1000, 0} {
+		value := []int64{2000, 600, -600}[i]
+		if out := c.UpdateDuration(value, time.Second); out != want {
+			t.Errorf("braking update %d: %d != %d", i, out, want)
+		}
+	}
+	if i := c.Integral(); i < -1000 || i > 0 {
+		t.Errorf("integral outside the limits: %d", i)
+	}
+}
+
+func TestSaturatingArithmetic(t *testing.T) {
+	const max, min = math.MaxInt64, math.MinInt64
+	tests := []struct {
+		name       string
+		f          func(a, b int64) int64
+		a, b, want int64
+	}{
+		{"add", addSaturating, max, 1, max},
+		{"add", addSaturating, min, -1, min},
+		{"add", addSaturating, 3, -5, -2},
+		{"sub", subSaturating, 0, min, max},
+		{"sub", subSaturating, -1, min, max},
+		{"sub", subSaturating, min, 1, min},
+		{"sub", subSaturating, 5, 3, 2},
+		{"mul", mulSaturating, max, 2, max},
+		{"mul", mulSaturating, max, -2, min},
+		{"mul", mulSaturating, min, -1, max},
+		{"mul", mulSaturating, -1, min, max},
+		{"mul", mulSaturating, min, 1, min},
+		{"mul", mulSaturating, -3, 4, -12},
+	}
+	for _, tt := range tests {
+		if got := tt.f(tt.a, tt.b); got != tt.want {
+			t.Errorf("%s(%d, %d) = %d, want %d", tt.name, tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestIntegerScaling(t *testing.T) {
+	s := IntegerScaling{PVCountsPerUnit: 40.95, OutputCountsPerUnit: 2.55}
+	c, err := s.NewIntegerPIDController(2, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SetOutputLimits(s.Limits(0, 100)).Set(s.Value(50))
+	// 5 °C below the setpoint with a gain of 2 %/°C is 10 %
+	out := s.Output(c.UpdateDuration(s.Value(45), time.Second))
+	if math.Abs(out-10) > 0.5 {
+		t.Errorf("output %f, expected ~10", out)
+	}
+	if _, _, _, err := s.Gains(0.0001, 0, 0); err == nil {
+		t.Error("expected precision error for tiny gain")
+	}
+}
+
+func TestIntegerPIDController_UpdateTicks(t *testing.T) {
+	a := NewIntegerPIDController(500, 500, 500).Set(10)
+	b := NewIntegerPIDController(500, 500, 500).Set(10)
+	for i, v := range []int64{5, 10, 15, 100, 0} {
+		if x, y := a.UpdateDuration(v, 250*time.Millisecond), b.UpdateTicks(v, 250000); x != y {
+			t.Errorf("update %d: %d != %d", i, y, x)
+		}
+	}
+}
+
+func TestIntegerPIDController_UpdateTicks1kHz(t *testing.T) {
+	// a steady error integrating 0.2 scaled units per 1 ms tick
+	c := NewIntegerPIDController(0, 100, 0).Set(2)
+	for i := 1; i <= 10000; i++ {
+		out := c.UpdateTicks(0, 1000)
+		if want := int64(i) / 5000; out != want {
+			t.Fatalf("tick %d: output %d != %d", i, out, want)
+		}
+	}
+}
+
+func TestIntegerPIDController_subMicros(t *testing.T) {
+	a := NewIntegerPIDController(500, 500_000, 500).Set(1000)
+	b := NewIntegerPIDController(500, 500_000, 500).Set(1000)
+	for i, ticks := range []int64{1, 2, 1, 2, 1, 2} {
+		if x, y := a.UpdateDuration(int64(i), 1500*time.Nanosecond), b.UpdateTicks(int64(i), ticks); x != y {
+			t.Errorf("update %d: %d != %d", i, x, y)
+		}
+	}
+}
+
+func TestIntegerPIDController_smallIncrements(t *testing.T) {
+	// increments of 0.5 scaled units per update, below the integral resolution
+	c := NewIntegerPIDController(0, 100, 0).Set(5)
+	f := NewPIDController(0, 0.1, 0).Set(5)
+	var out int64
+	for i := 0; i < 10000; i++ {
+		out = c.UpdateDuration(0, time.Millisecond)
+		f.UpdateDuration(0, time.Millisecond)
+	}
+	if want := f.Output(); math.Abs(float64(out)-want) > 1e-9 {
+		t.Errorf("output %d, float controller %v", out, want)
+	}
+	if c.SetIntegral(0).UpdateDuration(0, time.Millisecond) != 0 {
+		t.Error("remainder kept after SetIntegral")
+	}
+}
+
+// TestIntegerPIDController_reproducible pins the outputs of a long
+// pseudo-random run, so that a platform computing anything differently
+// fails.
+func TestIntegerPIDController_reproducible(t *testing.T) {
+	c := NewIntegerPIDController(1234, 567, 89).SetOutputLimits(-4000, 4000)
+	h := fnv.New64a()
+	x := uint32(1)
+	next := func() int64 {
+		x = x*1664525 + 1013904223
+		return int64(x >> 20)
+	}
+	var buf [8]byte
+	for i := 0; i < 10000; i++ {
+		if i%1000 == 0 {
+			c.Set(next())
+		}
+		out := c.UpdateDuration(next(), time.Duration(next())*time.Microsecond+time.Duration(next()))
+		binary.LittleEndian.PutUint64(buf[:], uint64(out))
+		h.Write(buf[:])
+	}
+	if sum := h.Sum64(); sum != 0x213a86bd73ef95bc {
+		t.Errorf("output checksum %#x", sum)
+	}
+}
@@ -0,0 +1,76 @@
+package pidctrl
+
+import (
+	"fmt"
+	"math"
+)
+
+// IntegerScaling converts engineering unit tunings into IntegerPIDController
+// parameters. Process values are sensor counts, PVCountsPerUnit of them per
+// engineering unit, and outputs are actuator counts, OutputCountsPerUnit of
+// them per output unit. E.g. a 12 bit ADC reading 0-100 °C has
+// PVCountsPerUnit 40.95, an 8 bit PWM driven by a 0-100 % tuning has
+// OutputCountsPerUnit 2.55.
+type IntegerScaling struct {
+	PVCountsPerUnit     float64
+	OutputCountsPerUnit float64
+}
+
+// GainPrecisionError is returned when a gain cannot be represented as an
+// IntegerPIDController gain.
+type GainPrecisionError struct {
+	gain   string
+	value  float64
+	scaled float64
+}
+
+func (e GainPrecisionError) Error() string {
+	return fmt.Sprintf("%s gain %v scales to %v, which is not representable with INTPID_SCALE %d", e.gain, e.value, e.scaled, INTPID_SCALE)
+}
+
+// Gains converts P, I, and D constants in engineering units into scaled
+// IntegerPIDController gains. It fails if a non-zero gain would round to zero
+// or overflow.
+func (s IntegerScaling) Gains(p, i, d float64) (ip, ii, id int64, err error) {
+	factor := s.OutputCountsPerUnit / s.PVCountsPerUnit * float64(INTPID_SCALE)
+	gains := [3]int64{}
+	for n, g := range [3]float64{p, i, d} {
+		scaled := math.Round(g * factor)
+		if (g != 0 && scaled == 0) || math.Abs(scaled) > math.MaxInt32 || math.IsNaN(scaled) {
+			return 0, 0, 0, GainPrecisionError{gain: [3]string{"P", "I", "D"}[n], value: g, scaled: g * factor}
+		}
+		gains[n] = int64(scaled)
+	}
+	return gains[0], gains[1], gains[2], nil
+}
+
+// Value converts a process value or setpoint in engineering units to sensor
+// counts.
+func (s IntegerScaling) Value(v float64) int64 {
+	return int64(math.Round(v * s.PVCountsPerUnit))
+}
+
+// OutputCounts converts an output in engineering units to actuator counts.
+func (s IntegerScaling) OutputCounts(v float64) int64 {
+	return int64(math.Round(v * s.OutputCountsPerUnit))
+}
+
+// Output converts actuator counts to an output in engineering units.
+func (s IntegerScaling) Output(counts int64) float64 {
+	return float64(counts) / s.OutputCountsPerUnit
+}
+
+// Limits converts output limits in engineering units to actuator counts.
+func (s IntegerScaling) Limits(min, max float64) (int64, int64) {
+	return s.OutputCounts(min), s.OutputCounts(max)
+}
+
+// NewIntegerPIDController returns a new IntegerPIDController for the given
+// engineering unit gains.
+func (s IntegerScaling) NewIntegerPIDController(p, i, d float64) (*IntegerPIDController, error) {
+	ip, ii, id, err := s.Gains(p, i, d)
+	if err != nil {
+		return nil, err
+	}
+	return NewIntegerPIDController(ip, ii, id), nil
+}
@@ -0,0 +1,37 @@
+package pidctrl
+
+// SetCompensatedIntegral enables compensated (Kahan) summation of the
+// integral. Every update adds a small increment to a possibly large
+// integral, and the rounding errors of these additions accumulate, which
+// makes the integral drift in loops running for months with short sample
+// times. Compensated summation carries the rounding error of each addition
+// over to the next one, keeping the integral accurate to its last bits at the
+// cost of a few additional operations per update. It is disabled by default.
+func (c *PIDController) SetCompensatedIntegral(enabled bool) *PIDController {
+	c.kahan = enabled
+	c.kahanErr = 0
+	return c
+}
+
+// CompensatedIntegral returns true if compensated summation of the integral
+// is enabled.
+func (c *PIDController) CompensatedIntegral() bool {
+	return c.kahan
+}
+
+// accumulate adds x to the integral, with compensated summation if enabled.
+// The carried rounding error is dropped when the integral was changed
+// otherwise since the last accumulation, e.g. clamped or set.
+func (c *PIDController) accumulate(x float64) {
+	if !c.kahan {
+		c.integral += x
+		return
+	}
+	if c.integral != c.kahanSum {
+		c.kahanErr = 0
+	}
+	y := x - c.kahanErr
+	t := c.integral + y
+	c.kahanErr = (t - c.integral) - y
+	c.integral, c.kahanSum = t, t
+}
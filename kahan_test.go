@@ -0,0 +1,33 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPIDController_CompensatedIntegral(t *testing.T) {
+	run := func(compensated bool) float64 {
+		c := NewPIDController(0, 1, 0).Set(1).SetCompensatedIntegral(compensated).SetIntegral(1e9)
+		for i := 0; i < 100000; i++ {
+			c.UpdateDuration(0, time.Microsecond)
+		}
+		return c.Integral() - 1e9
+	}
+	// 100000 increments of 1e-6
+	if drift := math.Abs(run(false) - 0.1); drift < 1e-4 {
+		t.Fatalf("plain summation drifted only %v, test ineffective", drift)
+	}
+	if drift := math.Abs(run(true) - 0.1); drift > 1e-6 {
+		t.Errorf("compensated summation drifted %v", drift)
+	}
+	c := NewPIDController(0, 1, 0).Set(1).SetCompensatedIntegral(true)
+	if !c.CompensatedIntegral() {
+		t.Error("compensated summation not enabled")
+	}
+	c.UpdateDuration(0, time.Second)
+	c.SetIntegral(5)
+	if c.UpdateDuration(0, time.Second); c.Integral() != 6 {
+		t.Errorf("integral %v after SetIntegral, want 6", c.Integral())
+	}
+}
@@ -0,0 +1,76 @@
+package pidctrl
+
+import "time"
+
+// Estimator pre-processes process values before they reach the controller.
+// Estimate is called with each raw measurement and the duration since the
+// previous one and returns the estimated value and its rate of change per
+// second.
+type Estimator interface {
+	Estimate(value float64, duration time.Duration) (estimate, rate float64)
+}
+
+// KalmanFilter is a scalar Kalman filter tracking a value and its rate of
+// change using a constant velocity model. It implements Estimator, so it can
+// be attached to a controller with SetEstimator.
+//
+// see http://en.wikipedia.org/wiki/Kalman_filter
+type KalmanFilter struct {
+	q       float64       // process noise (rate random walk), units²/s³
+	r       float64       // measurement noise variance, units²
+	x, v    float64       // estimated value and rate
+	p       [2][2]float64 // estimate covariance
+	started bool          // true after the first measurement
+}
+
+// NewKalmanFilter returns a new KalmanFilter. processNoise is the spectral
+// density of the random acceleration of the value, measurementNoise the
+// variance of a single measurement.
+func NewKalmanFilter(processNoise, measurementNoise float64) *KalmanFilter {
+	return &KalmanFilter{q: processNoise, r: measurementNoise}
+}
+
+// Estimate implements Estimator.
+func (k *KalmanFilter) Estimate(value float64, duration time.Duration) (float64, float64) {
+	if !k.started {
+		k.x, k.v = value, 0
+		k.p = [2][2]float64{{k.r, 0}, {0, k.r}}
+		k.started = true
+		return k.x, k.v
+	}
+	dt := duration.Seconds()
+
+	// predict
+	k.x += k.v * dt
+	p00 := k.p[0][0] + dt*(k.p[1][0]+k.p[0][1]) + dt*dt*k.p[1][1] + k.q*dt*dt*dt/3
+	p01 := k.p[0][1] + dt*k.p[1][1] + k.q*dt*dt/2
+	p10 := k.p[1][0] + dt*k.p[1][1] + k.q*dt*dt/2
+	p11 := k.p[1][1] + k.q*dt
+
+	// correct
+	s := p00 + k.r
+	k0, k1 := p00/s, p10/s
+	y := value - k.x
+	k.x += k0 * y
+	k.v += k1 * y
+	k.p = [2][2]float64{
+		{(1 - k0) * p00, (1 - k0) * p01},
+		{p10 - k1*p00, p11 - k1*p01},
+	}
+	return k.x, k.v
+}
+
+// Value returns the current estimate of the value.
+func (k *KalmanFilter) Value() float64 {
+	return k.x
+}
+
+// Rate returns the current estimate of the rate of change per second.
+func (k *KalmanFilter) Rate() float64 {
+	return k.v
+}
+
+// Reset discards the filter state, the next measurement starts over.
+func (k *KalmanFilter) Reset() {
+	k.x, k.v, k.p, k.started = 0, 0, [2][2]float64{}, false
+}
@@ -0,0 +1,38 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestKalmanFilter_ramp(t *testing.T) {
+	k := NewKalmanFilter(0.01, 0.25)
+	var value, rate float64
+	for i := 0; i < 200; i++ {
+		// ramp of 2 units/s with alternating measurement noise
+		noise := 0.5
+		if i%2 == 0 {
+			noise = -0.5
+		}
+		value, rate = k.Estimate(float64(i)*2+noise, time.Second)
+	}
+	if math.Abs(rate-2) > 0.05 {
+		t.Errorf("rate: %f, expected ~2", rate)
+	}
+	if math.Abs(value-398) > 0.5 {
+		t.Errorf("value: %f, expected ~398", value)
+	}
+}
+
+func TestPIDController_SetEstimator(t *testing.T) {
+	c := NewPIDController(0, 0, 1).SetEstimator(NewKalmanFilter(0.01, 0.25))
+	var output float64
+	for i := 0; i < 200; i++ {
+		output = c.UpdateDuration(float64(i), time.Second)
+	}
+	// derivative on measurement of a 1 unit/s ramp
+	if math.Abs(output+1) > 0.05 {
+		t.Errorf("output: %f, expected ~-1", output)
+	}
+}
@@ -0,0 +1,57 @@
+package pidctrl
+
+import "time"
+
+// KickHold selects how the setpoint part of the derivative term returns
+// after a setpoint change.
+type KickHold int
+
+const (
+	// KickSuppress differentiates the measurement only until the hold
+	// interval has passed.
+	KickSuppress KickHold = iota
+	// KickBlend fades the derivative setpoint weight in linearly over the
+	// hold interval.
+	KickBlend
+)
+
+// SetDerivativeKickHold suppresses or blends in the setpoint part of the
+// derivative term for interval after each change made with Set. With a
+// derivative setpoint weight above 0, e.g. derivative on error, this removes
+// the derivative kick of setpoint steps while keeping derivative action on
+// moving setpoints. An interval about the dead time of the process lets the
+// loop respond to the measurement before the weight returns. 0 disables the
+// hold.
+func (c *PIDController) SetDerivativeKickHold(interval time.Duration, mode KickHold) *PIDController {
+	c.kickHold, c.kickMode = interval, mode
+	if interval <= 0 {
+		c.kickLeft = 0
+	}
+	return c
+}
+
+// DerivativeKickHold returns the derivative kick hold interval and mode.
+func (c *PIDController) DerivativeKickHold() (time.Duration, KickHold) {
+	return c.kickHold, c.kickMode
+}
+
+// startKickHold starts the hold interval after a setpoint change.
+func (c *PIDController) startKickHold() {
+	if c.kickHold > 0 {
+		c.kickLeft = c.kickHold
+	}
+}
+
+// derivativeWeight returns the derivative setpoint weight of an update of
+// the given duration and advances the hold interval.
+func (c *PIDController) derivativeWeight(duration time.Duration) float64 {
+	if c.kickLeft <= 0 {
+		return c.dWeight
+	}
+	w := 0.0
+	if c.kickMode == KickBlend {
+		w = c.dWeight * (1 - float64(c.kickLeft)/float64(c.kickHold))
+	}
+	c.kickLeft -= duration
+	return w
+}
@@ -0,0 +1,48 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPIDController_DerivativeKickHold(t *testing.T) {
+	c := NewPIDController(0, 0, 1).SetDerivativeSource(DerivativeOnError)
+	c.UpdateDuration(0, time.Second)
+	c.Set(10)
+	if out := c.UpdateDuration(0, time.Second); out != 10 {
+		t.Fatalf("expected derivative kick without hold, got %v", out)
+	}
+
+	c.Reset().Set(0).SetDerivativeKickHold(2*time.Second, KickSuppress)
+	c.UpdateDuration(0, time.Second)
+	c.Set(10)
+	if out := c.UpdateDuration(0, time.Second); out != 0 {
+		t.Errorf("derivative kick not suppressed: %v", out)
+	}
+	c.Set(20) // restarts the hold
+	for i := 0; i < 2; i++ {
+		if out := c.UpdateDuration(0, time.Second); out != 0 {
+			t.Errorf("update %d: derivative kick not suppressed: %v", i, out)
+		}
+	}
+	c.Set(21)
+	c.SetDerivativeKickHold(0, KickSuppress)
+	if out := c.UpdateDuration(0, time.Second); out != 1 {
+		t.Errorf("derivative kick suppressed after disabling the hold: %v", out)
+	}
+}
+
+func TestPIDController_DerivativeKickBlend(t *testing.T) {
+	c := NewPIDController(0, 0, 1).SetDerivativeSource(DerivativeOnError).SetSetpointRamp(1)
+	c.SetDerivativeKickHold(4*time.Second, KickBlend)
+	c.UpdateDuration(0, time.Second)
+	c.Set(10)
+	for i, want := range []float64{0, 0.25, 0.5, 0.75, 1, 1} {
+		if out := c.UpdateDuration(0, time.Second); out != want {
+			t.Errorf("update %d: got %v, want %v", i, out, want)
+		}
+	}
+	if d, mode := c.DerivativeKickHold(); d != 4*time.Second || mode != KickBlend {
+		t.Errorf("unexpected hold: %v %v", d, mode)
+	}
+}
@@ -0,0 +1,78 @@
+package pidctrl
+
+import "math"
+
+// LoopStatus is the health of a single loop as input to RollupKPIs.
+type LoopStatus struct {
+	Name      string
+	Auto      bool // loop in automatic mode
+	Alarm     bool // loop has an active alarm
+	Saturated bool // output pinned at a limit
+	// PerformanceIndex rates the control performance, higher is worse,
+	// e.g. the integral of absolute error per hour. NaN if unknown.
+	PerformanceIndex float64
+}
+
+// LoopStatus returns the status of the controller for KPI rollups. The loop
+// is automatic in ModeAuto while not paused. Detected oscillation and
+// saturation faults are reported as alarm. The performance index is not
+// tracked by the controller itself and reported as NaN; applications fill it
+// in from their own metrics.
+func (c *PIDController) LoopStatus() LoopStatus {
+	return LoopStatus{
+		Auto:             c.mode == ModeAuto && !c.paused,
+		Alarm:            c.osc.active || c.satFault.active,
+		Saturated:        c.saturated,
+		PerformanceIndex: math.NaN(),
+	}
+}
+
+// FleetKPIs are fleet level key performance indicators computed by
+// RollupKPIs. Percentages are of all loops, except for PoorPercent which is
+// of the loops with a known performance index.
+type FleetKPIs struct {
+	Loops            int      `json:"loops"`
+	AutoPercent      float64  `json:"auto_percent"`
+	AlarmPercent     float64  `json:"alarm_percent"`
+	SaturatedPercent float64  `json:"saturated_percent"`
+	PoorPercent      float64  `json:"poor_percent"`
+	Alarms           []string `json:"alarms,omitempty"` // names of loops in alarm
+	Poor             []string `json:"poor,omitempty"`   // names of poorly performing loops
+}
+
+// RollupKPIs aggregates loop statuses into fleet KPIs. A loop performs
+// poorly if its performance index exceeds poorThreshold.
+func RollupKPIs(loops []LoopStatus, poorThreshold float64) FleetKPIs {
+	k := FleetKPIs{Loops: len(loops)}
+	if len(loops) == 0 {
+		return k
+	}
+	var auto, alarm, saturated, rated, poor int
+	for _, l := range loops {
+		if l.Auto {
+			auto++
+		}
+		if l.Alarm {
+			alarm++
+			k.Alarms = append(k.Alarms, l.Name)
+		}
+		if l.Saturated {
+			saturated++
+		}
+		if !math.IsNaN(l.PerformanceIndex) {
+			rated++
+			if l.PerformanceIndex > poorThreshold {
+				poor++
+				k.Poor = append(k.Poor, l.Name)
+			}
+		}
+	}
+	percent := func(n, of int) float64 { return 100 * float64(n) / float64(of) }
+	k.AutoPercent = percent(auto, len(loops))
+	k.AlarmPercent = percent(alarm, len(loops))
+	k.SaturatedPercent = percent(saturated, len(loops))
+	if rated > 0 {
+		k.PoorPercent = percent(poor, rated)
+	}
+	return k
+}
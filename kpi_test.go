@@ -0,0 +1,67 @@
+package pidctrl
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRollupKPIs(t *testing.T) {
+	c := NewPIDController(1, 0, 0).SetOutputLimits(0, 1).Set(10)
+	c.UpdateDuration(0, time.Second)
+	status := c.LoopStatus()
+	status.Name = "mash"
+
+	got := RollupKPIs([]LoopStatus{
+		status,
+		{Name: "boil", Auto: true, PerformanceIndex: 5},
+		{Name: "hlt", Alarm: true, PerformanceIndex: 50},
+		{Name: "fermenter", Auto: true, PerformanceIndex: math.NaN()},
+	}, 10)
+	want := FleetKPIs{
+		Loops:            4,
+		AutoPercent:      75,
+		AlarmPercent:     25,
+		SaturatedPercent: 25,
+		PoorPercent:      50,
+		Alarms:           []string{"hlt"},
+		Poor:             []string{"hlt"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("\n%+v\n%+v", got, want)
+	}
+	if k := RollupKPIs(nil, 10); k.Loops != 0 || k.AutoPercent != 0 {
+		t.Errorf("empty fleet: %+v", k)
+	}
+}
+
+func TestLoopStatus_auto(t *testing.T) {
+	c := NewPIDController(1, 0, 0)
+	if !c.LoopStatus().Auto {
+		t.Error("new controller not automatic")
+	}
+	for _, m := range []ControlMode{ModeOff, ModeManual, ModeTune, ModeFailsafe} {
+		if err := c.SetMode(m); err != nil {
+			t.Fatal(err)
+		}
+		if c.LoopStatus().Auto {
+			t.Errorf("%v reported as automatic", m)
+		}
+	}
+	if err := c.SetMode(ModeManual); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetMode(ModeAuto); err != nil {
+		t.Fatal(err)
+	}
+	if !c.LoopStatus().Auto {
+		t.Error("auto not reported as automatic")
+	}
+	if c.Pause().LoopStatus().Auto {
+		t.Error("paused loop reported as automatic")
+	}
+	if !c.Resume().LoopStatus().Auto {
+		t.Error("resumed loop not automatic")
+	}
+}
@@ -0,0 +1,30 @@
+package pidctrl
+
+import (
+	"math"
+	"time"
+)
+
+// SetIntegralLeak makes the integral decay towards zero with the given time
+// constant, so that it forgets old errors. This suits processes with a
+// drifting bias or intermittent measurements, at the cost of a small
+// steady-state error. A time constant of 0 (the default) disables the leak.
+func (c *PIDController) SetIntegralLeak(tau time.Duration) *PIDController {
+	if tau < 0 {
+		tau = 0
+	}
+	c.leak = tau
+	return c
+}
+
+// IntegralLeak returns the time constant of the integral leak.
+func (c *PIDController) IntegralLeak() time.Duration {
+	return c.leak
+}
+
+// leakIntegral decays the integral for a step of dt seconds.
+func (c *PIDController) leakIntegral(dt float64) {
+	if c.leak > 0 && dt > 0 {
+		c.integral *= math.Exp(-dt / c.leak.Seconds())
+	}
+}
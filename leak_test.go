@@ -0,0 +1,24 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestIntegralLeak(t *testing.T) {
+	c := NewPIDController(0, 1, 0).SetIntegralLeak(10 * time.Second).Set(1)
+	c.UpdateDuration(0, time.Second)
+	c.Set(0)
+	if i := c.UpdateDuration(0, 10*time.Second); math.Abs(i-math.Exp(-1)) > 1e-12 {
+		t.Errorf("integral after one time constant %v != %v", i, math.Exp(-1))
+	}
+	// with a constant error the integral settles at error*ki*tau
+	c.Set(1)
+	for i := 0; i < 1000; i++ {
+		c.UpdateDuration(0, 100*time.Millisecond)
+	}
+	if i := c.Terms().I; math.Abs(i-10) > 0.1 {
+		t.Errorf("leaky integral %v, expected about 10", i)
+	}
+}
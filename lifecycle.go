@@ -0,0 +1,177 @@
+package pidctrl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Lifecycle runs a function in the background between Start and Stop. It
+// implements the Start/Stop hooks of Loop and of the adapter sub-packages,
+// which fit the OnStart/OnStop hooks of DI frameworks like go.uber.org/fx.
+// The zero value is ready to use.
+type Lifecycle struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+}
+
+// ErrNotRunning is returned by Stop and health checks when a Lifecycle was
+// not started.
+var ErrNotRunning = errors.New("not running")
+
+// Start runs run in a new goroutine with a context that is cancelled by Stop.
+// It returns an error if the Lifecycle is already running. The context passed
+// to Start is only used for startup and does not bound the lifetime of run.
+func (l *Lifecycle) Start(ctx context.Context, run func(context.Context) error) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.running() {
+		return errors.New("already running")
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	runCtx, cancel := context.WithCancel(context.Background())
+	l.cancel, l.done, l.err = cancel, make(chan struct{}), nil
+	go func(done chan struct{}) {
+		err := run(runCtx)
+		l.mu.Lock()
+		l.err = err
+		l.mu.Unlock()
+		close(done)
+	}(l.done)
+	return nil
+}
+
+// Stop cancels the running function and waits for it to return or for ctx
+// to expire. It returns the error of the function unless that is the
+// cancellation caused by Stop.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	l.mu.Lock()
+	cancel, done := l.cancel, l.done
+	l.mu.Unlock()
+	if done == nil {
+		return ErrNotRunning
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if errors.Is(l.err, context.Canceled) {
+		return nil
+	}
+	return l.err
+}
+
+// Done returns a channel that is closed when the running function returns,
+// for run-group style supervision. It returns nil if never started.
+func (l *Lifecycle) Done() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.done
+}
+
+// Check returns nil while the function is running, its error if it returned
+// and ErrNotRunning if it was never started.
+func (l *Lifecycle) Check() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.done == nil {
+		return ErrNotRunning
+	}
+	if !l.running() {
+		if l.err == nil || errors.Is(l.err, context.Canceled) {
+			return ErrNotRunning
+		}
+		return fmt.Errorf("stopped: %w", l.err)
+	}
+	return nil
+}
+
+func (l *Lifecycle) running() bool {
+	if l.done == nil {
+		return false
+	}
+	select {
+	case <-l.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Loop is a control loop running a controller at a fixed interval, with
+// Start, Stop and Healthcheck hooks for embedding into larger services.
+type Loop struct {
+	Controller *SafePIDController
+	Interval   time.Duration
+	Read       func() float64
+	Write      func(float64)
+
+	lifecycle Lifecycle
+	mu        sync.Mutex
+	started   time.Time // time the loop started running
+	last      time.Time // time of the last update
+}
+
+// Start starts the loop in the background, see Lifecycle.Start. It refuses
+// to start a controller whose configuration has invalid issues, see
+// PIDController.Validate.
+func (l *Loop) Start(ctx context.Context) error {
+	var issues Issues
+	l.Controller.Do(func(c *PIDController) { issues = c.Validate() })
+	if err := issues.Err(); err != nil {
+		return err
+	}
+	return l.lifecycle.Start(ctx, func(ctx context.Context) error {
+		l.mu.Lock()
+		l.started, l.last = time.Now(), time.Time{}
+		l.mu.Unlock()
+		return l.Controller.Run(ctx, l.Interval, l.Read, func(out float64) {
+			l.Write(out)
+			l.mu.Lock()
+			l.last = time.Now()
+			l.mu.Unlock()
+		})
+	})
+}
+
+// Stop stops the loop, see Lifecycle.Stop.
+func (l *Loop) Stop(ctx context.Context) error {
+	return l.lifecycle.Stop(ctx)
+}
+
+// Done returns a channel that is closed when the loop stops.
+func (l *Loop) Done() <-chan struct{} {
+	return l.lifecycle.Done()
+}
+
+// Healthcheck returns an error if the loop is not running or has not
+// completed an update within the last three intervals, including a first
+// update that does not complete within three intervals of the start.
+func (l *Loop) Healthcheck(ctx context.Context) error {
+	if err := l.lifecycle.Check(); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	started, last := l.started, l.last
+	l.mu.Unlock()
+	if last.IsZero() {
+		if !started.IsZero() && time.Since(started) > 3*l.Interval {
+			return fmt.Errorf("no update since the start %v ago", time.Since(started).Round(time.Millisecond))
+		}
+		return nil
+	}
+	if time.Since(last) > 3*l.Interval {
+		return fmt.Errorf("last update %v ago", time.Since(last).Round(time.Millisecond))
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package pidctrl
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLoop(t *testing.T) {
+	writes := make(chan float64, 100)
+	l := &Loop{
+		Controller: NewSafePIDController(1, 0, 0).Set(1),
+		Interval:   time.Millisecond,
+		Read:       func() float64 { return 0 },
+		Write:      func(v float64) { writes <- v },
+	}
+	ctx := context.Background()
+	if err := l.Healthcheck(ctx); err != ErrNotRunning {
+		t.Errorf("healthcheck before start: %v", err)
+	}
+	if err := l.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Start(ctx); err == nil {
+		t.Error("expected error starting twice")
+	}
+	if v := <-writes; v != 1 {
+		t.Errorf("output %v != 1", v)
+	}
+	if err := l.Healthcheck(ctx); err != nil {
+		t.Errorf("healthcheck while running: %v", err)
+	}
+	if err := l.Stop(ctx); err != nil {
+		t.Errorf("stop: %v", err)
+	}
+	<-l.Done()
+	if err := l.Healthcheck(ctx); err != ErrNotRunning {
+		t.Errorf("healthcheck after stop: %v", err)
+	}
+	if err := l.Start(ctx); err != nil {
+		t.Errorf("restart: %v", err)
+	}
+	l.Stop(ctx)
+}
+
+func TestLoop_blockedStart(t *testing.T) {
+	// a first read that never returns makes the loop unhealthy
+	release := make(chan struct{})
+	l := &Loop{
+		Controller: NewSafePIDController(1, 0, 0),
+		Interval:   time.Millisecond,
+		Read:       func() float64 { <-release; return 0 },
+		Write:      func(float64) {},
+	}
+	ctx := context.Background()
+	if err := l.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for l.Healthcheck(ctx) == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("blocked loop reported healthy")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	if err := l.Stop(ctx); err != nil {
+		t.Errorf("stop: %v", err)
+	}
+}
+
+func TestLifecycle_Error(t *testing.T) {
+	var l Lifecycle
+	failure := errors.New("failure")
+	l.Start(context.Background(), func(context.Context) error { return failure })
+	<-l.Done()
+	if err := l.Check(); !errors.Is(err, failure) {
+		t.Errorf("check: %v", err)
+	}
+	if err := l.Stop(context.Background()); err != failure {
+		t.Errorf("stop: %v", err)
+	}
+}
@@ -0,0 +1,38 @@
+package pidctrl
+
+import "math"
+
+// SetOutputExponent enables a power-law transform of the clamped output:
+// the output is normalized to the output limits, raised to the given
+// exponent and scaled back. An exponent of 0.5 compensates actuators whose
+// effect grows with the square of the command, such as a PWM duty cycle
+// driving a resistive heater whose temperature rise should respond linearly
+// to the controller. The transform requires finite output limits and is a
+// no-op otherwise. An exponent of 0 or 1 disables it.
+func (c *PIDController) SetOutputExponent(exp float64) *PIDController {
+	if exp < 0 {
+		panic("pidctrl: output exponent must not be negative")
+	}
+	if exp == 1 {
+		exp = 0
+	}
+	c.outExp = exp
+	return c
+}
+
+// OutputExponent returns the output linearization exponent, 1 if disabled.
+func (c *PIDController) OutputExponent() float64 {
+	if c.outExp == 0 {
+		return 1
+	}
+	return c.outExp
+}
+
+// linearize applies the output exponent to a clamped output.
+func (c *PIDController) linearize(output float64) float64 {
+	if c.outExp == 0 || math.IsInf(c.outMin, 0) || math.IsInf(c.outMax, 0) || c.outMax == c.outMin {
+		return output
+	}
+	span := c.outMax - c.outMin
+	return c.outMin + span*math.Pow((output-c.outMin)/span, c.outExp)
+}
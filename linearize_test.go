@@ -0,0 +1,21 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOutputExponent(t *testing.T) {
+	c := NewPIDController(1, 0, 0).SetOutputLimits(0, 100).SetOutputExponent(0.5).Set(25)
+	if out := c.UpdateDuration(0, time.Second); out != 50 {
+		t.Errorf("sqrt transform: %f != 50", out)
+	}
+	if out := c.UpdateDuration(-100, time.Second); out != 100 {
+		t.Errorf("saturated output: %f != 100", out)
+	}
+	// without finite limits the transform is skipped
+	c = NewPIDController(1, 0, 0).SetOutputExponent(0.5).Set(25)
+	if out := c.UpdateDuration(0, time.Second); out != 25 {
+		t.Errorf("unbounded output: %f != 25", out)
+	}
+}
@@ -0,0 +1,72 @@
+package pidctrl
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"time"
+)
+
+// LogOptions configures the event logging installed with SetLogger.
+type LogOptions struct {
+	// Level is the level events are logged at.
+	Level slog.Level
+	// Throttle is the minimum time between two messages of the same kind,
+	// measured with the controller's clock. 0 logs every event.
+	Throttle time.Duration
+	// ErrorSpike is the change of the error between two updates that is
+	// logged as a spike. 0 disables spike logging.
+	ErrorSpike float64
+}
+
+// log event kinds, used for throttling
+const (
+	logSetpoint = iota
+	logGains
+	logSaturation
+	logWindup
+	logSpike
+	numLogEvents
+)
+
+// SetLogger enables structured logging of controller events: saturation
+// entered and left, setpoint and gain changes, integral anti-windup and error
+// spikes. Passing a nil logger disables logging.
+func (c *PIDController) SetLogger(l *slog.Logger, opts LogOptions) *PIDController {
+	c.logger = l
+	c.logOpts = opts
+	c.logLast = [numLogEvents]time.Time{}
+	return c
+}
+
+// log emits an event message unless the event kind is throttled.
+func (c *PIDController) log(kind int, msg string, attrs ...slog.Attr) {
+	if !c.logger.Enabled(context.Background(), c.logOpts.Level) {
+		return
+	}
+	if c.logOpts.Throttle > 0 {
+		now := c.now()
+		if last := c.logLast[kind]; !last.IsZero() && now.Sub(last) < c.logOpts.Throttle {
+			return
+		}
+		c.logLast[kind] = now
+	}
+	c.logger.LogAttrs(context.Background(), c.logOpts.Level, msg, attrs...)
+}
+
+// logUpdate logs the events of an update.
+func (c *PIDController) logUpdate(err float64, wasSaturated bool) {
+	if c.saturated != wasSaturated {
+		if c.saturated {
+			c.log(logSaturation, "output saturated", slog.Float64("output", c.output), slog.Float64("error", err))
+		} else {
+			c.log(logSaturation, "output left saturation", slog.Float64("output", c.output), slog.Float64("error", err))
+		}
+	}
+	if c.windup {
+		c.log(logWindup, "integral anti-windup", slog.Float64("integral", c.integral))
+	}
+	if c.logOpts.ErrorSpike > 0 && c.started && math.Abs(err-c.prevErr) >= c.logOpts.ErrorSpike {
+		c.log(logSpike, "error spike", slog.Float64("error", err), slog.Float64("previous", c.prevErr))
+	}
+}
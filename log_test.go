@@ -0,0 +1,41 @@
+package pidctrl
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPIDController_SetLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	clock := NewManualClock(time.Date(2016, 3, 2, 0, 0, 0, 0, time.UTC))
+	c := NewPIDController(1, 0, 0).SetOutputLimits(0, 10).SetClock(clock).
+		SetLogger(logger, LogOptions{Level: slog.LevelWarn, Throttle: time.Minute, ErrorSpike: 50})
+
+	c.Set(20)
+	c.UpdateDuration(0, time.Second)   // saturates
+	c.UpdateDuration(15, time.Second)  // leaves saturation, throttled
+	c.UpdateDuration(100, time.Second) // error spike
+	c.SetPID(2, 0, 0)
+
+	want := []string{
+		`level=WARN msg="setpoint changed" old=0 new=20`,
+		`level=WARN msg="output saturated" output=10 error=20`,
+		`level=WARN msg="error spike" error=-80 previous=5`,
+		`level=WARN msg="gains changed" p=2 i=0 d=0`,
+	}
+	got := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected log:\n%s\nexpected:\n%s", buf.String(), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,56 @@
+package pidctrl
+
+import "math"
+
+// OutputMapper maps controller outputs linearly from an input range to an
+// actuator range, e.g. -100..100 to a 1000..2000 µs servo pulse or 0..100 %
+// to an 8 bit PWM compare value. Inputs are clamped to the input range.
+// Inverted actuator ranges, with OutMin greater than OutMax, are allowed.
+type OutputMapper struct {
+	InMin, InMax   float64
+	OutMin, OutMax float64
+	// Deadzone maps inputs within ±Deadzone to the output for 0, e.g. to keep
+	// a servo centered or a motor stopped on small outputs.
+	Deadzone float64
+}
+
+// NewOutputMapper returns an OutputMapper between the given ranges. It
+// panics if inMin is not less than inMax.
+func NewOutputMapper(inMin, inMax, outMin, outMax float64) *OutputMapper {
+	if !(inMin < inMax) {
+		panic(MinMaxError{inMin, inMax})
+	}
+	return &OutputMapper{InMin: inMin, InMax: inMax, OutMin: outMin, OutMax: outMax}
+}
+
+// SetDeadzone changes the deadzone around zero.
+func (m *OutputMapper) SetDeadzone(deadzone float64) *OutputMapper {
+	m.Deadzone = math.Abs(deadzone)
+	return m
+}
+
+// Map returns the actuator value for the controller output v.
+func (m *OutputMapper) Map(v float64) float64 {
+	if math.Abs(v) <= m.Deadzone {
+		v = 0
+	}
+	v = math.Max(m.InMin, math.Min(m.InMax, v))
+	return m.OutMin + (v-m.InMin)*(m.OutMax-m.OutMin)/(m.InMax-m.InMin)
+}
+
+// MapInt returns the actuator value for v rounded to the nearest integer,
+// e.g. a PWM compare value. The ends of the input range map exactly to the
+// ends of the actuator range.
+func (m *OutputMapper) MapInt(v float64) int64 {
+	return int64(math.Round(m.Map(v)))
+}
+
+// Unmap returns the controller output for the actuator value a, the inverse
+// of Map without deadzone, e.g. to track a manually set actuator.
+func (m *OutputMapper) Unmap(a float64) float64 {
+	if m.OutMax == m.OutMin {
+		return m.InMin
+	}
+	v := m.InMin + (a-m.OutMin)*(m.InMax-m.InMin)/(m.OutMax-m.OutMin)
+	return math.Max(m.InMin, math.Min(m.InMax, v))
+}
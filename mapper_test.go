@@ -0,0 +1,42 @@
+package pidctrl
+
+import "testing"
+
+func TestOutputMapper(t *testing.T) {
+	servo := NewOutputMapper(-100, 100, 1000, 2000).SetDeadzone(2)
+	for in, want := range map[float64]float64{
+		-100: 1000,
+		100:  2000,
+		0:    1500,
+		1.5:  1500,
+		50:   1750,
+		150:  2000,
+		-150: 1000,
+	} {
+		if out := servo.Map(in); out != want {
+			t.Errorf("servo %v: %v != %v", in, out, want)
+		}
+	}
+	if v := servo.Unmap(1750); v != 50 {
+		t.Errorf("unmap %v != 50", v)
+	}
+
+	pwm := NewOutputMapper(0, 100, 0, 255)
+	for in, want := range map[float64]int64{0: 0, 100: 255, 50: 128, 0.1: 0, 99.9: 255} {
+		if out := pwm.MapInt(in); out != want {
+			t.Errorf("pwm %v: %v != %v", in, out, want)
+		}
+	}
+
+	inverted := NewOutputMapper(0, 1, 10, 0)
+	if out := inverted.Map(0.25); out != 7.5 {
+		t.Errorf("inverted %v != 7.5", out)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for empty input range")
+		}
+	}()
+	NewOutputMapper(1, 1, 0, 1)
+}
@@ -0,0 +1,136 @@
+package pidctrl
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+)
+
+// Metrics accumulates control performance figures of a controller, e.g.
+// per batch for record keeping: the integral error criteria over all
+// updates and the overshoot and settling time of every setpoint change.
+// Attach it to a controller or feed it UpdateInfo from an observer.
+type Metrics struct {
+	// SettlingBand is the error band around the setpoint, as a fraction of
+	// the setpoint change, that defines the settling time. 0.02 if 0.
+	SettlingBand float64
+
+	report   MetricsReport
+	since    time.Duration // time since the last setpoint change
+	setpoint float64       // working setpoint of the last update
+	changing bool          // setpoint changed on the last update
+	started  bool          // at least one update observed
+	outside  time.Duration // end of the last update outside the band, relative to the step
+	closed   bool          // the last step was dropped, no step is tracked
+}
+
+// MetricsReport are the accumulated metrics.
+type MetricsReport struct {
+	Duration time.Duration `json:"duration"` // observed time
+	IAE      float64       `json:"iae"`      // integral of the absolute error
+	ISE      float64       `json:"ise"`      // integral of the squared error
+	// ITAE is the integral of the absolute error weighted by the time since
+	// the last setpoint change.
+	ITAE  float64       `json:"itae"`
+	Steps []StepMetrics `json:"steps,omitempty"`
+}
+
+// StepMetrics describes the response to a setpoint change. A ramped change
+// counts as a single step from the start to the end of the ramp; changes
+// ending where they started are not counted.
+type StepMetrics struct {
+	At   time.Duration `json:"at"` // time of the change since the start of the metrics
+	From float64       `json:"from"`
+	To   float64       `json:"to"`
+	// Overshoot is the peak beyond the new setpoint in percent of the
+	// change.
+	Overshoot float64 `json:"overshoot"`
+	// SettlingTime is the time after the change after which the process
+	// value stayed in the settling band, negative while it is outside.
+	SettlingTime time.Duration `json:"settling_time"`
+}
+
+// NewMetrics returns a new Metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{}
+}
+
+// Attach installs m as observer of c, in addition to an observer already
+// installed.
+func (m *Metrics) Attach(c *PIDController) *Metrics {
+	prev := c.observer
+	c.SetObserver(func(info UpdateInfo) {
+		if prev != nil {
+			prev(info)
+		}
+		m.Observe(info)
+	})
+	return m
+}
+
+// Observe accumulates an update.
+func (m *Metrics) Observe(info UpdateInfo) {
+	r := &m.report
+	dt := info.Dt.Seconds()
+	if m.started && info.Setpoint != m.setpoint {
+		if m.changing && len(r.Steps) > 0 {
+			r.Steps[len(r.Steps)-1].To = info.Setpoint
+		} else {
+			r.Steps = append(r.Steps, StepMetrics{At: r.Duration, From: m.setpoint, To: info.Setpoint})
+			m.since, m.outside, m.closed = 0, 0, false
+		}
+		m.changing = true
+	} else {
+		m.changing = false
+	}
+	m.setpoint, m.started = info.Setpoint, true
+	r.Duration += info.Dt
+	m.since += info.Dt
+
+	err := info.Setpoint - info.Value
+	r.IAE += math.Abs(err) * dt
+	r.ISE += err * err * dt
+	r.ITAE += m.since.Seconds() * math.Abs(err) * dt
+
+	if len(r.Steps) == 0 || m.closed {
+		return
+	}
+	s := &r.Steps[len(r.Steps)-1]
+	span := s.To - s.From
+	if span == 0 {
+		// a change that returned to where it started is no step
+		if !m.changing {
+			r.Steps, m.closed = r.Steps[:len(r.Steps)-1], true
+		}
+		return
+	}
+	s.Overshoot = math.Max(s.Overshoot, ((info.Value-s.From)/span-1)*100)
+	band := m.SettlingBand
+	if band <= 0 {
+		band = 0.02
+	}
+	if m.changing || math.Abs(s.To-info.Value) > band*math.Abs(span) {
+		m.outside = m.since
+		s.SettlingTime = -1
+	} else {
+		s.SettlingTime = m.outside
+	}
+}
+
+// Report returns the accumulated metrics.
+func (m *Metrics) Report() MetricsReport {
+	r := m.report
+	r.Steps = append([]StepMetrics(nil), r.Steps...)
+	return r
+}
+
+// Reset clears the accumulated metrics, e.g. at the start of a batch.
+func (m *Metrics) Reset() *Metrics {
+	*m = Metrics{SettlingBand: m.SettlingBand}
+	return m
+}
+
+// MarshalJSON implements json.Marshaler, encoding the report.
+func (m *Metrics) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.Report())
+}
@@ -0,0 +1,118 @@
+package pidctrl
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+)
+
+// runFirstOrder runs c against a first order plant with unit gain and a
+// time constant of 5s, changing the setpoint to the given values at the
+// given steps.
+func runFirstOrder(c *PIDController, steps int, setpoints map[int]float64) {
+	value := 0.0
+	for i := 0; i < steps; i++ {
+		if sp, ok := setpoints[i]; ok {
+			c.Set(sp)
+		}
+		out := c.UpdateDuration(value, 100*time.Millisecond)
+		value += (out - value) * 0.1 / 5
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	var observed int
+	c := NewPIDController(4, 2, 0).SetObserver(func(UpdateInfo) { observed++ })
+	m := NewMetrics().Attach(c)
+	runFirstOrder(c, 600, map[int]float64{10: 10, 300: 5})
+	if observed != 600 {
+		t.Errorf("previous observer called %d times", observed)
+	}
+	r := m.Report()
+	if r.Duration != time.Minute {
+		t.Errorf("duration %v", r.Duration)
+	}
+	if len(r.Steps) != 2 {
+		t.Fatalf("steps %+v", r.Steps)
+	}
+	s := r.Steps[0]
+	if s.At != time.Second || s.From != 0 || s.To != 10 {
+		t.Errorf("unexpected step %+v", s)
+	}
+	if !(s.Overshoot > 1) || !(s.SettlingTime > time.Second) || s.SettlingTime > 25*time.Second {
+		t.Errorf("unexpected response %+v", s)
+	}
+	if r.Steps[1].From != 10 || r.Steps[1].To != 5 || r.Steps[1].SettlingTime < 0 {
+		t.Errorf("unexpected second step %+v", r.Steps[1])
+	}
+	if !(r.IAE > 0) || !(r.ISE > r.IAE) || !(r.ITAE > 0) {
+		t.Errorf("unexpected criteria %+v", r)
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded MetricsReport
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.IAE != r.IAE || len(decoded.Steps) != 2 {
+		t.Errorf("decoded %+v", decoded)
+	}
+
+	m.Reset()
+	if r := m.Report(); r.Duration != 0 || r.IAE != 0 || len(r.Steps) != 0 {
+		t.Errorf("not reset: %+v", r)
+	}
+}
+
+func TestMetrics_Ramp(t *testing.T) {
+	c := NewPIDController(4, 2, 0).SetSetpointRamp(2)
+	m := NewMetrics().Attach(c)
+	runFirstOrder(c, 300, map[int]float64{10: 10})
+	r := m.Report()
+	if len(r.Steps) != 1 || r.Steps[0].To != 10 {
+		t.Fatalf("ramp not counted as one step: %+v", r.Steps)
+	}
+	if st := r.Steps[0].SettlingTime; !(st >= 5*time.Second) {
+		t.Errorf("settling time %v shorter than the ramp", st)
+	}
+}
+
+func TestMetrics_Unsettled(t *testing.T) {
+	m := NewMetrics()
+	m.Observe(UpdateInfo{Setpoint: 0, Value: 0, Dt: time.Second})
+	m.Observe(UpdateInfo{Setpoint: 10, Value: 0, Dt: time.Second})
+	m.Observe(UpdateInfo{Setpoint: 10, Value: 12, Dt: time.Second})
+	s := m.Report().Steps[0]
+	if s.SettlingTime >= 0 || math.Abs(s.Overshoot-20) > 1e-9 {
+		t.Errorf("unexpected step %+v", s)
+	}
+}
+
+func TestMetrics_ZeroSpan(t *testing.T) {
+	m := NewMetrics()
+	m.Observe(UpdateInfo{Setpoint: 0, Value: 0, Dt: time.Second})
+	m.Observe(UpdateInfo{Setpoint: 10, Value: 0, Dt: time.Second})
+	m.Observe(UpdateInfo{Setpoint: 10, Value: 10, Dt: time.Second})
+	// a re-issued change returning to its start
+	m.Observe(UpdateInfo{Setpoint: 12, Value: 10, Dt: time.Second})
+	m.Observe(UpdateInfo{Setpoint: 10, Value: 10, Dt: time.Second})
+	m.Observe(UpdateInfo{Setpoint: 10, Value: 10.5, Dt: time.Second})
+	m.Observe(UpdateInfo{Setpoint: 10, Value: 10, Dt: time.Second})
+	r := m.Report()
+	if len(r.Steps) != 1 {
+		t.Fatalf("zero span step counted: %+v", r.Steps)
+	}
+	s := r.Steps[0]
+	if s.To != 10 || s.Overshoot != 0 || s.SettlingTime != time.Second {
+		t.Errorf("unexpected step %+v", s)
+	}
+	for _, v := range []float64{r.IAE, r.ISE, r.ITAE} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			t.Errorf("non-finite metrics %+v", r)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package pidctrl
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// ControlMode is the operating mode of a controller, see SetMode.
+type ControlMode int
+
+// Controller modes.
+const (
+	// ModeAuto computes the output from the process value, the default.
+	ModeAuto ControlMode = iota
+	// ModeOff holds the off output, 0 limited to the output limits, and
+	// clears the integral. Updates neither compute nor change any state.
+	ModeOff
+	// ModeManual emits the output set with SetManualOutput. The terms are
+	// still computed and the integral tracks the manual output, so the
+	// return to ModeAuto is bumpless.
+	ModeManual
+	// ModeTune is ModeManual while a tuning procedure drives the output with
+	// SetManualOutput, e.g. a step or relay test.
+	ModeTune
+	// ModeFailsafe emits the output set with SetFailsafeOutput. The
+	// integral tracks it like in ModeManual.
+	ModeFailsafe
+)
+
+var controlModeNames = [...]string{"auto", "off", "manual", "tune", "failsafe"}
+
+func (m ControlMode) String() string {
+	if m < 0 || int(m) >= len(controlModeNames) {
+		return "unknown"
+	}
+	return controlModeNames[m]
+}
+
+// MarshalText implements encoding.TextMarshaler, encoding modes by name.
+func (m ControlMode) MarshalText() ([]byte, error) {
+	if m < 0 || int(m) >= len(controlModeNames) {
+		return nil, fmt.Errorf("invalid mode %d", int(m))
+	}
+	return []byte(controlModeNames[m]), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (m *ControlMode) UnmarshalText(text []byte) error {
+	for i, name := range controlModeNames {
+		if string(text) == name {
+			*m = ControlMode(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown mode %q", text)
+}
+
+// modeTransitions lists the modes each mode may change to. ModeFailsafe can
+// be entered from any mode but only be left to ModeOff or ModeManual, so an
+// operator takes over before automatic control resumes. Tuning requires a
+// running plant.
+var modeTransitions = [...][]ControlMode{
+	ModeAuto:     {ModeOff, ModeManual, ModeTune, ModeFailsafe},
+	ModeOff:      {ModeAuto, ModeManual, ModeFailsafe},
+	ModeManual:   {ModeAuto, ModeOff, ModeTune, ModeFailsafe},
+	ModeTune:     {ModeAuto, ModeOff, ModeManual, ModeFailsafe},
+	ModeFailsafe: {ModeOff, ModeManual},
+}
+
+// ModeTransitionError is returned by SetMode for transitions that are not
+// allowed.
+type ModeTransitionError struct {
+	From, To ControlMode
+}
+
+func (e ModeTransitionError) Error() string {
+	return fmt.Sprintf("mode transition from %v to %v not allowed", e.From, e.To)
+}
+
+// SetMode changes the operating mode. The transitions are:
+//
+//	auto     → off, manual, tune, failsafe
+//	off      → auto, manual, failsafe
+//	manual   → auto, off, tune, failsafe
+//	tune     → auto, off, manual, failsafe
+//	failsafe → off, manual
+//
+// Entering ModeManual or ModeTune initializes the manual output with the
+// current output, so that the transfer is bumpless in both directions.
+// Leaving ModeOff restarts the controller with a cleared integral and without
+// differentiating across the time spent off. Setting the current mode does
+// nothing. Other transitions return a ModeTransitionError.
+func (c *PIDController) SetMode(m ControlMode) error {
+	if m == c.mode {
+		return nil
+	}
+	if !c.mode.allows(m) {
+		return ModeTransitionError{From: c.mode, To: m}
+	}
+	switch m {
+	case ModeOff:
+		c.integral = 0
+		c.output = c.limit(0)
+	case ModeManual, ModeTune:
+		c.manual = c.output
+	case ModeFailsafe:
+		c.output = c.limit(c.failsafe)
+	}
+	if c.mode == ModeOff {
+		c.resumed = true
+	}
+	c.jitterLast = time.Time{}
+	c.mode = m
+	c.emit(Event{Kind: EventMode, Mode: c.occupancy, Control: m, Active: c.paused})
+	return nil
+}
+
+// Mode returns the operating mode.
+func (c *PIDController) Mode() ControlMode {
+	return c.mode
+}
+
+func (m ControlMode) allows(to ControlMode) bool {
+	if m < 0 || int(m) >= len(modeTransitions) {
+		return false
+	}
+	for _, t := range modeTransitions[m] {
+		if t == to {
+			return true
+		}
+	}
+	return false
+}
+
+// SetManualOutput sets the output of ModeManual and ModeTune, limited to the
+// output limits. It takes effect on the next update.
+func (c *PIDController) SetManualOutput(output float64) *PIDController {
+	c.manual = output
+	return c
+}
+
+// ManualOutput returns the output of ModeManual and ModeTune.
+func (c *PIDController) ManualOutput() float64 {
+	return c.manual
+}
+
+// SetFailsafeOutput sets the output of ModeFailsafe, limited to the output
+// limits. The default is 0.
+func (c *PIDController) SetFailsafeOutput(output float64) *PIDController {
+	c.failsafe = output
+	if c.mode == ModeFailsafe {
+		c.output = c.limit(output)
+	}
+	return c
+}
+
+// FailsafeOutput returns the output of ModeFailsafe.
+func (c *PIDController) FailsafeOutput() float64 {
+	return c.failsafe
+}
+
+// modeOutput returns the output overriding the computed one in the current
+// mode and true, or false in ModeAuto.
+func (c *PIDController) modeOutput() (float64, bool) {
+	switch c.mode {
+	case ModeManual, ModeTune:
+		return c.limit(c.manual), true
+	case ModeFailsafe:
+		return c.limit(c.failsafe), true
+	}
+	return 0, false
+}
+
+// limit returns v limited to the output limits.
+func (c *PIDController) limit(v float64) float64 {
+	return math.Max(c.outMin, math.Min(c.outMax, v))
+}
+
+// SetMode changes the operating mode.
+func (s *SafePIDController) SetMode(m ControlMode) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.c.SetMode(m)
+}
+
+// Mode returns the operating mode.
+func (s *SafePIDController) Mode() ControlMode {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.c.Mode()
+}
+
+// SetManualOutput sets the output of ModeManual and ModeTune.
+func (s *SafePIDController) SetManualOutput(output float64) *SafePIDController {
+	s.mu.Lock()
+	s.c.SetManualOutput(output)
+	s.mu.Unlock()
+	return s
+}
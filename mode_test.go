@@ -0,0 +1,59 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPIDController_SetMode(t *testing.T) {
+	c := NewPIDController(1, 1, 0).SetOutputLimits(0, 100).Set(50)
+	if c.Mode() != ModeAuto {
+		t.Fatalf("initial mode %v", c.Mode())
+	}
+	auto := c.UpdateDuration(40, time.Second) // 10 + 10
+	if err := c.SetMode(ModeManual); err != nil {
+		t.Fatal(err)
+	}
+	if out := c.UpdateDuration(40, time.Second); out != auto || c.ManualOutput() != auto {
+		t.Errorf("manual output %v, want the last auto output %v", out, auto)
+	}
+	c.SetManualOutput(60)
+	if out := c.UpdateDuration(40, time.Second); out != 60 {
+		t.Errorf("manual output %v, want 60", out)
+	}
+	c.SetMode(ModeAuto)
+	if out := c.UpdateDuration(40, 0); out != 60 {
+		t.Errorf("output %v after the return to auto, want 60", out)
+	}
+
+	c.SetFailsafeOutput(-5)
+	c.SetMode(ModeFailsafe)
+	if out := c.UpdateDuration(40, time.Second); out != 0 {
+		t.Errorf("failsafe output %v, want the lower limit 0", out)
+	}
+	if err := c.SetMode(ModeAuto); err != (ModeTransitionError{From: ModeFailsafe, To: ModeAuto}) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := c.SetMode(ModeOff); err != nil {
+		t.Fatal(err)
+	}
+	if out := c.UpdateDuration(0, time.Second); out != 0 || c.Integral() != 0 {
+		t.Errorf("off output %v, integral %v", out, c.Integral())
+	}
+	if err := c.SetMode(ModeTune); err == nil {
+		t.Error("tuning allowed from off")
+	}
+	c.SetMode(ModeAuto)
+	if out := c.UpdateDuration(40, time.Hour); out != 10 {
+		t.Errorf("output %v after leaving off, want a restart without integrating", out)
+	}
+}
+
+func TestControlMode_String(t *testing.T) {
+	if s := ModeFailsafe.String(); s != "failsafe" {
+		t.Errorf("String() = %q", s)
+	}
+	if s := ControlMode(42).String(); s != "unknown" {
+		t.Errorf("String() = %q", s)
+	}
+}
@@ -0,0 +1,134 @@
+package pidctrl
+
+import (
+	"math"
+	"time"
+)
+
+// MotionLimits bound a motion profile. A Jerk of 0 gives a trapezoidal
+// velocity profile, a positive Jerk an S-curve.
+type MotionLimits struct {
+	Velocity     float64
+	Acceleration float64
+	Jerk         float64
+}
+
+// MotionState is a sample of a motion profile.
+type MotionState struct {
+	Position     float64
+	Velocity     float64
+	Acceleration float64
+}
+
+// MotionProfile is a point-to-point move from rest to rest, generating the
+// position setpoints of a motion axis without an external planner. The
+// acceleration and deceleration phases are symmetric.
+type MotionProfile struct {
+	from, dir float64
+	dist      float64 // absolute distance
+	ta        float64 // duration of the acceleration phase
+	tc        float64 // duration of the cruise phase
+	tj        float64 // duration of each jerk segment
+	jerk      float64
+	amax      float64 // peak acceleration
+	vmax      float64 // peak velocity
+	elapsed   time.Duration
+}
+
+// NewMotionProfile plans a move from one position to another within the
+// given limits. Velocity and Acceleration must be positive.
+func NewMotionProfile(from, to float64, l MotionLimits) *MotionProfile {
+	if l.Velocity <= 0 || l.Acceleration <= 0 {
+		panic("pidctrl: motion velocity and acceleration limits must be positive")
+	}
+	p := &MotionProfile{from: from, dir: 1, dist: math.Abs(to - from), jerk: l.Jerk}
+	if to < from {
+		p.dir = -1
+	}
+	shape := func(v float64) (ta, tj, a float64) {
+		switch {
+		case l.Jerk <= 0:
+			return v / l.Acceleration, 0, l.Acceleration
+		case v*l.Jerk >= l.Acceleration*l.Acceleration:
+			tj = l.Acceleration / l.Jerk
+			return v/l.Acceleration + tj, tj, l.Acceleration
+		default:
+			tj = math.Sqrt(v / l.Jerk)
+			return 2 * tj, tj, l.Jerk * tj
+		}
+	}
+	// accelerating to v and back covers v*ta, find the largest v that fits
+	v := l.Velocity
+	if ta, _, _ := shape(v); v*ta > p.dist {
+		lo, hi := 0.0, v
+		for i := 0; i < 100; i++ {
+			v = (lo + hi) / 2
+			if ta, _, _ := shape(v); v*ta > p.dist {
+				hi = v
+			} else {
+				lo = v
+			}
+		}
+		v = lo
+	}
+	p.vmax = v
+	p.ta, p.tj, p.amax = shape(v)
+	if v > 0 {
+		p.tc = (p.dist - v*p.ta) / v
+	}
+	return p
+}
+
+// Duration returns the duration of the move.
+func (p *MotionProfile) Duration() time.Duration {
+	return time.Duration((2*p.ta + p.tc) * float64(time.Second))
+}
+
+// At returns the state of the move at time t after its start.
+func (p *MotionProfile) At(t time.Duration) MotionState {
+	s := t.Seconds()
+	total := 2*p.ta + p.tc
+	var pos, vel, acc float64
+	switch {
+	case s <= 0:
+	case s < p.ta:
+		pos, vel, acc = p.accel(s)
+	case s < p.ta+p.tc:
+		pos, _, _ = p.accel(p.ta)
+		pos, vel = pos+p.vmax*(s-p.ta), p.vmax
+	case s < total:
+		pos, vel, acc = p.accel(total - s)
+		pos, acc = p.dist-pos, -acc
+	default:
+		pos = p.dist
+	}
+	return MotionState{Position: p.from + p.dir*pos, Velocity: p.dir * vel, Acceleration: p.dir * acc}
+}
+
+// Advance moves the profile time forward by dt and returns the new state.
+func (p *MotionProfile) Advance(dt time.Duration) MotionState {
+	p.elapsed += dt
+	return p.At(p.elapsed)
+}
+
+// Done returns true once Advance reached the end of the move.
+func (p *MotionProfile) Done() bool {
+	return p.elapsed >= p.Duration()
+}
+
+// accel returns the state at time s into the acceleration phase.
+func (p *MotionProfile) accel(s float64) (pos, vel, acc float64) {
+	j, tj, a := p.jerk, p.tj, p.amax
+	tca := p.ta - 2*tj // constant acceleration time
+	// end of the first jerk segment
+	v1, p1 := j*tj*tj/2, j*tj*tj*tj/6
+	if s < tj {
+		return j * s * s * s / 6, j * s * s / 2, j * s
+	}
+	if u := s - tj; u < tca {
+		return p1 + v1*u + a*u*u/2, v1 + a*u, a
+	}
+	v2, p2 := v1+a*tca, p1+v1*tca+a*tca*tca/2
+	u := s - tj - tca
+	return p2 + v2*u + a*u*u/2 - j*u*u*u/6, v2 + a*u - j*u*u/2, a - j*u
+}
@@ -0,0 +1,72 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMotionProfile_Trapezoidal(t *testing.T) {
+	p := NewMotionProfile(0, 100, MotionLimits{Velocity: 10, Acceleration: 5})
+	// 2s accelerating over 10, 8s cruising over 80, 2s decelerating
+	if d := p.Duration(); d != 12*time.Second {
+		t.Errorf("duration %v != 12s", d)
+	}
+	for _, tc := range []struct {
+		t    time.Duration
+		want MotionState
+	}{
+		{time.Second, MotionState{2.5, 5, 5}},
+		{6 * time.Second, MotionState{50, 10, 0}},
+		{11 * time.Second, MotionState{97.5, 5, -5}},
+		{20 * time.Second, MotionState{100, 0, 0}},
+	} {
+		if got := p.At(tc.t); !motionEqual(got, tc.want) {
+			t.Errorf("at %v: %+v != %+v", tc.t, got, tc.want)
+		}
+	}
+	// short moves never reach the velocity limit
+	p = NewMotionProfile(10, 0, MotionLimits{Velocity: 10, Acceleration: 5})
+	if s := p.At(p.Duration() / 2); math.Abs(s.Position-5) > 1e-6 || s.Velocity >= 0 || s.Velocity < -10+1e-3 {
+		t.Errorf("triangular midpoint %+v", s)
+	}
+}
+
+func TestMotionProfile_SCurve(t *testing.T) {
+	l := MotionLimits{Velocity: 10, Acceleration: 5, Jerk: 10}
+	for _, dist := range []float64{0.1, 5, 30, 200} {
+		p := NewMotionProfile(0, dist, l)
+		prev := p.At(0)
+		dt := time.Millisecond
+		for ts := dt; ts <= p.Duration()+dt; ts += dt {
+			s := p.At(ts)
+			if s.Velocity > l.Velocity+1e-9 || math.Abs(s.Acceleration) > l.Acceleration+1e-9 {
+				t.Fatalf("dist %v at %v: limits exceeded %+v", dist, ts, s)
+			}
+			if jerk := math.Abs(s.Acceleration-prev.Acceleration) / dt.Seconds(); jerk > l.Jerk*1.01 && s.Acceleration != 0 {
+				t.Fatalf("dist %v at %v: jerk %v", dist, ts, jerk)
+			}
+			if s.Position < prev.Position-1e-12 {
+				t.Fatalf("dist %v at %v: moving backwards", dist, ts)
+			}
+			prev = s
+		}
+		if end := p.At(p.Duration()); math.Abs(end.Position-dist) > 1e-6 || math.Abs(end.Velocity) > 1e-6 {
+			t.Errorf("dist %v: end state %+v", dist, end)
+		}
+	}
+}
+
+func TestMotionProfile_Advance(t *testing.T) {
+	p := NewMotionProfile(0, 1, MotionLimits{Velocity: 1, Acceleration: 1})
+	for !p.Done() {
+		p.Advance(100 * time.Millisecond)
+	}
+	if s := p.Advance(0); s.Position != 1 {
+		t.Errorf("final position %v", s.Position)
+	}
+}
+
+func motionEqual(a, b MotionState) bool {
+	return math.Abs(a.Position-b.Position) < 1e-9 && math.Abs(a.Velocity-b.Velocity) < 1e-9 && math.Abs(a.Acceleration-b.Acceleration) < 1e-9
+}
@@ -0,0 +1,50 @@
+package pidctrl
+
+import (
+	"math"
+	"time"
+)
+
+// MotorLoop is a motor velocity (or current) loop in the structure common to
+// motion control: a PID on the velocity error plus feed-forward of the
+// voltage needed for the target velocity (Kv), acceleration (Ka) and static
+// friction (Ks), with compensation for the supply voltage. The PID and the
+// feed-forward work in volts; the output is the PWM duty cycle in [-1, 1].
+type MotorLoop struct {
+	PID *PIDController
+	Kv  float64 // volts per unit of velocity
+	Ka  float64 // volts per unit of acceleration
+	Ks  float64 // volts to overcome static friction
+
+	// NominalVoltage is used as bus voltage when Update is passed a bus
+	// voltage of 0, e.g. for supplies without voltage measurement.
+	NominalVoltage float64
+}
+
+// NewMotorLoop returns a MotorLoop using the given velocity PID and
+// feed-forward constants. The loop owns the PID and overrides its output
+// limits and feed-forward value on every update.
+func NewMotorLoop(pid *PIDController, kv, ka, ks float64) *MotorLoop {
+	return &MotorLoop{PID: pid, Kv: kv, Ka: ka, Ks: ks}
+}
+
+// Update computes the duty cycle for a target velocity and acceleration, the
+// measured velocity and the current bus voltage. The PID output is limited
+// to the available bus voltage, so integral windup adapts to a sagging
+// supply, and the resulting voltage is divided by the bus voltage, which
+// keeps the loop gain constant as the supply varies.
+func (m *MotorLoop) Update(velocity, acceleration, measured, bus float64, duration time.Duration) float64 {
+	if bus == 0 {
+		bus = m.NominalVoltage
+	}
+	bus = math.Abs(bus)
+	if bus == 0 {
+		return 0
+	}
+	ff := m.Kv*velocity + m.Ka*acceleration
+	if velocity != 0 {
+		ff += math.Copysign(m.Ks, velocity)
+	}
+	volts := m.PID.Set(velocity).SetOutputLimits(-bus, bus).SetFeedForward(ff).UpdateDuration(measured, duration)
+	return volts / bus
+}
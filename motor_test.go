@@ -0,0 +1,27 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestMotorLoop(t *testing.T) {
+	m := NewMotorLoop(NewPIDController(0.1, 0, 0), 0.02, 0.001, 0.5)
+	// feed-forward alone: 0.5 + 0.02*300 + 0.001*1000 = 7.5V of 12V
+	if duty := m.Update(300, 1000, 300, 12, time.Millisecond); math.Abs(duty-7.5/12) > 1e-12 {
+		t.Errorf("duty %v != %v", duty, 7.5/12)
+	}
+	// the same voltage needs a larger duty cycle on a sagging supply
+	if duty := m.Update(300, 1000, 300, 10, time.Millisecond); math.Abs(duty-0.75) > 1e-12 {
+		t.Errorf("duty on 10V %v != 0.75", duty)
+	}
+	// and saturates at full duty
+	if duty := m.Update(300, 1000, 0, 10, time.Millisecond); duty != 1 {
+		t.Errorf("saturated duty %v != 1", duty)
+	}
+	m.NominalVoltage = 24
+	if duty := m.Update(-300, 0, -300, 0, time.Millisecond); math.Abs(duty+6.5/24) > 1e-12 {
+		t.Errorf("duty at nominal voltage %v != %v", duty, -6.5/24)
+	}
+}
@@ -0,0 +1,88 @@
+package pidctrl
+
+import (
+	"fmt"
+	"math"
+)
+
+// NonFiniteError reports a NaN or infinite process value or gain.
+type NonFiniteError struct {
+	Name  string // "value", "p", "i" or "d"
+	Value float64
+}
+
+func (e NonFiniteError) Error() string {
+	return fmt.Sprintf("%s: %v is not finite", e.Name, e.Value)
+}
+
+// NonFinitePolicy selects how a controller handles NaN and infinite process
+// values and gains.
+type NonFinitePolicy int
+
+const (
+	// NonFinitePropagate passes non-finite values on, which usually
+	// corrupts the integral until Reset. This is the default.
+	NonFinitePropagate NonFinitePolicy = iota
+	// NonFiniteHold ignores non-finite samples and holds the last output.
+	// The duration of an ignored sample is dropped.
+	NonFiniteHold
+	// NonFiniteReset resets the controller on a non-finite sample, see
+	// Reset, and outputs 0 until the next finite one.
+	NonFiniteReset
+)
+
+// SetNonFinitePolicy sets the handling of non-finite process values. With a
+// policy other than NonFinitePropagate, SetPID also rejects non-finite gains
+// and keeps the current ones. f is called with a NonFiniteError for every
+// rejected value; it may be nil.
+func (c *PIDController) SetNonFinitePolicy(p NonFinitePolicy, f func(error)) *PIDController {
+	c.nonFinite, c.onNonFinite = p, f
+	return c
+}
+
+// NonFinitePolicy returns the handling of non-finite values.
+func (c *PIDController) NonFinitePolicy() NonFinitePolicy {
+	return c.nonFinite
+}
+
+// rejectSample applies the policy to a process value and reports whether the
+// sample is to be ignored.
+func (c *PIDController) rejectSample(value float64) bool {
+	if c.nonFinite == NonFinitePropagate || !nonFinite(value) {
+		return false
+	}
+	if c.nonFinite == NonFiniteReset {
+		c.Reset()
+	}
+	c.reportNonFinite("value", value)
+	return true
+}
+
+// rejectGains applies the policy to new gains and reports whether they are
+// to be ignored.
+func (c *PIDController) rejectGains(p, i, d float64) bool {
+	if c.nonFinite == NonFinitePropagate {
+		return false
+	}
+	rejected := false
+	for _, g := range []struct {
+		name  string
+		value float64
+	}{{"p", p}, {"i", i}, {"d", d}} {
+		if nonFinite(g.value) {
+			c.reportNonFinite(g.name, g.value)
+			rejected = true
+		}
+	}
+	return rejected
+}
+
+func (c *PIDController) reportNonFinite(name string, value float64) {
+	if c.onNonFinite != nil {
+		c.onNonFinite(NonFiniteError{name, value})
+	}
+}
+
+func nonFinite(v float64) bool {
+	return math.IsNaN(v) || math.IsInf(v, 0)
+}
@@ -0,0 +1,55 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPIDController_NonFiniteHold(t *testing.T) {
+	var errs []error
+	c := NewPIDController(1, 0.5, 0).Set(10).SetNonFinitePolicy(NonFiniteHold, func(err error) { errs = append(errs, err) })
+	out := c.UpdateDuration(8, time.Second)
+	for _, v := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		if got := c.UpdateDuration(v, time.Second); got != out {
+			t.Errorf("%v: output %v not held at %v", v, got, out)
+		}
+	}
+	if c.Integral() != 1 || c.ProcessValue() != 8 {
+		t.Errorf("state corrupted: integral %v value %v", c.Integral(), c.ProcessValue())
+	}
+	if len(errs) != 3 || errs[0].(NonFiniteError).Name != "value" {
+		t.Errorf("unexpected errors %v", errs)
+	}
+	if got := c.UpdateDuration(8, time.Second); got != 4 {
+		t.Errorf("output %v != 4 after recovery", got)
+	}
+}
+
+func TestPIDController_NonFiniteReset(t *testing.T) {
+	c := NewPIDController(1, 0.5, 0).Set(10).SetNonFinitePolicy(NonFiniteReset, nil)
+	c.UpdateDuration(8, time.Second)
+	if out := c.UpdateDuration(math.NaN(), time.Second); out != 0 || c.Integral() != 0 {
+		t.Errorf("not reset: output %v integral %v", out, c.Integral())
+	}
+	if out := c.UpdateDuration(8, time.Second); out != 3 {
+		t.Errorf("output %v != 3", out)
+	}
+}
+
+func TestPIDController_NonFiniteGains(t *testing.T) {
+	var errs []error
+	c := NewPIDController(1, 0.5, 0).SetNonFinitePolicy(NonFiniteHold, func(err error) { errs = append(errs, err) })
+	c.SetPID(math.NaN(), 1, math.Inf(1))
+	if p, i, d := c.PID(); p != 1 || i != 0.5 || d != 0 {
+		t.Errorf("gains changed to %v %v %v", p, i, d)
+	}
+	if len(errs) != 2 || errs[1] != (NonFiniteError{"d", math.Inf(1)}) {
+		t.Errorf("unexpected errors %v", errs)
+	}
+
+	c = NewPIDController(1, 0, 0).SetPID(math.NaN(), 0, 0)
+	if p, _, _ := c.PID(); !math.IsNaN(p) {
+		t.Errorf("default policy rejected gain: %v", p)
+	}
+}
@@ -0,0 +1,73 @@
+package pidctrl
+
+import (
+	"math"
+	"time"
+)
+
+// NudgeOptions configure Nudge.
+type NudgeOptions struct {
+	// Min and Max bound the setpoint. Both zero leave it unbounded.
+	Min, Max float64
+	// MaxStep bounds the magnitude of a single nudge after acceleration.
+	// 0 disables the bound.
+	MaxStep float64
+	// Repeated nudges in the same direction within Window of each other are
+	// multiplied by Acceleration to the power of the number of repeats,
+	// capped at MaxMultiplier (10 if 0). An Acceleration of 0 or 1 disables
+	// acceleration.
+	Acceleration  float64
+	MaxMultiplier float64
+	Window        time.Duration
+}
+
+// SetNudgeOptions configures Nudge.
+func (c *PIDController) SetNudgeOptions(opts NudgeOptions) *PIDController {
+	c.nudge = opts
+	return c
+}
+
+// NudgeOptions returns the Nudge configuration.
+func (c *PIDController) NudgeOptions() NudgeOptions {
+	return c.nudge
+}
+
+// Nudge adjusts the setpoint by delta within the configured bounds and
+// returns the new setpoint. It is meant for rotary encoders and buttons of
+// local HMIs: holding a button down, i.e. calling Nudge repeatedly, moves
+// the setpoint faster if acceleration is configured.
+func (c *PIDController) Nudge(delta float64) float64 {
+	o := c.nudge
+	now := c.now()
+	dir := math.Copysign(1, delta)
+	if dir == c.nudgeDir && !c.nudgeLast.IsZero() && now.Sub(c.nudgeLast) <= o.Window {
+		c.nudgeRepeats++
+	} else {
+		c.nudgeRepeats = 0
+	}
+	c.nudgeDir, c.nudgeLast = dir, now
+
+	if o.Acceleration > 1 {
+		max := o.MaxMultiplier
+		if max == 0 {
+			max = 10
+		}
+		delta *= math.Min(math.Pow(o.Acceleration, float64(c.nudgeRepeats)), max)
+	}
+	if o.MaxStep > 0 && math.Abs(delta) > o.MaxStep {
+		delta = math.Copysign(o.MaxStep, delta)
+	}
+	sp := c.target + delta
+	if o.Min != 0 || o.Max != 0 {
+		sp = math.Max(o.Min, math.Min(o.Max, sp))
+	}
+	c.Set(sp)
+	return sp
+}
+
+// Nudge atomically adjusts the setpoint, see PIDController.Nudge.
+func (s *SafePIDController) Nudge(delta float64) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.c.Nudge(delta)
+}
@@ -0,0 +1,52 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNudge(t *testing.T) {
+	clock := NewManualClock(time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC))
+	c := NewPIDController(1, 0, 0).SetClock(clock).Set(20).SetNudgeOptions(NudgeOptions{
+		Min: 5, Max: 30, MaxStep: 2,
+		Acceleration: 2, Window: 500 * time.Millisecond,
+	})
+	var got []float64
+	for i := 0; i < 4; i++ {
+		got = append(got, c.Nudge(0.5))
+		clock.Advance(100 * time.Millisecond)
+	}
+	// 0.5, 1, 2, then capped at MaxStep 2
+	if want := []float64{20.5, 21.5, 23.5, 25.5}; !floatsEqual(got, want) {
+		t.Errorf("accelerated nudges %v != %v", got, want)
+	}
+	// direction changes and pauses restart acceleration
+	if sp := c.Nudge(-0.5); sp != 25 {
+		t.Errorf("reversed nudge: %v != 25", sp)
+	}
+	clock.Advance(time.Second)
+	if sp := c.Nudge(-0.5); sp != 24.5 {
+		t.Errorf("nudge after pause: %v != 24.5", sp)
+	}
+	for i := 0; i < 20; i++ {
+		c.Nudge(1)
+	}
+	if sp := c.Get(); sp != 30 {
+		t.Errorf("setpoint %v exceeds bound 30", sp)
+	}
+	if c.WorkingSetpoint() != 30 {
+		t.Error("working setpoint not updated")
+	}
+}
+
+func floatsEqual(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
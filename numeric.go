@@ -0,0 +1,302 @@
+package pidctrl
+
+import (
+	"math"
+	"math/big"
+	"time"
+)
+
+// Arithmetic is the numeric backend of a NumericPIDController. It provides
+// the few operations the control law needs on values of type T. Operations
+// must not modify their arguments, as the controller keeps them as state.
+// Operations without a defined result either return NaN or panic with
+// big.ErrNaN.
+type Arithmetic[T any] interface {
+	Copy(v T) T                // returns a value not sharing state with v
+	FromFloat(v float64) T     // converts a float64, e.g. a gain
+	Float(v T) float64         // converts back, e.g. for error messages
+	Seconds(d time.Duration) T // converts a duration to seconds
+	Add(a, b T) T              // returns a+b
+	Sub(a, b T) T              // returns a-b
+	Mul(a, b T) T              // returns a*b
+	Quo(a, b T) T              // returns a/b, b is never zero
+	Cmp(a, b T) int            // returns -1, 0 or +1 like big.Float.Cmp
+	Zero() T                   // returns 0
+}
+
+// Float64Arithmetic is the float64 Arithmetic. It mainly serves as a
+// reference for other backends; PIDController is the faster choice for
+// float64 processes.
+type Float64Arithmetic struct{}
+
+func (Float64Arithmetic) Copy(v float64) float64          { return v }
+func (Float64Arithmetic) FromFloat(v float64) float64     { return v }
+func (Float64Arithmetic) Float(v float64) float64         { return v }
+func (Float64Arithmetic) Seconds(d time.Duration) float64 { return d.Seconds() }
+func (Float64Arithmetic) Add(a, b float64) float64        { return a + b }
+func (Float64Arithmetic) Sub(a, b float64) float64        { return a - b }
+func (Float64Arithmetic) Mul(a, b float64) float64        { return a * b }
+func (Float64Arithmetic) Quo(a, b float64) float64        { return a / b }
+func (Float64Arithmetic) Zero() float64                   { return 0 }
+func (Float64Arithmetic) Cmp(a, b float64) int {
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
+
+// BigFloatArithmetic is an Arithmetic on *big.Float values with Prec bits of
+// mantissa, or DefaultBigFloatPrec if Prec is 0. Its exponent range exceeds
+// float64 by far, so processes spanning many orders of magnitude neither
+// overflow nor lose small contributions to the integral. Every operation
+// allocates a new value. NaN is not representable; undefined operations like
+// Inf-Inf panic with big.ErrNaN.
+type BigFloatArithmetic struct {
+	Prec uint
+}
+
+// DefaultBigFloatPrec is the mantissa precision of a BigFloatArithmetic
+// without Prec.
+const DefaultBigFloatPrec uint = 256
+
+func (a BigFloatArithmetic) new() *big.Float {
+	if a.Prec == 0 {
+		return new(big.Float).SetPrec(DefaultBigFloatPrec)
+	}
+	return new(big.Float).SetPrec(a.Prec)
+}
+
+func (a BigFloatArithmetic) Copy(v *big.Float) *big.Float   { return a.new().Set(v) }
+func (a BigFloatArithmetic) FromFloat(v float64) *big.Float { return a.new().SetFloat64(v) }
+func (a BigFloatArithmetic) Float(v *big.Float) float64     { f, _ := v.Float64(); return f }
+func (a BigFloatArithmetic) Add(x, y *big.Float) *big.Float { return a.new().Add(x, y) }
+func (a BigFloatArithmetic) Sub(x, y *big.Float) *big.Float { return a.new().Sub(x, y) }
+func (a BigFloatArithmetic) Mul(x, y *big.Float) *big.Float { return a.new().Mul(x, y) }
+func (a BigFloatArithmetic) Quo(x, y *big.Float) *big.Float { return a.new().Quo(x, y) }
+func (a BigFloatArithmetic) Cmp(x, y *big.Float) int        { return x.Cmp(y) }
+func (a BigFloatArithmetic) Zero() *big.Float               { return a.new() }
+
+// Seconds converts d exactly, without the rounding of time.Duration.Seconds.
+func (a BigFloatArithmetic) Seconds(d time.Duration) *big.Float {
+	return a.Quo(a.new().SetInt64(int64(d)), a.new().SetInt64(int64(time.Second)))
+}
+
+// NewNumericPIDController returns a new NumericPIDController computing with
+// the given backend and gain values.
+func NewNumericPIDController[T any](a Arithmetic[T], p, i, d T) *NumericPIDController[T] {
+	zero := a.Zero()
+	return &NumericPIDController[T]{a: a, p: a.Copy(p), i: a.Copy(i), d: a.Copy(d),
+		setpoint: zero, prevValue: zero, integral: zero, prevErr: zero, output: zero}
+}
+
+// NumericPIDController implements a PID controller on top of an Arithmetic
+// backend, e.g. BigFloatArithmetic for scientific instrumentation needing
+// a precision or range beyond float64. Values passed in and returned are
+// copies, so callers may reuse them.
+//
+// It is a separate controller implementing only the plain control law of
+// IntegerPIDController: proportional, integral clamped to the output limits,
+// the derivative of the selected DerivativeSource and a NonFinitePolicy. The
+// output is unbounded until SetOutputLimits is called. None of the other
+// features of PIDController are available, e.g. setpoint ramps and weights,
+// derivative filters, anti-windup modes, operating modes, gain schedules,
+// observers and events. PIDController and IntegerPIDController do not go
+// through a backend and remain the fast paths.
+type NumericPIDController[T any] struct {
+	a          Arithmetic[T]    // numeric backend
+	p          T                // proportional gain
+	i          T                // integral gain
+	d          T                // derrivate gain
+	setpoint   T                // current setpoint
+	prevValue  T                // last process value
+	prevErr    T                // error of the last update
+	integral   T                // integral sum
+	lastUpdate time.Time        // time of last update
+	outMin     T                // Output Min
+	outMax     T                // Output Max
+	limited    bool             // outMin and outMax are set
+	output     T                // output of the last update
+	clock      Clock            // time source for Update, nil means the real clock
+	dSource    DerivativeSource // signal of the derivative term
+
+	nonFinite   NonFinitePolicy // handling of undefined results
+	onNonFinite func(error)     // called for rejected updates
+}
+
+// Arithmetic returns the backend of the controller.
+func (c *NumericPIDController[T]) Arithmetic() Arithmetic[T] {
+	return c.a
+}
+
+// Set changes the setpoint of the controller.
+func (c *NumericPIDController[T]) Set(setpoint T) *NumericPIDController[T] {
+	c.setpoint = c.a.Copy(setpoint)
+	return c
+}
+
+// Get returns the setpoint of the controller.
+func (c *NumericPIDController[T]) Get() T {
+	return c.a.Copy(c.setpoint)
+}
+
+// SetPID changes the P, I, and D constants
+func (c *NumericPIDController[T]) SetPID(p, i, d T) *NumericPIDController[T] {
+	c.p = c.a.Copy(p)
+	c.i = c.a.Copy(i)
+	c.d = c.a.Copy(d)
+	return c
+}
+
+// PID returns the P, I, and D constants
+func (c *NumericPIDController[T]) PID() (p, i, d T) {
+	return c.a.Copy(c.p), c.a.Copy(c.i), c.a.Copy(c.d)
+}
+
+// SetOutputLimits sets the min and max output values
+func (c *NumericPIDController[T]) SetOutputLimits(min, max T) *NumericPIDController[T] {
+	if c.a.Cmp(min, max) > 0 {
+		panic(MinMaxError{c.a.Float(min), c.a.Float(max)})
+	}
+	c.outMin = c.a.Copy(min)
+	c.outMax = c.a.Copy(max)
+	c.limited = true
+	c.integral = c.clamp(c.integral)
+	return c
+}
+
+// SetOutputLimitsE is like SetOutputLimits, but returns a MinMaxError instead
+// of panicking when min is greater than max.
+func (c *NumericPIDController[T]) SetOutputLimitsE(min, max T) error {
+	if c.a.Cmp(min, max) > 0 {
+		return MinMaxError{c.a.Float(min), c.a.Float(max)}
+	}
+	c.SetOutputLimits(min, max)
+	return nil
+}
+
+// ClearOutputLimits removes the output limits.
+func (c *NumericPIDController[T]) ClearOutputLimits() *NumericPIDController[T] {
+	c.limited = false
+	return c
+}
+
+// OutputLimits returns the min and max output values. ok is false if the
+// output is unbounded.
+func (c *NumericPIDController[T]) OutputLimits() (min, max T, ok bool) {
+	if !c.limited {
+		return min, max, false
+	}
+	return c.a.Copy(c.outMin), c.a.Copy(c.outMax), true
+}
+
+// SetDerivativeSource selects the signal the derivative term acts on. The
+// default is DerivativeOnMeasurement.
+func (c *NumericPIDController[T]) SetDerivativeSource(s DerivativeSource) *NumericPIDController[T] {
+	c.dSource = s
+	return c
+}
+
+// SetClock replaces the time source used by Update. Pass nil to use the real
+// clock.
+func (c *NumericPIDController[T]) SetClock(clock Clock) *NumericPIDController[T] {
+	c.clock = clock
+	return c
+}
+
+// SetNonFinitePolicy sets the handling of updates whose result is NaN,
+// e.g. from infinite process values, see PIDController.SetNonFinitePolicy.
+// f is called with a NonFiniteError of the process value for every rejected
+// update; it may be nil. Backends that cannot represent NaN and panic with
+// big.ErrNaN instead, like BigFloatArithmetic, treat NonFinitePropagate as
+// NonFiniteHold.
+func (c *NumericPIDController[T]) SetNonFinitePolicy(p NonFinitePolicy, f func(error)) *NumericPIDController[T] {
+	c.nonFinite, c.onNonFinite = p, f
+	return c
+}
+
+// Integral returns the integral sum.
+func (c *NumericPIDController[T]) Integral() T {
+	return c.a.Copy(c.integral)
+}
+
+// Reset clears the integral and derivative history.
+func (c *NumericPIDController[T]) Reset() *NumericPIDController[T] {
+	zero := c.a.Zero()
+	c.integral, c.prevValue, c.prevErr = zero, zero, zero
+	c.lastUpdate = time.Time{}
+	return c
+}
+
+// Update is identical to UpdateDuration, but automatically keeps track of the
+// durations between updates.
+func (c *NumericPIDController[T]) Update(value T) T {
+	var duration time.Duration
+	now := time.Now()
+	if c.clock != nil {
+		now = c.clock.Now()
+	}
+	if !c.lastUpdate.IsZero() {
+		duration = now.Sub(c.lastUpdate)
+	}
+	c.lastUpdate = now
+	return c.UpdateDuration(value, duration)
+}
+
+// UpdateDuration updates the controller with the given value and duration since
+// the last update. It returns the new output.
+func (c *NumericPIDController[T]) UpdateDuration(value T, duration time.Duration) T {
+	value = c.a.Copy(value)
+	integral, err, output, ok := c.compute(value, duration)
+	if !ok || c.nonFinite != NonFinitePropagate && math.IsNaN(c.a.Float(output)) {
+		if c.onNonFinite != nil {
+			c.onNonFinite(NonFiniteError{"value", c.a.Float(value)})
+		}
+		if c.nonFinite == NonFiniteReset {
+			c.Reset()
+			c.output = c.a.Zero()
+		}
+		return c.a.Copy(c.output)
+	}
+	c.integral, c.prevValue, c.prevErr, c.output = integral, value, err, output
+	return c.a.Copy(output)
+}
+
+// compute returns the integral, error and output of an update without
+// changing the state. ok is false if the backend panicked with big.ErrNaN.
+func (c *NumericPIDController[T]) compute(value T, duration time.Duration) (integral, err, output T, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, nan := r.(big.ErrNaN); !nan {
+				panic(r)
+			}
+		}
+	}()
+	var (
+		a  = c.a
+		dt = a.Seconds(duration)
+		d  = a.Zero()
+	)
+	err = a.Sub(c.setpoint, value)
+	integral = c.clamp(a.Add(c.integral, a.Mul(a.Mul(err, c.i), dt)))
+	if duration > 0 && c.dSource == DerivativeOnError {
+		d = a.Quo(a.Mul(a.Sub(err, c.prevErr), c.d), dt)
+	} else if duration > 0 {
+		d = a.Quo(a.Mul(a.Sub(c.prevValue, value), c.d), dt)
+	}
+	return integral, err, c.clamp(a.Add(a.Add(a.Mul(c.p, err), integral), d)), true
+}
+
+// clamp limits v to the output limits.
+func (c *NumericPIDController[T]) clamp(v T) T {
+	if !c.limited {
+		return v
+	}
+	if c.a.Cmp(v, c.outMax) > 0 {
+		return c.outMax
+	} else if c.a.Cmp(v, c.outMin) < 0 {
+		return c.outMin
+	}
+	return v
+}
@@ -0,0 +1,160 @@
+package pidctrl
+
+import (
+	"math"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestNumericPIDController(t *testing.T) {
+	// the float64 backend follows the plain PIDController
+	for _, dSource := range []DerivativeSource{DerivativeOnMeasurement, DerivativeOnError} {
+		ref := NewPIDController(0.6, 1.2, 0.075).SetOutputLimits(-10, 10).SetDerivativeSource(dSource).Set(7)
+		c := NewNumericPIDController[float64](Float64Arithmetic{}, 0.6, 1.2, 0.075).SetOutputLimits(-10, 10).SetDerivativeSource(dSource).Set(7)
+		for i, v := range []float64{0, 1, 3, 6, 8, 9, 7.5, 7, 6.8, 7.1} {
+			want := ref.UpdateDuration(v, 100*time.Millisecond)
+			if got := c.UpdateDuration(v, 100*time.Millisecond); math.Abs(got-want) > 1e-12 {
+				t.Errorf("%v update %d: %v != %v", dSource, i, got, want)
+			}
+		}
+	}
+}
+
+func TestNumericPIDController_bigFloat(t *testing.T) {
+	a := BigFloatArithmetic{}
+	f := NewNumericPIDController[float64](Float64Arithmetic{}, 0.5, 0.5, 0.5).Set(10)
+	b := NewNumericPIDController(a, a.FromFloat(0.5), a.FromFloat(0.5), a.FromFloat(0.5)).Set(a.FromFloat(10))
+	for i, v := range []float64{5, 10, 15, 100, 0} {
+		want := f.UpdateDuration(v, time.Second)
+		if got := a.Float(b.UpdateDuration(a.FromFloat(v), time.Second)); math.Abs(got-want) > 1e-12 {
+			t.Errorf("update %d: %v != %v", i, got, want)
+		}
+	}
+
+	// the arguments are kept as state and must not be modified
+	sp := a.FromFloat(3)
+	b.Set(sp).UpdateDuration(a.FromFloat(1), time.Second)
+	if sp.Cmp(a.FromFloat(3)) != 0 {
+		t.Errorf("setpoint modified: %v", sp)
+	}
+}
+
+func TestNumericPIDController_range(t *testing.T) {
+	// a gain and error product beyond float64
+	a := BigFloatArithmetic{Prec: 128}
+	huge := new(big.Float).SetMantExp(big.NewFloat(1), 2000)
+	c := NewNumericPIDController(a, huge, a.Zero(), a.Zero()).Set(huge)
+	out := c.UpdateDuration(a.Zero(), time.Second)
+	if want := new(big.Float).SetMantExp(big.NewFloat(1), 4000); out.Cmp(want) != 0 {
+		t.Errorf("output %v != %v", out, want)
+	}
+	if f := a.Float(out); !math.IsInf(f, 1) {
+		t.Errorf("float conversion %v", f)
+	}
+
+	// small contributions are not lost to a large integral
+	c = NewNumericPIDController(a, a.Zero(), a.FromFloat(1), a.Zero())
+	c.Set(a.FromFloat(1e20))
+	c.UpdateDuration(a.Zero(), time.Second)
+	c.Set(a.FromFloat(1e-10))
+	for i := 0; i < 1000; i++ {
+		c.UpdateDuration(a.Zero(), time.Second)
+	}
+	got := a.Sub(c.Integral(), a.FromFloat(1e20))
+	if f := a.Float(got); math.Abs(f-1e-7) > 1e-15 {
+		t.Errorf("small contributions: %v", f)
+	}
+}
+
+func TestNumericPIDController_limits(t *testing.T) {
+	a := BigFloatArithmetic{}
+	c := NewNumericPIDController(a, a.Zero(), a.FromFloat(1), a.Zero()).Set(a.FromFloat(10))
+	if _, _, ok := c.OutputLimits(); ok {
+		t.Error("limited by default")
+	}
+	c.SetOutputLimits(a.FromFloat(-5), a.FromFloat(5))
+	for i := 0; i < 3; i++ {
+		c.UpdateDuration(a.Zero(), time.Second)
+	}
+	if c.Integral().Cmp(a.FromFloat(5)) != 0 {
+		t.Errorf("integral %v not clamped", c.Integral())
+	}
+	if out := c.ClearOutputLimits().UpdateDuration(a.Zero(), time.Second); out.Cmp(a.FromFloat(15)) != 0 {
+		t.Errorf("unbounded output %v != 15", out)
+	}
+	if err := c.SetOutputLimitsE(a.FromFloat(1), a.FromFloat(0)); err == nil {
+		t.Error("no error for swapped limits")
+	}
+	if c.Reset().Integral().Sign() != 0 {
+		t.Error("integral not reset")
+	}
+}
+
+func TestNumericPIDController_copies(t *testing.T) {
+	a := BigFloatArithmetic{}
+	sp, p, min, max := a.FromFloat(10), a.FromFloat(1), a.FromFloat(-5), a.FromFloat(5)
+	c := NewNumericPIDController(a, p, a.Zero(), a.Zero()).Set(sp).SetOutputLimits(min, max)
+	// changing the caller's values does not affect the controller
+	sp.SetInt64(100)
+	p.SetInt64(100)
+	min.SetInt64(-100)
+	max.SetInt64(100)
+	if out := c.UpdateDuration(a.FromFloat(7), time.Second); out.Cmp(a.FromFloat(3)) != 0 {
+		t.Errorf("output %v != 3", out)
+	}
+	// neither does changing the returned values
+	c.Get().SetInt64(0)
+	gp, _, _ := c.PID()
+	gp.SetInt64(0)
+	gmin, gmax, _ := c.OutputLimits()
+	gmin.SetInt64(0)
+	gmax.SetInt64(0)
+	if out := c.UpdateDuration(a.FromFloat(0), time.Second); out.Cmp(a.FromFloat(5)) != 0 {
+		t.Errorf("output %v != 5", out)
+	}
+}
+
+func TestNumericPIDController_nonFinite(t *testing.T) {
+	a := BigFloatArithmetic{}
+	c := NewNumericPIDController(a, a.FromFloat(1), a.FromFloat(1), a.Zero()).Set(a.FromFloat(10))
+	var errs []error
+	c.SetNonFinitePolicy(NonFinitePropagate, func(err error) { errs = append(errs, err) })
+	want := c.UpdateDuration(a.FromFloat(8), time.Second)
+
+	// Inf-Inf panics with big.ErrNaN, the update is held
+	inf := new(big.Float).SetInf(false)
+	c.Set(inf)
+	if out := c.UpdateDuration(inf, time.Second); out.Cmp(want) != 0 {
+		t.Errorf("held output %v != %v", out, want)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("errors %v", errs)
+	}
+	if err, ok := errs[0].(NonFiniteError); !ok || err.Name != "value" || !math.IsInf(err.Value, 1) {
+		t.Errorf("error %#v", errs[0])
+	}
+	if c.Integral().Cmp(a.FromFloat(2)) != 0 {
+		t.Errorf("integral %v changed", c.Integral())
+	}
+
+	c.SetNonFinitePolicy(NonFiniteReset, nil)
+	if out := c.UpdateDuration(inf, time.Second); out.Sign() != 0 {
+		t.Errorf("reset output %v", out)
+	}
+	if c.Integral().Sign() != 0 {
+		t.Errorf("integral %v not reset", c.Integral())
+	}
+
+	// float64 NaN results follow the policy too
+	f := NewNumericPIDController[float64](Float64Arithmetic{}, 1, 0, 0).Set(1)
+	if out := f.UpdateDuration(math.NaN(), time.Second); !math.IsNaN(out) {
+		t.Errorf("propagated output %v", out)
+	}
+	f = NewNumericPIDController[float64](Float64Arithmetic{}, 1, 0, 0).Set(1)
+	f.SetNonFinitePolicy(NonFiniteHold, nil)
+	f.UpdateDuration(0, time.Second)
+	if out := f.UpdateDuration(math.Inf(1), time.Second); out != 1 {
+		t.Errorf("held output %v != 1", out)
+	}
+}
@@ -0,0 +1,27 @@
+package pidctrl
+
+import "time"
+
+// UpdateInfo describes a single controller update in detail.
+type UpdateInfo struct {
+	Setpoint    float64       // working setpoint
+	Value       float64       // process value, after estimation
+	Error       float64       // setpoint minus value
+	Dt          time.Duration // duration since the previous update
+	Terms       Terms         // term contributions
+	Unclamped   float64       // output before clamping
+	Output      float64       // final output
+	Clamped     bool          // output clamped to the output limits
+	Windup      bool          // integral clamped by anti-windup
+	Oscillating bool          // oscillation detected
+	Fault       bool          // persistent saturation fault raised
+	Disturbance float64       // disturbance observer estimate
+}
+
+// SetObserver installs a function that is called after every update with
+// the details of that update, e.g. for telemetry, dashboards or alarms. It is
+// called synchronously, so it should return quickly. Pass nil to remove it.
+func (c *PIDController) SetObserver(f func(UpdateInfo)) *PIDController {
+	c.observer = f
+	return c
+}
@@ -0,0 +1,28 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPIDController_SetObserver(t *testing.T) {
+	var infos []UpdateInfo
+	c := NewPIDController(1, 1, 0).SetOutputLimits(0, 5).SetObserver(func(info UpdateInfo) {
+		infos = append(infos, info)
+	}).Set(4)
+	c.UpdateDuration(1, time.Second)
+	c.UpdateDuration(3, time.Second)
+
+	want := []UpdateInfo{
+		{Setpoint: 4, Value: 1, Error: 3, Dt: time.Second, Terms: Terms{P: 3, I: 3, Clamp: ClampP}, Unclamped: 6, Output: 5, Clamped: true},
+		{Setpoint: 4, Value: 3, Error: 1, Dt: time.Second, Terms: Terms{P: 1, I: 4}, Unclamped: 5, Output: 5},
+	}
+	if len(infos) != len(want) {
+		t.Fatalf("got %d updates, expected %d", len(infos), len(want))
+	}
+	for i := range want {
+		if infos[i] != want[i] {
+			t.Errorf("update %d:\n%+v\n%+v", i, infos[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package pidctrl
+
+import "fmt"
+
+// Occupancy is a season or occupancy mode of HVAC style applications. Each
+// mode maps to an OccupancyProfile.
+type Occupancy string
+
+// Common occupancy modes. Applications may define their own.
+const (
+	OccupancyComfort Occupancy = "comfort"
+	OccupancyEco     Occupancy = "eco"
+	OccupancyAway    Occupancy = "away"
+)
+
+// OccupancyProfile describes how a controller behaves in an occupancy mode.
+type OccupancyProfile struct {
+	SetpointOffset float64 `json:"setpoint_offset"` // added to the setpoint given to Set
+	Gains          *Gains  `json:"gains,omitempty"` // gains to switch to, nil keeps the current ones
+}
+
+// UnknownOccupancyError is returned when switching to a mode without profile.
+type UnknownOccupancyError struct {
+	occupancy Occupancy
+}
+
+func (e UnknownOccupancyError) Error() string {
+	return fmt.Sprintf("unknown occupancy mode: %q", string(e.occupancy))
+}
+
+// SetOccupancyProfiles installs the table of occupancy modes. The current
+// mode is re-applied from the new table if it is contained in it.
+func (c *PIDController) SetOccupancyProfiles(profiles map[Occupancy]OccupancyProfile) *PIDController {
+	c.profiles = make(map[Occupancy]OccupancyProfile, len(profiles))
+	for o, p := range profiles {
+		c.profiles[o] = p
+	}
+	if _, ok := c.profiles[c.occupancy]; ok {
+		c.SetOccupancy(c.occupancy)
+	}
+	return c
+}
+
+// OccupancyProfiles returns a copy of the table of occupancy modes.
+func (c *PIDController) OccupancyProfiles() map[Occupancy]OccupancyProfile {
+	profiles := make(map[Occupancy]OccupancyProfile, len(c.profiles))
+	for o, p := range c.profiles {
+		profiles[o] = p
+	}
+	return profiles
+}
+
+// SetOccupancy switches to the given occupancy mode, applying the setpoint
+// offset and gains of its profile together. The setpoint offset follows the
+// setpoint ramp if one is configured. The empty mode clears the offset and
+// leaves the gains unchanged.
+func (c *PIDController) SetOccupancy(o Occupancy) error {
+	var profile OccupancyProfile
+	if o != "" {
+		var ok bool
+		if profile, ok = c.profiles[o]; !ok {
+			return UnknownOccupancyError{o}
+		}
+	}
+	if o != c.occupancy {
+		defer c.emit(Event{Kind: EventMode, Mode: o, Control: c.mode, Active: c.paused})
+	}
+	c.occupancy = o
+	c.offset = profile.SetpointOffset
+	if profile.Gains != nil {
+		c.SetGains(*profile.Gains)
+	}
+	if c.rampRate == 0 {
+		c.setpoint = c.goal()
+	}
+	return nil
+}
+
+// Occupancy returns the current occupancy mode.
+func (c *PIDController) Occupancy() Occupancy {
+	return c.occupancy
+}
+
+// goal returns the setpoint the working setpoint is heading for.
+func (c *PIDController) goal() float64 {
+	return c.target + c.offset
+}
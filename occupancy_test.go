@@ -0,0 +1,34 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOccupancy(t *testing.T) {
+	eco := Gains{P: 0.5}
+	c := NewPIDController(1, 0, 0).SetOccupancyProfiles(map[Occupancy]OccupancyProfile{
+		OccupancyComfort: {},
+		OccupancyEco:     {SetpointOffset: -2, Gains: &eco},
+	}).Set(21)
+
+	if err := c.SetOccupancy(OccupancyEco); err != nil {
+		t.Fatal(err)
+	}
+	if c.Get() != 21 || c.WorkingSetpoint() != 19 || c.Gains() != eco {
+		t.Errorf("eco not applied: setpoint %v, working %v, gains %+v", c.Get(), c.WorkingSetpoint(), c.Gains())
+	}
+	if out := c.UpdateDuration(18, time.Second); out != 0.5 {
+		t.Errorf("eco output %f != 0.5", out)
+	}
+	if err := c.SetOccupancy(OccupancyAway); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+	if c.Occupancy() != OccupancyEco {
+		t.Errorf("failed switch changed mode to %q", c.Occupancy())
+	}
+	c.SetOccupancy(OccupancyComfort)
+	if c.WorkingSetpoint() != 21 {
+		t.Errorf("comfort working setpoint %v != 21", c.WorkingSetpoint())
+	}
+}
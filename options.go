@@ -0,0 +1,268 @@
+package pidctrl
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Direction is the action of a controller.
+type Direction int
+
+const (
+	// Direct acting controllers increase the output when the value is
+	// below the setpoint, e.g. heating. This is the default.
+	Direct Direction = iota
+	// Reverse acting controllers increase the output when the value is
+	// above the setpoint, e.g. cooling.
+	Reverse
+)
+
+// SetDirection changes the action of the controller.
+func (c *PIDController) SetDirection(d Direction) *PIDController {
+	c.reverse = d == Reverse
+	return c
+}
+
+// Direction returns the action of the controller.
+func (c *PIDController) Direction() Direction {
+	if c.reverse {
+		return Reverse
+	}
+	return Direct
+}
+
+// sign returns the error sign of the controller direction.
+func (c *PIDController) sign() float64 {
+	if c.reverse {
+		return -1
+	}
+	return 1
+}
+
+// SetDeadband treats errors with a magnitude up to deadband as zero, which
+// keeps actuators from chattering around the setpoint. 0 disables it.
+func (c *PIDController) SetDeadband(deadband float64) *PIDController {
+	c.deadband = math.Abs(deadband)
+	return c
+}
+
+// Deadband returns the error deadband.
+func (c *PIDController) Deadband() float64 {
+	return c.deadband
+}
+
+// AntiWindup selects how integral windup is prevented.
+type AntiWindup int
+
+const (
+	// AntiWindupClamp clamps the integral to the integral limits, the
+	// default.
+	AntiWindupClamp AntiWindup = iota
+	// AntiWindupConditional additionally stops integrating while the output
+	// is saturated and the error would drive it further into the limit.
+	AntiWindupConditional
+)
+
+// SetAntiWindup changes the anti-windup mode.
+func (c *PIDController) SetAntiWindup(a AntiWindup) *PIDController {
+	c.antiWindup = a
+	return c
+}
+
+// AntiWindup returns the anti-windup mode.
+func (c *PIDController) AntiWindup() AntiWindup {
+	return c.antiWindup
+}
+
+// conditionalWindup reports whether integration of err is suspended by
+// conditional anti-windup.
+func (c *PIDController) conditionalWindup(err, ki float64) bool {
+	return c.antiWindup == AntiWindupConditional && c.satDir*err*ki > 0
+}
+
+// Options is the complete construction-time configuration of a controller,
+// see NewPIDControllerWithOptions. The zero value is a controller with zero
+// gains and unbounded output.
+type Options struct {
+	Gains          Gains
+	OutputLimits   *Limits // nil or nil fields are unbounded
+	IntegralLimits *Limits // nil bounds the integral by the output limits
+	AntiWindup     AntiWindup
+	Discretization Discretization
+	Direction      Direction
+	Deadband       float64
+	Setpoint       float64
+	Clock          Clock
+	Estimator      Estimator
+	DtPolicy       DtPolicy
+	SampleTime     time.Duration
+}
+
+// Option changes Options.
+type Option func(*Options)
+
+// WithOptions replaces all options with o.
+func WithOptions(o Options) Option { return func(opts *Options) { *opts = o } }
+
+// WithGains sets the P, I and D gains.
+func WithGains(p, i, d float64) Option {
+	return func(o *Options) { o.Gains = Gains{P: p, I: i, D: d} }
+}
+
+// WithOutputLimits sets the output limits.
+func WithOutputLimits(min, max float64) Option {
+	return func(o *Options) { o.OutputLimits = &Limits{Min: finiteOrNil(min), Max: finiteOrNil(max)} }
+}
+
+// WithIntegralLimits sets the integral limits.
+func WithIntegralLimits(min, max float64) Option {
+	return func(o *Options) { o.IntegralLimits = &Limits{Min: finiteOrNil(min), Max: finiteOrNil(max)} }
+}
+
+// WithAntiWindup sets the anti-windup mode.
+func WithAntiWindup(a AntiWindup) Option { return func(o *Options) { o.AntiWindup = a } }
+
+// WithDiscretization sets the discretization methods.
+func WithDiscretization(d Discretization) Option { return func(o *Options) { o.Discretization = d } }
+
+// WithDirection sets the controller action.
+func WithDirection(d Direction) Option { return func(o *Options) { o.Direction = d } }
+
+// WithDeadband sets the error deadband.
+func WithDeadband(deadband float64) Option { return func(o *Options) { o.Deadband = deadband } }
+
+// WithSetpoint sets the initial setpoint.
+func WithSetpoint(setpoint float64) Option { return func(o *Options) { o.Setpoint = setpoint } }
+
+// WithClock sets the clock used by Update.
+func WithClock(clock Clock) Option { return func(o *Options) { o.Clock = clock } }
+
+// WithEstimator sets the process value estimator.
+func WithEstimator(e Estimator) Option { return func(o *Options) { o.Estimator = e } }
+
+// WithDtPolicy sets the handling of pathological durations.
+func WithDtPolicy(p DtPolicy) Option { return func(o *Options) { o.DtPolicy = p } }
+
+// WithMaxDt handles durations longer than max with the given action.
+func WithMaxDt(max time.Duration, action DtAction) Option {
+	return func(o *Options) { o.DtPolicy.MaxDt, o.DtPolicy.Gap = max, action }
+}
+
+// WithSampleTime sets the minimum time between output computations.
+func WithSampleTime(d time.Duration) Option { return func(o *Options) { o.SampleTime = d } }
+
+// limits returns the bounds of l, unbounded if l or its fields are nil.
+func (l *Limits) limits() (min, max float64) {
+	min, max = math.Inf(-1), math.Inf(0)
+	if l != nil && l.Min != nil {
+		min = *l.Min
+	}
+	if l != nil && l.Max != nil {
+		max = *l.Max
+	}
+	return min, max
+}
+
+// Validate checks the options for invalid values in a fixed order and
+// returns the error of the first invalid one.
+func (o Options) Validate() error {
+	for _, v := range []struct {
+		name string
+		v    float64
+	}{
+		{"p", o.Gains.P},
+		{"i", o.Gains.I},
+		{"d", o.Gains.D},
+		{"deadband", o.Deadband},
+		{"setpoint", o.Setpoint},
+	} {
+		if math.IsNaN(v.v) || math.IsInf(v.v, 0) {
+			return fmt.Errorf("%s: %v is not finite", v.name, v.v)
+		}
+	}
+	for _, l := range []struct {
+		name   string
+		limits *Limits
+	}{
+		{"output limits", o.OutputLimits},
+		{"integral limits", o.IntegralLimits},
+	} {
+		min, max := l.limits.limits()
+		if math.IsNaN(min) || math.IsNaN(max) {
+			return fmt.Errorf("%s: min %v or max %v is NaN", l.name, min, max)
+		}
+		if min > max {
+			return MinMaxError{min, max}
+		}
+	}
+	if o.Deadband < 0 {
+		return fmt.Errorf("deadband: %v is negative", o.Deadband)
+	}
+	if o.DtPolicy.MaxDt < 0 {
+		return fmt.Errorf("max dt: %v is negative", o.DtPolicy.MaxDt)
+	}
+	if o.SampleTime < 0 {
+		return fmt.Errorf("sample time: %v is negative", o.SampleTime)
+	}
+	if o.Discretization.DerivativeFilter < 0 {
+		return fmt.Errorf("derivative filter: %v is negative", o.Discretization.DerivativeFilter)
+	}
+	return nil
+}
+
+// NewPIDControllerWithOptions returns a new PIDController configured by the
+// given options, applied in order. Unlike the setters it returns an error
+// for invalid configurations instead of panicking.
+func NewPIDControllerWithOptions(opts ...Option) (*PIDController, error) {
+	var o Options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	return NewPIDController(0, 0, 0).applyOptions(o), nil
+}
+
+// applyOptions configures the controller from validated options.
+func (c *PIDController) applyOptions(o Options) *PIDController {
+	c.SetGains(o.Gains).
+		SetOutputLimits(o.OutputLimits.limits()).
+		SetAntiWindup(o.AntiWindup).
+		SetDiscretization(o.Discretization).
+		SetDirection(o.Direction).
+		SetDeadband(o.Deadband).
+		SetClock(o.Clock).
+		SetEstimator(o.Estimator).
+		SetDtPolicy(o.DtPolicy).
+		SetSampleTime(o.SampleTime).
+		Set(o.Setpoint)
+	if o.IntegralLimits != nil {
+		c.SetIntegralLimits(o.IntegralLimits.limits())
+	} else {
+		c.ClearIntegralLimits()
+	}
+	return c
+}
+
+// options returns the configuration of the controller as Options.
+func (c *PIDController) options() Options {
+	o := Options{
+		Gains:          c.Gains(),
+		OutputLimits:   &Limits{Min: finiteOrNil(c.outMin), Max: finiteOrNil(c.outMax)},
+		AntiWindup:     c.antiWindup,
+		Discretization: c.disc,
+		Direction:      c.Direction(),
+		Deadband:       c.deadband,
+		Setpoint:       c.target,
+		Clock:          c.clock,
+		Estimator:      c.estimator,
+		DtPolicy:       c.dtPolicy,
+		SampleTime:     c.sampleTime,
+	}
+	if c.iLimits {
+		o.IntegralLimits = &Limits{Min: finiteOrNil(c.iMin), Max: finiteOrNil(c.iMax)}
+	}
+	return o
+}
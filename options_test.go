@@ -0,0 +1,118 @@
+package pidctrl
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewPIDControllerWithOptions(t *testing.T) {
+	c, err := NewPIDControllerWithOptions(
+		WithGains(1, 2, 3),
+		WithOutputLimits(-10, 10),
+		WithIntegralLimits(-5, 5),
+		WithSetpoint(4),
+		WithDirection(Reverse),
+		WithDeadband(0.5),
+		WithAntiWindup(AntiWindupConditional),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, i, d := c.PID(); p != 1 || i != 2 || d != 3 {
+		t.Errorf("gains %v %v %v", p, i, d)
+	}
+	if min, max := c.OutputLimits(); min != -10 || max != 10 {
+		t.Errorf("output limits %v %v", min, max)
+	}
+	if min, max := c.IntegralLimits(); min != -5 || max != 5 {
+		t.Errorf("integral limits %v %v", min, max)
+	}
+	if c.Get() != 4 || c.Direction() != Reverse || c.Deadband() != 0.5 || c.AntiWindup() != AntiWindupConditional {
+		t.Errorf("options not applied: %v %v %v %v", c.Get(), c.Direction(), c.Deadband(), c.AntiWindup())
+	}
+
+	c, err = NewPIDControllerWithOptions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if min, max := c.OutputLimits(); !math.IsInf(min, -1) || !math.IsInf(max, 1) {
+		t.Errorf("default limits %v %v", min, max)
+	}
+}
+
+func TestNewPIDControllerWithOptions_Invalid(t *testing.T) {
+	for _, opt := range []Option{
+		WithOutputLimits(1, -1),
+		WithIntegralLimits(1, -1),
+		WithGains(math.NaN(), 0, 0),
+		WithGains(0, math.Inf(1), 0),
+		WithDeadband(-1),
+		WithDiscretization(Discretization{DerivativeFilter: -1}),
+	} {
+		if c, err := NewPIDControllerWithOptions(opt); err == nil || c != nil {
+			t.Errorf("expected error, got %v %v", c, err)
+		}
+	}
+	if _, err := NewPIDControllerWithOptions(WithOutputLimits(1, -1)); err != (MinMaxError{1, -1}) {
+		t.Errorf("unexpected error %v", err)
+	}
+	for _, opt := range []Option{
+		WithOutputLimits(math.NaN(), 1),
+		WithOutputLimits(0, math.NaN()),
+		WithIntegralLimits(math.NaN(), math.NaN()),
+	} {
+		if _, err := NewPIDControllerWithOptions(opt); err == nil {
+			t.Error("expected error for NaN limits")
+		}
+	}
+
+	// several invalid options always report the same one
+	one, zero := 1.0, 0.0
+	opts := Options{Gains: Gains{P: math.NaN()}, Deadband: -1, SampleTime: -1, OutputLimits: &Limits{Min: &one, Max: &zero}}
+	want := opts.Validate()
+	for i := 0; i < 20; i++ {
+		if err := opts.Validate(); err == nil || err.Error() != want.Error() || !strings.HasPrefix(err.Error(), "p:") {
+			t.Fatalf("unstable error %v, first %v", err, want)
+		}
+	}
+}
+
+func TestDirection(t *testing.T) {
+	c := NewPIDController(1, 1, 1).Set(10)
+	r := NewPIDController(1, 1, 1).Set(10).SetDirection(Reverse)
+	for _, v := range []float64{12, 13, 11} {
+		if a, b := c.UpdateDuration(v, time.Second), r.UpdateDuration(v, time.Second); a != -b {
+			t.Errorf("value %v: reverse output %v != %v", v, b, -a)
+		}
+	}
+}
+
+func TestDeadband(t *testing.T) {
+	c := NewPIDController(1, 1, 0).Set(10).SetDeadband(0.5)
+	if out := c.UpdateDuration(10.4, time.Second); out != 0 {
+		t.Errorf("output inside deadband %v", out)
+	}
+	if out := c.UpdateDuration(9, time.Second); out != 2 {
+		t.Errorf("output outside deadband %v", out)
+	}
+}
+
+func TestAntiWindupConditional(t *testing.T) {
+	for _, test := range []struct {
+		mode     AntiWindup
+		integral float64
+	}{
+		{AntiWindupClamp, 3},
+		{AntiWindupConditional, 1}, // only the first, unsaturated update integrates
+	} {
+		c := NewPIDController(5, 1, 0).SetOutputLimits(-3, 3).Set(1).SetAntiWindup(test.mode)
+		for i := 0; i < 5; i++ {
+			c.UpdateDuration(0, time.Second)
+		}
+		if c.integral != test.integral {
+			t.Errorf("mode %v: integral %v != %v", test.mode, c.integral, test.integral)
+		}
+	}
+}
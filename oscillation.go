@@ -0,0 +1,87 @@
+package pidctrl
+
+import "time"
+
+// oscillation detects a hunting loop from sign changes of the error.
+type oscillation struct {
+	amplitude float64         // minimum error peak of a half cycle
+	window    time.Duration   // observation window, 0 disables detection
+	crossings int             // sign changes within window raising the alarm
+	now       time.Duration   // sum of update durations
+	times     []time.Duration // ring buffer of the last significant sign changes
+	first     int             // index of the oldest sign change in times
+	count     int             // number of sign changes in times
+	sign      float64         // sign of the last non-zero error
+	peak      float64         // peak absolute error since the last sign change
+	active    bool            // alarm raised on the last update
+}
+
+// SetOscillationDetection enables detection of sustained oscillation: the
+// loop is flagged as oscillating while the error changed sign at least
+// crossings times within window, counting only half cycles whose error peak
+// reached amplitude so that measurement noise around the setpoint is
+// ignored. A window of 0 disables detection.
+func (c *PIDController) SetOscillationDetection(amplitude float64, window time.Duration, crossings int) *PIDController {
+	c.osc = oscillation{amplitude: amplitude, window: window, crossings: crossings}
+	if crossings > 0 {
+		c.osc.times = make([]time.Duration, crossings)
+	}
+	return c
+}
+
+// Oscillating returns true if oscillation was detected on the last update.
+func (c *PIDController) Oscillating() bool {
+	return c.osc.active
+}
+
+// update feeds the error of an update into the detector.
+func (o *oscillation) update(err float64, duration time.Duration) {
+	if o.window == 0 {
+		return
+	}
+	o.now += duration
+	if err < 0 && -err > o.peak {
+		o.peak = -err
+	} else if err > o.peak {
+		o.peak = err
+	}
+	if err != 0 {
+		sign := 1.0
+		if err < 0 {
+			sign = -1
+		}
+		if o.sign != 0 && sign != o.sign {
+			if o.peak >= o.amplitude {
+				o.record(o.now)
+			}
+			o.peak = 0
+		}
+		o.sign = sign
+	}
+	for o.count > 0 && o.times[o.first] < o.now-o.window {
+		o.first = (o.first + 1) % len(o.times)
+		o.count--
+	}
+	o.active = o.count >= o.crossings
+}
+
+// record adds a sign change at t. The ring buffer holds as many sign changes
+// as raise the alarm; a full buffer drops the oldest.
+func (o *oscillation) record(t time.Duration) {
+	n := len(o.times)
+	if n == 0 {
+		return
+	}
+	if o.count == n {
+		o.times[o.first] = t
+		o.first = (o.first + 1) % n
+		return
+	}
+	o.times[(o.first+o.count)%n] = t
+	o.count++
+}
+
+// reset clears the detector state, keeping its configuration and buffer.
+func (o *oscillation) reset() {
+	*o = oscillation{amplitude: o.amplitude, window: o.window, crossings: o.crossings, times: o.times}
+}
@@ -0,0 +1,48 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestOscillationDetection(t *testing.T) {
+	var infos []UpdateInfo
+	c := NewPIDController(1, 0, 0).SetOscillationDetection(1, time.Minute, 4)
+	c.SetObserver(func(info UpdateInfo) { infos = append(infos, info) })
+
+	// noise around the setpoint is ignored
+	for i := 0; i < 100; i++ {
+		c.UpdateDuration(0.1*math.Sin(float64(i)), time.Second)
+	}
+	if c.Oscillating() {
+		t.Fatal("noise flagged as oscillation")
+	}
+	// a 20s period sine of amplitude 2 changes sign every 10s
+	for i := 0; i < 45; i++ {
+		c.UpdateDuration(2*math.Sin(2*math.Pi*float64(i)/20+0.1), time.Second)
+	}
+	if !c.Oscillating() || !infos[len(infos)-1].Oscillating || !c.LoopStatus().Alarm {
+		t.Error("oscillation not detected")
+	}
+	for i := 0; i < 60; i++ {
+		c.UpdateDuration(0, time.Second)
+	}
+	if c.Oscillating() {
+		t.Error("alarm not cleared after the loop settled")
+	}
+
+	// after many fast cycles, a period of 50s has too few sign changes
+	for i := 0; i < 400; i++ {
+		c.UpdateDuration(2*math.Sin(2*math.Pi*float64(i)/20+0.1), time.Second)
+	}
+	if !c.Oscillating() || c.osc.count != 4 {
+		t.Errorf("oscillating %v with %d sign changes", c.Oscillating(), c.osc.count)
+	}
+	for i := 0; i < 200; i++ {
+		c.UpdateDuration(2*math.Sin(2*math.Pi*float64(i)/50+0.1), time.Second)
+		if i > 60 && c.Oscillating() {
+			t.Fatalf("update %d: slow cycle flagged as oscillation", i)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package pidctrl
+
+import "time"
+
+// Pause freezes the controller, e.g. while the plant is shut down or in
+// manual operation: updates return the held output without integrating or
+// changing any other state, and the time of the last update is forgotten.
+func (c *PIDController) Pause() *PIDController {
+	if !c.paused {
+		c.emit(Event{Kind: EventMode, Mode: c.occupancy, Control: c.mode, Active: true})
+	}
+	c.paused = true
+	c.lastUpdate = time.Time{}
+	c.pending = 0
+	return c
+}
+
+// Resume continues a paused controller. The first update after Resume
+// neither integrates nor differentiates across the pause, whatever duration
+// is passed to it, so a long pause does not cause a spike.
+func (c *PIDController) Resume() *PIDController {
+	if c.paused {
+		c.paused = false
+		c.resumed = true
+		c.jitterLast = time.Time{}
+		c.emit(Event{Kind: EventMode, Mode: c.occupancy, Control: c.mode})
+	}
+	return c
+}
+
+// Paused returns true while the controller is paused.
+func (c *PIDController) Paused() bool {
+	return c.paused
+}
+
+// restart makes the next computation start from value without integrating or
+// differentiating across the time since the last one.
+func (c *PIDController) restart(value float64) {
+	c.prevValue, c.prevSetpoint, c.dFilt = value, c.setpoint, 0
+}
+
+// Pause freezes the controller.
+func (s *SafePIDController) Pause() *SafePIDController {
+	s.mu.Lock()
+	s.c.Pause()
+	s.mu.Unlock()
+	return s
+}
+
+// Resume continues a paused controller.
+func (s *SafePIDController) Resume() *SafePIDController {
+	s.mu.Lock()
+	s.c.Resume()
+	s.mu.Unlock()
+	return s
+}
+
+// Paused returns true while the controller is paused.
+func (s *SafePIDController) Paused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.c.Paused()
+}
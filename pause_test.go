@@ -0,0 +1,52 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPIDController_Pause(t *testing.T) {
+	clock := NewManualClock(time.Unix(0, 0))
+	c := NewPIDController(1, 0.5, 1).Set(10).SetClock(clock)
+	c.Update(8)
+	clock.Advance(time.Second)
+	out := c.Update(8)
+	integral := c.Integral()
+
+	c.Pause()
+	for i := 0; i < 3; i++ {
+		clock.Advance(time.Hour)
+		if got := c.Update(2); got != out {
+			t.Errorf("output %v not held at %v", got, out)
+		}
+		if got := c.UpdateDuration(2, time.Hour); got != out {
+			t.Errorf("output %v not held at %v", got, out)
+		}
+	}
+	if !c.Paused() || c.Integral() != integral {
+		t.Errorf("integral changed while paused: %v != %v", c.Integral(), integral)
+	}
+
+	c.Resume()
+	clock.Advance(time.Hour)
+	if got, want := c.Update(9), 1+integral; got != want {
+		t.Errorf("output %v != %v after resume", got, want)
+	}
+	clock.Advance(time.Second)
+	if got, want := c.Update(9), 1+integral+0.5; got != want {
+		t.Errorf("output %v != %v", got, want)
+	}
+}
+
+func TestPIDController_ResumeUpdateDuration(t *testing.T) {
+	c := NewPIDController(1, 0.5, 1).Set(10)
+	c.UpdateDuration(8, time.Second)
+	integral := c.Integral()
+	c.Pause().Resume()
+	if got, want := c.UpdateDuration(4, 24*time.Hour), 6+integral; got != want {
+		t.Errorf("output %v != %v after resume", got, want)
+	}
+	if c.Resume().Paused() {
+		t.Error("Resume paused the controller")
+	}
+}
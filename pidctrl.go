@@ -5,6 +5,7 @@ package pidctrl
 
 import (
 	"fmt"
+	"log/slog"
 	"math"
 	"time"
 )
@@ -19,35 +20,193 @@ func (e MinMaxError) Error() string {
 
 // NewPIDController returns a new PIDController using the given gain values.
 func NewPIDController(p, i, d float64) *PIDController {
-	return &PIDController{p: p, i: i, d: d, outMin: math.Inf(-1), outMax: math.Inf(0)}
+	return &PIDController{p: p, i: i, d: d, outMin: math.Inf(-1), outMax: math.Inf(0), rampIntegral: 1, pWeight: 1}
 }
 
 // PIDController implements a PID controller.
 type PIDController struct {
-	p          float64   // proportional gain
-	i          float64   // integral gain
-	d          float64   // derrivate gain
-	setpoint   float64   // current setpoint
-	prevValue  float64   // last process value
-	integral   float64   // integral sum
-	lastUpdate time.Time // time of last update
-	outMin     float64   // Output Min
-	outMax     float64   // Output Max
+	p          float64       // proportional gain
+	i          float64       // integral gain
+	d          float64       // derrivate gain
+	setpoint   float64       // current setpoint
+	target     float64       // requested setpoint, approached by the ramp
+	prevValue  float64       // last process value
+	integral   float64       // integral sum
+	lastUpdate time.Time     // time of last update
+	outMin     float64       // Output Min
+	outMax     float64       // Output Max
+	estimator  Estimator     // optional process value pre-processor
+	clock      Clock         // time source for Update, nil means the real clock
+	interval   time.Duration // interval of UpdateConstInterval
+	started    bool          // true after the first update
+	paused     bool          // frozen by Pause
+	resumed    bool          // Resume called since the last update
+
+	rampRate     float64 // setpoint ramp rate per second, 0 disables
+	rampIntegral float64 // fraction of integral accumulation while ramping
+
+	guardHorizon time.Duration // overshoot guard prediction horizon, 0 disables
+	guardOutput  float64       // output while the overshoot guard is active
+	guarding     bool          // overshoot guard active during last update
+
+	approach    [3]float64 // aggressive P, I and D gains far from the setpoint
+	band        float64    // error band using the normal gains, 0 disables
+	approaching bool       // aggressive gains used during last update
+
+	negGains   [3]float64 // P, I and D gains for negative errors
+	asymmetric bool       // negative gains enabled
+	negative   bool       // negative gains used during last update
+
+	outExp float64 // output linearization exponent, 0 disables
+
+	disc  Discretization // integral and derivative discretization
+	dFilt float64        // filtered derivative input
+
+	leak  time.Duration // integral leak time constant, 0 disables
+	iBand float64       // conditional integration band, 0 disables
+
+	errTransform func(float64) float64 // optional nonlinear error transform
+
+	bumpless bool       // bumpless retuning enabled
+	retuning bool       // gains changed since the last update
+	effGains [2]float64 // effective P and D gains of the last update
+
+	dtPolicy    DtPolicy        // handling of pathological durations
+	nonFinite   NonFinitePolicy // handling of NaN and infinite values
+	onNonFinite func(error)     // called for rejected non-finite values
+	sampleTime  time.Duration   // minimum time between computations, 0 disables
+	pending     time.Duration   // time since the last computation
+
+	reverse    bool       // reverse acting
+	deadband   float64    // error deadband
+	antiWindup AntiWindup // anti-windup mode
+
+	tracking   bool    // tracking signal set
+	trackValue float64 // external reset feedback
+
+	iMin, iMax float64 // integral limits
+	iLimits    bool    // integral limits set, otherwise the output limits apply
+
+	smoothing time.Duration // output smoothing time constant, 0 disables
+	smoothed  bool          // output smoothing initialized
+	smoothOut float64       // smoothed output
+
+	quantum   float64 // output quantization step, 0 disables
+	qHyst     float64 // quantization hysteresis
+	qLevel    float64 // quantized output level
+	quantized bool    // qLevel valid
+
+	outHyst   float64 // output hysteresis band, 0 disables
+	hystOut   float64 // last output emitted by the hysteresis
+	hystValid bool    // hystOut valid
+
+	pWeight      float64 // setpoint weight of the proportional term
+	dWeight      float64 // setpoint weight of the derivative term
+	prevSetpoint float64 // working setpoint of the last update
+
+	kickHold time.Duration // derivative kick hold interval, 0 disables
+	kickMode KickHold      // derivative kick hold mode
+	kickLeft time.Duration // remaining derivative kick hold
+
+	spIntegral  SetpointIntegral // integral policy on setpoint changes
+	spThreshold float64          // setpoint change triggering spIntegral
+
+	disabled [3]bool // disabled P, I and D terms
+	iResume  bool    // integral term re-enabled since the last update
+
+	mode     ControlMode // operating mode
+	manual   float64     // output of ModeManual and ModeTune
+	failsafe float64     // output of ModeFailsafe
+
+	kahan    bool    // compensated integral summation enabled
+	kahanErr float64 // rounding error carried to the next accumulation
+	kahanSum float64 // integral after the last accumulation
+
+	ambientGain float64              // ambient feed-forward gain
+	ambientRef  float64              // ambient value without feed-forward contribution
+	ambient     float64              // current ambient value
+	ff          float64              // external feed-forward value
+	bias        float64              // constant output offset
+	pvSpan      Span                 // process value span, zero without spans
+	outSpan     Span                 // output span
+	dob         *DisturbanceObserver // optional load estimation
+
+	occupancy Occupancy                      // current occupancy mode
+	profiles  map[Occupancy]OccupancyProfile // occupancy mode table
+	offset    float64                        // setpoint offset of the occupancy mode
+
+	terms     Terms   // term contributions of the last update
+	saturated bool    // output clamped on the last update
+	satDir    float64 // direction of the clamp on the last update
+	windup    bool    // integral clamped on the last update
+	prevErr   float64 // error of the last update
+	output    float64 // output of the last update
+
+	dt       time.Duration // duration used by the last update
+	computed bool          // last call to UpdateDuration computed a new output
+
+	extRate    float64 // rate passed to UpdateWithRate
+	hasExtRate bool    // extRate replaces the computed rate
+
+	batchLast    time.Duration // last time of UpdateBatch
+	batchStarted bool          // batchLast valid
+
+	jitterThreshold time.Duration // jitter compensation threshold, 0 disables
+	jitterLast      time.Time     // time of the last UpdateConstInterval
+	jitter          JitterStats   // measured jitter
+
+	osc       oscillation     // oscillation detector
+	stale     staleWatch      // stale measurement detector
+	satFault  saturationFault // persistent saturation detector
+	rateAlarm rateAlarm       // process value rate-of-change alarm
+	soft      softStart       // soft start output ceiling
+	schedule  gainSchedule    // gain schedule
+
+	nudge        NudgeOptions // Nudge configuration
+	nudgeLast    time.Time    // time of the last nudge
+	nudgeDir     float64      // direction of the last nudge
+	nudgeRepeats int          // repeated nudges in the same direction
+
+	observer func(UpdateInfo) // optional update observer
+	events   *EventBus        // optional event bus
+
+	logger  *slog.Logger            // optional event logger
+	logOpts LogOptions              // event logging options
+	logLast [numLogEvents]time.Time // time each event was last logged
 }
 
-// Set changes the setpoint of the controller.
+// Set changes the setpoint of the controller. If a setpoint ramp is
+// configured the working setpoint approaches it gradually.
 func (c *PIDController) Set(setpoint float64) *PIDController {
-	c.setpoint = setpoint
+	if c.logger != nil && setpoint != c.target {
+		c.log(logSetpoint, "setpoint changed", slog.Float64("old", c.target), slog.Float64("new", setpoint))
+	}
+	if setpoint != c.target {
+		c.emit(Event{Kind: EventSetpoint, Old: c.target, New: setpoint})
+		c.startKickHold()
+		c.applySetpointIntegral(c.target, setpoint)
+	}
+	c.target = setpoint
+	if c.rampRate == 0 {
+		c.setpoint = c.goal()
+	}
 	return c
 }
 
 // Get returns the setpoint of the controller.
 func (c *PIDController) Get() float64 {
-	return c.setpoint
+	return c.target
 }
 
 // SetPID changes the P, I, and D constants
 func (c *PIDController) SetPID(p, i, d float64) *PIDController {
+	if c.rejectGains(p, i, d) {
+		return c
+	}
+	if c.logger != nil && (p != c.p || i != c.i || d != c.d) {
+		c.log(logGains, "gains changed", slog.Float64("p", p), slog.Float64("i", i), slog.Float64("d", d))
+	}
+	c.retune()
 	c.p = p
 	c.i = i
 	c.d = d
@@ -66,57 +225,200 @@ func (c *PIDController) SetOutputLimits(min, max float64) *PIDController {
 	}
 	c.outMin = min
 	c.outMax = max
-
-	if c.integral > c.outMax {
-		c.integral = c.outMax
-	} else if c.integral < c.outMin {
-		c.integral = c.outMin
+	if c.bumpless && c.started {
+		c.rebaseLimits()
+	} else {
+		c.clampIntegral()
 	}
 	return c
 }
 
+// SetOutputLimitsE is like SetOutputLimits, but returns a MinMaxError instead
+// of panicking when min is greater than max.
+func (c *PIDController) SetOutputLimitsE(min, max float64) error {
+	if min > max {
+		return MinMaxError{min, max}
+	}
+	c.SetOutputLimits(min, max)
+	return nil
+}
+
 // OutputLimits returns the min and max output values
 func (c *PIDController) OutputLimits() (min, max float64) {
 	return c.outMin, c.outMax
 }
 
+// SetEstimator installs an Estimator that pre-processes every process value.
+// The controller then works on the estimated value and takes the derivative
+// from the estimated rate instead of finite differences. Pass nil to remove it.
+func (c *PIDController) SetEstimator(e Estimator) *PIDController {
+	c.estimator = e
+	return c
+}
+
+// Estimator returns the installed Estimator, or nil.
+func (c *PIDController) Estimator() Estimator {
+	return c.estimator
+}
+
 // Update is identical to UpdateDuration, but automatically keeps track of the
 // durations between updates.
 func (c *PIDController) Update(value float64) float64 {
-	var duration time.Duration
+	if c.paused {
+		return c.output
+	}
+	var (
+		duration time.Duration
+		now      = c.now()
+	)
 	if !c.lastUpdate.IsZero() {
-		duration = time.Since(c.lastUpdate)
+		duration = now.Sub(c.lastUpdate)
 	}
-	c.lastUpdate = time.Now()
+	c.lastUpdate = now
 	return c.UpdateDuration(value, duration)
 }
 
 // UpdateDuration updates the controller with the given value and duration since
 // the last update. It returns the new output.
 //
+// UpdateDuration neither allocates, locks nor reads the clock, so it is safe
+// for hard loop rates on small targets. Installed callbacks, estimators and
+// loggers are outside of this contract; with a throttled logger the clock is
+// read when an event is logged.
+//
 // see http://en.wikipedia.org/wiki/PID_controller#Pseudocode
 func (c *PIDController) UpdateDuration(value float64, duration time.Duration) float64 {
+	c.computed = false
+	if c.paused || c.mode == ModeOff || c.rejectSample(value) {
+		return c.output
+	}
+	ok := true
+	if c.resumed {
+		c.resumed = false
+		c.restart(value)
+		duration = 0
+	} else if duration, ok = c.checkDt(value, duration); ok {
+		duration, ok = c.sample(duration)
+	}
+	if !ok {
+		return c.output
+	}
+	c.computed, c.dt = true, duration
 	var (
-		dt  = duration.Seconds()
-		err = c.setpoint - value
-		d   float64
+		dt       = duration.Seconds()
+		rate     float64
+		wasStale = c.stale.active
+		stale    = c.stale.update(value, duration)
 	)
-	c.integral += err * dt * c.i
-	if c.integral > c.outMax {
-		c.integral = c.outMax
-	} else if c.integral < c.outMin {
-		c.integral = c.outMin
+	if c.estimator != nil {
+		value, rate = c.estimator.Estimate(value, duration)
+	} else if dt > 0 {
+		rate = (value - c.prevValue) / dt
+	}
+	if c.hasExtRate {
+		rate = c.extRate
+	}
+	wasRate := c.rateAlarm.active
+	if c.started {
+		c.rateAlarm.update(rate)
+	}
+	c.soft.update(value, duration)
+	ramping := c.advanceRamp(dt)
+	sign := c.sign()
+	err := sign * (c.setpoint - value)
+	if c.errTransform != nil {
+		err = c.errTransform(err)
+	}
+	if math.Abs(err) <= c.deadband {
+		err = 0
+	}
+	d := -rate
+	if w := c.derivativeWeight(duration); w != 0 && dt > 0 {
+		d += w * (c.setpoint - c.prevSetpoint) / dt
 	}
-	if dt > 0 {
-		d = -((value - c.prevValue) / dt)
+	c.prevSetpoint = c.setpoint
+	d = c.filterDerivative(sign*d, dt)
+	pErr := err - sign*(1-c.pWeight)*c.setpoint
+	c.applySchedule(value)
+	integral := c.integral
+	kp, ki, kd := c.gains(err, pErr, d)
+	kp, ki, kd = c.enabledGains(kp, ki, kd)
+	c.compensateRetune(integral, kp, kd, pErr, d)
+	c.resumeIntegral(kp*pErr, kd*d)
+	if c.tracking && !c.disabled[1] {
+		c.trackIntegral(kp, ki, dt)
+	} else if !c.disabled[1] {
+		if stale || c.separated(err) || c.conditionalWindup(err, ki) {
+			ki = 0
+		}
+		c.leakIntegral(dt)
+		if ramping {
+			c.accumulate(c.integrand(err) * dt * ki * c.rampIntegral)
+		} else {
+			c.accumulate(c.integrand(err) * dt * ki)
+		}
+	}
+	c.windup = c.clampIntegral()
+	if c.dob != nil {
+		c.dob.Update(value, c.output, duration)
 	}
 	c.prevValue = value
-	output := (c.p * err) + c.integral + (c.d * d)
+	c.started = true
+	c.terms = Terms{P: kp * pErr, I: c.integral, D: kd * d, FeedForward: c.feedForward()}
+	if c.disabled[1] {
+		c.terms.I = 0
+	}
+	output := c.terms.P + c.terms.I + c.terms.D + c.terms.FeedForward
+	if c.guard(value, rate, c.setpoint-value) {
+		output = c.guardOutput
+	}
 
-	if output > c.outMax {
-		output = c.outMax
+	unclamped := output
+	wasSaturated := c.saturated
+	c.saturated, c.satDir = true, 0
+	if outMax := c.soft.limit(c.outMax); output > outMax {
+		output, c.satDir = outMax, 1
+		c.terms.Clamp = c.terms.clampCause(1)
 	} else if output < c.outMin {
-		output = c.outMin
+		output, c.satDir = c.outMin, -1
+		c.terms.Clamp = c.terms.clampCause(-1)
+	} else {
+		c.saturated = false
+	}
+	output = c.hysteresis(c.quantize(c.smooth(c.linearize(output), dt)))
+	if stale {
+		output = c.stale.output(c.output)
+	}
+	if o, ok := c.modeOutput(); ok {
+		output = o
+		c.track(o)
+	}
+	c.output = output
+	wasOscillating := c.osc.active
+	c.osc.update(err, duration)
+	c.satFault.update(c.saturated, err, duration)
+	if c.logger != nil {
+		c.logUpdate(err, wasSaturated)
+	}
+	if c.events != nil {
+		c.emitUpdate(wasSaturated, wasOscillating, wasStale, wasRate)
+	}
+	c.prevErr = err
+	if c.observer != nil {
+		c.observer(UpdateInfo{
+			Setpoint:    c.setpoint,
+			Value:       value,
+			Error:       err,
+			Dt:          duration,
+			Terms:       c.terms,
+			Unclamped:   unclamped,
+			Output:      output,
+			Clamped:     c.saturated,
+			Windup:      c.windup,
+			Oscillating: c.osc.active,
+			Fault:       c.satFault.active,
+			Disturbance: c.Disturbance(),
+		})
 	}
 
 	return output
@@ -183,3 +183,28 @@ func TestUpdate_p(t *testing.T) {
 		}
 	}
 }
+
+func TestSetOutputLimitsE(t *testing.T) {
+	c := NewPIDController(1, 0, 0).SetOutputLimits(-1, 1)
+	if err := c.SetOutputLimitsE(2, 1); err != (MinMaxError{2, 1}) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if min, max := c.OutputLimits(); min != -1 || max != 1 {
+		t.Errorf("limits changed on error: %v %v", min, max)
+	}
+	if err := c.SetOutputLimitsE(0, 2); err != nil {
+		t.Fatal(err)
+	}
+	if min, max := c.OutputLimits(); min != 0 || max != 2 {
+		t.Errorf("limits not applied: %v %v", min, max)
+	}
+	if err := c.SetIntegralLimitsE(1, 0); err == nil {
+		t.Error("expected error for swapped integral limits")
+	}
+	if err := NewIntegerPIDController(1, 0, 0).SetOutputLimitsE(1, 0); err == nil {
+		t.Error("expected error for swapped integer limits")
+	}
+	if err := NewSafePIDController(1, 0, 0).SetOutputLimitsE(1, 0); err == nil {
+		t.Error("expected error for swapped safe limits")
+	}
+}
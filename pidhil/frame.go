@@ -0,0 +1,119 @@
+package pidhil
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// FrameSize is the size of an encoded frame in bytes.
+const FrameSize = 40
+
+// Version is the protocol version written into every frame.
+const Version = 1
+
+var magic = [2]byte{'P', 'H'}
+
+// Errors returned when decoding a frame.
+var (
+	ErrFrameSize = errors.New("pidhil: invalid frame size")
+	ErrMagic     = errors.New("pidhil: invalid frame magic")
+)
+
+// UnsupportedVersionError is returned when decoding a frame of another
+// protocol version.
+type UnsupportedVersionError struct {
+	Version byte
+}
+
+func (e UnsupportedVersionError) Error() string {
+	return fmt.Sprintf("pidhil: unsupported protocol version %d", e.Version)
+}
+
+// Kind is the type of a frame.
+type Kind byte
+
+const (
+	// Measurement frames are sent by the simulator or microcontroller with
+	// the process value. A setpoint other than NaN changes the setpoint of
+	// the controller; the output is ignored.
+	Measurement Kind = iota + 1
+	// Output frames are the replies of the server with the setpoint, process
+	// value and output of the controller.
+	Output
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Measurement:
+		return "measurement"
+	case Output:
+		return "output"
+	}
+	return fmt.Sprintf("Kind(%d)", byte(k))
+}
+
+// Frame is a protocol message. Encoded, all fields are big endian.
+//
+//	offset size field
+//	     0    2 magic "PH"
+//	     2    1 version
+//	     3    1 kind
+//	     4    4 sequence number
+//	     8    8 time in nanoseconds, int64
+//	    16    8 setpoint, float64
+//	    24    8 process value, float64
+//	    32    8 output, float64
+type Frame struct {
+	Kind Kind
+	// Seq numbers the measurements of a peer. Replies carry the sequence
+	// number of the measurement they answer.
+	Seq uint32
+	// Time is the time of the measurement on the clock of the peer, e.g. the
+	// simulation time. The controller runs on the differences between frames.
+	Time     time.Duration
+	Setpoint float64
+	Value    float64
+	Output   float64
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (f Frame) MarshalBinary() ([]byte, error) {
+	b := make([]byte, FrameSize)
+	f.put(b)
+	return b, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (f *Frame) UnmarshalBinary(b []byte) error {
+	if len(b) != FrameSize {
+		return ErrFrameSize
+	}
+	if b[0] != magic[0] || b[1] != magic[1] {
+		return ErrMagic
+	}
+	if b[2] != Version {
+		return UnsupportedVersionError{b[2]}
+	}
+	*f = Frame{
+		Kind:     Kind(b[3]),
+		Seq:      binary.BigEndian.Uint32(b[4:]),
+		Time:     time.Duration(binary.BigEndian.Uint64(b[8:])),
+		Setpoint: math.Float64frombits(binary.BigEndian.Uint64(b[16:])),
+		Value:    math.Float64frombits(binary.BigEndian.Uint64(b[24:])),
+		Output:   math.Float64frombits(binary.BigEndian.Uint64(b[32:])),
+	}
+	return nil
+}
+
+// put encodes f into b, which must be at least FrameSize bytes long.
+func (f Frame) put(b []byte) {
+	b[0], b[1], b[2], b[3] = magic[0], magic[1], Version, byte(f.Kind)
+	binary.BigEndian.PutUint32(b[4:], f.Seq)
+	binary.BigEndian.PutUint64(b[8:], uint64(f.Time))
+	binary.BigEndian.PutUint64(b[16:], math.Float64bits(f.Setpoint))
+	binary.BigEndian.PutUint64(b[24:], math.Float64bits(f.Value))
+	binary.BigEndian.PutUint64(b[32:], math.Float64bits(f.Output))
+}
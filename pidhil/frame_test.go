@@ -0,0 +1,59 @@
+package pidhil
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFrame(t *testing.T) {
+	f := Frame{Kind: Output, Seq: 7, Time: 1500 * time.Millisecond, Setpoint: 21.5, Value: -3, Output: math.Inf(1)}
+	b, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) != FrameSize || string(b[:2]) != "PH" || b[2] != Version || b[3] != byte(Output) || b[7] != 7 {
+		t.Errorf("unexpected header % x", b[:8])
+	}
+	var g Frame
+	if err := g.UnmarshalBinary(b); err != nil {
+		t.Fatal(err)
+	}
+	if g != f {
+		t.Errorf("%+v != %+v", g, f)
+	}
+}
+
+func TestFrame_Errors(t *testing.T) {
+	b, _ := Frame{Kind: Measurement}.MarshalBinary()
+	var f Frame
+	if err := f.UnmarshalBinary(b[:FrameSize-1]); err != ErrFrameSize {
+		t.Errorf("short frame: %v", err)
+	}
+	b[2] = 9
+	if err := f.UnmarshalBinary(b); err != (UnsupportedVersionError{9}) {
+		t.Errorf("version: %v", err)
+	}
+	b[0] = 'X'
+	if err := f.UnmarshalBinary(b); err != ErrMagic {
+		t.Errorf("magic: %v", err)
+	}
+}
+
+// FuzzFrame checks that decoding arbitrary datagrams never panics and that
+// accepted frames survive a round trip.
+func FuzzFrame(f *testing.F) {
+	b, _ := Frame{Kind: Measurement, Seq: 1, Time: time.Second, Setpoint: math.NaN(), Value: 20}.MarshalBinary()
+	f.Add(b)
+	f.Add([]byte("PH"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var fr Frame
+		if fr.UnmarshalBinary(data) != nil {
+			return
+		}
+		b, _ := fr.MarshalBinary()
+		if string(b) != string(data) {
+			t.Fatalf("round trip changed % x to % x", data, b)
+		}
+	})
+}
@@ -0,0 +1,176 @@
+// Package pidhil implements a small binary UDP protocol for hardware-in-the-
+// loop tests, exchanging setpoint, process value and output frames between a
+// controller running in Go and an external simulator or microcontroller.
+//
+// The peer sends a Measurement frame per sample and the Server answers each
+// with an Output frame carrying the same sequence number. The controller is
+// updated with the difference of the peer timestamps, so the loop runs on the
+// time base of the peer rather than on network arrival times. Duplicated and
+// reordered measurements are dropped.
+package pidhil
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+// Server answers the measurements of a peer with the output of a controller.
+// It keeps the time and sequence state of a single peer; measurements of
+// several peers must not be mixed on one server.
+type Server struct {
+	Controller *pidctrl.SafePIDController
+
+	conn    net.PacketConn
+	started bool
+	seq     uint32
+	time    time.Duration
+	dropped atomic.Uint64
+}
+
+// NewServer returns a new Server answering on conn.
+func NewServer(c *pidctrl.SafePIDController, conn net.PacketConn) *Server {
+	return &Server{Controller: c, conn: conn}
+}
+
+// Listen returns a new Server listening on the given UDP address.
+func Listen(c *pidctrl.SafePIDController, address string) (*Server, error) {
+	conn, err := net.ListenPacket("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	return NewServer(c, conn), nil
+}
+
+// Addr returns the local address of the server.
+func (s *Server) Addr() net.Addr {
+	return s.conn.LocalAddr()
+}
+
+// Dropped returns the number of frames dropped because they were malformed,
+// of the wrong kind, duplicated or out of order.
+func (s *Server) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Serve answers measurements until ctx is cancelled, in which case it returns
+// ctx.Err(), or reading from the connection fails.
+func (s *Server) Serve(ctx context.Context) error {
+	stop := context.AfterFunc(ctx, func() { s.conn.SetReadDeadline(time.Now()) })
+	defer stop()
+	var buf [FrameSize + 1]byte
+	for {
+		n, addr, err := s.conn.ReadFrom(buf[:])
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		var f Frame
+		if f.UnmarshalBinary(buf[:n]) != nil || !s.handle(&f) {
+			s.dropped.Add(1)
+			continue
+		}
+		f.put(buf[:])
+		if _, err := s.conn.WriteTo(buf[:FrameSize], addr); err != nil && ctx.Err() == nil {
+			return err
+		}
+	}
+}
+
+// Close closes the connection of the server.
+func (s *Server) Close() error {
+	return s.conn.Close()
+}
+
+// handle updates the controller with a measurement and turns f into the
+// reply. It returns false if the frame is to be dropped.
+func (s *Server) handle(f *Frame) bool {
+	if f.Kind != Measurement {
+		return false
+	}
+	var dt time.Duration
+	if s.started {
+		if int32(f.Seq-s.seq) <= 0 {
+			return false
+		}
+		if dt = f.Time - s.time; dt < 0 {
+			return false
+		}
+	}
+	s.started, s.seq, s.time = true, f.Seq, f.Time
+	s.Controller.Do(func(c *pidctrl.PIDController) {
+		if f.Setpoint == f.Setpoint {
+			c.Set(f.Setpoint)
+		}
+		f.Output = c.UpdateDuration(f.Value, dt)
+		f.Setpoint = c.Get()
+	})
+	f.Kind = Output
+	return true
+}
+
+// Client is the peer side of the protocol, for simulators written in Go and
+// for testing servers.
+type Client struct {
+	// Timeout limits how long Exchange waits for the reply. 0 means one
+	// second.
+	Timeout time.Duration
+
+	conn net.Conn
+	seq  uint32
+	buf  [FrameSize + 1]byte
+}
+
+// Dial returns a new Client sending to the server at the given UDP address.
+func Dial(address string) (*Client, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(conn), nil
+}
+
+// NewClient returns a new Client using conn.
+func NewClient(conn net.Conn) *Client {
+	return &Client{conn: conn}
+}
+
+// Exchange sends a measurement of value taken at time t and returns the
+// reply of the server. A setpoint other than NaN changes the setpoint of the
+// controller. Replies to earlier measurements are skipped; the error of a
+// missing reply is a net.Error with Timeout() true.
+func (c *Client) Exchange(t time.Duration, setpoint, value float64) (Frame, error) {
+	c.seq++
+	f := Frame{Kind: Measurement, Seq: c.seq, Time: t, Setpoint: setpoint, Value: value}
+	f.put(c.buf[:])
+	if _, err := c.conn.Write(c.buf[:FrameSize]); err != nil {
+		return Frame{}, err
+	}
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = time.Second
+	}
+	if err := c.conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return Frame{}, err
+	}
+	for {
+		n, err := c.conn.Read(c.buf[:])
+		if err != nil {
+			return Frame{}, err
+		}
+		var reply Frame
+		if reply.UnmarshalBinary(c.buf[:n]) == nil && reply.Kind == Output && reply.Seq == c.seq {
+			return reply, nil
+		}
+	}
+}
+
+// Close closes the connection of the client.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
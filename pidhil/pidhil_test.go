@@ -0,0 +1,92 @@
+package pidhil
+
+import (
+	"context"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+func TestServer(t *testing.T) {
+	c := pidctrl.NewSafePIDController(2, 1, 0).Set(10)
+	s, err := Listen(c, "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Serve(ctx) }()
+
+	client, err := Dial(s.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	ref := pidctrl.NewPIDController(2, 1, 0).Set(10)
+	for n, v := range []float64{4, 5, 6} {
+		reply, err := client.Exchange(time.Duration(n)*500*time.Millisecond, math.NaN(), v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dt := 500 * time.Millisecond
+		if n == 0 {
+			dt = 0
+		}
+		if want := ref.UpdateDuration(v, dt); reply.Output != want || reply.Setpoint != 10 || reply.Value != v {
+			t.Errorf("measurement %d: reply %+v, want output %v", n, reply, want)
+		}
+	}
+	reply, err := client.Exchange(2*time.Second, 20, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.Setpoint != 20 || c.Get() != 20 {
+		t.Errorf("setpoint not changed: %+v", reply)
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Serve returned %v", err)
+	}
+}
+
+func TestServer_Drop(t *testing.T) {
+	s := NewServer(pidctrl.NewSafePIDController(1, 0, 0), nil)
+	frames := []struct {
+		f    Frame
+		keep bool
+	}{
+		{Frame{Kind: Measurement, Seq: 5, Time: time.Second}, true},
+		{Frame{Kind: Output, Seq: 6, Time: 2 * time.Second}, false},
+		{Frame{Kind: Measurement, Seq: 5, Time: 2 * time.Second}, false},
+		{Frame{Kind: Measurement, Seq: 6, Time: 0}, false},
+		{Frame{Kind: Measurement, Seq: 7, Time: 2 * time.Second}, true},
+	}
+	for n, tc := range frames {
+		if keep := s.handle(&tc.f); keep != tc.keep {
+			t.Errorf("frame %d: keep %v != %v", n, keep, tc.keep)
+		}
+	}
+}
+
+func TestClient_Timeout(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client, err := Dial(conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	client.Timeout = 10 * time.Millisecond
+	_, err = client.Exchange(0, math.NaN(), 1)
+	if ne, ok := err.(net.Error); !ok || !ne.Timeout() {
+		t.Errorf("expected timeout, got %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+// Package pidhttp provides an http.Handler for tuning controllers at runtime.
+//
+// Routes, relative to where the handler is mounted:
+//
+//	GET  /                names of all controllers as a JSON array
+//	GET  /{name}          settings of the named controller as JSON
+//	PUT  /{name}          change settings of the named controller
+//	POST /{name}/rollback roll back the last change of a guarded controller
+//
+// Bodies are JSON encoded pidctrl.Settings. A PUT body contains the settings
+// to change, omitted fields are left alone:
+//
+//	curl -X PUT -d '{"gains":{"p":2,"i":0.1,"d":0}}' localhost:8080/pid/mash
+//	curl -X PUT -d '{"mode":"manual"}' localhost:8080/pid/mash
+//
+// Changes of controllers added with AddGuarded pass through a
+// pidctrl.ChangeGuard, which limits and audits them.
+package pidhttp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/felixge/pidctrl"
+)
+
+// Handler serves the settings of the controllers of a registry.
+type Handler struct {
+	registry *pidctrl.Registry
+
+	// Who returns the requester recorded in the audit log of guarded
+	// controllers, the remote address if nil.
+	Who func(r *http.Request) string
+
+	mu     sync.Mutex
+	guards map[string]*pidctrl.ChangeGuard
+}
+
+// NewHandler returns a new Handler with its own empty registry.
+func NewHandler() *Handler {
+	return NewRegistryHandler(pidctrl.NewRegistry())
+}
+
+// NewRegistryHandler returns a new Handler serving the controllers of r.
+func NewRegistryHandler(r *pidctrl.Registry) *Handler {
+	return &Handler{registry: r}
+}
+
+// Registry returns the registry of the handler.
+func (h *Handler) Registry() *pidctrl.Registry {
+	return h.registry
+}
+
+// Add makes a controller available under the given name, replacing any
+// controller previously registered under it.
+func (h *Handler) Add(name string, c *pidctrl.SafePIDController) *Handler {
+	h.registry.Unregister(name)
+	h.registry.Register(name, c)
+	return h
+}
+
+// AddGuarded makes the controller of g available under the given name like
+// Add, applying changes through g.
+func (h *Handler) AddGuarded(name string, g *pidctrl.ChangeGuard) *Handler {
+	h.Add(name, g.Controller)
+	h.mu.Lock()
+	if h.guards == nil {
+		h.guards = map[string]*pidctrl.ChangeGuard{}
+	}
+	h.guards[name] = g
+	h.mu.Unlock()
+	return h
+}
+
+// Remove removes the controller with the given name.
+func (h *Handler) Remove(name string) {
+	h.registry.Unregister(name)
+	h.mu.Lock()
+	delete(h.guards, name)
+	h.mu.Unlock()
+}
+
+func (h *Handler) guard(name string) *pidctrl.ChangeGuard {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.guards[name]
+}
+
+func (h *Handler) who(r *http.Request) string {
+	if h.Who != nil {
+		return h.Who(r)
+	}
+	return r.RemoteAddr
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.Trim(r.URL.Path, "/")
+	if name == "" {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, h.registry.Names())
+		return
+	}
+
+	if name, ok := strings.CutSuffix(name, "/rollback"); ok {
+		h.rollback(w, r, name)
+		return
+	}
+	c, ok := h.registry.Get(name)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, c.Settings())
+	case http.MethodPut:
+		var s pidctrl.Settings
+		if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+			http.Error(w, "invalid settings: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		var err error
+		if g := h.guard(name); g != nil {
+			err = g.Apply(h.who(r), s)
+		} else {
+			err = c.ApplySettings(s)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), errorStatus(err))
+			return
+		}
+		writeJSON(w, c.Settings())
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// rollback serves POST /{name}/rollback.
+func (h *Handler) rollback(w http.ResponseWriter, r *http.Request, name string) {
+	g := h.guard(name)
+	if g == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := g.Rollback(h.who(r)); err != nil {
+		http.Error(w, err.Error(), errorStatus(err))
+		return
+	}
+	writeJSON(w, g.Controller.Settings())
+}
+
+// errorStatus returns the HTTP status of a rejected change.
+func errorStatus(err error) int {
+	var (
+		limit pidctrl.ChangeLimitError
+		mode  pidctrl.ModeTransitionError
+	)
+	switch {
+	case errors.Is(err, pidctrl.ErrChangeTooSoon):
+		return http.StatusTooManyRequests
+	case errors.As(err, &limit):
+		return http.StatusForbidden
+	case errors.Is(err, pidctrl.ErrNoHistory), errors.As(err, &mode):
+		return http.StatusConflict
+	}
+	return http.StatusBadRequest
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
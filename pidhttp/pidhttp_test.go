@@ -0,0 +1,125 @@
+package pidhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+func serve(h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return rec
+}
+
+func TestHandler(t *testing.T) {
+	mash := pidctrl.NewSafePIDController(1, 0, 0).Set(66)
+	h := NewHandler().Add("mash", mash).Add("boil", pidctrl.NewSafePIDController(1, 0, 0))
+
+	if rec := serve(h, "GET", "/", ""); strings.TrimSpace(rec.Body.String()) != `["boil","mash"]` {
+		t.Errorf("unexpected list: %s", rec.Body.String())
+	}
+	rec := serve(h, "GET", "/mash", "")
+	if got := strings.TrimSpace(rec.Body.String()); got != `{"gains":{"p":1,"i":0,"d":0},"setpoint":66,"output_limits":{"min":null,"max":null},"occupancy":"","mode":"auto"}` {
+		t.Errorf("unexpected settings: %s", got)
+	}
+
+	rec = serve(h, "PUT", "/mash", `{"gains":{"p":2,"i":0.1,"d":0},"output_limits":{"min":0,"max":100}}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT failed: %d %s", rec.Code, rec.Body.String())
+	}
+	if p, i, _ := mash.PID(); p != 2 || i != 0.1 {
+		t.Errorf("gains not applied: %v %v", p, i)
+	}
+	if min, max := mash.OutputLimits(); min != 0 || max != 100 {
+		t.Errorf("limits not applied: %v %v", min, max)
+	}
+	if mash.Get() != 66 {
+		t.Errorf("omitted setpoint changed to %v", mash.Get())
+	}
+
+	for _, body := range []string{
+		`{"output_limits":{"min":10,"max":1},"setpoint":1}`,
+		`{"occupancy":"away","setpoint":1}`,
+		`{"setpoint":`,
+	} {
+		if rec := serve(h, "PUT", "/mash", body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected bad request, got %d", body, rec.Code)
+		}
+	}
+	if mash.Get() != 66 {
+		t.Errorf("rejected PUT changed setpoint to %v", mash.Get())
+	}
+	if rec := serve(h, "GET", "/hlt", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected not found, got %d", rec.Code)
+	}
+	if rec := serve(h, "DELETE", "/mash", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected method not allowed, got %d", rec.Code)
+	}
+}
+
+func TestHandler_Guarded(t *testing.T) {
+	c := pidctrl.NewSafePIDController(1, 0, 0).Set(66)
+	var who []string
+	g := pidctrl.NewChangeGuard(c, pidctrl.ChangeLimits{MaxSetpointDelta: 5, MinInterval: time.Hour}, func(e pidctrl.AuditEntry) {
+		who = append(who, e.Who)
+	})
+	h := NewHandler().AddGuarded("mash", g)
+	h.Who = func(r *http.Request) string { return r.Header.Get("X-User") }
+
+	if rec := serve(h, "PUT", "/mash", `{"setpoint":80}`); rec.Code != http.StatusForbidden {
+		t.Errorf("expected forbidden, got %d", rec.Code)
+	}
+	if rec := serve(h, "PUT", "/mash", `{"setpoint":68}`); rec.Code != http.StatusOK || c.Get() != 68 {
+		t.Errorf("PUT failed: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := serve(h, "PUT", "/mash", `{"setpoint":69}`); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected too many requests, got %d", rec.Code)
+	}
+	if rec := serve(h, "GET", "/mash/rollback", ""); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected method not allowed, got %d", rec.Code)
+	}
+	if rec := serve(h, "POST", "/mash/rollback", ""); rec.Code != http.StatusOK || c.Get() != 66 {
+		t.Errorf("rollback failed: %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := serve(h, "POST", "/mash/rollback", ""); rec.Code != http.StatusConflict {
+		t.Errorf("expected conflict, got %d", rec.Code)
+	}
+	if len(who) != 5 {
+		t.Errorf("%d audit entries, want 5", len(who))
+	}
+	if rec := serve(NewHandler().Add("mash", c), "POST", "/mash/rollback", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected not found for an unguarded controller, got %d", rec.Code)
+	}
+}
+
+func TestHandler_Mode(t *testing.T) {
+	c := pidctrl.NewSafePIDController(1, 0, 0).Set(66)
+	h := NewHandler().Add("mash", c)
+	rec := serve(h, "PUT", "/mash", `{"mode":"manual"}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"mode":"manual"`) {
+		t.Fatalf("PUT failed: %d %s", rec.Code, rec.Body.String())
+	}
+	var mode pidctrl.ControlMode
+	c.Do(func(c *pidctrl.PIDController) { mode = c.Mode() })
+	if mode != pidctrl.ModeManual {
+		t.Errorf("mode %v not applied", mode)
+	}
+	if rec := serve(h, "GET", "/mash", ""); !strings.Contains(rec.Body.String(), `"mode":"manual"`) {
+		t.Errorf("GET settings: %s", rec.Body.String())
+	}
+	serve(h, "PUT", "/mash", `{"mode":"failsafe"}`)
+	if rec := serve(h, "PUT", "/mash", `{"mode":"auto","setpoint":1}`); rec.Code != http.StatusConflict {
+		t.Errorf("failsafe to auto: expected conflict, got %d", rec.Code)
+	}
+	if c.Get() != 66 {
+		t.Errorf("rejected transition changed setpoint to %v", c.Get())
+	}
+	if rec := serve(h, "PUT", "/mash", `{"mode":"sleep"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown mode: expected bad request, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,251 @@
+// Package pidmodbus maps the parameters and live values of a controller onto
+// Modbus holding and input registers, so PLC and SCADA systems can read and
+// adjust loops running in Go.
+//
+// The package does not implement the Modbus protocol itself. A Server
+// provides the register access functions that Modbus server libraries expect
+// from their handlers; errors are ExceptionCodes that the library can return
+// to the master unchanged.
+//
+// Values are transferred as signed 16 bit integers, scaled by the Scale of
+// their register, e.g. a scale of 10 transfers 21.5 °C as 215. Values outside
+// of the int16 range saturate. Unbounded output limits read as the int16
+// extremes, and writing an extreme makes the limit unbounded.
+package pidmodbus
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/felixge/pidctrl"
+)
+
+// Quantity is a controller parameter or live value that can be mapped to a
+// register.
+type Quantity int
+
+// Quantities in Holding registers are writable, quantities in Input
+// registers are read-only.
+const (
+	Setpoint Quantity = iota
+	P
+	I
+	D
+	OutputMin
+	OutputMax
+	Value
+	Output
+	Saturated
+)
+
+var quantityNames = [...]string{"setpoint", "p", "i", "d", "output min", "output max", "value", "output", "saturated"}
+
+func (q Quantity) String() string {
+	if q < 0 || int(q) >= len(quantityNames) {
+		return fmt.Sprintf("Quantity(%d)", int(q))
+	}
+	return quantityNames[q]
+}
+
+// Register maps a quantity to a register.
+type Register struct {
+	Quantity Quantity
+	// Scale is multiplied with the value before it is transferred. 0 means 1.
+	Scale float64
+}
+
+// Map is a register map, keyed by register address.
+type Map struct {
+	Holding map[uint16]Register
+	Input   map[uint16]Register
+}
+
+// DefaultMap returns a map with the setpoint, P, I, D, output min and output
+// max in holding registers 0-5 and the process value, output and saturation
+// flag in input registers 0-2. All values except the saturation flag use the
+// given scale.
+func DefaultMap(scale float64) Map {
+	return Map{
+		Holding: map[uint16]Register{
+			0: {Setpoint, scale},
+			1: {P, scale},
+			2: {I, scale},
+			3: {D, scale},
+			4: {OutputMin, scale},
+			5: {OutputMax, scale},
+		},
+		Input: map[uint16]Register{
+			0: {Value, scale},
+			1: {Output, scale},
+			2: {Saturated, 1},
+		},
+	}
+}
+
+// ExceptionCode is a Modbus exception code.
+type ExceptionCode byte
+
+// Exception codes returned by a Server.
+const (
+	IllegalDataAddress ExceptionCode = 2
+	IllegalDataValue   ExceptionCode = 3
+)
+
+func (e ExceptionCode) Error() string {
+	switch e {
+	case IllegalDataAddress:
+		return "modbus: illegal data address"
+	case IllegalDataValue:
+		return "modbus: illegal data value"
+	}
+	return fmt.Sprintf("modbus: exception %d", byte(e))
+}
+
+// Server serves the registers of a controller.
+type Server struct {
+	Map        Map
+	Controller *pidctrl.SafePIDController
+}
+
+// NewServer returns a new Server.
+func NewServer(c *pidctrl.SafePIDController, m Map) *Server {
+	return &Server{Map: m, Controller: c}
+}
+
+// ReadHoldingRegisters returns quantity holding registers starting at
+// address. All addresses must be mapped.
+func (s *Server) ReadHoldingRegisters(address, quantity uint16) ([]uint16, error) {
+	return s.read(s.Map.Holding, address, quantity)
+}
+
+// ReadInputRegisters returns quantity input registers starting at address.
+// All addresses must be mapped.
+func (s *Server) ReadInputRegisters(address, quantity uint16) ([]uint16, error) {
+	return s.read(s.Map.Input, address, quantity)
+}
+
+// WriteHoldingRegisters writes values to the holding registers starting at
+// address. The write is applied atomically; nothing is changed if an address
+// is not mapped or the resulting settings are invalid.
+func (s *Server) WriteHoldingRegisters(address uint16, values []uint16) error {
+	regs, err := lookup(s.Map.Holding, address, len(values))
+	if err != nil {
+		return err
+	}
+	var applyErr error
+	s.Controller.Do(func(c *pidctrl.PIDController) {
+		settings := c.Settings()
+		for n, r := range regs {
+			v := decode(values[n], r.scale())
+			switch r.Quantity {
+			case Setpoint:
+				settings.Setpoint = &v
+			case P:
+				settings.Gains.P = v
+			case I:
+				settings.Gains.I = v
+			case D:
+				settings.Gains.D = v
+			case OutputMin:
+				settings.OutputLimits.Min = limit(values[n], r.scale())
+			case OutputMax:
+				settings.OutputLimits.Max = limit(values[n], r.scale())
+			default:
+				applyErr = IllegalDataAddress
+				return
+			}
+		}
+		if c.ApplySettings(settings) != nil {
+			applyErr = IllegalDataValue
+		}
+	})
+	return applyErr
+}
+
+func (s *Server) read(m map[uint16]Register, address, quantity uint16) ([]uint16, error) {
+	regs, err := lookup(m, address, int(quantity))
+	if err != nil {
+		return nil, err
+	}
+	values := make([]uint16, len(regs))
+	s.Controller.Do(func(c *pidctrl.PIDController) {
+		p, i, d := c.PID()
+		min, max := c.OutputLimits()
+		for n, r := range regs {
+			var v float64
+			switch r.Quantity {
+			case Setpoint:
+				v = c.Get()
+			case P:
+				v = p
+			case I:
+				v = i
+			case D:
+				v = d
+			case OutputMin:
+				v = min
+			case OutputMax:
+				v = max
+			case Value:
+				v = c.ProcessValue()
+			case Output:
+				v = c.Output()
+			case Saturated:
+				if c.Saturated() {
+					v = 1
+				}
+			}
+			values[n] = encode(v, r.scale())
+		}
+	})
+	return values, nil
+}
+
+func lookup(m map[uint16]Register, address uint16, quantity int) ([]Register, error) {
+	if quantity == 0 || int(address)+quantity > math.MaxUint16+1 {
+		return nil, IllegalDataAddress
+	}
+	regs := make([]Register, quantity)
+	for n := range regs {
+		r, ok := m[address+uint16(n)]
+		if !ok {
+			return nil, IllegalDataAddress
+		}
+		regs[n] = r
+	}
+	return regs, nil
+}
+
+func (r Register) scale() float64 {
+	if r.Scale == 0 {
+		return 1
+	}
+	return r.Scale
+}
+
+func encode(v, scale float64) uint16 {
+	v = math.Round(v * scale)
+	switch {
+	case math.IsNaN(v):
+		v = 0
+	case v > math.MaxInt16:
+		v = math.MaxInt16
+	case v < math.MinInt16:
+		v = math.MinInt16
+	}
+	return uint16(int16(v))
+}
+
+func decode(raw uint16, scale float64) float64 {
+	return float64(int16(raw)) / scale
+}
+
+// limit decodes an output limit, which is unbounded (nil) at the int16
+// extremes.
+func limit(raw uint16, scale float64) *float64 {
+	if v := int16(raw); v == math.MaxInt16 || v == math.MinInt16 {
+		return nil
+	}
+	v := decode(raw, scale)
+	return &v
+}
@@ -0,0 +1,80 @@
+package pidmodbus
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+func TestServer(t *testing.T) {
+	c := pidctrl.NewSafePIDController(2, 0, 0).SetOutputLimits(0, 100).Set(21.5)
+	c.UpdateDuration(20, time.Second)
+	s := NewServer(c, DefaultMap(10))
+
+	holding, err := s.ReadHoldingRegisters(0, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint16{215, 20, 0, 0, 0, 1000}; !equal(holding, want) {
+		t.Errorf("holding %v != %v", holding, want)
+	}
+	input, err := s.ReadInputRegisters(0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint16{200, 30, 0}; !equal(input, want) {
+		t.Errorf("input %v != %v", input, want)
+	}
+
+	neg := int16(-50)
+	if err := s.WriteHoldingRegisters(0, []uint16{230, 15}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WriteHoldingRegisters(4, []uint16{uint16(neg), math.MaxInt16}); err != nil {
+		t.Fatal(err)
+	}
+	if sp := c.Get(); sp != 23 {
+		t.Errorf("setpoint %v != 23", sp)
+	}
+	if p, _, _ := c.PID(); p != 1.5 {
+		t.Errorf("p %v != 1.5", p)
+	}
+	if min, max := c.OutputLimits(); min != -5 || !math.IsInf(max, 1) {
+		t.Errorf("limits %v %v", min, max)
+	}
+	if v, _ := s.ReadHoldingRegisters(5, 1); v[0] != math.MaxInt16 {
+		t.Errorf("unbounded max read as %d", v[0])
+	}
+}
+
+func TestServer_Errors(t *testing.T) {
+	c := pidctrl.NewSafePIDController(1, 0, 0).SetOutputLimits(0, 100)
+	s := NewServer(c, DefaultMap(1))
+	if _, err := s.ReadHoldingRegisters(5, 2); err != IllegalDataAddress {
+		t.Errorf("expected illegal address, got %v", err)
+	}
+	if err := s.WriteHoldingRegisters(0, []uint16{5, 1, 0, 0, 50, 10}); err != IllegalDataValue {
+		t.Errorf("expected illegal value, got %v", err)
+	}
+	if sp := c.Get(); sp != 0 {
+		t.Errorf("invalid write partially applied: setpoint %v", sp)
+	}
+	s.Map.Holding[6] = Register{Quantity: Output}
+	if err := s.WriteHoldingRegisters(6, []uint16{1}); err != IllegalDataAddress {
+		t.Errorf("expected illegal address for read-only quantity, got %v", err)
+	}
+}
+
+func equal(a, b []uint16) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,168 @@
+package pidmqtt
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Component is the Home Assistant entity type the setpoint is exposed as.
+type Component string
+
+// Supported setpoint components.
+const (
+	Climate Component = "climate"
+	Number  Component = "number"
+)
+
+// Discovery describes how a bridge appears in Home Assistant. The setpoint
+// becomes a climate or number entity and the process value and output become
+// sensors, grouped into one device.
+type Discovery struct {
+	// Prefix is the discovery prefix configured in Home Assistant. Empty
+	// means "homeassistant".
+	Prefix string
+	// ObjectID identifies the controller, e.g. "boiler". It must be unique
+	// and consist of ASCII letters, digits, underscores and hyphens.
+	ObjectID string
+	// Name is the device name shown in Home Assistant.
+	Name string
+	// Component is the entity type of the setpoint. Empty means Number.
+	Component Component
+	// Min, Max and Step describe the setpoint range of the entity.
+	Min, Max, Step float64
+	// Unit is the unit of the setpoint and process value, e.g. "°C".
+	Unit string
+}
+
+// DiscoveryMessage is a retained discovery config message.
+type DiscoveryMessage struct {
+	Topic   string
+	Payload []byte
+}
+
+// ErrObjectID is returned for a Discovery with an invalid ObjectID.
+var ErrObjectID = errors.New("pidmqtt: invalid discovery object id")
+
+type discoveryDevice struct {
+	Identifiers []string `json:"identifiers"`
+	Name        string   `json:"name,omitempty"`
+	Model       string   `json:"model"`
+}
+
+type discoveryConfig struct {
+	Name     string          `json:"name"`
+	UniqueID string          `json:"unique_id"`
+	Device   discoveryDevice `json:"device"`
+
+	// number and sensor
+	StateTopic   string   `json:"state_topic,omitempty"`
+	CommandTopic string   `json:"command_topic,omitempty"`
+	Unit         string   `json:"unit_of_measurement,omitempty"`
+	Min          *float64 `json:"min,omitempty"`
+	Max          *float64 `json:"max,omitempty"`
+	Step         float64  `json:"step,omitempty"`
+
+	// climate
+	Modes                   []string `json:"modes,omitempty"`
+	TemperatureCommandTopic string   `json:"temperature_command_topic,omitempty"`
+	TemperatureStateTopic   string   `json:"temperature_state_topic,omitempty"`
+	CurrentTemperatureTopic string   `json:"current_temperature_topic,omitempty"`
+	MinTemp                 *float64 `json:"min_temp,omitempty"`
+	MaxTemp                 *float64 `json:"max_temp,omitempty"`
+	TempStep                float64  `json:"temp_step,omitempty"`
+}
+
+// DiscoveryMessages returns the discovery config messages of the bridge.
+func (b *Bridge) DiscoveryMessages(d Discovery) ([]DiscoveryMessage, error) {
+	topics, err := d.topics()
+	if err != nil {
+		return nil, err
+	}
+	device := discoveryDevice{Identifiers: []string{d.ObjectID}, Name: d.Name, Model: "pidctrl"}
+	setpoint := discoveryConfig{Name: "Setpoint", UniqueID: d.ObjectID + "_setpoint", Device: device}
+	if d.component() == Climate {
+		setpoint.Modes = []string{"auto"}
+		setpoint.TemperatureCommandTopic = b.Topic(TopicSetpointCommand)
+		setpoint.TemperatureStateTopic = b.Topic(TopicSetpoint)
+		setpoint.CurrentTemperatureTopic = b.Topic(TopicValue)
+		setpoint.MinTemp, setpoint.MaxTemp, setpoint.TempStep = &d.Min, &d.Max, d.Step
+	} else {
+		setpoint.CommandTopic = b.Topic(TopicSetpointCommand)
+		setpoint.StateTopic = b.Topic(TopicSetpoint)
+		setpoint.Unit = d.Unit
+		setpoint.Min, setpoint.Max, setpoint.Step = &d.Min, &d.Max, d.Step
+	}
+	configs := []discoveryConfig{
+		setpoint,
+		{Name: "Value", UniqueID: d.ObjectID + "_value", Device: device, StateTopic: b.Topic(TopicValue), Unit: d.Unit},
+		{Name: "Output", UniqueID: d.ObjectID + "_output", Device: device, StateTopic: b.Topic(TopicOutput)},
+	}
+	messages := make([]DiscoveryMessage, len(configs))
+	for n, config := range configs {
+		payload, err := json.Marshal(config)
+		if err != nil {
+			return nil, err
+		}
+		messages[n] = DiscoveryMessage{Topic: topics[n], Payload: payload}
+	}
+	return messages, nil
+}
+
+// PublishDiscovery publishes the retained discovery config messages, which
+// makes the controller appear in Home Assistant.
+func (b *Bridge) PublishDiscovery(d Discovery) error {
+	messages, err := b.DiscoveryMessages(d)
+	if err != nil {
+		return err
+	}
+	for _, m := range messages {
+		if err := b.Client.Publish(m.Topic, true, m.Payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveDiscovery clears the retained discovery config messages, which
+// removes the controller from Home Assistant.
+func (b *Bridge) RemoveDiscovery(d Discovery) error {
+	topics, err := d.topics()
+	if err != nil {
+		return err
+	}
+	for _, topic := range topics {
+		if err := b.Client.Publish(topic, true, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// topics returns the config topics of the setpoint, value and output
+// entities.
+func (d Discovery) topics() ([]string, error) {
+	if d.ObjectID == "" {
+		return nil, ErrObjectID
+	}
+	for _, r := range d.ObjectID {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return nil, ErrObjectID
+		}
+	}
+	prefix := d.Prefix
+	if prefix == "" {
+		prefix = "homeassistant"
+	}
+	return []string{
+		prefix + "/" + string(d.component()) + "/" + d.ObjectID + "/config",
+		prefix + "/sensor/" + d.ObjectID + "_value/config",
+		prefix + "/sensor/" + d.ObjectID + "_output/config",
+	}, nil
+}
+
+func (d Discovery) component() Component {
+	if d.Component == "" {
+		return Number
+	}
+	return d.Component
+}
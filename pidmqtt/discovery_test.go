@@ -0,0 +1,69 @@
+package pidmqtt
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/felixge/pidctrl"
+)
+
+func TestBridge_PublishDiscovery(t *testing.T) {
+	client := newFakeClient()
+	b := &Bridge{Client: client, Controller: pidctrl.NewSafePIDController(1, 0, 0), Prefix: "home/boiler"}
+	d := Discovery{ObjectID: "boiler", Name: "Boiler", Component: Climate, Min: 30, Max: 80, Step: 0.5, Unit: "°C"}
+	if err := b.PublishDiscovery(d); err != nil {
+		t.Fatal(err)
+	}
+	var climate map[string]interface{}
+	if err := json.Unmarshal([]byte(client.get("homeassistant/climate/boiler/config")), &climate); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]interface{}{
+		"unique_id":                 "boiler_setpoint",
+		"temperature_command_topic": "home/boiler/setpoint/set",
+		"temperature_state_topic":   "home/boiler/setpoint",
+		"current_temperature_topic": "home/boiler/value",
+		"min_temp":                  30.0,
+		"max_temp":                  80.0,
+		"temp_step":                 0.5,
+	} {
+		if climate[key] != want {
+			t.Errorf("%s: %v != %v", key, climate[key], want)
+		}
+	}
+	var output map[string]interface{}
+	if err := json.Unmarshal([]byte(client.get("homeassistant/sensor/boiler_output/config")), &output); err != nil {
+		t.Fatal(err)
+	}
+	if output["state_topic"] != "home/boiler/output" || output["device"].(map[string]interface{})["name"] != "Boiler" {
+		t.Errorf("unexpected output config %v", output)
+	}
+
+	if err := b.RemoveDiscovery(d); err != nil {
+		t.Fatal(err)
+	}
+	if got := client.get("homeassistant/sensor/boiler_value/config"); got != "" {
+		t.Errorf("config not cleared: %q", got)
+	}
+}
+
+func TestBridge_DiscoveryNumber(t *testing.T) {
+	b := &Bridge{Prefix: "plant/tank"}
+	messages, err := b.DiscoveryMessages(Discovery{Prefix: "ha", ObjectID: "tank-level", Max: 100, Unit: "%"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 3 || messages[0].Topic != "ha/number/tank-level/config" {
+		t.Fatalf("unexpected messages %v", messages)
+	}
+	var number map[string]interface{}
+	json.Unmarshal(messages[0].Payload, &number)
+	if number["command_topic"] != "plant/tank/setpoint/set" || number["min"] != 0.0 || number["max"] != 100.0 || number["unit_of_measurement"] != "%" {
+		t.Errorf("unexpected number config %v", number)
+	}
+	for _, id := range []string{"", "a/b", "tank level"} {
+		if _, err := b.DiscoveryMessages(Discovery{ObjectID: id}); err != ErrObjectID {
+			t.Errorf("%q: expected ErrObjectID, got %v", id, err)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package pidmqtt
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Start runs the bridge in the background until Stop, see
+// pidctrl.Lifecycle.Start.
+func (b *Bridge) Start(ctx context.Context) error {
+	return b.lifecycle.Start(ctx, b.Run)
+}
+
+// Stop stops a bridge started with Start.
+func (b *Bridge) Stop(ctx context.Context) error {
+	return b.lifecycle.Stop(ctx)
+}
+
+// Healthcheck returns an error if the bridge is not running or the last
+// publish failed.
+func (b *Bridge) Healthcheck(ctx context.Context) error {
+	if err := b.lifecycle.Check(); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.publishErr != nil {
+		return fmt.Errorf("publish failed %v ago: %w", time.Since(b.publishTime).Round(time.Millisecond), b.publishErr)
+	}
+	return nil
+}
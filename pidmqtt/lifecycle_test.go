@@ -0,0 +1,48 @@
+package pidmqtt
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+func TestBridge_Lifecycle(t *testing.T) {
+	client := newFakeClient()
+	b := &Bridge{
+		Client:     client,
+		Controller: pidctrl.NewSafePIDController(1, 0, 0),
+		Prefix:     "boiler",
+		Interval:   time.Millisecond,
+	}
+	ctx := context.Background()
+	if err := b.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for client.get("boiler/state") == "" {
+		time.Sleep(time.Millisecond)
+	}
+	if err := b.Healthcheck(ctx); err != nil {
+		t.Errorf("healthcheck: %v", err)
+	}
+
+	client.mu.Lock()
+	client.fail = errors.New("disconnected")
+	client.mu.Unlock()
+	b.Publish()
+	if err := b.Healthcheck(ctx); err == nil {
+		t.Error("expected healthcheck to report the failed publish")
+	}
+
+	if err := b.Stop(ctx); err != nil {
+		t.Errorf("stop: %v", err)
+	}
+	if client.send("boiler/setpoint/set", "1") {
+		t.Error("still subscribed after stop")
+	}
+	if err := b.Healthcheck(ctx); err != pidctrl.ErrNotRunning {
+		t.Errorf("healthcheck after stop: %v", err)
+	}
+}
@@ -0,0 +1,214 @@
+// Package pidmqtt bridges a controller to MQTT: setpoints (and optionally
+// gains) are received on command topics and the process value, output and
+// full state are published at a fixed rate. PublishDiscovery announces the
+// bridge to Home Assistant via MQTT discovery.
+//
+// The package does not depend on a particular MQTT library; applications
+// provide a Client, which for most libraries is a thin wrapper, e.g. for
+// github.com/eclipse/paho.mqtt.golang:
+//
+//	type pahoClient struct{ c mqtt.Client }
+//
+//	func (p pahoClient) Publish(topic string, retained bool, payload []byte) error {
+//		t := p.c.Publish(topic, 1, retained, payload)
+//		t.Wait()
+//		return t.Error()
+//	}
+//
+//	func (p pahoClient) Subscribe(topic string, handler func(topic string, payload []byte)) error {
+//		t := p.c.Subscribe(topic, 1, func(_ mqtt.Client, m mqtt.Message) { handler(m.Topic(), m.Payload()) })
+//		t.Wait()
+//		return t.Error()
+//	}
+//
+//	func (p pahoClient) Unsubscribe(topic string) error {
+//		t := p.c.Unsubscribe(topic)
+//		t.Wait()
+//		return t.Error()
+//	}
+package pidmqtt
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+// Client is the subset of an MQTT client used by a Bridge.
+type Client interface {
+	Publish(topic string, retained bool, payload []byte) error
+	Subscribe(topic string, handler func(topic string, payload []byte)) error
+	Unsubscribe(topic string) error
+}
+
+// Topic suffixes, relative to the bridge prefix.
+const (
+	TopicSetpointCommand = "setpoint/set" // plain number
+	TopicGainsCommand    = "gains/set"    // JSON encoded pidctrl.Gains
+	TopicSetpoint        = "setpoint"
+	TopicValue           = "value"
+	TopicOutput          = "output"
+	TopicState           = "state" // JSON encoded State
+)
+
+// State is the payload published on the state topic.
+type State struct {
+	pidctrl.Settings
+	Value  float64       `json:"value"`
+	Output float64       `json:"output"`
+	Terms  pidctrl.Terms `json:"terms"`
+}
+
+// Bridge connects a controller to MQTT.
+type Bridge struct {
+	Client     Client
+	Controller *pidctrl.SafePIDController
+	// Prefix is prepended to all topics, e.g. "home/boiler".
+	Prefix string
+	// Interval is the publish rate.
+	Interval time.Duration
+	// AcceptGains enables the gains command topic.
+	AcceptGains bool
+	// OnError is called with errors of invalid commands and failed
+	// publishes. It may be nil.
+	OnError func(error)
+
+	lifecycle   pidctrl.Lifecycle
+	mu          sync.Mutex
+	publishErr  error     // error of the last publish
+	publishTime time.Time // time of the last publish
+}
+
+// Topic returns the full topic name for a topic suffix.
+func (b *Bridge) Topic(suffix string) string {
+	return strings.TrimSuffix(b.Prefix, "/") + "/" + suffix
+}
+
+// Run subscribes to the command topics and publishes the controller state
+// every Interval until ctx is cancelled. It returns ctx.Err() or the error of
+// a failed subscription.
+func (b *Bridge) Run(ctx context.Context) error {
+	if err := b.subscribe(); err != nil {
+		return err
+	}
+	defer b.unsubscribe()
+	ticker := time.NewTicker(b.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			b.Publish()
+		}
+	}
+}
+
+func (b *Bridge) subscribe() error {
+	if err := b.Client.Subscribe(b.Topic(TopicSetpointCommand), b.handleSetpoint); err != nil {
+		return err
+	}
+	if b.AcceptGains {
+		if err := b.Client.Subscribe(b.Topic(TopicGainsCommand), b.handleGains); err != nil {
+			b.Client.Unsubscribe(b.Topic(TopicSetpointCommand))
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Bridge) unsubscribe() {
+	b.Client.Unsubscribe(b.Topic(TopicSetpointCommand))
+	if b.AcceptGains {
+		b.Client.Unsubscribe(b.Topic(TopicGainsCommand))
+	}
+}
+
+// Publish publishes the current controller state once.
+func (b *Bridge) Publish() {
+	err := b.publish()
+	b.mu.Lock()
+	b.publishErr, b.publishTime = err, time.Now()
+	b.mu.Unlock()
+	if err != nil {
+		b.error(err)
+	}
+}
+
+func (b *Bridge) publish() error {
+	s := b.State()
+	state, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	for _, m := range []struct {
+		topic   string
+		payload []byte
+	}{
+		{TopicSetpoint, formatFloat(*s.Setpoint)},
+		{TopicValue, formatFloat(s.Value)},
+		{TopicOutput, formatFloat(s.Output)},
+		{TopicState, state},
+	} {
+		if err := b.Client.Publish(b.Topic(m.topic), false, m.payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// State returns the current state of the controller.
+func (b *Bridge) State() State {
+	var s State
+	b.Controller.Do(func(c *pidctrl.PIDController) {
+		s = State{Settings: c.Settings(), Value: c.ProcessValue(), Output: c.Output(), Terms: c.Terms()}
+	})
+	return s
+}
+
+func (b *Bridge) handleSetpoint(topic string, payload []byte) {
+	sp, err := strconv.ParseFloat(strings.TrimSpace(string(payload)), 64)
+	if err != nil {
+		b.error(err)
+		return
+	}
+	if math.IsNaN(sp) || math.IsInf(sp, 0) {
+		b.error(pidctrl.NonFiniteError{Name: "setpoint", Value: sp})
+		return
+	}
+	b.Controller.Set(sp)
+}
+
+func (b *Bridge) handleGains(topic string, payload []byte) {
+	var g pidctrl.Gains
+	if err := json.Unmarshal(payload, &g); err != nil {
+		b.error(err)
+		return
+	}
+	for _, v := range []struct {
+		name string
+		v    float64
+	}{{"p", g.P}, {"i", g.I}, {"d", g.D}} {
+		if math.IsNaN(v.v) || math.IsInf(v.v, 0) {
+			b.error(pidctrl.NonFiniteError{Name: v.name, Value: v.v})
+			return
+		}
+	}
+	b.Controller.SetPID(g.P, g.I, g.D)
+}
+
+func (b *Bridge) error(err error) {
+	if b.OnError != nil {
+		b.OnError(err)
+	}
+}
+
+func formatFloat(v float64) []byte {
+	return strconv.AppendFloat(nil, v, 'g', -1, 64)
+}
@@ -0,0 +1,125 @@
+package pidmqtt
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+// fakeClient is an in-memory broker for a single client.
+type fakeClient struct {
+	mu        sync.Mutex
+	handlers  map[string]func(string, []byte)
+	published map[string]string
+	fail      error // returned by Publish if set
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{handlers: map[string]func(string, []byte){}, published: map[string]string{}}
+}
+
+func (f *fakeClient) Publish(topic string, retained bool, payload []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail != nil {
+		return f.fail
+	}
+	f.published[topic] = string(payload)
+	return nil
+}
+
+func (f *fakeClient) Subscribe(topic string, handler func(string, []byte)) error {
+	f.mu.Lock()
+	f.handlers[topic] = handler
+	f.mu.Unlock()
+	return nil
+}
+
+func (f *fakeClient) Unsubscribe(topic string) error {
+	f.mu.Lock()
+	delete(f.handlers, topic)
+	f.mu.Unlock()
+	return nil
+}
+
+func (f *fakeClient) send(topic, payload string) bool {
+	f.mu.Lock()
+	h := f.handlers[topic]
+	f.mu.Unlock()
+	if h != nil {
+		h(topic, []byte(payload))
+	}
+	return h != nil
+}
+
+func (f *fakeClient) get(topic string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.published[topic]
+}
+
+func TestBridge(t *testing.T) {
+	client := newFakeClient()
+	c := pidctrl.NewSafePIDController(2, 0, 0)
+	var errs []error
+	b := &Bridge{Client: client, Controller: c, Prefix: "home/boiler/", Interval: time.Millisecond, AcceptGains: true,
+		OnError: func(err error) { errs = append(errs, err) }}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- b.Run(ctx) }()
+	for !client.send("home/boiler/setpoint/set", "55.5") {
+		time.Sleep(time.Millisecond)
+	}
+	client.send("home/boiler/gains/set", `{"p":1,"i":0.5,"d":0}`)
+	client.send("home/boiler/setpoint/set", "hot")
+	c.UpdateDuration(50, time.Second)
+	for client.get("home/boiler/value") != "50" {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if c.Get() != 55.5 {
+		t.Errorf("setpoint %v != 55.5", c.Get())
+	}
+	if p, i, _ := c.PID(); p != 1 || i != 0.5 {
+		t.Errorf("gains not applied: %v %v", p, i)
+	}
+	if len(errs) != 1 {
+		t.Errorf("expected one error for invalid setpoint, got %v", errs)
+	}
+	if got := client.get("home/boiler/setpoint"); got != "55.5" {
+		t.Errorf("published setpoint %q", got)
+	}
+	if got := client.get("home/boiler/output"); got != "8.25" {
+		t.Errorf("published output %q", got)
+	}
+	if len(client.handlers) != 0 {
+		t.Errorf("subscriptions left after Run returned: %v", client.handlers)
+	}
+}
+
+func TestBridge_nonFinite(t *testing.T) {
+	c := pidctrl.NewSafePIDController(2, 0, 0).Set(20)
+	var errs []error
+	b := &Bridge{Client: newFakeClient(), Controller: c, OnError: func(err error) { errs = append(errs, err) }}
+	for _, payload := range []string{"NaN", "Inf", "-inf", "1e400"} {
+		b.handleSetpoint(TopicSetpointCommand, []byte(payload))
+	}
+	b.handleGains(TopicGainsCommand, []byte(`{"p":1e400,"i":0,"d":0}`))
+	if c.Get() != 20 {
+		t.Errorf("setpoint changed to %v", c.Get())
+	}
+	if p, _, _ := c.PID(); p != 2 {
+		t.Errorf("gains changed: p %v", p)
+	}
+	if len(errs) != 5 {
+		t.Errorf("expected five errors, got %v", errs)
+	}
+}
@@ -0,0 +1,249 @@
+// Package pidopcua maps the controllers of a registry onto an OPC UA address
+// space, so SCADA systems and historians can browse and trend loops running
+// in Go.
+//
+// The package does not implement the OPC UA protocol itself. A Server
+// provides the Browse, Read and Write services that OPC UA server libraries
+// expect from a custom namespace; errors are StatusCodes that the library can
+// return to the client unchanged.
+//
+// Every loop is an object below the root folder with the variables
+//
+//	PV     Double   process value, read-only
+//	SP     Double   setpoint
+//	OUT    Double   output, read-only
+//	Mode   String   occupancy mode, see pidctrl.Occupancy
+//	Gains  Object   with the Double variables P, I and D
+//
+// Node ids are string ids in the namespace of the server, e.g.
+// "ns=2;s=PID.boiler.SP" for the setpoint of the loop named boiler.
+package pidopcua
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/felixge/pidctrl"
+)
+
+// StatusCode is an OPC UA status code.
+type StatusCode uint32
+
+// Status codes returned by a Server.
+const (
+	BadNodeIdUnknown      StatusCode = 0x80340000
+	BadAttributeIdInvalid StatusCode = 0x80350000
+	BadNotWritable        StatusCode = 0x803B0000
+	BadOutOfRange         StatusCode = 0x803C0000
+	BadTypeMismatch       StatusCode = 0x80740000
+)
+
+func (s StatusCode) Error() string {
+	switch s {
+	case BadNodeIdUnknown:
+		return "opcua: BadNodeIdUnknown"
+	case BadAttributeIdInvalid:
+		return "opcua: BadAttributeIdInvalid"
+	case BadNotWritable:
+		return "opcua: BadNotWritable"
+	case BadOutOfRange:
+		return "opcua: BadOutOfRange"
+	case BadTypeMismatch:
+		return "opcua: BadTypeMismatch"
+	}
+	return fmt.Sprintf("opcua: status 0x%08X", uint32(s))
+}
+
+// NodeClass is the class of a node.
+type NodeClass int
+
+// Node classes used by a Server.
+const (
+	Object NodeClass = iota
+	Variable
+)
+
+// Node describes a node of the address space.
+type Node struct {
+	ID         string
+	BrowseName string
+	Class      NodeClass
+	// DataType is "Double", "String" or empty for objects.
+	DataType string
+	Writable bool
+}
+
+// variable is a variable of a loop, relative to the loop node.
+type variable struct {
+	path     string
+	dataType string
+	writable bool
+}
+
+var (
+	loopChildren  = []variable{{"PV", "Double", false}, {"SP", "Double", true}, {"OUT", "Double", false}, {"Mode", "String", true}, {"Gains", "", false}}
+	gainsChildren = []variable{{"Gains.P", "Double", true}, {"Gains.I", "Double", true}, {"Gains.D", "Double", true}}
+)
+
+// Server serves the controllers of a registry.
+type Server struct {
+	Registry *pidctrl.Registry
+	// Namespace is the namespace index of the node ids.
+	Namespace uint16
+}
+
+// NewServer returns a new Server.
+func NewServer(r *pidctrl.Registry, namespace uint16) *Server {
+	return &Server{Registry: r, Namespace: namespace}
+}
+
+// RootID returns the node id of the folder containing the loops.
+func (s *Server) RootID() string {
+	return "ns=" + strconv.Itoa(int(s.Namespace)) + ";s=PID"
+}
+
+// Browse returns the children of a node in browse order.
+func (s *Server) Browse(id string) ([]Node, error) {
+	if id == s.RootID() {
+		var nodes []Node
+		for _, name := range s.Registry.Names() {
+			nodes = append(nodes, Node{ID: s.RootID() + "." + name, BrowseName: name, Class: Object})
+		}
+		return nodes, nil
+	}
+	name, path, _, err := s.resolve(id)
+	if err != nil {
+		return nil, err
+	}
+	var children []variable
+	switch path {
+	case "":
+		children = loopChildren
+	case "Gains":
+		children = gainsChildren
+	default:
+		return nil, nil
+	}
+	nodes := make([]Node, len(children))
+	for n, v := range children {
+		nodes[n] = s.node(name, v)
+	}
+	return nodes, nil
+}
+
+// Read returns the value of a variable as float64 for Double and string for
+// String variables. Objects have no value.
+func (s *Server) Read(id string) (interface{}, error) {
+	_, path, c, err := s.resolve(id)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	c.Do(func(c *pidctrl.PIDController) {
+		p, i, d := c.PID()
+		switch path {
+		case "PV":
+			v = c.ProcessValue()
+		case "SP":
+			v = c.Get()
+		case "OUT":
+			v = c.Output()
+		case "Mode":
+			v = string(c.Occupancy())
+		case "Gains.P":
+			v = p
+		case "Gains.I":
+			v = i
+		case "Gains.D":
+			v = d
+		}
+	})
+	if v == nil {
+		return nil, BadAttributeIdInvalid
+	}
+	return v, nil
+}
+
+// Write changes the value of a writable variable. Double variables take a
+// float64 and the Mode a string naming a configured occupancy mode.
+func (s *Server) Write(id string, value interface{}) error {
+	_, path, c, err := s.resolve(id)
+	if err != nil {
+		return err
+	}
+	if path == "Mode" {
+		mode, ok := value.(string)
+		if !ok {
+			return BadTypeMismatch
+		}
+		if c.SetOccupancy(pidctrl.Occupancy(mode)) != nil {
+			return BadOutOfRange
+		}
+		return nil
+	}
+	if !writable(path) {
+		return BadNotWritable
+	}
+	v, ok := value.(float64)
+	if !ok {
+		return BadTypeMismatch
+	}
+	c.Do(func(c *pidctrl.PIDController) {
+		p, i, d := c.PID()
+		switch path {
+		case "SP":
+			c.Set(v)
+		case "Gains.P":
+			c.SetPID(v, i, d)
+		case "Gains.I":
+			c.SetPID(p, v, d)
+		case "Gains.D":
+			c.SetPID(p, i, v)
+		}
+	})
+	return nil
+}
+
+func (s *Server) node(name string, v variable) Node {
+	n := Node{ID: s.RootID() + "." + name + "." + v.path, BrowseName: v.path[strings.LastIndexByte(v.path, '.')+1:], Class: Variable, DataType: v.dataType, Writable: v.writable}
+	if v.dataType == "" {
+		n.Class = Object
+	}
+	return n
+}
+
+// resolve splits a node id into loop name and variable path, which is empty
+// for the loop object itself.
+func (s *Server) resolve(id string) (name, path string, c *pidctrl.SafePIDController, err error) {
+	rest := strings.TrimPrefix(id, s.RootID()+".")
+	if rest == id {
+		return "", "", nil, BadNodeIdUnknown
+	}
+	if c, ok := s.Registry.Get(rest); ok {
+		return rest, "", c, nil
+	}
+	for _, vars := range [][]variable{gainsChildren, loopChildren} {
+		for _, v := range vars {
+			name := strings.TrimSuffix(rest, "."+v.path)
+			if name == rest {
+				continue
+			}
+			if c, ok := s.Registry.Get(name); ok {
+				return name, v.path, c, nil
+			}
+		}
+	}
+	return "", "", nil, BadNodeIdUnknown
+}
+
+func writable(path string) bool {
+	for _, vars := range [][]variable{gainsChildren, loopChildren} {
+		for _, v := range vars {
+			if v.path == path {
+				return v.writable
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package pidopcua
+
+import (
+	"testing"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+func testServer(t *testing.T) (*Server, *pidctrl.SafePIDController) {
+	r := pidctrl.NewRegistry()
+	c := pidctrl.NewSafePIDController(2, 0.5, 0).Set(21)
+	c.Do(func(c *pidctrl.PIDController) {
+		c.SetOccupancyProfiles(map[pidctrl.Occupancy]pidctrl.OccupancyProfile{pidctrl.OccupancyEco: {SetpointOffset: -2}})
+	})
+	c.UpdateDuration(20, time.Second)
+	if err := r.Register("hall.boiler", c); err != nil {
+		t.Fatal(err)
+	}
+	return NewServer(r, 2), c
+}
+
+func TestServer_Browse(t *testing.T) {
+	s, _ := testServer(t)
+	loops, err := s.Browse("ns=2;s=PID")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loops) != 1 || loops[0].ID != "ns=2;s=PID.hall.boiler" || loops[0].Class != Object {
+		t.Fatalf("unexpected loops %+v", loops)
+	}
+	vars, err := s.Browse(loops[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, v := range vars {
+		names = append(names, v.BrowseName)
+	}
+	if len(names) != 5 || names[0] != "PV" || names[4] != "Gains" || vars[4].Class != Object || vars[1].Writable != true || vars[0].Writable {
+		t.Errorf("unexpected variables %+v", vars)
+	}
+	gains, err := s.Browse(vars[4].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gains) != 3 || gains[0].ID != "ns=2;s=PID.hall.boiler.Gains.P" || gains[0].BrowseName != "P" || gains[0].DataType != "Double" {
+		t.Errorf("unexpected gains %+v", gains)
+	}
+	if _, err := s.Browse("ns=2;s=PID.pool"); err != BadNodeIdUnknown {
+		t.Errorf("expected unknown node, got %v", err)
+	}
+}
+
+func TestServer_ReadWrite(t *testing.T) {
+	s, c := testServer(t)
+	for id, want := range map[string]interface{}{
+		"ns=2;s=PID.hall.boiler.PV":      20.0,
+		"ns=2;s=PID.hall.boiler.SP":      21.0,
+		"ns=2;s=PID.hall.boiler.OUT":     2.5,
+		"ns=2;s=PID.hall.boiler.Mode":    "",
+		"ns=2;s=PID.hall.boiler.Gains.I": 0.5,
+	} {
+		if v, err := s.Read(id); err != nil || v != want {
+			t.Errorf("%s: %v %v, want %v", id, v, err, want)
+		}
+	}
+	if err := s.Write("ns=2;s=PID.hall.boiler.SP", 23.0); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Write("ns=2;s=PID.hall.boiler.Gains.D", 0.1); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Write("ns=2;s=PID.hall.boiler.Mode", "eco"); err != nil {
+		t.Fatal(err)
+	}
+	if p, i, d := c.PID(); c.Get() != 23 || p != 2 || i != 0.5 || d != 0.1 || c.Occupancy() != pidctrl.OccupancyEco {
+		t.Errorf("writes not applied: sp %v gains %v %v %v mode %v", c.Get(), p, i, d, c.Occupancy())
+	}
+}
+
+func TestServer_Errors(t *testing.T) {
+	s, _ := testServer(t)
+	for _, tc := range []struct {
+		id    string
+		value interface{}
+		err   error
+	}{
+		{"ns=2;s=PID.hall.boiler.OUT", 1.0, BadNotWritable},
+		{"ns=2;s=PID.hall.boiler.SP", "hot", BadTypeMismatch},
+		{"ns=2;s=PID.hall.boiler.Mode", "party", BadOutOfRange},
+		{"ns=3;s=PID.hall.boiler.SP", 1.0, BadNodeIdUnknown},
+		{"ns=2;s=PID.hall.boiler.Gains", 1.0, BadNotWritable},
+	} {
+		if err := s.Write(tc.id, tc.value); err != tc.err {
+			t.Errorf("write %s: %v != %v", tc.id, err, tc.err)
+		}
+	}
+	if _, err := s.Read("ns=2;s=PID.hall.boiler"); err != BadAttributeIdInvalid {
+		t.Errorf("read object: %v", err)
+	}
+}
@@ -0,0 +1,116 @@
+// Package pidperiph connects controllers to PWM pins and temperature
+// sensors, e.g. a heater on a Raspberry Pi driven from a BME280 or DS18B20
+// reading.
+//
+// The package does not depend on periph.io. PWMPin and TemperatureSensor
+// have the method sets of periph's gpio.PinOut.PWM and physic.SenseEnv, with
+// local Duty, Frequency and Temperature types of the same representation
+// and units, so the wrappers are plain conversions:
+//
+//	type pin struct{ p gpio.PinOut }
+//
+//	func (p pin) PWM(duty pidperiph.Duty, f pidperiph.Frequency) error {
+//		return p.p.PWM(gpio.Duty(duty), physic.Frequency(f))
+//	}
+//
+//	type sensor struct{ s physic.SenseEnv }
+//
+//	func (s sensor) Sense(e *pidperiph.Env) error {
+//		var env physic.Env
+//		err := s.s.Sense(&env)
+//		e.Temperature = pidperiph.Temperature(env.Temperature)
+//		return err
+//	}
+//
+// periph drivers need host.Init() to be called before any bus or pin is
+// opened.
+package pidperiph
+
+import (
+	"math"
+)
+
+// Duty is a PWM duty cycle, with DutyMax for always on, like gpio.Duty.
+type Duty int32
+
+// DutyMax is a duty cycle of 100%.
+const DutyMax Duty = 1 << 24
+
+// Frequency is a frequency in µHz, like physic.Frequency.
+type Frequency int64
+
+// Frequency units.
+const (
+	MicroHertz Frequency = 1
+	Hertz      Frequency = 1000000 * MicroHertz
+	KiloHertz  Frequency = 1000 * Hertz
+)
+
+// Temperature is a temperature in nK, like physic.Temperature.
+type Temperature int64
+
+// Temperature units.
+const (
+	NanoKelvin  Temperature = 1
+	Kelvin      Temperature = 1000000000 * NanoKelvin
+	ZeroCelsius             = 273150000000 * NanoKelvin
+)
+
+// Celsius returns the temperature in °C.
+func (t Temperature) Celsius() float64 {
+	return float64(t-ZeroCelsius) / float64(Kelvin)
+}
+
+// Env is the part of physic.Env read by Sensor.
+type Env struct {
+	Temperature Temperature
+}
+
+// PWMPin is a pin with PWM output, like gpio.PinOut.
+type PWMPin interface {
+	PWM(duty Duty, f Frequency) error
+}
+
+// TemperatureSensor is an environmental sensor, like physic.SenseEnv.
+type TemperatureSensor interface {
+	Sense(e *Env) error
+}
+
+// Sensor returns a read function for PIDController.Run reading the sensor
+// in °C. A failed read returns NaN, which the controller handles according
+// to its NonFinitePolicy, and calls onError if it is not nil.
+func Sensor(s TemperatureSensor, onError func(error)) func() float64 {
+	return func() float64 {
+		var e Env
+		if err := s.Sense(&e); err != nil {
+			if onError != nil {
+				onError(err)
+			}
+			return math.NaN()
+		}
+		return e.Temperature.Celsius()
+	}
+}
+
+// PWM returns a write function for PIDController.Run driving the pin at
+// frequency f with the output as duty cycle, where 0 is off and 1 is always
+// on. Outputs outside are clamped, so the controller output limits should
+// be 0 and 1. Failed writes call onError if it is not nil.
+func PWM(p PWMPin, f Frequency, onError func(error)) func(float64) {
+	return func(out float64) {
+		if err := p.PWM(DutyOf(out), f); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
+
+// DutyOf returns the duty cycle of a fraction between 0 and 1. Values
+// outside, including NaN, are clamped.
+func DutyOf(fraction float64) Duty {
+	if !(fraction > 0) {
+		return 0
+	} else if fraction >= 1 {
+		return DutyMax
+	}
+	return Duty(math.Round(fraction * float64(DutyMax)))
+}
@@ -0,0 +1,67 @@
+package pidperiph
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+type fakePin struct {
+	duty Duty
+	f    Frequency
+	err  error
+}
+
+func (p *fakePin) PWM(duty Duty, f Frequency) error {
+	p.duty, p.f = duty, f
+	return p.err
+}
+
+type fakeSensor struct {
+	t   Temperature
+	err error
+}
+
+func (s fakeSensor) Sense(e *Env) error {
+	e.Temperature = s.t
+	return s.err
+}
+
+func TestSensor(t *testing.T) {
+	read := Sensor(fakeSensor{t: ZeroCelsius + 21500*Kelvin/1000}, nil)
+	if v := read(); math.Abs(v-21.5) > 1e-9 {
+		t.Errorf("temperature %v != 21.5", v)
+	}
+	var got error
+	read = Sensor(fakeSensor{err: errors.New("bus")}, func(err error) { got = err })
+	if v := read(); !math.IsNaN(v) || got == nil {
+		t.Errorf("failed read: %v, %v", v, got)
+	}
+}
+
+func TestPWM(t *testing.T) {
+	p := &fakePin{}
+	write := PWM(p, 10*Hertz, nil)
+	for _, u := range []struct {
+		out  float64
+		duty Duty
+	}{
+		{0.5, DutyMax / 2},
+		{0, 0},
+		{1, DutyMax},
+		{-1, 0},
+		{2, DutyMax},
+		{math.NaN(), 0},
+	} {
+		write(u.out)
+		if p.duty != u.duty || p.f != 10*Hertz {
+			t.Errorf("output %v: duty %v at %v", u.out, p.duty, p.f)
+		}
+	}
+	var got error
+	p.err = errors.New("pin")
+	PWM(p, Hertz, func(err error) { got = err })(0.5)
+	if got != p.err {
+		t.Errorf("error %v", got)
+	}
+}
@@ -0,0 +1,234 @@
+// Package pidros exposes a controller in a ROS 2 graph: the setpoint is
+// received on a std_msgs/Float64 topic, the controller state is published
+// at a fixed rate in the layout of control_msgs/PidState, and gains, limits
+// and setpoint are node parameters that can be reconfigured at runtime.
+//
+// The package does not depend on rclgo. Applications provide a Node, which
+// wraps an rclgo node with its generated message types, e.g.:
+//
+//	type rclNode struct {
+//		node *rclgo.Node
+//		pubs map[string]*control_msgs_msg.PidStatePublisher
+//		subs map[string]*std_msgs_msg.Float64Subscription
+//	}
+//
+//	func (n *rclNode) Publish(topic string, s pidros.PidState) error {
+//		msg := control_msgs_msg.NewPidState()
+//		msg.Header.Stamp = builtin_interfaces_msg.Time{Sec: int32(s.Stamp.Unix()), Nanosec: uint32(s.Stamp.Nanosecond())}
+//		msg.Error, msg.PTerm, msg.ITerm, msg.DTerm = s.Error, s.PTerm, s.ITerm, s.DTerm
+//		msg.PGain, msg.IGain, msg.DGain, msg.Output = s.PGain, s.IGain, s.DGain, s.Output
+//		return n.pubs[topic].Publish(msg)
+//	}
+//
+// with Subscribe creating a Float64 subscription and DeclareParameters
+// declaring double parameters and registering onSet as the set parameters
+// callback.
+package pidros
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+// Node is the subset of a ROS 2 node used by a Bridge.
+type Node interface {
+	Publish(topic string, state PidState) error
+	Subscribe(topic string, handler func(float64)) error
+	Unsubscribe(topic string) error
+	// DeclareParameters declares double parameters with the given initial
+	// values and calls onSet for every parameter change request. A change is
+	// rejected if onSet returns an unsuccessful result.
+	DeclareParameters(params []Parameter, onSet func([]Parameter) SetParametersResult) error
+}
+
+// Topic names, relative to the bridge namespace.
+const (
+	TopicSetpoint = "setpoint" // std_msgs/Float64
+	TopicState    = "state"    // control_msgs/PidState
+)
+
+// Parameter names.
+const (
+	ParamSetpoint  = "setpoint"
+	ParamP         = "p"
+	ParamI         = "i"
+	ParamD         = "d"
+	ParamOutputMin = "output_min"
+	ParamOutputMax = "output_max"
+)
+
+// Parameter is a double node parameter. Unbounded output limits are
+// infinite.
+type Parameter struct {
+	Name  string
+	Value float64
+}
+
+// SetParametersResult mirrors rcl_interfaces/SetParametersResult.
+type SetParametersResult struct {
+	Successful bool
+	Reason     string
+}
+
+// PidState is the published state, with the fields of control_msgs/PidState
+// that apply to this controller.
+type PidState struct {
+	Stamp    time.Time
+	Timestep time.Duration
+	Setpoint float64
+	Value    float64
+	Error    float64
+	PTerm    float64
+	ITerm    float64
+	DTerm    float64
+	PGain    float64
+	IGain    float64
+	DGain    float64
+	Output   float64
+}
+
+// Bridge connects a controller to a ROS 2 node.
+type Bridge struct {
+	Node       Node
+	Controller *pidctrl.SafePIDController
+	// Namespace is prepended to all topics, e.g. "/boiler". Empty means
+	// topics relative to the node namespace.
+	Namespace string
+	// Interval is the publish rate.
+	Interval time.Duration
+	// OnError is called with errors of failed publishes. It may be nil.
+	OnError func(error)
+
+	last time.Time // time of the last publish
+}
+
+// Topic returns the full topic name.
+func (b *Bridge) Topic(name string) string {
+	if b.Namespace == "" {
+		return name
+	}
+	return strings.TrimSuffix(b.Namespace, "/") + "/" + name
+}
+
+// Run declares the parameters, subscribes to the setpoint topic and
+// publishes the controller state every Interval until ctx is cancelled. It
+// returns ctx.Err() or the error of a failed declaration or subscription.
+func (b *Bridge) Run(ctx context.Context) error {
+	if err := b.Node.DeclareParameters(b.Parameters(), b.SetParameters); err != nil {
+		return err
+	}
+	if err := b.Node.Subscribe(b.Topic(TopicSetpoint), func(sp float64) { b.Controller.Set(sp) }); err != nil {
+		return err
+	}
+	defer b.Node.Unsubscribe(b.Topic(TopicSetpoint))
+	ticker := time.NewTicker(b.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			b.Publish(now)
+		}
+	}
+}
+
+// Publish publishes the current controller state once, stamped with now.
+func (b *Bridge) Publish(now time.Time) {
+	s := b.State()
+	s.Stamp = now
+	if !b.last.IsZero() {
+		s.Timestep = now.Sub(b.last)
+	}
+	b.last = now
+	if err := b.Node.Publish(b.Topic(TopicState), s); err != nil && b.OnError != nil {
+		b.OnError(err)
+	}
+}
+
+// State returns the current state of the controller without stamp and
+// timestep.
+func (b *Bridge) State() PidState {
+	var s PidState
+	b.Controller.Do(func(c *pidctrl.PIDController) {
+		p, i, d := c.PID()
+		t := c.Terms()
+		s = PidState{
+			Setpoint: c.WorkingSetpoint(),
+			Value:    c.ProcessValue(),
+			PTerm:    t.P,
+			ITerm:    t.I,
+			DTerm:    t.D,
+			PGain:    p,
+			IGain:    i,
+			DGain:    d,
+			Output:   c.Output(),
+		}
+		s.Error = s.Setpoint - s.Value
+	})
+	return s
+}
+
+// Parameters returns the current parameter values.
+func (b *Bridge) Parameters() []Parameter {
+	var params []Parameter
+	b.Controller.Do(func(c *pidctrl.PIDController) {
+		p, i, d := c.PID()
+		min, max := c.OutputLimits()
+		params = []Parameter{
+			{ParamSetpoint, c.Get()},
+			{ParamP, p},
+			{ParamI, i},
+			{ParamD, d},
+			{ParamOutputMin, min},
+			{ParamOutputMax, max},
+		}
+	})
+	return params
+}
+
+// SetParameters applies a parameter change request atomically. Nothing is
+// changed if a parameter is unknown or the resulting settings are invalid.
+func (b *Bridge) SetParameters(params []Parameter) SetParametersResult {
+	var err error
+	b.Controller.Do(func(c *pidctrl.PIDController) {
+		settings := c.Settings()
+		for _, p := range params {
+			v := p.Value
+			switch p.Name {
+			case ParamSetpoint:
+				settings.Setpoint = &v
+			case ParamP:
+				settings.Gains.P = v
+			case ParamI:
+				settings.Gains.I = v
+			case ParamD:
+				settings.Gains.D = v
+			case ParamOutputMin:
+				settings.OutputLimits.Min = finiteOrNil(v)
+			case ParamOutputMax:
+				settings.OutputLimits.Max = finiteOrNil(v)
+			default:
+				err = fmt.Errorf("unknown parameter %q", p.Name)
+				return
+			}
+		}
+		err = c.ApplySettings(settings)
+	})
+	if err != nil {
+		return SetParametersResult{Reason: err.Error()}
+	}
+	return SetParametersResult{Successful: true}
+}
+
+func finiteOrNil(v float64) *float64 {
+	if math.IsInf(v, 0) {
+		return nil
+	}
+	return &v
+}
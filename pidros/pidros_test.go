@@ -0,0 +1,137 @@
+package pidros
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+type fakeNode struct {
+	mu        sync.Mutex
+	published map[string]PidState
+	handlers  map[string]func(float64)
+	params    []Parameter
+	onSet     func([]Parameter) SetParametersResult
+}
+
+func newFakeNode() *fakeNode {
+	return &fakeNode{published: map[string]PidState{}, handlers: map[string]func(float64){}}
+}
+
+func (n *fakeNode) Publish(topic string, s PidState) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.published[topic] = s
+	return nil
+}
+
+func (n *fakeNode) Subscribe(topic string, handler func(float64)) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.handlers[topic] = handler
+	return nil
+}
+
+func (n *fakeNode) Unsubscribe(topic string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.handlers, topic)
+	return nil
+}
+
+func (n *fakeNode) DeclareParameters(params []Parameter, onSet func([]Parameter) SetParametersResult) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.params, n.onSet = params, onSet
+	return nil
+}
+
+func (n *fakeNode) state(topic string) (PidState, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	s, ok := n.published[topic]
+	return s, ok
+}
+
+func TestBridge(t *testing.T) {
+	c := pidctrl.NewSafePIDController(2, 0, 0).SetOutputLimits(0, 100).Set(21)
+	c.UpdateDuration(20, time.Second)
+	node := newFakeNode()
+	b := &Bridge{Node: node, Controller: c, Namespace: "/boiler/", Interval: time.Millisecond}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- b.Run(ctx) }()
+
+	var s PidState
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		var ok bool
+		if s, ok = node.state("/boiler/state"); ok || time.Now().After(deadline) {
+			break
+		}
+	}
+	if s.Setpoint != 21 || s.Value != 20 || s.Error != 1 || s.PTerm != 2 || s.PGain != 2 || s.Output != 2 || s.Stamp.IsZero() {
+		t.Errorf("unexpected state %+v", s)
+	}
+
+	node.mu.Lock()
+	params, onSet, handler := node.params, node.onSet, node.handlers["/boiler/setpoint"]
+	node.mu.Unlock()
+	if len(params) != 6 || params[4] != (Parameter{ParamOutputMin, 0}) || params[5] != (Parameter{ParamOutputMax, 100}) {
+		t.Errorf("unexpected parameters %v", params)
+	}
+	handler(23)
+	if sp := c.Get(); sp != 23 {
+		t.Errorf("setpoint %v != 23", sp)
+	}
+	if r := onSet([]Parameter{{ParamP, 1.5}, {ParamOutputMax, math.Inf(1)}}); !r.Successful {
+		t.Errorf("change rejected: %s", r.Reason)
+	}
+	if p, _, _ := c.PID(); p != 1.5 {
+		t.Errorf("p %v != 1.5", p)
+	}
+	if _, max := c.OutputLimits(); !math.IsInf(max, 1) {
+		t.Errorf("max %v not unbounded", max)
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Run returned %v", err)
+	}
+	if len(node.handlers) != 0 {
+		t.Error("setpoint topic not unsubscribed")
+	}
+}
+
+func TestBridge_SetParametersRejected(t *testing.T) {
+	c := pidctrl.NewSafePIDController(1, 0, 0).SetOutputLimits(0, 100)
+	b := &Bridge{Controller: c}
+	for _, params := range [][]Parameter{
+		{{ParamP, 5}, {"kp", 1}},
+		{{ParamOutputMin, 50}, {ParamOutputMax, 10}},
+	} {
+		if r := b.SetParameters(params); r.Successful || r.Reason == "" {
+			t.Errorf("%v: accepted", params)
+		}
+	}
+	if p, _, _ := c.PID(); p != 1 {
+		t.Errorf("p changed to %v", p)
+	}
+	if min, max := c.OutputLimits(); min != 0 || max != 100 {
+		t.Errorf("limits changed to %v %v", min, max)
+	}
+}
+
+func TestBridge_Timestep(t *testing.T) {
+	node := newFakeNode()
+	b := &Bridge{Node: node, Controller: pidctrl.NewSafePIDController(1, 0, 0)}
+	start := time.Unix(100, 0)
+	b.Publish(start)
+	b.Publish(start.Add(250 * time.Millisecond))
+	if s, _ := node.state(TopicState); s.Timestep != 250*time.Millisecond {
+		t.Errorf("timestep %v", s.Timestep)
+	}
+}
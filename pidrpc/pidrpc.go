@@ -0,0 +1,226 @@
+// Package pidrpc provides PIDService, a remote management service for the
+// controllers of a registry, e.g. for controllers embedded in a headless
+// device. The service lists the loops, gets and sets their gains, sets
+// setpoints and reports their state; Client is its Go client and streams
+// the state of a loop.
+//
+// The service uses net/rpc with its default gob encoding, so neither the
+// device nor the client needs dependencies beyond the standard library:
+//
+//	l, err := net.Listen("tcp", ":9090")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	go pidrpc.NewServer(registry).Accept(l)
+//
+//	c, err := pidrpc.Dial("tcp", "device:9090")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	err = c.SetGains("mash", pidctrl.Gains{P: 2, I: 0.1})
+package pidrpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net/rpc"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+// ServiceName is the net/rpc name of the service.
+const ServiceName = "PIDService"
+
+// ErrUnknownLoop is returned for names without a registered controller.
+var ErrUnknownLoop = errors.New("pidrpc: unknown loop")
+
+// State is the live state of a loop.
+type State struct {
+	Setpoint float64
+	Output   float64
+	Terms    pidctrl.Terms
+	Mode     pidctrl.ControlMode
+	Status   pidctrl.LoopStatus
+}
+
+// GainsArgs are the arguments of PIDService.SetGains.
+type GainsArgs struct {
+	Name  string
+	Gains pidctrl.Gains
+}
+
+// SetpointArgs are the arguments of PIDService.SetSetpoint.
+type SetpointArgs struct {
+	Name     string
+	Setpoint float64
+}
+
+// Service implements PIDService for the controllers of a registry. Its
+// methods are called through net/rpc.
+type Service struct {
+	registry *pidctrl.Registry
+}
+
+// NewServer returns an rpc.Server serving PIDService for the controllers of
+// r.
+func NewServer(r *pidctrl.Registry) *rpc.Server {
+	s := rpc.NewServer()
+	if err := s.RegisterName(ServiceName, &Service{registry: r}); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (s *Service) get(name string) (*pidctrl.SafePIDController, error) {
+	c, ok := s.registry.Get(name)
+	if !ok {
+		return nil, ErrUnknownLoop
+	}
+	return c, nil
+}
+
+// List returns the names of all loops.
+func (s *Service) List(_ struct{}, names *[]string) error {
+	*names = s.registry.Names()
+	return nil
+}
+
+// Gains returns the gains of the named loop.
+func (s *Service) Gains(name string, g *pidctrl.Gains) error {
+	c, err := s.get(name)
+	if err != nil {
+		return err
+	}
+	c.Do(func(c *pidctrl.PIDController) { *g = c.Gains() })
+	return nil
+}
+
+// SetGains changes the gains of a loop like ApplySettings. Non-finite gains
+// are rejected with a pidctrl.NonFiniteError.
+func (s *Service) SetGains(args GainsArgs, _ *struct{}) error {
+	c, err := s.get(args.Name)
+	if err != nil {
+		return err
+	}
+	for _, g := range []struct {
+		name string
+		v    float64
+	}{{"p", args.Gains.P}, {"i", args.Gains.I}, {"d", args.Gains.D}} {
+		if math.IsNaN(g.v) || math.IsInf(g.v, 0) {
+			return pidctrl.NonFiniteError{Name: g.name, Value: g.v}
+		}
+	}
+	return c.ApplySettings(pidctrl.Settings{Gains: &args.Gains})
+}
+
+// SetSetpoint changes the setpoint of a loop like ApplySettings. A
+// non-finite setpoint is rejected with a pidctrl.NonFiniteError.
+func (s *Service) SetSetpoint(args SetpointArgs, _ *struct{}) error {
+	c, err := s.get(args.Name)
+	if err != nil {
+		return err
+	}
+	if math.IsNaN(args.Setpoint) || math.IsInf(args.Setpoint, 0) {
+		return pidctrl.NonFiniteError{Name: "setpoint", Value: args.Setpoint}
+	}
+	return c.ApplySettings(pidctrl.Settings{Setpoint: &args.Setpoint})
+}
+
+// State returns the state of the named loop.
+func (s *Service) State(name string, st *State) error {
+	c, err := s.get(name)
+	if err != nil {
+		return err
+	}
+	c.Do(func(c *pidctrl.PIDController) {
+		*st = State{Setpoint: c.Get(), Output: c.Output(), Terms: c.Terms(), Mode: c.Mode(), Status: c.LoopStatus()}
+	})
+	st.Status.Name = name
+	return nil
+}
+
+// Client is a client of PIDService.
+type Client struct {
+	c *rpc.Client
+}
+
+// Dial connects to a PIDService at the given address.
+func Dial(network, address string) (*Client, error) {
+	c, err := rpc.Dial(network, address)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{c: c}, nil
+}
+
+// NewClient returns a client of the PIDService at the other end of conn.
+func NewClient(conn io.ReadWriteCloser) *Client {
+	return &Client{c: rpc.NewClient(conn)}
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	return c.c.Close()
+}
+
+func (c *Client) call(method string, args, reply any) error {
+	err := c.c.Call(ServiceName+"."+method, args, reply)
+	if err != nil && err.Error() == ErrUnknownLoop.Error() {
+		return ErrUnknownLoop
+	}
+	return err
+}
+
+// List returns the names of all loops.
+func (c *Client) List() ([]string, error) {
+	var names []string
+	err := c.call("List", struct{}{}, &names)
+	return names, err
+}
+
+// Gains returns the gains of the named loop.
+func (c *Client) Gains(name string) (pidctrl.Gains, error) {
+	var g pidctrl.Gains
+	err := c.call("Gains", name, &g)
+	return g, err
+}
+
+// SetGains changes the gains of the named loop.
+func (c *Client) SetGains(name string, g pidctrl.Gains) error {
+	return c.call("SetGains", GainsArgs{name, g}, &struct{}{})
+}
+
+// SetSetpoint changes the setpoint of the named loop.
+func (c *Client) SetSetpoint(name string, setpoint float64) error {
+	return c.call("SetSetpoint", SetpointArgs{name, setpoint}, &struct{}{})
+}
+
+// State returns the state of the named loop.
+func (c *Client) State(name string) (State, error) {
+	var st State
+	err := c.call("State", name, &st)
+	return st, err
+}
+
+// StreamState calls f with the state of the named loop every interval until
+// ctx is done or a call fails. It returns the error of the failed call or
+// the error of ctx.
+func (c *Client) StreamState(ctx context.Context, name string, interval time.Duration, f func(State)) error {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		st, err := c.State(name)
+		if err != nil {
+			return err
+		}
+		f(st)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package pidrpc
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+func connect(t *testing.T, r *pidctrl.Registry) *Client {
+	server, client := net.Pipe()
+	go NewServer(r).ServeConn(server)
+	c := NewClient(client)
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func TestClient(t *testing.T) {
+	r := pidctrl.NewRegistry()
+	mash := pidctrl.NewSafePIDController(1, 0, 0).Set(66)
+	r.Register("mash", mash)
+	r.Register("boil", pidctrl.NewSafePIDController(1, 0, 0))
+	c := connect(t, r)
+
+	if names, err := c.List(); err != nil || len(names) != 2 || names[0] != "boil" || names[1] != "mash" {
+		t.Errorf("List() = %v, %v", names, err)
+	}
+	if err := c.SetGains("mash", pidctrl.Gains{P: 2, I: 0.1}); err != nil {
+		t.Fatal(err)
+	}
+	if g, err := c.Gains("mash"); err != nil || g != (pidctrl.Gains{P: 2, I: 0.1}) {
+		t.Errorf("Gains() = %v, %v", g, err)
+	}
+	if err := c.SetSetpoint("mash", 70); err != nil || mash.Get() != 70 {
+		t.Errorf("setpoint %v, %v", mash.Get(), err)
+	}
+	mash.UpdateDuration(65, time.Second)
+	st, err := c.State("mash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Setpoint != 70 || st.Output != 10.5 || st.Terms.P != 10 || st.Mode != pidctrl.ModeAuto || st.Status.Name != "mash" || !st.Status.Auto {
+		t.Errorf("State() = %+v", st)
+	}
+
+	if _, err := c.Gains("mash tun"); !errors.Is(err, ErrUnknownLoop) {
+		t.Errorf("unknown loop: %v", err)
+	}
+	if err := c.SetGains("mash", pidctrl.Gains{P: math.NaN()}); err == nil {
+		t.Error("no error for invalid gains")
+	}
+	if err := c.SetSetpoint("mash", math.Inf(1)); err == nil || mash.Get() != 70 {
+		t.Errorf("infinite setpoint: %v, setpoint %v", err, mash.Get())
+	}
+}
+
+func TestClient_StreamState(t *testing.T) {
+	r := pidctrl.NewRegistry()
+	mash := pidctrl.NewSafePIDController(1, 0, 0).Set(66)
+	r.Register("mash", mash)
+	c := connect(t, r)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var outputs []float64
+	err := c.StreamState(ctx, "mash", time.Millisecond, func(st State) {
+		outputs = append(outputs, st.Output)
+		mash.UpdateDuration(60+float64(len(outputs)), time.Second)
+		if len(outputs) == 3 {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("stream ended with %v", err)
+	}
+	if len(outputs) != 3 || outputs[0] != 0 || outputs[1] != 5 || outputs[2] != 4 {
+		t.Errorf("streamed outputs %v", outputs)
+	}
+	if err := c.StreamState(context.Background(), "boil", time.Millisecond, func(State) {}); !errors.Is(err, ErrUnknownLoop) {
+		t.Errorf("unknown loop: %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+// Package pidtest provides helpers asserting invariants of pidctrl
+// controllers under random inputs. They are meant for the tests of
+// downstream projects validating their configurations, and for forks
+// validating behavior parity.
+package pidtest
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+// Factory returns a freshly configured controller. The checks call it as
+// often as they need independent controllers.
+type Factory func() *pidctrl.PIDController
+
+// DefaultUpdates is the number of random updates run by CheckAll.
+const DefaultUpdates = 1000
+
+// input is a random update.
+type input struct {
+	setpoint float64
+	value    float64
+	duration time.Duration
+}
+
+func randomInputs(rng *rand.Rand, n int) []input {
+	inputs := make([]input, n)
+	sp := rng.NormFloat64() * 100
+	for i := range inputs {
+		if rng.Intn(20) == 0 {
+			sp = rng.NormFloat64() * 100
+		}
+		inputs[i] = input{
+			setpoint: sp,
+			value:    sp + rng.NormFloat64()*50,
+			duration: time.Duration(rng.Int63n(int64(2 * time.Second))),
+		}
+	}
+	return inputs
+}
+
+// CheckOutputLimits asserts that the output never leaves the output limits.
+func CheckOutputLimits(tb testing.TB, f Factory, rng *rand.Rand, n int) {
+	tb.Helper()
+	c := f()
+	min, max := c.OutputLimits()
+	for i, in := range randomInputs(rng, n) {
+		out := c.Set(in.setpoint).UpdateDuration(in.value, in.duration)
+		if out < min || out > max {
+			tb.Errorf("update %d: output %v outside limits [%v, %v] (%+v)", i, out, min, max, in)
+			return
+		}
+	}
+}
+
+// CheckFinite asserts that finite inputs never produce NaN or infinite
+// outputs.
+func CheckFinite(tb testing.TB, f Factory, rng *rand.Rand, n int) {
+	tb.Helper()
+	c := f()
+	for i, in := range randomInputs(rng, n) {
+		out := c.Set(in.setpoint).UpdateDuration(in.value, in.duration)
+		if math.IsNaN(out) || math.IsInf(out, 0) {
+			tb.Errorf("update %d: non-finite output %v (%+v)", i, out, in)
+			return
+		}
+	}
+}
+
+// CheckMonotone asserts that the output of a controller without integral and
+// derivative action is a monotonically non-decreasing function of the error.
+// The controller returned by f must have I and D gains of zero and a
+// non-negative P gain.
+func CheckMonotone(tb testing.TB, f Factory, rng *rand.Rand, n int) {
+	tb.Helper()
+	errs := make([]float64, n)
+	for i := range errs {
+		errs[i] = rng.NormFloat64() * 100
+	}
+	sort.Float64s(errs)
+	prev := math.Inf(-1)
+	for i, e := range errs {
+		out := f().UpdateDuration(-e, time.Second)
+		if out < prev {
+			tb.Errorf("error %v: output %v decreased from %v at index %d", e, out, prev, i)
+			return
+		}
+		prev = out
+	}
+}
+
+// CheckBumpless asserts that changing the integral gain between two updates
+// does not step the output.
+func CheckBumpless(tb testing.TB, f Factory, rng *rand.Rand, n int) {
+	tb.Helper()
+	a, b := f(), f()
+	for i, in := range randomInputs(rng, n) {
+		a.Set(in.setpoint).UpdateDuration(in.value, in.duration)
+		b.Set(in.setpoint).UpdateDuration(in.value, in.duration)
+		if i%10 != 0 {
+			continue
+		}
+		p, ki, d := b.PID()
+		b.SetPID(p, ki*(0.5+rng.Float64()), d)
+		outA := a.UpdateDuration(in.value, 0)
+		outB := b.UpdateDuration(in.value, 0)
+		if math.Abs(outA-outB) > 1e-9*math.Max(1, math.Abs(outA)) {
+			tb.Errorf("update %d: integral gain change stepped output from %v to %v", i, outA, outB)
+			return
+		}
+		b.SetPID(a.PID())
+	}
+}
+
+// CheckAll runs all checks applicable to the controllers returned by f with
+// a random source seeded with seed.
+func CheckAll(tb testing.TB, f Factory, seed int64) {
+	tb.Helper()
+	CheckOutputLimits(tb, f, rand.New(rand.NewSource(seed)), DefaultUpdates)
+	CheckFinite(tb, f, rand.New(rand.NewSource(seed)), DefaultUpdates)
+	CheckBumpless(tb, f, rand.New(rand.NewSource(seed)), DefaultUpdates)
+	if p, i, d := f().PID(); i == 0 && d == 0 && p >= 0 {
+		CheckMonotone(tb, f, rand.New(rand.NewSource(seed)), DefaultUpdates)
+	}
+}
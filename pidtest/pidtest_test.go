@@ -0,0 +1,26 @@
+package pidtest
+
+import (
+	"testing"
+
+	"github.com/felixge/pidctrl"
+)
+
+func TestCheckAll(t *testing.T) {
+	for name, f := range map[string]Factory{
+		"p":       func() *pidctrl.PIDController { return pidctrl.NewPIDController(0.5, 0, 0) },
+		"pid":     func() *pidctrl.PIDController { return pidctrl.NewPIDController(0.6, 1.2, 0.075) },
+		"limited": func() *pidctrl.PIDController { return pidctrl.NewPIDController(40, 0.5, 12).SetOutputLimits(0, 255) },
+		"ramped":  func() *pidctrl.PIDController { return pidctrl.NewPIDController(1, 0.1, 0).SetSetpointRamp(5) },
+		"approach": func() *pidctrl.PIDController {
+			return pidctrl.NewPIDController(1, 0.1, 0).SetApproachGains(4, 0, 0, 10)
+		},
+		"estimated": func() *pidctrl.PIDController {
+			return pidctrl.NewPIDController(1, 0.1, 0.5).SetEstimator(pidctrl.NewKalmanFilter(1, 1))
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			CheckAll(t, f, 1)
+		})
+	}
+}
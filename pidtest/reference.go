@@ -0,0 +1,87 @@
+package pidtest
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+// Reference is a deliberately straightforward textbook PID controller with
+// derivative on measurement and integral clamping. It is the oracle of
+// CheckReference and CheckIntegerReference and favors readability over
+// speed.
+type Reference struct {
+	P, I, D  float64
+	Min, Max float64
+
+	integral  float64
+	prevValue float64
+}
+
+// Update returns the output for the given setpoint, process value and time
+// step in seconds.
+func (r *Reference) Update(setpoint, value, dt float64) float64 {
+	err := setpoint - value
+
+	r.integral = r.integral + r.I*err*dt
+	r.integral = math.Min(r.integral, r.Max)
+	r.integral = math.Max(r.integral, r.Min)
+
+	derivative := 0.0
+	if dt > 0 {
+		derivative = -(value - r.prevValue) / dt
+	}
+	r.prevValue = value
+
+	output := r.P*err + r.integral + r.D*derivative
+	output = math.Min(output, r.Max)
+	output = math.Max(output, r.Min)
+	return output
+}
+
+// CheckReference asserts that the controllers returned by f agree with
+// Reference within tol, relative to the magnitude of the output, on random
+// inputs. The controllers must not use features beyond gains and output
+// limits, but any internal optimizations must preserve the result.
+func CheckReference(tb testing.TB, f Factory, rng *rand.Rand, n int, tol float64) {
+	tb.Helper()
+	c := f()
+	p, i, d := c.PID()
+	min, max := c.OutputLimits()
+	ref := &Reference{P: p, I: i, D: d, Min: min, Max: max}
+	for k, in := range randomInputs(rng, n) {
+		want := ref.Update(in.setpoint, in.value, in.duration.Seconds())
+		got := c.Set(in.setpoint).UpdateDuration(in.value, in.duration)
+		if math.Abs(got-want) > tol*math.Max(1, math.Abs(want)) {
+			tb.Errorf("update %d: output %v differs from reference %v (%+v)", k, got, want, in)
+			return
+		}
+	}
+}
+
+// CheckIntegerReference asserts that an IntegerPIDController with the given
+// scaled gains and output limits agrees with Reference within tol output
+// counts on random integer inputs. Rounding in the fixed-point integral
+// accumulates, so tol should grow with n.
+func CheckIntegerReference(tb testing.TB, p, i, d, min, max int64, rng *rand.Rand, n int, tol float64) {
+	tb.Helper()
+	c := pidctrl.NewIntegerPIDController(p, i, d).SetOutputLimits(min, max)
+	scale := float64(pidctrl.INTPID_SCALE)
+	ref := &Reference{
+		P: float64(p) / scale, I: float64(i) / scale, D: float64(d) / scale,
+		Min: float64(min), Max: float64(max),
+	}
+	for k, in := range randomInputs(rng, n) {
+		sp, v := math.Round(in.setpoint), math.Round(in.value)
+		dur := in.duration.Truncate(time.Microsecond)
+		want := ref.Update(sp, v, dur.Seconds())
+		got := c.Set(int64(sp)).UpdateDuration(int64(v), dur)
+		if math.Abs(float64(got)-want) > tol {
+			tb.Errorf("update %d: output %d differs from reference %v (%+v)", k, got, want, in)
+			return
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package pidtest
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/felixge/pidctrl"
+)
+
+// fakeTB records failures instead of failing the test.
+type fakeTB struct {
+	testing.TB
+	failed bool
+}
+
+func (f *fakeTB) Errorf(string, ...interface{}) { f.failed = true }
+
+func TestCheckReference(t *testing.T) {
+	CheckReference(t, func() *pidctrl.PIDController {
+		return pidctrl.NewPIDController(1.5, 0.8, 0.05).SetOutputLimits(-100, 100)
+	}, rand.New(rand.NewSource(1)), DefaultUpdates, 1e-9)
+}
+
+func TestCheckIntegerReference(t *testing.T) {
+	CheckIntegerReference(t, 1500, 800, 50, -100, 100, rand.New(rand.NewSource(1)), DefaultUpdates, 2)
+}
+
+func TestCheckReference_Detects(t *testing.T) {
+	ft := &fakeTB{TB: t}
+	CheckReference(ft, func() *pidctrl.PIDController {
+		return pidctrl.NewPIDController(1, 1, 0).SetOutputLimits(-100, 100).SetSetpointRamp(1)
+	}, rand.New(rand.NewSource(1)), DefaultUpdates, 1e-9)
+	if !ft.failed {
+		t.Error("setpoint ramp not detected as deviation")
+	}
+}
@@ -0,0 +1,88 @@
+package pidtest
+
+import (
+	"math"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+// SoakOptions configures Soak.
+type SoakOptions struct {
+	// Duration is the simulated run time, e.g. several months.
+	Duration time.Duration
+	// Step is the simulated sample interval.
+	Step time.Duration
+	// MaxHeapGrowth is the allowed growth of the live heap in bytes over the
+	// whole run.
+	MaxHeapGrowth uint64
+}
+
+// DefaultSoakOptions simulates 90 days at one update per second.
+var DefaultSoakOptions = SoakOptions{
+	Duration:      90 * 24 * time.Hour,
+	Step:          time.Second,
+	MaxHeapGrowth: 1 << 20,
+}
+
+// Soak runs a controller returned by f in closed loop with a first order
+// plant (unity gain, 60 s time constant) for opts.Duration of simulated time.
+// It asserts that the live heap stays bounded and that updates timed by the
+// controller's clock yield exactly the same outputs as updates with
+// explicitly supplied durations, i.e. that timestamp handling does not drift.
+// f must not install a clock, Soak installs its own.
+func Soak(tb testing.TB, f Factory, opts SoakOptions) {
+	tb.Helper()
+	clock := pidctrl.NewManualClock(time.Date(2016, 3, 2, 0, 0, 0, 0, time.UTC))
+	timed := f().SetClock(clock)
+	explicit := f()
+	timed.Update(0)
+	explicit.UpdateDuration(0, 0)
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	alpha := opts.Step.Seconds() / 60
+	var pvA, pvB float64
+	steps := int64(opts.Duration / opts.Step)
+	for n := int64(0); n < steps; n++ {
+		clock.Advance(opts.Step)
+		outA := timed.Update(pvA)
+		outB := explicit.UpdateDuration(pvB, opts.Step)
+		if outA != outB && !(math.IsNaN(outA) && math.IsNaN(outB)) {
+			tb.Errorf("update %d (%v simulated): clock timed output %v drifted from %v", n, time.Duration(n)*opts.Step, outA, outB)
+			return
+		}
+		pvA += (outA - pvA) * alpha
+		pvB += (outB - pvB) * alpha
+	}
+
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	if after.HeapAlloc > before.HeapAlloc && after.HeapAlloc-before.HeapAlloc > opts.MaxHeapGrowth {
+		tb.Errorf("heap grew by %d bytes over %v simulated", after.HeapAlloc-before.HeapAlloc, opts.Duration)
+	}
+}
+
+// SoakInteger runs an IntegerPIDController with integral gain only against a
+// constant error for opts.Duration of simulated time and asserts that the
+// fixed-point integral accumulates without any loss of precision. The step
+// must be a whole number of microseconds.
+func SoakInteger(tb testing.TB, i, err int64, opts SoakOptions) {
+	tb.Helper()
+	c := pidctrl.NewIntegerPIDController(0, i, 0).Set(err)
+	steps := int64(opts.Duration / opts.Step)
+	micros := int64(opts.Step / time.Microsecond)
+	var output int64
+	for n := int64(0); n < steps; n++ {
+		output = c.UpdateDuration(0, opts.Step)
+	}
+	// the integral is truncated once per step
+	want := steps * (err * i * micros / 1e6) / pidctrl.INTPID_SCALE
+	if output != want {
+		tb.Errorf("fixed-point integral after %v simulated: %d != %d", opts.Duration, output, want)
+	}
+}
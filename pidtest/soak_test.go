@@ -0,0 +1,26 @@
+package pidtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+func soakOptions() SoakOptions {
+	opts := DefaultSoakOptions
+	if testing.Short() {
+		opts.Duration = 24 * time.Hour
+	}
+	return opts
+}
+
+func TestSoak(t *testing.T) {
+	Soak(t, func() *pidctrl.PIDController {
+		return pidctrl.NewPIDController(2, 0.05, 1).SetOutputLimits(0, 100).Set(50)
+	}, soakOptions())
+}
+
+func TestSoakInteger(t *testing.T) {
+	SoakInteger(t, 7, 3, soakOptions())
+}
@@ -0,0 +1,40 @@
+package pidctrl
+
+import "context"
+
+// Pipe connects the controller to a channel based pipeline. Setpoints
+// received on setpoints are applied with Set, every process value received on
+// values is passed to Update and the resulting output is sent on the returned
+// channel. setpoints may be nil. The output channel is closed once values is
+// closed or ctx is cancelled, so downstream stages can range over it.
+//
+// The controller must not be used by other goroutines while the pipeline
+// runs.
+func (c *PIDController) Pipe(ctx context.Context, setpoints <-chan float64, values <-chan float64) <-chan float64 {
+	out := make(chan float64)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sp, ok := <-setpoints:
+				if !ok {
+					setpoints = nil
+					continue
+				}
+				c.Set(sp)
+			case v, ok := <-values:
+				if !ok {
+					return
+				}
+				select {
+				case out <- c.Update(v):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,37 @@
+package pidctrl
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPIDController_Pipe(t *testing.T) {
+	c := NewPIDController(2, 0, 0)
+	setpoints := make(chan float64)
+	values := make(chan float64)
+	out := c.Pipe(context.Background(), setpoints, values)
+
+	setpoints <- 10
+	values <- 4
+	if v := <-out; v != 12 {
+		t.Errorf("output %f != 12", v)
+	}
+	close(setpoints)
+	values <- 6
+	if v := <-out; v != 8 {
+		t.Errorf("output %f != 8", v)
+	}
+	close(values)
+	if _, ok := <-out; ok {
+		t.Error("output channel not closed")
+	}
+}
+
+func TestPIDController_PipeCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	out := NewPIDController(1, 0, 0).Pipe(ctx, nil, make(chan float64))
+	cancel()
+	if _, ok := <-out; ok {
+		t.Error("output channel not closed after cancel")
+	}
+}
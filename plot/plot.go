@@ -0,0 +1,236 @@
+// Package plot renders controller traces recorded by a pidctrl.Recorder or a
+// sim.Harness run as SVG or PNG charts for reports. The chart has two
+// panels sharing the time axis: setpoint and process value on top, the
+// output below.
+//
+//	rec := pidctrl.NewRecorder(3600)
+//	// ... run the loop, calling rec.Record(c) after every update
+//	plot.SVG(f, rec.Records(), plot.Options{Title: "mash tun"})
+package plot
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+// Options configures a chart.
+type Options struct {
+	Title  string
+	Width  int // in pixels, 800 if 0
+	Height int // in pixels, 480 if 0
+}
+
+var (
+	setpointColor = color.RGBA{0x2c, 0xa0, 0x2c, 0xff}
+	valueColor    = color.RGBA{0x1f, 0x77, 0xb4, 0xff}
+	outputColor   = color.RGBA{0xd6, 0x27, 0x28, 0xff}
+	axisColor     = color.RGBA{0x99, 0x99, 0x99, 0xff}
+)
+
+const margin = 40 // pixels around and between the panels
+
+// panel is a plot area with its value range.
+type panel struct {
+	x, y, w, h float64 // pixels
+	min, max   float64
+}
+
+func (p panel) py(v float64) float64 {
+	return p.y + p.h - (v-p.min)/(p.max-p.min)*p.h
+}
+
+// layout computes the panels and the time range of a chart.
+type layout struct {
+	width, height int
+	top, bottom   panel
+	start, span   time.Duration
+}
+
+func newLayout(recs []pidctrl.Record, opts Options) layout {
+	l := layout{width: opts.Width, height: opts.Height}
+	if l.width <= 0 {
+		l.width = 800
+	}
+	if l.height <= 0 {
+		l.height = 480
+	}
+	w := float64(l.width - 2*margin)
+	h := (float64(l.height) - 3*margin) / 2
+	l.top = panel{x: margin, y: margin, w: w, h: h * 3 / 2}
+	l.bottom = panel{x: margin, y: 2*margin + h*3/2, w: w, h: h / 2}
+	l.top.min, l.top.max = math.Inf(1), math.Inf(-1)
+	l.bottom.min, l.bottom.max = math.Inf(1), math.Inf(-1)
+	for _, r := range recs {
+		l.top.min = math.Min(l.top.min, math.Min(r.Setpoint, r.Value))
+		l.top.max = math.Max(l.top.max, math.Max(r.Setpoint, r.Value))
+		l.bottom.min = math.Min(l.bottom.min, r.Output)
+		l.bottom.max = math.Max(l.bottom.max, r.Output)
+	}
+	for _, p := range []*panel{&l.top, &l.bottom} {
+		if math.IsInf(p.min, 0) || math.IsNaN(p.min) || math.IsNaN(p.max) {
+			p.min, p.max = 0, 1
+		}
+		if p.max == p.min {
+			p.min, p.max = p.min-1, p.max+1
+		}
+	}
+	if len(recs) > 1 {
+		l.span = recs[len(recs)-1].Time.Sub(recs[0].Time)
+	}
+	if l.span <= 0 {
+		l.span = time.Second
+	}
+	return l
+}
+
+func (l layout) px(recs []pidctrl.Record, i int) float64 {
+	return l.top.x + float64(recs[i].Time.Sub(recs[0].Time))/float64(l.span)*l.top.w
+}
+
+// trace is a series of a chart and the panel it is drawn in.
+type trace struct {
+	panel panel
+	color color.RGBA
+	name  string
+	value func(pidctrl.Record) float64
+}
+
+func (l layout) traces() []trace {
+	return []trace{
+		{l.top, setpointColor, "setpoint", func(r pidctrl.Record) float64 { return r.Setpoint }},
+		{l.top, valueColor, "value", func(r pidctrl.Record) float64 { return r.Value }},
+		{l.bottom, outputColor, "output", func(r pidctrl.Record) float64 { return r.Output }},
+	}
+}
+
+// SVG writes a chart of the records to w.
+func SVG(w io.Writer, recs []pidctrl.Record, opts Options) error {
+	l := newLayout(recs, opts)
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`+"\n", l.width, l.height, l.width, l.height)
+	fmt.Fprintf(b, `<rect width="100%%" height="100%%" fill="white"/>`+"\n")
+	if opts.Title != "" {
+		fmt.Fprintf(b, `<text x="%d" y="%d" text-anchor="middle" font-size="14">%s</text>`+"\n", l.width/2, margin/2+5, html.EscapeString(opts.Title))
+	}
+	for _, p := range []panel{l.top, l.bottom} {
+		fmt.Fprintf(b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="none" stroke="%s"/>`+"\n", p.x, p.y, p.w, p.h, hex(axisColor))
+		fmt.Fprintf(b, `<text x="%.1f" y="%.1f" text-anchor="end">%.4g</text>`+"\n", p.x-3, p.y+10, p.max)
+		fmt.Fprintf(b, `<text x="%.1f" y="%.1f" text-anchor="end">%.4g</text>`+"\n", p.x-3, p.y+p.h, p.min)
+	}
+	fmt.Fprintf(b, `<text x="%.1f" y="%.1f">0s</text>`+"\n", l.bottom.x, l.bottom.y+l.bottom.h+14)
+	fmt.Fprintf(b, `<text x="%.1f" y="%.1f" text-anchor="end">%v</text>`+"\n", l.bottom.x+l.bottom.w, l.bottom.y+l.bottom.h+14, l.span)
+	for n, s := range l.traces() {
+		fmt.Fprintf(b, `<text x="%.1f" y="%.1f" fill="%s">%s</text>`+"\n", l.top.x+float64(n)*70, l.top.y-5, hex(s.color), s.name)
+		if len(recs) == 0 {
+			continue
+		}
+		fmt.Fprintf(b, `<polyline fill="none" stroke="%s" stroke-width="1.5" points="`, hex(s.color))
+		for i, r := range recs {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			fmt.Fprintf(b, "%.1f,%.1f", l.px(recs, i), s.panel.py(s.value(r)))
+		}
+		b.WriteString(`"/>` + "\n")
+	}
+	b.WriteString("</svg>\n")
+	return b.Flush()
+}
+
+// PNG writes a chart of the records to w. PNG charts carry no text, use SVG
+// for labelled charts.
+func PNG(w io.Writer, recs []pidctrl.Record, opts Options) error {
+	l := newLayout(recs, opts)
+	img := image.NewRGBA(image.Rect(0, 0, l.width, l.height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for _, p := range []panel{l.top, l.bottom} {
+		x0, y0, x1, y1 := p.x, p.y, p.x+p.w, p.y+p.h
+		line(img, x0, y0, x1, y0, axisColor)
+		line(img, x1, y0, x1, y1, axisColor)
+		line(img, x1, y1, x0, y1, axisColor)
+		line(img, x0, y1, x0, y0, axisColor)
+	}
+	for _, s := range l.traces() {
+		for i := 1; i < len(recs); i++ {
+			line(img, l.px(recs, i-1), s.panel.py(s.value(recs[i-1])), l.px(recs, i), s.panel.py(s.value(recs[i])), s.color)
+		}
+	}
+	return png.Encode(w, img)
+}
+
+// File writes a chart of the records to the named file, as PNG if the name
+// ends in .png and as SVG otherwise.
+func File(name string, recs []pidctrl.Record, opts Options) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	write := SVG
+	if strings.EqualFold(filepath.Ext(name), ".png") {
+		write = PNG
+	}
+	if err := write(f, recs, opts); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// line draws a line with Bresenham's algorithm.
+func line(img *image.RGBA, fx0, fy0, fx1, fy1 float64, c color.RGBA) {
+	for _, v := range []float64{fx0, fy0, fx1, fy1} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return
+		}
+	}
+	x0, y0, x1, y1 := int(math.Round(fx0)), int(math.Round(fy0)), int(math.Round(fx1)), int(math.Round(fy1))
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	e := dx + dy
+	for {
+		img.SetRGBA(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func hex(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -0,0 +1,117 @@
+package plot
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/felixge/pidctrl"
+	"github.com/felixge/pidctrl/sim"
+)
+
+func records(t *testing.T) []pidctrl.Record {
+	t.Helper()
+	rec := pidctrl.NewRecorder(100)
+	h := &sim.Harness{
+		Controller: pidctrl.NewPIDController(2, 0.5, 0).SetOutputLimits(0, 100).Set(10),
+		Plant:      sim.NewFirstOrder(1, 5*time.Second),
+		Duration:   30 * time.Second,
+		Recorder:   rec,
+		Clock:      pidctrl.NewManualClock(time.Unix(0, 0)),
+	}
+	h.Run()
+	return rec.Records()
+}
+
+func TestSVG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := SVG(&buf, records(t), Options{Title: "tank <1>"}); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Width int      `xml:"width,attr"`
+		Texts []string `xml:"text"`
+	}
+	dec := xml.NewDecoder(&buf)
+	if err := dec.Decode(&doc); err != nil {
+		t.Fatalf("invalid SVG: %v", err)
+	}
+	if doc.Width != 800 {
+		t.Errorf("width %d", doc.Width)
+	}
+	if doc.Texts[0] != "tank <1>" {
+		t.Errorf("title %q", doc.Texts[0])
+	}
+	if !strings.Contains(strings.Join(doc.Texts, " "), "30s") {
+		t.Errorf("time axis not labelled: %q", doc.Texts)
+	}
+
+	// an empty chart is still valid
+	buf.Reset()
+	if err := SVG(&buf, nil, Options{}); err != nil || xml.NewDecoder(&buf).Decode(new(struct{})) != nil {
+		t.Errorf("empty chart: %v %s", err, buf.String())
+	}
+}
+
+func TestSVG_Polylines(t *testing.T) {
+	var buf bytes.Buffer
+	recs := records(t)
+	SVG(&buf, recs, Options{Width: 400, Height: 300})
+	if n := strings.Count(buf.String(), "<polyline"); n != 3 {
+		t.Errorf("%d polylines, want 3", n)
+	}
+	if n := strings.Count(buf.String(), ","); n < 3*len(recs) {
+		t.Errorf("%d points, want %d", n, 3*len(recs))
+	}
+}
+
+func TestPNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := PNG(&buf, records(t), Options{Width: 320, Height: 200}); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 320 || b.Dy() != 200 {
+		t.Errorf("size %v", b)
+	}
+	// some pixels of each trace are drawn
+	found := map[[3]uint32]bool{}
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 320; x++ {
+			r, g, b, _ := img.At(x, y).RGBA()
+			found[[3]uint32{r >> 8, g >> 8, b >> 8}] = true
+		}
+	}
+	for _, c := range [...][3]uint32{
+		{uint32(setpointColor.R), uint32(setpointColor.G), uint32(setpointColor.B)},
+		{uint32(valueColor.R), uint32(valueColor.G), uint32(valueColor.B)},
+		{uint32(outputColor.R), uint32(outputColor.G), uint32(outputColor.B)},
+	} {
+		if !found[c] {
+			t.Errorf("color %v not drawn", c)
+		}
+	}
+}
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	recs := records(t)
+	for _, name := range []string{"run.svg", "run.PNG"} {
+		path := filepath.Join(dir, name)
+		if err := File(path, recs, Options{}); err != nil {
+			t.Fatal(err)
+		}
+		data, _ := os.ReadFile(path)
+		if isPNG := bytes.HasPrefix(data, []byte("\x89PNG")); isPNG != strings.HasSuffix(name, "PNG") {
+			t.Errorf("%s: wrong format", name)
+		}
+	}
+}
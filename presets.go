@@ -0,0 +1,114 @@
+package pidctrl
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// NamespacePresets is the Store namespace of tuning presets.
+const NamespacePresets = "presets"
+
+// Preset is a named set of tuning parameters, e.g. a "summer" and a "winter"
+// tuning, that can be saved and applied at runtime.
+type Preset struct {
+	Gains           Gains          `json:"gains"`
+	OutputLimits    *Limits        `json:"output_limits,omitempty"`   // nil is unbounded
+	IntegralLimits  *Limits        `json:"integral_limits,omitempty"` // nil bounds the integral by the output limits
+	Discretization  Discretization `json:"discretization"`
+	OutputSmoothing time.Duration  `json:"output_smoothing,omitempty"`
+	Deadband        float64        `json:"deadband,omitempty"`
+}
+
+// Preset returns the current tuning of the controller.
+func (c *PIDController) Preset() Preset {
+	p := Preset{
+		Gains:           c.Gains(),
+		OutputLimits:    &Limits{Min: finiteOrNil(c.outMin), Max: finiteOrNil(c.outMax)},
+		Discretization:  c.disc,
+		OutputSmoothing: c.smoothing,
+		Deadband:        c.deadband,
+	}
+	if c.iLimits {
+		p.IntegralLimits = &Limits{Min: finiteOrNil(c.iMin), Max: finiteOrNil(c.iMax)}
+	}
+	return p
+}
+
+// ApplyPreset changes the tuning to p. Gain and limit changes of a running
+// controller are bumpless, see SetBumpless, whether or not bumpless retuning
+// is enabled. Nothing is changed if a limit pair is swapped.
+func (c *PIDController) ApplyPreset(p Preset) error {
+	min, max := p.OutputLimits.limits()
+	if min > max {
+		return MinMaxError{min, max}
+	}
+	iMin, iMax := p.IntegralLimits.limits()
+	if iMin > iMax {
+		return MinMaxError{iMin, iMax}
+	}
+	bumpless := c.bumpless
+	c.bumpless = true
+	c.SetGains(p.Gains)
+	c.SetOutputLimits(min, max)
+	c.bumpless = bumpless
+	if p.IntegralLimits != nil {
+		c.SetIntegralLimits(iMin, iMax)
+	} else {
+		c.ClearIntegralLimits()
+	}
+	c.SetDiscretization(p.Discretization).SetOutputSmoothing(p.OutputSmoothing).SetDeadband(p.Deadband)
+	return nil
+}
+
+// SavePreset stores the current tuning under name in the presets namespace.
+func (c *PIDController) SavePreset(s Store, name string) error {
+	data, err := json.Marshal(c.Preset())
+	if err != nil {
+		return err
+	}
+	return s.Put(NamespacePresets, name, data)
+}
+
+// LoadPreset applies the preset stored under name in the presets namespace.
+func (c *PIDController) LoadPreset(s Store, name string) error {
+	data, err := s.Get(NamespacePresets, name)
+	if err != nil {
+		return err
+	}
+	var p Preset
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+	return c.ApplyPreset(p)
+}
+
+// Preset returns the current tuning of the controller.
+func (s *SafePIDController) Preset() Preset {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.c.Preset()
+}
+
+// ApplyPreset atomically changes the tuning to p.
+func (s *SafePIDController) ApplyPreset(p Preset) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.c.ApplyPreset(p)
+}
+
+// WritePresets writes presets keyed by name to w as JSON.
+func WritePresets(w io.Writer, presets map[string]Preset) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(presets)
+}
+
+// ReadPresets reads presets written by WritePresets.
+func ReadPresets(r io.Reader) (map[string]Preset, error) {
+	var presets map[string]Preset
+	if err := json.NewDecoder(r).Decode(&presets); err != nil {
+		return nil, err
+	}
+	return presets, nil
+}
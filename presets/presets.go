@@ -0,0 +1,150 @@
+// Package presets provides common thermal setpoint profiles for
+// pidctrl.Profile, parameterized by their target values, so that hobbyist
+// projects can start from proven profiles: reflow soldering, 3D printer bed
+// warmup and sous-vide pasteurization.
+//
+// The presets are plain structs; copy one and change its fields to adapt
+// it, e.g. the peak temperature recommended for a particular solder paste:
+//
+//	r := presets.LeadFree
+//	r.Peak = 250
+//	profile, err := r.Profile(25)
+//
+// Temperatures are in °C, rates in °C per second. The values follow common
+// datasheet and food safety recommendations, but always check them against
+// those of the paste, filament or food at hand.
+package presets
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+// Reflow is a reflow soldering profile: a ramp to SoakStart, a soak slowly
+// ramping to SoakEnd over SoakTime, a ramp to Peak held for PeakHold and a
+// controlled cool-down to CoolTo.
+type Reflow struct {
+	Ramp      float64 // preheat and reflow ramp rate
+	SoakStart float64
+	SoakEnd   float64
+	SoakTime  time.Duration
+	Peak      float64
+	PeakHold  time.Duration
+	CoolTo    float64
+	CoolRate  float64
+}
+
+// Reflow profiles for common solder alloys.
+var (
+	// LeadFree is a SAC305 (Sn96.5Ag3.0Cu0.5) profile, liquidus 217 °C.
+	LeadFree = Reflow{Ramp: 2, SoakStart: 150, SoakEnd: 200, SoakTime: 90 * time.Second, Peak: 245, PeakHold: 10 * time.Second, CoolTo: 50, CoolRate: 3}
+	// Leaded is a Sn63Pb37 profile, eutectic at 183 °C.
+	Leaded = Reflow{Ramp: 2, SoakStart: 100, SoakEnd: 150, SoakTime: 90 * time.Second, Peak: 220, PeakHold: 10 * time.Second, CoolTo: 50, CoolRate: 3}
+)
+
+// Validate checks that the temperatures rise towards the peak, the cool-down
+// ends below it and rates and times are positive.
+func (r Reflow) Validate() error {
+	switch {
+	case !(r.Ramp > 0) || !(r.CoolRate > 0):
+		return errors.New("presets: reflow rates must be positive")
+	case !(r.SoakStart <= r.SoakEnd && r.SoakEnd < r.Peak && r.CoolTo < r.Peak):
+		return fmt.Errorf("presets: reflow temperatures must rise from %v over %v to the peak %v and cool below it", r.SoakStart, r.SoakEnd, r.Peak)
+	case r.SoakTime <= 0 || r.PeakHold < 0:
+		return errors.New("presets: reflow soak time must be positive and the peak hold not negative")
+	}
+	return nil
+}
+
+// Segments returns the profile segments.
+func (r Reflow) Segments() []pidctrl.Segment {
+	return []pidctrl.Segment{
+		{Target: r.SoakStart, Rate: r.Ramp},
+		{Target: r.SoakEnd, Rate: (r.SoakEnd - r.SoakStart) / r.SoakTime.Seconds()},
+		{Target: r.Peak, Rate: r.Ramp, Hold: r.PeakHold},
+		{Target: r.CoolTo, Rate: r.CoolRate},
+	}
+}
+
+// Profile returns a profile starting at the temperature start, e.g. the
+// ambient temperature.
+func (r Reflow) Profile(start float64) (*pidctrl.Profile, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	return pidctrl.NewProfile(start, r.Segments()...), nil
+}
+
+// BedWarmup heats a 3D printer bed to Target at Rate and lets it settle for
+// Settle, after which the profile is done and the setpoint stays at Target.
+// A slow ramp reduces thermal stress on glass and PEI sheets.
+type BedWarmup struct {
+	Target float64
+	Rate   float64 // 0 heats at full power
+	Settle time.Duration
+}
+
+// Bed warmup profiles for common filaments.
+var (
+	PLABed  = BedWarmup{Target: 60, Rate: 1, Settle: 2 * time.Minute}
+	PETGBed = BedWarmup{Target: 80, Rate: 1, Settle: 2 * time.Minute}
+	ABSBed  = BedWarmup{Target: 100, Rate: 1, Settle: 5 * time.Minute}
+)
+
+// Segments returns the profile segments.
+func (b BedWarmup) Segments() []pidctrl.Segment {
+	return []pidctrl.Segment{{Target: b.Target, Rate: b.Rate, Hold: b.Settle}}
+}
+
+// Profile returns a profile starting at the temperature start.
+func (b BedWarmup) Profile(start float64) (*pidctrl.Profile, error) {
+	if b.Rate < 0 || b.Settle < 0 {
+		return nil, errors.New("presets: bed warmup rate and settle time must not be negative")
+	}
+	return pidctrl.NewProfile(start, b.Segments()...), nil
+}
+
+// Pasteurization is an entry of a pasteurization table: the time the core of
+// the food has to be held at Temperature.
+type Pasteurization struct {
+	Temperature float64
+	Hold        time.Duration
+}
+
+// Listeria6D is the table of core temperatures and times for a 6 log
+// reduction of Listeria monocytogenes, equivalent to 70 °C for 2 minutes,
+// as recommended by the UK Advisory Committee on the Microbiological Safety
+// of Food.
+var Listeria6D = []Pasteurization{
+	{Temperature: 60, Hold: 45 * time.Minute},
+	{Temperature: 65, Hold: 10 * time.Minute},
+	{Temperature: 70, Hold: 2 * time.Minute},
+	{Temperature: 75, Hold: 30 * time.Second},
+	{Temperature: 80, Hold: 6 * time.Second},
+}
+
+// ErrBelowTable is returned by SousVide for temperatures below the lowest
+// one of the pasteurization table.
+var ErrBelowTable = errors.New("presets: temperature below the pasteurization table")
+
+// SousVide returns the segments of a sous-vide cook at temperature that
+// pasteurizes according to table, which is sorted by temperature. The hold
+// time is that of the highest table temperature not above temperature, so
+// temperatures in between are on the safe side, plus coreLag, the time the
+// core of the food needs to reach the bath temperature, which depends on
+// its thickness.
+func SousVide(table []Pasteurization, temperature float64, coreLag time.Duration) ([]pidctrl.Segment, error) {
+	hold := time.Duration(-1)
+	for _, p := range table {
+		if p.Temperature <= temperature {
+			hold = p.Hold
+		}
+	}
+	if hold < 0 {
+		return nil, ErrBelowTable
+	}
+	return []pidctrl.Segment{{Target: temperature, Hold: hold + coreLag}}, nil
+}
@@ -0,0 +1,72 @@
+package presets
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReflow(t *testing.T) {
+	for name, r := range map[string]Reflow{"lead-free": LeadFree, "leaded": Leaded} {
+		p, err := r.Profile(25)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		var peak float64
+		var elapsed time.Duration
+		for !p.Done() && elapsed < time.Hour {
+			if s := p.Advance(time.Second); s > peak {
+				peak = s
+			}
+			elapsed += time.Second
+		}
+		if peak != r.Peak || p.Setpoint() != r.CoolTo {
+			t.Errorf("%s: peak %v, final %v", name, peak, p.Setpoint())
+		}
+		// ramp, soak, ramp with hold, cool-down
+		want := time.Duration((r.SoakStart-25)/r.Ramp+(r.Peak-r.SoakEnd)/r.Ramp+(r.Peak-r.CoolTo)/r.CoolRate)*time.Second + r.SoakTime + r.PeakHold
+		if d := elapsed - want; d < -time.Second || d > time.Second {
+			t.Errorf("%s: duration %v, want %v", name, elapsed, want)
+		}
+	}
+	r := LeadFree
+	r.Peak = 190
+	if _, err := r.Profile(25); err == nil {
+		t.Error("expected error for a peak below the soak")
+	}
+}
+
+func TestBedWarmup(t *testing.T) {
+	p, err := PLABed.Profile(20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := p.Advance(20 * time.Second); s != 40 {
+		t.Errorf("setpoint %v after 20s, want 40", s)
+	}
+	if p.Advance(20*time.Second + PLABed.Settle); !p.Done() || p.Setpoint() != 60 {
+		t.Errorf("warmup not done at %v", p.Setpoint())
+	}
+}
+
+func TestSousVide(t *testing.T) {
+	for _, tt := range []struct {
+		temperature float64
+		want        time.Duration
+	}{
+		{60, 45 * time.Minute},
+		{63, 45 * time.Minute},
+		{70, 2 * time.Minute},
+		{90, 6 * time.Second},
+	} {
+		s, err := SousVide(Listeria6D, tt.temperature, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(s) != 1 || s[0].Target != tt.temperature || s[0].Hold != tt.want+time.Hour {
+			t.Errorf("%v °C: %+v, want hold %v", tt.temperature, s, tt.want+time.Hour)
+		}
+	}
+	if _, err := SousVide(Listeria6D, 55, 0); err != ErrBelowTable {
+		t.Errorf("unexpected error %v", err)
+	}
+}
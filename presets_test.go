@@ -0,0 +1,86 @@
+package pidctrl
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPIDController_ApplyPreset(t *testing.T) {
+	winter := Preset{
+		Gains:           Gains{P: 4, I: 0.5, D: 0.1},
+		OutputLimits:    &Limits{Min: finiteOrNil(0), Max: finiteOrNil(100)},
+		IntegralLimits:  &Limits{Min: finiteOrNil(-50), Max: finiteOrNil(50)},
+		Discretization:  Discretization{Integral: IntegralTrapezoidal, DerivativeFilter: time.Second},
+		OutputSmoothing: 2 * time.Second,
+		Deadband:        0.2,
+	}
+	c := NewPIDController(2, 0.25, 0).SetOutputLimits(0, 100).Set(50)
+	c.UpdateDuration(40, time.Second)
+	out := c.UpdateDuration(40, time.Second)
+	if err := c.ApplyPreset(winter); err != nil {
+		t.Fatal(err)
+	}
+	if got := c.Preset(); !reflect.DeepEqual(got, winter) {
+		t.Errorf("preset not applied:\n%+v\n%+v", got, winter)
+	}
+	if c.Bumpless() {
+		t.Error("bumpless retuning left enabled")
+	}
+	if next := c.UpdateDuration(40, 0); math.Abs(next-out) > 1e-9 {
+		t.Errorf("output stepped from %v to %v", out, next)
+	}
+
+	if err := c.ApplyPreset(Preset{Gains: Gains{P: 9}, OutputLimits: &Limits{Min: finiteOrNil(5), Max: finiteOrNil(1)}}); err == nil {
+		t.Error("expected error for swapped limits")
+	}
+	if p, _, _ := c.PID(); p != 4 {
+		t.Errorf("gains changed by invalid preset: %v", p)
+	}
+
+	c.ApplyPreset(Preset{Gains: Gains{P: 1}})
+	if min, max := c.OutputLimits(); !math.IsInf(min, -1) || !math.IsInf(max, 1) {
+		t.Errorf("nil limits not unbounded: %v %v", min, max)
+	}
+	if min, max := c.IntegralLimits(); !math.IsInf(min, -1) || !math.IsInf(max, 1) {
+		t.Errorf("integral limits not cleared: %v %v", min, max)
+	}
+}
+
+func TestPIDController_SavePreset(t *testing.T) {
+	s := NewFileStore(t.TempDir())
+	summer := NewPIDController(1, 0.1, 0).SetOutputLimits(0, 50).SetDeadband(0.5)
+	if err := summer.SavePreset(s, "summer"); err != nil {
+		t.Fatal(err)
+	}
+	c := NewPIDController(3, 0, 0)
+	if err := c.LoadPreset(s, "summer"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(c.Preset(), summer.Preset()) {
+		t.Errorf("%+v != %+v", c.Preset(), summer.Preset())
+	}
+	if err := c.LoadPreset(s, "winter"); err != (NotFoundError{NamespacePresets, "winter"}) {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
+
+func TestWritePresets(t *testing.T) {
+	presets := map[string]Preset{
+		"summer": NewPIDController(1, 0.1, 0).Preset(),
+		"winter": NewPIDController(4, 0.5, 0.1).SetOutputLimits(0, 100).Preset(),
+	}
+	var buf bytes.Buffer
+	if err := WritePresets(&buf, presets); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadPresets(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, presets) {
+		t.Errorf("%+v != %+v", got, presets)
+	}
+}
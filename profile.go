@@ -0,0 +1,155 @@
+package pidctrl
+
+import (
+	"math"
+	"time"
+)
+
+// Segment is a step of a Profile: the setpoint ramps to Target at Rate units
+// per second, then soaks there for Hold. A Rate of 0 jumps to the target.
+type Segment struct {
+	Target float64
+	Rate   float64
+	Hold   time.Duration
+}
+
+// Profile is a setpoint programmer running a sequence of ramp and soak
+// segments, like the temperature profiles of reflow ovens and kilns.
+type Profile struct {
+	Segments []Segment
+
+	onSegment func(int) // called when a segment starts
+	onDone    func()    // called when the last segment ends
+
+	setpoint float64
+	index    int           // current segment
+	held     time.Duration // soak time of the current segment
+	started  bool
+	paused   bool
+}
+
+// NewProfile returns a Profile starting at the setpoint start.
+func NewProfile(start float64, segments ...Segment) *Profile {
+	return &Profile{Segments: segments, setpoint: start}
+}
+
+// OnSegment sets a function called with the index of each segment as it
+// starts.
+func (p *Profile) OnSegment(f func(index int)) *Profile {
+	p.onSegment = f
+	return p
+}
+
+// OnDone sets a function called once the last segment ended.
+func (p *Profile) OnDone(f func()) *Profile {
+	p.onDone = f
+	return p
+}
+
+// Setpoint returns the current setpoint of the profile.
+func (p *Profile) Setpoint() float64 {
+	return p.setpoint
+}
+
+// Segment returns the index of the current segment, len(Segments) once the
+// profile is done.
+func (p *Profile) Segment() int {
+	return p.index
+}
+
+// Done returns true once the last segment ended.
+func (p *Profile) Done() bool {
+	return p.index >= len(p.Segments)
+}
+
+// Pause stops the profile, Advance keeps the setpoint until Resume.
+func (p *Profile) Pause() *Profile {
+	p.paused = true
+	return p
+}
+
+// Resume continues a paused profile.
+func (p *Profile) Resume() *Profile {
+	p.paused = false
+	return p
+}
+
+// Paused returns true while the profile is paused.
+func (p *Profile) Paused() bool {
+	return p.paused
+}
+
+// Skip ends the current segment immediately. The next segment ramps from
+// the current setpoint.
+func (p *Profile) Skip() *Profile {
+	if !p.Done() {
+		p.start()
+		p.next()
+	}
+	return p
+}
+
+// Restart runs the profile again from the setpoint start.
+func (p *Profile) Restart(start float64) *Profile {
+	p.setpoint, p.index, p.held, p.started = start, 0, 0, false
+	return p
+}
+
+// Advance moves the profile forward by dt and returns the new setpoint.
+func (p *Profile) Advance(dt time.Duration) float64 {
+	if p.paused {
+		return p.setpoint
+	}
+	p.start()
+	for !p.Done() {
+		s := p.Segments[p.index]
+		if p.setpoint != s.Target {
+			remaining := math.Abs(s.Target - p.setpoint)
+			if step := s.Rate * dt.Seconds(); s.Rate > 0 && step < remaining {
+				p.setpoint += math.Copysign(step, s.Target-p.setpoint)
+				return p.setpoint
+			} else if s.Rate > 0 {
+				dt -= time.Duration(remaining / s.Rate * float64(time.Second))
+			}
+			p.setpoint = s.Target
+		}
+		left := s.Hold - p.held
+		if dt < left {
+			p.held += dt
+			return p.setpoint
+		}
+		dt -= left
+		p.next()
+	}
+	return p.setpoint
+}
+
+// UpdateDuration advances the profile by duration, applies its setpoint to c
+// and returns the output of c for value.
+func (p *Profile) UpdateDuration(c *PIDController, value float64, duration time.Duration) float64 {
+	return c.Set(p.Advance(duration)).UpdateDuration(value, duration)
+}
+
+// start notifies the start of the first segment.
+func (p *Profile) start() {
+	if !p.started {
+		p.started = true
+		p.notify()
+	}
+}
+
+// next moves to the following segment.
+func (p *Profile) next() {
+	p.index++
+	p.held = 0
+	p.notify()
+}
+
+// notify calls the callback for the current segment.
+func (p *Profile) notify() {
+	if !p.Done() && p.onSegment != nil {
+		p.onSegment(p.index)
+	} else if p.Done() && p.onDone != nil {
+		p.onDone()
+	}
+}
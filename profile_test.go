@@ -0,0 +1,56 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProfile(t *testing.T) {
+	var (
+		started []int
+		done    bool
+	)
+	p := NewProfile(20,
+		Segment{Target: 150, Rate: 2, Hold: 60 * time.Second},
+		Segment{Target: 220, Rate: 1},
+		Segment{Target: 50, Hold: 10 * time.Second},
+	).OnSegment(func(i int) { started = append(started, i) }).OnDone(func() { done = true })
+
+	for _, step := range []struct {
+		dt       time.Duration
+		setpoint float64
+		segment  int
+	}{
+		{10 * time.Second, 40, 0},
+		{55 * time.Second, 150, 0}, // reached the target
+		{60 * time.Second, 150, 1}, // soaked
+		{65 * time.Second, 215, 1},
+		{10 * time.Second, 50, 2}, // jumped after reaching 220
+		{5 * time.Second, 50, 3},
+	} {
+		if sp := p.Advance(step.dt); sp != step.setpoint || p.Segment() != step.segment {
+			t.Errorf("after %v: setpoint %v segment %d, want %v segment %d", step.dt, sp, p.Segment(), step.setpoint, step.segment)
+		}
+	}
+	if !p.Done() || !done || len(started) != 3 {
+		t.Errorf("done %v %v, started %v", p.Done(), done, started)
+	}
+}
+
+func TestProfile_PauseSkip(t *testing.T) {
+	p := NewProfile(0, Segment{Target: 10, Rate: 1}, Segment{Target: 0, Rate: 1})
+	p.Advance(2 * time.Second)
+	p.Pause()
+	if sp := p.Advance(time.Hour); sp != 2 {
+		t.Errorf("paused setpoint %v != 2", sp)
+	}
+	p.Resume().Skip()
+	if sp := p.Advance(time.Second); sp != 1 || p.Segment() != 1 {
+		t.Errorf("after skip: setpoint %v segment %d", sp, p.Segment())
+	}
+
+	c := NewPIDController(1, 0, 0)
+	if out := p.UpdateDuration(c, 0, 0); out != 1 || c.Get() != 1 {
+		t.Errorf("controller output %v setpoint %v", out, c.Get())
+	}
+}
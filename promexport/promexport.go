@@ -0,0 +1,218 @@
+// Package promexport exposes PID controller metrics in the Prometheus text
+// exposition format.
+//
+// It does not depend on the Prometheus client library; an Exporter is an
+// http.Handler that can be mounted at /metrics directly.
+//
+// see https://prometheus.io/docs/instrumenting/exposition_formats/
+package promexport
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+// Exporter collects metrics of registered loops.
+type Exporter struct {
+	mu    sync.Mutex
+	loops map[string]*Loop
+}
+
+// New returns a new Exporter.
+func New() *Exporter {
+	return &Exporter{loops: map[string]*Loop{}}
+}
+
+// Register adds a controller under the given loop name and returns the Loop
+// through which it has to be updated. Registering a name twice replaces the
+// previous loop.
+func (e *Exporter) Register(name string, c *pidctrl.PIDController) *Loop {
+	l := &Loop{c: c}
+	e.mu.Lock()
+	e.loops[name] = l
+	e.mu.Unlock()
+	return l
+}
+
+// Unregister removes the loop with the given name.
+func (e *Exporter) Unregister(name string) {
+	e.mu.Lock()
+	delete(e.loops, name)
+	e.mu.Unlock()
+}
+
+// Loop wraps a controller and records metrics on every update. The wrapped
+// controller must only be updated through the Loop.
+type Loop struct {
+	c *pidctrl.PIDController
+
+	mu          sync.Mutex
+	setpoint    float64
+	value       float64
+	output      float64
+	terms       pidctrl.Terms
+	saturated   bool
+	saturations uint64
+	updates     uint64
+	latency     time.Duration
+}
+
+// Controller returns the wrapped controller.
+func (l *Loop) Controller() *pidctrl.PIDController {
+	return l.c
+}
+
+// Update calls Update on the wrapped controller and records the result.
+func (l *Loop) Update(value float64) float64 {
+	start := time.Now()
+	output := l.c.Update(value)
+	l.record(value, output, time.Since(start))
+	return output
+}
+
+// UpdateDuration calls UpdateDuration on the wrapped controller and records
+// the result.
+func (l *Loop) UpdateDuration(value float64, duration time.Duration) float64 {
+	start := time.Now()
+	output := l.c.UpdateDuration(value, duration)
+	l.record(value, output, time.Since(start))
+	return output
+}
+
+func (l *Loop) record(value, output float64, latency time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	saturated := l.c.Saturated()
+	if saturated && !l.saturated {
+		l.saturations++
+	}
+	l.saturated = saturated
+	l.setpoint = l.c.WorkingSetpoint()
+	l.value = value
+	l.output = output
+	l.terms = l.c.Terms()
+	l.updates++
+	l.latency += latency
+}
+
+// ServeHTTP implements http.Handler.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	e.WriteTo(w)
+}
+
+type metric struct {
+	name, help, typ string
+	value           func(l *Loop) float64
+}
+
+var metrics = []metric{
+	{"pidctrl_setpoint", "Working setpoint of the loop.", "gauge", func(l *Loop) float64 { return l.setpoint }},
+	{"pidctrl_process_value", "Last process value.", "gauge", func(l *Loop) float64 { return l.value }},
+	{"pidctrl_output", "Last output.", "gauge", func(l *Loop) float64 { return l.output }},
+	{"pidctrl_saturated", "1 if the last output was clamped to the output limits.", "gauge", func(l *Loop) float64 { return bool2float(l.saturated) }},
+	{"pidctrl_saturations_total", "Number of times the output entered saturation.", "counter", func(l *Loop) float64 { return float64(l.saturations) }},
+	{"pidctrl_updates_total", "Number of controller updates.", "counter", func(l *Loop) float64 { return float64(l.updates) }},
+}
+
+// WriteTo writes the metrics of all registered loops to w.
+func (e *Exporter) WriteTo(w io.Writer) (int64, error) {
+	e.mu.Lock()
+	names := make([]string, 0, len(e.loops))
+	for name := range e.loops {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	loops := make([]*Loop, len(names))
+	for i, name := range names {
+		loops[i] = e.loops[name]
+	}
+	e.mu.Unlock()
+
+	for _, l := range loops {
+		l.mu.Lock()
+	}
+	defer func() {
+		for _, l := range loops {
+			l.mu.Unlock()
+		}
+	}()
+
+	cw := &countWriter{w: bufio.NewWriter(w)}
+	for _, m := range metrics {
+		header(cw, m.name, m.help, m.typ)
+		for i, l := range loops {
+			sample(cw, m.name, labels(names[i]), m.value(l))
+		}
+	}
+	header(cw, "pidctrl_term", "Contribution of the individual terms to the output.", "gauge")
+	for i, l := range loops {
+		for _, t := range []struct {
+			name  string
+			value float64
+		}{{"p", l.terms.P}, {"i", l.terms.I}, {"d", l.terms.D}, {"feedforward", l.terms.FeedForward}} {
+			sample(cw, "pidctrl_term", labels(names[i])+`,term="`+t.name+`"`, t.value)
+		}
+	}
+	header(cw, "pidctrl_clamp_cause", "1 for the term that drove the output into its limit on the last update.", "gauge")
+	for i, l := range loops {
+		for cause := pidctrl.ClampP; cause <= pidctrl.ClampFeedForward; cause++ {
+			sample(cw, "pidctrl_clamp_cause", labels(names[i])+`,cause="`+cause.String()+`"`, bool2float(l.terms.Clamp == cause))
+		}
+	}
+	header(cw, "pidctrl_update_duration_seconds", "Time spent in controller updates.", "summary")
+	for i, l := range loops {
+		sample(cw, "pidctrl_update_duration_seconds_sum", labels(names[i]), l.latency.Seconds())
+		sample(cw, "pidctrl_update_duration_seconds_count", labels(names[i]), float64(l.updates))
+	}
+	if cw.err == nil {
+		cw.err = cw.w.(*bufio.Writer).Flush()
+	}
+	return cw.n, cw.err
+}
+
+func header(w io.Writer, name, help, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func sample(w io.Writer, name, labels string, value float64) {
+	fmt.Fprintf(w, "%s{%s} %s\n", name, labels, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func labels(loop string) string {
+	return `loop="` + labelEscaper.Replace(loop) + `"`
+}
+
+func bool2float(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+type countWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
@@ -0,0 +1,38 @@
+package promexport
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+func TestExporter(t *testing.T) {
+	e := New()
+	mash := e.Register("mash", pidctrl.NewPIDController(2, 0, 0).SetOutputLimits(0, 10).Set(66))
+	mash.UpdateDuration(60, time.Second)
+	mash.UpdateDuration(65, time.Second)
+	e.Register(`sparge "hlt"`, pidctrl.NewPIDController(1, 0, 0))
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE pidctrl_setpoint gauge\n",
+		`pidctrl_setpoint{loop="mash"} 66`,
+		`pidctrl_process_value{loop="mash"} 65`,
+		`pidctrl_output{loop="mash"} 2`,
+		`pidctrl_saturations_total{loop="mash"} 1`,
+		`pidctrl_updates_total{loop="mash"} 2`,
+		`pidctrl_term{loop="mash",term="p"} 2`,
+		`pidctrl_update_duration_seconds_count{loop="mash"} 2`,
+		`pidctrl_clamp_cause{loop="mash",cause="p"} 0`,
+		`pidctrl_updates_total{loop="sparge \"hlt\""} 0`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in:\n%s", want, body)
+		}
+	}
+}
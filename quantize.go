@@ -0,0 +1,33 @@
+package pidctrl
+
+import "math"
+
+// SetOutputQuantization rounds the output to multiples of step, for
+// actuators with discrete positions like multi-stage heaters or damper
+// steps. The output only changes to another level once it passes the
+// midpoint between two levels by more than hysteresis, so it does not
+// chatter between adjacent levels. Levels beyond the output limits are
+// clamped. A step of 0 disables quantization.
+func (c *PIDController) SetOutputQuantization(step, hysteresis float64) *PIDController {
+	c.quantum, c.qHyst = math.Abs(step), math.Abs(hysteresis)
+	c.quantized = false
+	return c
+}
+
+// OutputQuantization returns the quantization step and hysteresis.
+func (c *PIDController) OutputQuantization() (step, hysteresis float64) {
+	return c.quantum, c.qHyst
+}
+
+// quantize returns the quantized output level for output.
+func (c *PIDController) quantize(output float64) float64 {
+	if c.quantum == 0 {
+		return output
+	}
+	level := math.Round(output/c.quantum) * c.quantum
+	if c.quantized && level != c.qLevel && math.Abs(output-c.qLevel) < c.quantum/2+c.qHyst {
+		level = c.qLevel
+	}
+	c.qLevel, c.quantized = level, true
+	return math.Max(c.outMin, math.Min(c.outMax, level))
+}
@@ -0,0 +1,24 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOutputQuantization(t *testing.T) {
+	c := NewPIDController(1, 0, 0).SetOutputLimits(0, 100).SetOutputQuantization(25, 5).Set(100)
+	for _, step := range []struct {
+		value, output float64
+	}{
+		{50, 50},
+		{35, 50}, // 65 is within the hysteresis band above 50
+		{30, 75},
+		{40, 75}, // 60 is within the hysteresis band below 75
+		{43, 50},
+		{0, 100},
+	} {
+		if out := c.UpdateDuration(step.value, time.Second); out != step.output {
+			t.Errorf("value %v: output %v != %v", step.value, out, step.output)
+		}
+	}
+}
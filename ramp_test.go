@@ -0,0 +1,30 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetpointRamp(t *testing.T) {
+	c := NewPIDController(1, 0, 0).SetSetpointRamp(2).Set(10)
+	for i, want := range []float64{2, 4, 6, 8, 10, 10} {
+		if out := c.UpdateDuration(0, time.Second); out != want {
+			t.Errorf("step %d: output %f != %f", i, out, want)
+		}
+	}
+	if c.Ramping() {
+		t.Error("ramp did not finish")
+	}
+}
+
+func TestRampIntegralFactor(t *testing.T) {
+	c := NewPIDController(0, 1, 0).SetSetpointRamp(1).SetRampIntegralFactor(0).Set(3)
+	for i := 0; i < 3; i++ {
+		if out := c.UpdateDuration(0, time.Second); out != 0 {
+			t.Errorf("step %d: integral accumulated while ramping: %f", i, out)
+		}
+	}
+	if out := c.UpdateDuration(0, time.Second); out != 3 {
+		t.Errorf("integral after ramp: %f != 3", out)
+	}
+}
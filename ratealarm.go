@@ -0,0 +1,43 @@
+package pidctrl
+
+// rateAlarm detects a process value changing faster than allowed.
+type rateAlarm struct {
+	rise, fall float64    // maximum rising and falling rates per second, 0 disables
+	callback   func(bool) // optional, called when the alarm is raised or cleared
+	rate       float64    // process value rate of the last update
+	active     bool       // alarm raised on the last update
+}
+
+// SetRateAlarm enables the process value rate-of-change alarm: it is raised
+// while the value rises faster than rise or falls faster than fall, both in
+// units per second, e.g. a temperature rising faster than 5 °C/s indicating
+// thermal runaway. A limit of 0 disables that direction. The rate is that of
+// the derivative term, so an Estimator keeps measurement noise from raising
+// the alarm. f is called with true when the alarm is raised and with false
+// when it clears; it may be nil. A Supervisor monitoring FaultRate switches
+// to its fail-safe output on the alarm.
+func (c *PIDController) SetRateAlarm(rise, fall float64, f func(alarm bool)) *PIDController {
+	c.rateAlarm = rateAlarm{rise: rise, fall: fall, callback: f}
+	return c
+}
+
+// RateAlarm returns true while the rate-of-change alarm is raised.
+func (c *PIDController) RateAlarm() bool {
+	return c.rateAlarm.active
+}
+
+// update feeds the process value rate of an update into the alarm.
+func (a *rateAlarm) update(rate float64) {
+	if a.rise <= 0 && a.fall <= 0 {
+		return
+	}
+	a.rate = rate
+	active := (a.rise > 0 && rate > a.rise) || (a.fall > 0 && -rate > a.fall)
+	if active == a.active {
+		return
+	}
+	a.active = active
+	if a.callback != nil {
+		a.callback(active)
+	}
+}
@@ -0,0 +1,66 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPIDController_RateAlarm(t *testing.T) {
+	var calls []bool
+	c := NewPIDController(1, 0, 0).Set(100).SetRateAlarm(5, 10, func(alarm bool) { calls = append(calls, alarm) })
+	bus := NewEventBus()
+	var events []Event
+	bus.Subscribe(func(e Event) { events = append(events, e) }, EventRate)
+	c.SetEventBus(bus)
+
+	for i, tt := range []struct {
+		value float64
+		alarm bool
+	}{
+		{20, false}, // first update, no rate
+		{24, false}, // rising at 4/s
+		{30, true},  // rising at 6/s
+		{35, false}, // rising at 5/s
+		{26, false}, // falling at 9/s
+		{15, true},  // falling at 11/s
+	} {
+		c.UpdateDuration(tt.value, time.Second)
+		if c.RateAlarm() != tt.alarm {
+			t.Errorf("update %d: alarm %v, want %v", i, c.RateAlarm(), tt.alarm)
+		}
+	}
+	if want := []bool{true, false, true}; !equalBools(calls, want) {
+		t.Errorf("callback calls %v, want %v", calls, want)
+	}
+	if len(events) != 3 || !events[0].Active || events[0].New != 6 || events[2].New != -11 {
+		t.Errorf("unexpected events: %+v", events)
+	}
+	c.Reset()
+	if c.RateAlarm() {
+		t.Error("alarm not cleared by reset")
+	}
+}
+
+func TestSupervisor_RateAlarm(t *testing.T) {
+	c := NewPIDController(1, 0, 0).Set(100).SetRateAlarm(5, 0, nil)
+	s := NewSupervisor(c, 0)
+	s.UpdateDuration(20, time.Second)
+	if out := s.UpdateDuration(30, time.Second); out != 0 || !s.Tripped() || s.Faults() != FaultRate {
+		t.Errorf("runaway not tripped: output %v, faults %v", out, s.Faults())
+	}
+	if FaultRate.String() != "rate" {
+		t.Errorf("unexpected name %q", FaultRate.String())
+	}
+}
+
+func equalBools(a, b []bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+package pidctrl
+
+import (
+	"math"
+	"time"
+)
+
+// Deadbands are the changes of setpoint, process value and output that an
+// ExceptionReporter considers significant.
+type Deadbands struct {
+	Setpoint float64
+	Value    float64
+	Output   float64
+}
+
+// ExceptionReporter implements report-by-exception telemetry: of all updates
+// it observes, only those where setpoint, process value or output moved
+// beyond their deadband since the last published update are published, plus
+// one update whenever the heartbeat interval elapses without publishing.
+type ExceptionReporter struct {
+	deadbands Deadbands
+	heartbeat time.Duration
+	clock     Clock
+	publish   func(UpdateInfo)
+
+	last          UpdateInfo // last published update
+	lastPublished time.Time  // time of the last publish
+	published     bool       // true after the first publish
+}
+
+// NewExceptionReporter returns an ExceptionReporter calling publish for
+// significant updates. A heartbeat of 0 disables heartbeats. clock may be nil
+// to use the real clock.
+func NewExceptionReporter(deadbands Deadbands, heartbeat time.Duration, clock Clock, publish func(UpdateInfo)) *ExceptionReporter {
+	if clock == nil {
+		clock = RealClock
+	}
+	return &ExceptionReporter{deadbands: deadbands, heartbeat: heartbeat, clock: clock, publish: publish}
+}
+
+// Observe considers an update for publishing. It can be installed directly
+// with SetObserver.
+func (r *ExceptionReporter) Observe(info UpdateInfo) {
+	now := r.clock.Now()
+	if r.published &&
+		math.Abs(info.Setpoint-r.last.Setpoint) <= r.deadbands.Setpoint &&
+		math.Abs(info.Value-r.last.Value) <= r.deadbands.Value &&
+		math.Abs(info.Output-r.last.Output) <= r.deadbands.Output &&
+		(r.heartbeat == 0 || now.Sub(r.lastPublished) < r.heartbeat) {
+		return
+	}
+	r.last, r.lastPublished, r.published = info, now, true
+	r.publish(info)
+}
+
+// Reset forgets the last published update, so the next one is published.
+func (r *ExceptionReporter) Reset() {
+	r.published = false
+}
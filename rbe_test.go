@@ -0,0 +1,32 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExceptionReporter(t *testing.T) {
+	clock := NewManualClock(time.Date(2016, 3, 2, 0, 0, 0, 0, time.UTC))
+	var published []float64
+	r := NewExceptionReporter(Deadbands{Value: 0.5, Output: 100}, time.Minute, clock, func(info UpdateInfo) {
+		published = append(published, info.Value)
+	})
+	c := NewPIDController(1, 0, 0).SetObserver(r.Observe).Set(20)
+	for _, v := range []float64{10, 10.2, 10.4, 10.6, 10.7, 10.8} {
+		clock.Advance(10 * time.Second)
+		c.UpdateDuration(v, 10*time.Second)
+	}
+	clock.Advance(time.Minute)
+	c.UpdateDuration(10.8, 10*time.Second) // heartbeat
+
+	want := []float64{10, 10.6, 10.8}
+	if len(published) != len(want) {
+		t.Fatalf("published %v, expected %v", published, want)
+	}
+	for i := range want {
+		if published[i] != want[i] {
+			t.Errorf("published %v, expected %v", published, want)
+			break
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package pidctrl
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Record is a single controller update captured by a Recorder.
+type Record struct {
+	Time     time.Time `json:"time"`
+	Setpoint float64   `json:"setpoint"`
+	Value    float64   `json:"value"`
+	Output   float64   `json:"output"`
+	Terms    Terms     `json:"terms"`
+}
+
+// Recorder captures controller updates into a ring buffer of bounded size,
+// keeping the most recent records. It is safe for concurrent use.
+type Recorder struct {
+	mu      sync.Mutex
+	records []Record // ring buffer
+	next    int      // index of the next write
+	full    bool     // true once the buffer wrapped around
+}
+
+// NewRecorder returns a new Recorder keeping up to capacity records.
+func NewRecorder(capacity int) *Recorder {
+	if capacity <= 0 {
+		panic("pidctrl: recorder capacity must be positive")
+	}
+	return &Recorder{records: make([]Record, capacity)}
+}
+
+// Record captures the state of the last update of c, timestamped with the
+// controller's clock. Call it right after each update.
+func (r *Recorder) Record(c *PIDController) {
+	r.Add(Record{
+		Time:     c.now(),
+		Setpoint: c.setpoint,
+		Value:    c.prevValue,
+		Output:   c.output,
+		Terms:    c.terms,
+	})
+}
+
+// Add appends a record, discarding the oldest one if the buffer is full.
+func (r *Recorder) Add(rec Record) {
+	r.mu.Lock()
+	r.records[r.next] = rec
+	r.next++
+	if r.next == len(r.records) {
+		r.next = 0
+		r.full = true
+	}
+	r.mu.Unlock()
+}
+
+// Len returns the number of records held.
+func (r *Recorder) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.full {
+		return len(r.records)
+	}
+	return r.next
+}
+
+// Records returns a copy of the held records, oldest first.
+func (r *Recorder) Records() []Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]Record(nil), r.records[:r.next]...)
+	}
+	out := make([]Record, 0, len(r.records))
+	out = append(out, r.records[r.next:]...)
+	return append(out, r.records[:r.next]...)
+}
+
+// Range returns a copy of the held records with from <= Time < to, oldest
+// first. A zero from or to leaves that end of the range open. Records are
+// expected to be added in chronological order, which Record guarantees for a
+// monotonic clock.
+func (r *Recorder) Range(from, to time.Time) []Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := r.next
+	if r.full {
+		n = len(r.records)
+	}
+	lo, hi := 0, n
+	if !from.IsZero() {
+		lo = sort.Search(n, func(i int) bool { return !r.at(i).Time.Before(from) })
+	}
+	if !to.IsZero() {
+		hi = sort.Search(n, func(i int) bool { return !r.at(i).Time.Before(to) })
+	}
+	if lo >= hi {
+		return nil
+	}
+	out := make([]Record, 0, hi-lo)
+	for i := lo; i < hi; i++ {
+		out = append(out, *r.at(i))
+	}
+	return out
+}
+
+// Since returns a copy of the held records at or after t, oldest first.
+func (r *Recorder) Since(t time.Time) []Record {
+	return r.Range(t, time.Time{})
+}
+
+// at returns the i-th held record, oldest first. r.mu must be held.
+func (r *Recorder) at(i int) *Record {
+	if r.full {
+		i = (r.next + i) % len(r.records)
+	}
+	return &r.records[i]
+}
+
+// Reset discards all records.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	r.next, r.full = 0, false
+	r.mu.Unlock()
+}
+
+// csvHeader are the column names written by WriteCSV.
+var csvHeader = []string{"time", "setpoint", "value", "output", "p", "i", "d", "feedforward"}
+
+// WriteCSV writes the held records to w as CSV with a header line. Times are
+// written in RFC 3339 format with nanoseconds.
+func (r *Recorder) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, rec := range r.Records() {
+		if err := cw.Write(rec.csv()); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Encode passes each held record to enc, e.g. a json.Encoder.
+func (r *Recorder) Encode(enc interface{ Encode(v any) error }) error {
+	for _, rec := range r.Records() {
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (rec Record) csv() []string {
+	f := func(v float64) string {
+		if v == 0 {
+			v = 0 // no negative zero in the output
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return []string{
+		rec.Time.Format(time.RFC3339Nano),
+		f(rec.Setpoint), f(rec.Value), f(rec.Output),
+		f(rec.Terms.P), f(rec.Terms.I), f(rec.Terms.D), f(rec.Terms.FeedForward),
+	}
+}
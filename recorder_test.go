@@ -0,0 +1,96 @@
+package pidctrl
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecorder(t *testing.T) {
+	clock := NewManualClock(time.Date(2016, 3, 2, 0, 0, 0, 0, time.UTC))
+	c := NewPIDController(1, 0, 0).SetClock(clock).Set(10)
+	r := NewRecorder(3)
+	for i := 0; i < 5; i++ {
+		clock.Advance(time.Second)
+		c.Update(float64(i))
+		r.Record(c)
+	}
+	recs := r.Records()
+	if len(recs) != 3 || r.Len() != 3 {
+		t.Fatalf("expected 3 records, got %d", len(recs))
+	}
+	if recs[0].Value != 2 || recs[2].Value != 4 || recs[2].Output != 6 {
+		t.Errorf("unexpected records: %+v", recs)
+	}
+
+	var buf bytes.Buffer
+	if err := r.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || lines[0] != "time,setpoint,value,output,p,i,d,feedforward" {
+		t.Errorf("unexpected CSV:\n%s", buf.String())
+	}
+	if lines[3] != "2016-03-02T00:00:05Z,10,4,6,6,0,0,0" {
+		t.Errorf("unexpected CSV line: %s", lines[3])
+	}
+
+	buf.Reset()
+	if err := r.Encode(json.NewEncoder(&buf)); err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 3 {
+		t.Errorf("expected 3 JSON records, got %d", n)
+	}
+	r.Reset()
+	if r.Len() != 0 {
+		t.Error("records left after reset")
+	}
+}
+
+func TestRecorder_Range(t *testing.T) {
+	start := time.Date(2016, 3, 2, 0, 0, 0, 0, time.UTC)
+	at := func(s int) time.Time { return start.Add(time.Duration(s) * time.Second) }
+	r := NewRecorder(4)
+	if recs := r.Range(time.Time{}, time.Time{}); len(recs) != 0 {
+		t.Errorf("empty recorder returned %d records", len(recs))
+	}
+	for i := 0; i < 6; i++ {
+		r.Add(Record{Time: at(i), Value: float64(i)})
+	}
+	// records 2..5 are held, the buffer has wrapped
+	tests := []struct {
+		from, to time.Time
+		want     []float64
+	}{
+		{time.Time{}, time.Time{}, []float64{2, 3, 4, 5}},
+		{at(3), at(5), []float64{3, 4}},
+		{at(0), at(3), []float64{2}},
+		{at(4), time.Time{}, []float64{4, 5}},
+		{at(3).Add(time.Millisecond), at(4).Add(time.Millisecond), []float64{4}},
+		{at(6), time.Time{}, nil},
+		{at(4), at(4), nil},
+	}
+	for _, tt := range tests {
+		recs := r.Range(tt.from, tt.to)
+		var got []float64
+		for _, rec := range recs {
+			got = append(got, rec.Value)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("Range(%v, %v) = %v, want %v", tt.from, tt.to, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Range(%v, %v) = %v, want %v", tt.from, tt.to, got, tt.want)
+				break
+			}
+		}
+	}
+	if recs := r.Since(at(5)); len(recs) != 1 || recs[0].Value != 5 {
+		t.Errorf("unexpected Since result: %+v", recs)
+	}
+}
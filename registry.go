@@ -0,0 +1,141 @@
+package pidctrl
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Registry holds controllers by name, as a foundation for remote management
+// and for applications running many loops. It is safe for concurrent use.
+type Registry struct {
+	mu    sync.RWMutex
+	loops map[string]*SafePIDController
+}
+
+// NewRegistry returns a new empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{loops: map[string]*SafePIDController{}}
+}
+
+// DuplicateNameError is returned when registering a name twice.
+type DuplicateNameError struct {
+	name string
+}
+
+func (e DuplicateNameError) Error() string {
+	return fmt.Sprintf("controller %q already registered", e.name)
+}
+
+// Register adds a controller under the given name.
+func (r *Registry) Register(name string, c *SafePIDController) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.loops[name]; ok {
+		return DuplicateNameError{name}
+	}
+	r.loops[name] = c
+	return nil
+}
+
+// Unregister removes the controller with the given name.
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	delete(r.loops, name)
+	r.mu.Unlock()
+}
+
+// Get returns the controller with the given name.
+func (r *Registry) Get(name string) (*SafePIDController, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.loops[name]
+	return c, ok
+}
+
+// Len returns the number of registered controllers.
+func (r *Registry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.loops)
+}
+
+// Names returns the names of all controllers in sorted order.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.loops))
+	for name := range r.loops {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Each calls f for every controller in name order. The registry may be
+// modified from f.
+func (r *Registry) Each(f func(name string, c *SafePIDController)) {
+	for _, name := range r.Names() {
+		if c, ok := r.Get(name); ok {
+			f(name, c)
+		}
+	}
+}
+
+// ResetAll resets the dynamic state of all controllers.
+func (r *Registry) ResetAll() {
+	r.Each(func(_ string, c *SafePIDController) { c.Reset() })
+}
+
+// SetOccupancyAll switches all controllers to the given occupancy mode.
+// Controllers without a profile for the mode are left alone and their
+// errors returned, keyed by name.
+func (r *Registry) SetOccupancyAll(o Occupancy) map[string]error {
+	var errs map[string]error
+	r.Each(func(name string, c *SafePIDController) {
+		if err := c.SetOccupancy(o); err != nil {
+			if errs == nil {
+				errs = map[string]error{}
+			}
+			errs[name] = err
+		}
+	})
+	return errs
+}
+
+// SnapshotAll returns the JSON encoded state of all controllers, keyed by
+// name. Each controller is locked while it is encoded.
+func (r *Registry) SnapshotAll() (map[string]json.RawMessage, error) {
+	snapshots := map[string]json.RawMessage{}
+	var err error
+	r.Each(func(name string, c *SafePIDController) {
+		if err != nil {
+			return
+		}
+		var data []byte
+		c.Do(func(c *PIDController) { data, err = c.MarshalJSON() })
+		snapshots[name] = data
+	})
+	if err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+// Statuses returns the status of all controllers in name order.
+func (r *Registry) Statuses() []LoopStatus {
+	var statuses []LoopStatus
+	r.Each(func(name string, c *SafePIDController) {
+		var s LoopStatus
+		c.Do(func(c *PIDController) { s = c.LoopStatus() })
+		s.Name = name
+		statuses = append(statuses, s)
+	})
+	return statuses
+}
+
+// KPIs returns the fleet KPIs of all controllers, see RollupKPIs.
+func (r *Registry) KPIs(poorThreshold float64) FleetKPIs {
+	return RollupKPIs(r.Statuses(), poorThreshold)
+}
@@ -0,0 +1,57 @@
+package pidctrl
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	mash := NewSafePIDController(1, 1, 0).SetOutputLimits(0, 1)
+	boil := NewSafePIDController(1, 0, 0)
+	if err := r.Register("mash", mash); err != nil {
+		t.Fatal(err)
+	}
+	r.Register("boil", boil)
+	if err := r.Register("mash", boil); err == nil {
+		t.Error("expected error for duplicate name")
+	}
+	if names := r.Names(); len(names) != 2 || names[0] != "boil" || r.Len() != 2 {
+		t.Errorf("unexpected names %v", names)
+	}
+
+	mash.Set(10).UpdateDuration(0, time.Second)
+	if k := r.KPIs(1); k.SaturatedPercent != 50 {
+		t.Errorf("saturated %v%% != 50%%", k.SaturatedPercent)
+	}
+
+	snapshots, err := r.SnapshotAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var state struct{ Integral float64 }
+	if err := json.Unmarshal(snapshots["mash"], &state); err != nil || state.Integral != 1 {
+		t.Errorf("mash snapshot %s", snapshots["mash"])
+	}
+
+	r.ResetAll()
+	mash.Do(func(c *PIDController) {
+		if c.integral != 0 {
+			t.Errorf("integral not reset: %v", c.integral)
+		}
+	})
+
+	mash.Do(func(c *PIDController) { c.SetOccupancyProfiles(map[Occupancy]OccupancyProfile{OccupancyEco: {}}) })
+	if errs := r.SetOccupancyAll(OccupancyEco); len(errs) != 1 || errs["boil"] == nil {
+		t.Errorf("unexpected errors %v", errs)
+	}
+	if mash.Occupancy() != OccupancyEco {
+		t.Error("occupancy not applied")
+	}
+
+	r.Unregister("boil")
+	if _, ok := r.Get("boil"); ok {
+		t.Error("boil still registered")
+	}
+}
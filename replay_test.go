@@ -0,0 +1,52 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestReplay(t *testing.T) {
+	clock := NewManualClock(time.Date(2016, 3, 2, 0, 0, 0, 0, time.UTC))
+	recorded := func() *PIDController {
+		return NewPIDController(0.5, 0.1, 0).SetOutputLimits(0, 10).Set(5)
+	}
+	c := recorded().SetClock(clock)
+	rec := NewRecorder(100)
+	value := 0.0
+	for i := 0; i < 60; i++ {
+		if i == 30 {
+			c.Set(8)
+		}
+		clock.Advance(time.Second)
+		out := c.Update(value)
+		rec.Record(c)
+		value += (out - value) * 0.2
+	}
+	records := rec.Records()
+
+	observed := 0
+	same := recorded().SetObserver(func(UpdateInfo) { observed++ })
+	r := Replay(records, same)
+	if len(r.Outputs) != len(records) || r.Deviation != 0 {
+		t.Errorf("replay of the recorded configuration deviates by %v", r.Deviation)
+	}
+	if observed != len(records) {
+		t.Errorf("observer called %d times", observed)
+	}
+	same.UpdateDuration(0, time.Second)
+	if observed != len(records)+1 {
+		t.Error("observer not restored")
+	}
+	if len(r.Metrics.Steps) != 1 || r.Metrics.Steps[0].To != 8 || r.Metrics.IAE <= 0 {
+		t.Errorf("unexpected metrics: %+v", r.Metrics)
+	}
+
+	aggressive := Replay(records, NewPIDController(5, 0.1, 0).SetOutputLimits(0, 10))
+	if aggressive.Deviation <= 0 || aggressive.Travel <= r.Travel || aggressive.Saturated <= r.Saturated {
+		t.Errorf("aggressive tuning: %+v, recorded: %+v", aggressive, r)
+	}
+	if math.Abs(aggressive.Metrics.IAE-r.Metrics.IAE) > 1e-9 {
+		t.Error("metrics of the recorded values depend on the replayed tuning")
+	}
+}
@@ -0,0 +1,50 @@
+package pidctrl
+
+import "time"
+
+// Reset clears the dynamic state of the controller: the integral, the
+// previous process value and the time of the last update. Gains, limits,
+// setpoints and options are kept. The working setpoint jumps to the
+// requested setpoint. A paused controller stays paused and a configured soft
+// start starts again.
+func (c *PIDController) Reset() *PIDController {
+	c.integral = 0
+	c.prevValue = 0
+	c.prevSetpoint = 0
+	c.prevErr = 0
+	c.dFilt = 0
+	c.kickLeft = 0
+	c.lastUpdate = time.Time{}
+	c.jitterLast = time.Time{}
+	c.pending = 0
+	c.started = false
+	c.resumed = false
+	c.batchStarted = false
+	c.setpoint = c.goal()
+	c.terms = Terms{}
+	c.saturated = false
+	c.satDir = 0
+	c.windup = false
+	c.guarding = false
+	c.approaching = false
+	c.output = 0
+	c.smoothed = false
+	c.quantized = false
+	c.hystValid = false
+	c.retuning = false
+	c.iResume = false
+	c.satFault = saturationFault{after: c.satFault.after, callback: c.satFault.callback}
+	c.stale = staleWatch{timeout: c.stale.timeout, action: c.stale.action, failsafe: c.stale.failsafe, callback: c.stale.callback}
+	c.rateAlarm = rateAlarm{rise: c.rateAlarm.rise, fall: c.rateAlarm.fall, callback: c.rateAlarm.callback}
+	c.soft.restart()
+	c.osc.reset()
+	return c
+}
+
+// Reset clears the dynamic state of the controller.
+func (s *SafePIDController) Reset() *SafePIDController {
+	s.mu.Lock()
+	s.c.Reset()
+	s.mu.Unlock()
+	return s
+}
@@ -0,0 +1,46 @@
+package pidctrl
+
+import (
+	"context"
+	"time"
+)
+
+// Run drives the controller from a ticker until ctx is cancelled. On every
+// tick it reads the process value, updates the controller with the nominal
+// interval as duration and writes the output. Using the nominal interval
+// keeps scheduling jitter of the calling goroutine out of the integral and
+// derivative terms; ticks missed because read or write were too slow are
+// accounted for as multiples of the interval. Run returns ctx.Err().
+func (c *PIDController) Run(ctx context.Context, interval time.Duration, read func() float64, write func(float64)) error {
+	return run(ctx, interval, read, write, c.UpdateDuration)
+}
+
+// Run is the locked counterpart of PIDController.Run.
+func (s *SafePIDController) Run(ctx context.Context, interval time.Duration, read func() float64, write func(float64)) error {
+	return run(ctx, interval, read, write, s.UpdateDuration)
+}
+
+func run(ctx context.Context, interval time.Duration, read func() float64, write func(float64), update func(float64, time.Duration) float64) error {
+	return tick(ctx, interval, func(dt time.Duration) { write(update(read(), dt)) })
+}
+
+// tick calls step at the given interval until ctx is cancelled, with the
+// accumulated nominal duration of the ticks since the last call.
+func tick(ctx context.Context, interval time.Duration, step func(time.Duration)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	last := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-ticker.C:
+			ticks := (now.Sub(last) + interval/2) / interval
+			if ticks < 1 {
+				ticks = 1
+			}
+			last = last.Add(ticks * interval)
+			step(ticks * interval)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package pidctrl
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPIDController_Run(t *testing.T) {
+	c := NewPIDController(0, 1, 0).Set(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	var (
+		writes int
+		output float64
+	)
+	err := c.Run(ctx, time.Millisecond, func() float64 { return 0 }, func(v float64) {
+		writes++
+		output = v
+		if writes == 10 {
+			cancel()
+		}
+	})
+	if err != context.Canceled {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if writes != 10 {
+		t.Errorf("writes after cancel: %d", writes)
+	}
+	// the integral only depends on the nominal interval, not on jitter
+	if output < 0.0099 {
+		t.Errorf("output %f, expected at least 0.01", output)
+	}
+}
@@ -0,0 +1,128 @@
+package pidctrl
+
+import (
+	"sync"
+	"time"
+)
+
+// SafePIDController wraps a PIDController with a mutex so it can be tuned and
+// updated from multiple goroutines, e.g. an HTTP tuning handler and the
+// control loop.
+type SafePIDController struct {
+	mu sync.Mutex
+	c  *PIDController
+}
+
+// NewSafePIDController returns a new SafePIDController using the given gain
+// values.
+func NewSafePIDController(p, i, d float64) *SafePIDController {
+	return &SafePIDController{c: NewPIDController(p, i, d)}
+}
+
+// NewSafePIDControllerFrom wraps an existing controller. The controller must
+// not be used directly afterwards.
+func NewSafePIDControllerFrom(c *PIDController) *SafePIDController {
+	return &SafePIDController{c: c}
+}
+
+// Do calls f with the wrapped controller while holding the lock, giving
+// access to the parts of the PIDController API without a locked counterpart.
+// f must not retain the controller.
+func (s *SafePIDController) Do(f func(c *PIDController)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f(s.c)
+}
+
+// Set changes the setpoint of the controller.
+func (s *SafePIDController) Set(setpoint float64) *SafePIDController {
+	s.mu.Lock()
+	s.c.Set(setpoint)
+	s.mu.Unlock()
+	return s
+}
+
+// Get returns the setpoint of the controller.
+func (s *SafePIDController) Get() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.c.Get()
+}
+
+// SetPID atomically changes the P, I, and D constants.
+func (s *SafePIDController) SetPID(p, i, d float64) *SafePIDController {
+	s.mu.Lock()
+	s.c.SetPID(p, i, d)
+	s.mu.Unlock()
+	return s
+}
+
+// PID returns the P, I, and D constants
+func (s *SafePIDController) PID() (p, i, d float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.c.PID()
+}
+
+// SwapPID atomically replaces the P, I, and D constants and returns the
+// previous ones.
+func (s *SafePIDController) SwapPID(p, i, d float64) (oldP, oldI, oldD float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	oldP, oldI, oldD = s.c.PID()
+	s.c.SetPID(p, i, d)
+	return
+}
+
+// SetOutputLimits sets the min and max output values
+func (s *SafePIDController) SetOutputLimits(min, max float64) *SafePIDController {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.c.SetOutputLimits(min, max)
+	return s
+}
+
+// SetOutputLimitsE is like SetOutputLimits, but returns a MinMaxError instead
+// of panicking when min is greater than max.
+func (s *SafePIDController) SetOutputLimitsE(min, max float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.c.SetOutputLimitsE(min, max)
+}
+
+// OutputLimits returns the min and max output values
+func (s *SafePIDController) OutputLimits() (min, max float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.c.OutputLimits()
+}
+
+// Update is identical to UpdateDuration, but automatically keeps track of the
+// durations between updates.
+func (s *SafePIDController) Update(value float64) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.c.Update(value)
+}
+
+// UpdateDuration updates the controller with the given value and duration since
+// the last update. It returns the new output.
+func (s *SafePIDController) UpdateDuration(value float64, duration time.Duration) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.c.UpdateDuration(value, duration)
+}
+
+// SetOccupancy atomically switches to the given occupancy mode.
+func (s *SafePIDController) SetOccupancy(o Occupancy) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.c.SetOccupancy(o)
+}
+
+// Occupancy returns the current occupancy mode.
+func (s *SafePIDController) Occupancy() Occupancy {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.c.Occupancy()
+}
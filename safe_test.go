@@ -0,0 +1,36 @@
+package pidctrl
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSafePIDController_concurrent(t *testing.T) {
+	s := NewSafePIDController(1, 0.1, 0).SetOutputLimits(-100, 100)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			s.UpdateDuration(float64(i%10), time.Millisecond)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			s.SetPID(float64(i%3), 0.1, 0)
+			s.Set(float64(i % 5))
+		}
+	}()
+	wg.Wait()
+
+	if p, i, d := s.SwapPID(2, 0, 0); p != 0 || i != 0.1 || d != 0 {
+		t.Errorf("SwapPID returned unexpected gains: %v %v %v", p, i, d)
+	}
+	s.Do(func(c *PIDController) {
+		if p, _, _ := c.PID(); p != 2 {
+			t.Errorf("swapped P gain: %v != 2", p)
+		}
+	})
+}
@@ -0,0 +1,32 @@
+package pidctrl
+
+import "time"
+
+// SetSampleTime makes the controller recompute its output only once at least
+// d has elapsed since the last computation. Updates in between return the
+// previous output and their durations accumulate, which absorbs jitter of
+// the calling loop like the Arduino PID library does. 0 recomputes on every
+// update.
+func (c *PIDController) SetSampleTime(d time.Duration) *PIDController {
+	c.sampleTime = d
+	return c
+}
+
+// SampleTime returns the minimum time between output computations.
+func (c *PIDController) SampleTime() time.Duration {
+	return c.sampleTime
+}
+
+// sample accumulates duration and returns the duration since the last
+// computation and whether the output has to be recomputed.
+func (c *PIDController) sample(duration time.Duration) (time.Duration, bool) {
+	if c.sampleTime <= 0 || !c.started {
+		return duration, true
+	}
+	c.pending += duration
+	if c.pending < c.sampleTime {
+		return 0, false
+	}
+	duration, c.pending = c.pending, 0
+	return duration, true
+}
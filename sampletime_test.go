@@ -0,0 +1,25 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSampleTime(t *testing.T) {
+	c := NewPIDController(1, 1, 0).Set(10).SetSampleTime(time.Second)
+	if out := c.UpdateDuration(0, 0); out != 10 {
+		t.Fatalf("first output %v", out)
+	}
+	for i := 0; i < 3; i++ {
+		if out := c.UpdateDuration(5, 300*time.Millisecond); out != 10 {
+			t.Errorf("update %d: output %v, want held output", i, out)
+		}
+	}
+	// 1.2s accumulated
+	if out := c.UpdateDuration(5, 300*time.Millisecond); out != 5+6 {
+		t.Errorf("output %v", out)
+	}
+	if _, err := NewPIDControllerWithOptions(WithSampleTime(-time.Second)); err == nil {
+		t.Error("expected error for negative sample time")
+	}
+}
@@ -0,0 +1,164 @@
+package pidctrl
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Weekdays is a set of days of the week.
+type Weekdays uint8
+
+// Common sets of days.
+const (
+	WorkingDays Weekdays = 1<<time.Monday | 1<<time.Tuesday | 1<<time.Wednesday | 1<<time.Thursday | 1<<time.Friday
+	Weekend     Weekdays = 1<<time.Saturday | 1<<time.Sunday
+	Everyday             = WorkingDays | Weekend
+)
+
+// Days returns the set of the given days.
+func Days(days ...time.Weekday) Weekdays {
+	var w Weekdays
+	for _, d := range days {
+		w |= 1 << d
+	}
+	return w
+}
+
+// Contains returns true if d is in the set.
+func (w Weekdays) Contains(d time.Weekday) bool {
+	return w&(1<<d) != 0
+}
+
+// ScheduleEntry changes the setpoint at the time of day At, the duration
+// since midnight, on the given days.
+type ScheduleEntry struct {
+	Days     Weekdays      `json:"days"`
+	At       time.Duration `json:"at"`
+	Setpoint float64       `json:"setpoint"`
+}
+
+// Scheduler changes the setpoint of a controller according to a weekly
+// program of entries, like a programmable thermostat. A setpoint set
+// manually in between is kept until the next scheduled change.
+type Scheduler struct {
+	Controller *PIDController
+	Entries    []ScheduleEntry
+	Location   *time.Location // time zone of the entries, nil means local time
+
+	clock   Clock
+	applied time.Time // time of the last applied change
+}
+
+// NewScheduler returns a Scheduler for the controller c.
+func NewScheduler(c *PIDController, entries ...ScheduleEntry) *Scheduler {
+	return &Scheduler{Controller: c, Entries: entries}
+}
+
+// SetClock changes the time source used by Apply. Passing nil restores the
+// real clock.
+func (s *Scheduler) SetClock(clock Clock) *Scheduler {
+	s.clock = clock
+	return s
+}
+
+// Setpoint returns the setpoint scheduled at t and false if there are no
+// entries.
+func (s *Scheduler) Setpoint(t time.Time) (float64, bool) {
+	_, setpoint, ok := s.find(t, -1)
+	return setpoint, ok
+}
+
+// Next returns the time and setpoint of the first scheduled change after t,
+// e.g. for display, and false if there are no entries.
+func (s *Scheduler) Next(t time.Time) (time.Time, float64, bool) {
+	return s.find(t, 1)
+}
+
+// Apply sets the setpoint of the controller if a scheduled change became
+// due since the last call and returns the current setpoint and whether it
+// was changed.
+func (s *Scheduler) Apply() (float64, bool) {
+	now := time.Now()
+	if s.clock != nil {
+		now = s.clock.Now()
+	}
+	at, setpoint, ok := s.find(now, -1)
+	if !ok || at.Equal(s.applied) {
+		return s.Controller.Get(), false
+	}
+	s.applied = at
+	s.Controller.Set(setpoint)
+	return setpoint, true
+}
+
+// scheduleJSON is the stored form of a Scheduler program.
+type scheduleJSON struct {
+	Location string          `json:"location,omitempty"`
+	Entries  []ScheduleEntry `json:"entries"`
+}
+
+// SaveSchedule stores the entries and the location under key in the
+// schedules namespace.
+func (s *Scheduler) SaveSchedule(st Store, key string) error {
+	j := scheduleJSON{Entries: s.Entries}
+	if s.Location != nil {
+		j.Location = s.Location.String()
+	}
+	data, err := json.Marshal(j)
+	if err != nil {
+		return err
+	}
+	return st.Put(NamespaceSchedules, key, data)
+}
+
+// LoadSchedule replaces the entries and the location with the ones stored
+// under key in the schedules namespace.
+func (s *Scheduler) LoadSchedule(st Store, key string) error {
+	data, err := st.Get(NamespaceSchedules, key)
+	if err != nil {
+		return err
+	}
+	var j scheduleJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	var loc *time.Location
+	if j.Location != "" {
+		if loc, err = time.LoadLocation(j.Location); err != nil {
+			return err
+		}
+	}
+	s.Entries, s.Location = j.Entries, loc
+	return nil
+}
+
+// find returns the latest entry at or before t for dir -1 and the earliest
+// after t for dir 1, searching a week.
+func (s *Scheduler) find(t time.Time, dir int) (time.Time, float64, bool) {
+	loc := s.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	t = t.In(loc)
+	var (
+		best     time.Time
+		setpoint float64
+		found    bool
+	)
+	for k := 0; k <= 7; k++ {
+		day := time.Date(t.Year(), t.Month(), t.Day()+dir*k, 0, 0, 0, 0, loc)
+		for _, e := range s.Entries {
+			if !e.Days.Contains(day.Weekday()) {
+				continue
+			}
+			at := day.Add(e.At)
+			if (dir < 0 && at.After(t)) || (dir > 0 && !at.After(t)) {
+				continue
+			}
+			if !found || (dir < 0 && at.After(best)) || (dir > 0 && at.Before(best)) {
+				best, setpoint, found = at, e.Setpoint, true
+			}
+		}
+	}
+	return best, setpoint, found
+}
@@ -0,0 +1,72 @@
+package pidctrl
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestScheduler(t *testing.T) {
+	c := NewPIDController(1, 0, 0)
+	s := NewScheduler(c,
+		ScheduleEntry{Days: WorkingDays, At: 6 * time.Hour, Setpoint: 21},
+		ScheduleEntry{Days: WorkingDays, At: 22 * time.Hour, Setpoint: 17},
+		ScheduleEntry{Days: Weekend, At: 8 * time.Hour, Setpoint: 22},
+		ScheduleEntry{Days: Days(time.Saturday, time.Sunday), At: 23 * time.Hour, Setpoint: 18},
+	)
+	s.Location = time.UTC
+	// Friday, 4 March 2016
+	friday := time.Date(2016, 3, 4, 12, 0, 0, 0, time.UTC)
+	if sp, ok := s.Setpoint(friday); !ok || sp != 21 {
+		t.Errorf("friday noon: %v %v", sp, ok)
+	}
+	if at, sp, ok := s.Next(friday.Add(12 * time.Hour)); !ok || sp != 22 || !at.Equal(time.Date(2016, 3, 5, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("next after friday midnight: %v %v %v", at, sp, ok)
+	}
+	// Monday morning before 6 is still the sunday night program
+	if sp, _ := s.Setpoint(time.Date(2016, 3, 7, 5, 0, 0, 0, time.UTC)); sp != 18 {
+		t.Errorf("monday early: %v", sp)
+	}
+
+	clock := NewManualClock(friday)
+	s.SetClock(clock)
+	if sp, changed := s.Apply(); !changed || sp != 21 || c.Get() != 21 {
+		t.Errorf("apply: %v %v, setpoint %v", sp, changed, c.Get())
+	}
+	// a manual override lasts until the next change
+	c.Set(23)
+	clock.Advance(time.Hour)
+	if _, changed := s.Apply(); changed || c.Get() != 23 {
+		t.Errorf("override lost: %v", c.Get())
+	}
+	clock.Advance(9 * time.Hour)
+	if sp, changed := s.Apply(); !changed || sp != 17 {
+		t.Errorf("evening: %v %v", sp, changed)
+	}
+
+	if _, ok := NewScheduler(c).Setpoint(friday); ok {
+		t.Error("empty schedule has a setpoint")
+	}
+}
+
+func TestScheduler_Store(t *testing.T) {
+	st := NewFileStore(t.TempDir())
+	s := NewScheduler(NewPIDController(1, 0, 0),
+		ScheduleEntry{Days: WorkingDays, At: 6 * time.Hour, Setpoint: 21},
+		ScheduleEntry{Days: Weekend, At: 8*time.Hour + 30*time.Minute, Setpoint: 22},
+	)
+	s.Location = time.UTC
+	if err := s.SaveSchedule(st, "living"); err != nil {
+		t.Fatal(err)
+	}
+	loaded := NewScheduler(NewPIDController(1, 0, 0))
+	if err := loaded.LoadSchedule(st, "living"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded.Entries, s.Entries) || loaded.Location.String() != "UTC" {
+		t.Errorf("loaded %+v in %v", loaded.Entries, loaded.Location)
+	}
+	if err := loaded.LoadSchedule(st, "missing"); err != (NotFoundError{NamespaceSchedules, "missing"}) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
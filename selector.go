@@ -0,0 +1,80 @@
+package pidctrl
+
+import "time"
+
+// SelectMode selects whether a Selector picks the lowest or the highest
+// controller output.
+type SelectMode int
+
+const (
+	SelectMin SelectMode = iota
+	SelectMax
+)
+
+// Selector implements override control: several controllers with their own
+// process values act on one actuator, e.g. a flow controller limited by a
+// pressure controller, and the lowest or highest output wins. All
+// controllers are fed the selected output as tracking signal, see
+// SetTrackingSignal, so the controllers that are not selected do not wind up
+// and take over without a bump.
+type Selector struct {
+	Mode        SelectMode
+	Controllers []*PIDController
+	// OnSelect is called with the index of the newly selected controller
+	// when the selection changes. It may be nil.
+	OnSelect func(i int)
+
+	output   float64
+	selected int
+	started  bool
+}
+
+// NewSelector returns a new Selector for the given controllers.
+func NewSelector(mode SelectMode, controllers ...*PIDController) *Selector {
+	return &Selector{Mode: mode, Controllers: controllers}
+}
+
+// UpdateDuration updates controller i with values[i] and returns the
+// selected output. It panics if the number of values does not match the
+// number of controllers.
+func (s *Selector) UpdateDuration(values []float64, duration time.Duration) float64 {
+	if len(values) != len(s.Controllers) {
+		panic("pidctrl: number of values does not match the number of controllers")
+	}
+	selected := 0
+	var output float64
+	for i, c := range s.Controllers {
+		if s.started {
+			c.SetTrackingSignal(s.output)
+		}
+		out := c.UpdateDuration(values[i], duration)
+		if i == 0 || s.Mode == SelectMin && out < output || s.Mode == SelectMax && out > output {
+			selected, output = i, out
+		}
+	}
+	changed := s.started && selected != s.selected
+	s.output, s.selected, s.started = output, selected, true
+	if changed && s.OnSelect != nil {
+		s.OnSelect(selected)
+	}
+	return output
+}
+
+// Selected returns the index of the controller selected on the last update.
+func (s *Selector) Selected() int {
+	return s.selected
+}
+
+// Output returns the output of the last update.
+func (s *Selector) Output() float64 {
+	return s.output
+}
+
+// Reset resets all controllers and clears their tracking signals.
+func (s *Selector) Reset() *Selector {
+	for _, c := range s.Controllers {
+		c.Reset().ClearTrackingSignal()
+	}
+	s.output, s.selected, s.started = 0, 0, false
+	return s
+}
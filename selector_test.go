@@ -0,0 +1,56 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSelector(t *testing.T) {
+	flow := NewPIDController(2, 1, 0).SetOutputLimits(0, 100).Set(50)
+	pressure := NewPIDController(4, 2, 0).SetOutputLimits(0, 100).Set(10)
+	s := NewSelector(SelectMin, flow, pressure)
+	var selections []int
+	s.OnSelect = func(i int) { selections = append(selections, i) }
+
+	out := 0.0
+	for i := 0; i < 1000; i++ {
+		out = s.UpdateDuration([]float64{out / 4, out / 6}, 100*time.Millisecond)
+	}
+	if math.Abs(out-60) > 0.5 || s.Selected() != 1 || s.Output() != out {
+		t.Errorf("pressure override not active: output %v selected %d", out, s.Selected())
+	}
+	if math.Abs(flow.Integral()-out) > 0.5 {
+		t.Errorf("flow controller wound up: integral %v", flow.Integral())
+	}
+
+	// Lowering the demand hands control back to the flow controller.
+	flow.Set(12)
+	for i := 0; i < 1000; i++ {
+		out = s.UpdateDuration([]float64{out / 4, out / 6}, 100*time.Millisecond)
+	}
+	if math.Abs(out-48) > 0.5 || s.Selected() != 0 {
+		t.Errorf("flow control not resumed: output %v selected %d", out, s.Selected())
+	}
+	if len(selections) != 1 || selections[0] != 0 {
+		t.Errorf("unexpected selections %v", selections)
+	}
+
+	s.Reset()
+	if _, ok := flow.TrackingSignal(); ok || s.Output() != 0 {
+		t.Error("tracking not cleared by Reset")
+	}
+}
+
+func TestSelector_Max(t *testing.T) {
+	s := NewSelector(SelectMax, NewPIDController(1, 0, 0).Set(5), NewPIDController(1, 0, 0).Set(8))
+	if out := s.UpdateDuration([]float64{0, 0}, time.Second); out != 8 || s.Selected() != 1 {
+		t.Errorf("output %v selected %d", out, s.Selected())
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for value count mismatch")
+		}
+	}()
+	s.UpdateDuration([]float64{0}, time.Second)
+}
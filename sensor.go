@@ -0,0 +1,161 @@
+package pidctrl
+
+import (
+	"context"
+	"time"
+)
+
+// Quality is the quality of a sensor reading.
+type Quality int
+
+const (
+	// QualityGood readings are used as they are.
+	QualityGood Quality = iota
+	// QualityUncertain readings, e.g. out of calibration or from a
+	// substituted source, are used only if the policy accepts them.
+	QualityUncertain
+	// QualityBad readings, e.g. from a disconnected or failed sensor, are
+	// never used.
+	QualityBad
+)
+
+var qualityNames = [...]string{"good", "uncertain", "bad"}
+
+func (q Quality) String() string {
+	if q < 0 || int(q) >= len(qualityNames) {
+		return "unknown"
+	}
+	return qualityNames[q]
+}
+
+// Sensor provides process values along with their quality and the time they
+// were sampled.
+type Sensor interface {
+	Read() (value float64, quality Quality, timestamp time.Time)
+}
+
+// SensorFunc adapts a function to the Sensor interface.
+type SensorFunc func() (value float64, quality Quality, timestamp time.Time)
+
+// Read implements Sensor.
+func (f SensorFunc) Read() (float64, Quality, time.Time) {
+	return f()
+}
+
+// SensorAction selects what RunSensor does with an unusable reading.
+type SensorAction int
+
+const (
+	// SensorHold pauses the controller and holds its output.
+	SensorHold SensorAction = iota
+	// SensorSubstitute updates the controller with the substitute value,
+	// e.g. a model prediction.
+	SensorSubstitute
+	// SensorFailsafe pauses the controller and outputs the failsafe value.
+	SensorFailsafe
+)
+
+// SensorPolicy configures how RunSensor handles bad quality and late
+// readings.
+type SensorPolicy struct {
+	// MaxAge is the maximum age of a reading at the time it is read, older
+	// readings are unusable. 0 accepts readings of any age.
+	MaxAge time.Duration
+	// AcceptUncertain makes readings of uncertain quality usable.
+	AcceptUncertain bool
+	// Action is applied to unusable readings.
+	Action SensorAction
+	// Substitute returns the process value used by SensorSubstitute.
+	// Without it, unusable readings are handled like SensorHold.
+	Substitute func() float64
+	// Failsafe is the output of SensorFailsafe.
+	Failsafe float64
+	// Watchdog, if set, is kicked on every usable reading, so that it
+	// expires once the sensor stays unusable for its timeout.
+	Watchdog *Watchdog
+	// OnUnusable, if set, is called with every unusable reading.
+	OnUnusable func(value float64, quality Quality, age time.Duration)
+}
+
+// Usable reports whether a reading of the given quality and age is usable.
+func (p SensorPolicy) Usable(quality Quality, age time.Duration) bool {
+	if quality == QualityBad || (quality == QualityUncertain && !p.AcceptUncertain) {
+		return false
+	}
+	return p.MaxAge <= 0 || age <= p.MaxAge
+}
+
+// RunSensor is like Run, but reads the process value from s and applies
+// policy to readings of bad quality or too old. Controllers paused for
+// unusable readings resume without a bump once the readings are usable
+// again; a pause of the caller is left alone.
+func (c *PIDController) RunSensor(ctx context.Context, interval time.Duration, s Sensor, write func(float64), policy SensorPolicy) error {
+	return runSensor(ctx, interval, s, write, policy, sensorLoop{
+		update: c.UpdateDuration,
+		paused: c.Paused,
+		pause:  func() { c.Pause() },
+		resume: func() { c.Resume() },
+		output: c.Output,
+	})
+}
+
+// RunSensor is the locked counterpart of PIDController.RunSensor.
+func (s *SafePIDController) RunSensor(ctx context.Context, interval time.Duration, sensor Sensor, write func(float64), policy SensorPolicy) error {
+	return runSensor(ctx, interval, sensor, write, policy, sensorLoop{
+		update: s.UpdateDuration,
+		paused: s.Paused,
+		pause:  func() { s.Pause() },
+		resume: func() { s.Resume() },
+		output: func() (out float64) {
+			s.Do(func(c *PIDController) { out = c.Output() })
+			return out
+		},
+	})
+}
+
+// sensorLoop are the controller operations used by runSensor.
+type sensorLoop struct {
+	update        func(float64, time.Duration) float64
+	paused        func() bool
+	pause, resume func()
+	output        func() float64
+}
+
+func runSensor(ctx context.Context, interval time.Duration, s Sensor, write func(float64), p SensorPolicy, l sensorLoop) error {
+	held := false // paused by runSensor
+	return tick(ctx, interval, func(dt time.Duration) {
+		value, quality, ts := s.Read()
+		age := time.Since(ts)
+		if p.Usable(quality, age) {
+			if p.Watchdog != nil {
+				p.Watchdog.Kick()
+			}
+			if held {
+				held = false
+				l.resume()
+			}
+			write(l.update(value, dt))
+			return
+		}
+		if p.OnUnusable != nil {
+			p.OnUnusable(value, quality, age)
+		}
+		switch {
+		case p.Action == SensorSubstitute && p.Substitute != nil:
+			if held {
+				held = false
+				l.resume()
+			}
+			write(l.update(p.Substitute(), dt))
+			return
+		case !held && !l.paused():
+			held = true
+			l.pause()
+		}
+		if p.Action == SensorFailsafe {
+			write(p.Failsafe)
+		} else {
+			write(l.output())
+		}
+	})
+}
@@ -0,0 +1,91 @@
+package pidctrl
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSensorPolicy_Usable(t *testing.T) {
+	p := SensorPolicy{MaxAge: time.Second}
+	tests := []struct {
+		quality Quality
+		age     time.Duration
+		want    bool
+	}{
+		{QualityGood, 0, true},
+		{QualityGood, time.Second, true},
+		{QualityGood, 2 * time.Second, false},
+		{QualityUncertain, 0, false},
+		{QualityBad, 0, false},
+	}
+	for _, tt := range tests {
+		if got := p.Usable(tt.quality, tt.age); got != tt.want {
+			t.Errorf("Usable(%v, %v) = %v, want %v", tt.quality, tt.age, got, tt.want)
+		}
+	}
+	p.AcceptUncertain = true
+	if !p.Usable(QualityUncertain, 0) || p.Usable(QualityBad, 0) {
+		t.Error("uncertain readings not accepted")
+	}
+	if QualityBad.String() != "bad" || Quality(7).String() != "unknown" {
+		t.Error("unexpected quality names")
+	}
+}
+
+// runScripted runs c with policy against readings returned in turn, each
+// with the given quality and age, and returns the written outputs.
+func runScripted(t *testing.T, c *PIDController, policy SensorPolicy, readings []float64, qualities []Quality, ages []time.Duration) []float64 {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	n := 0
+	s := SensorFunc(func() (float64, Quality, time.Time) {
+		v, q, ts := readings[n], qualities[n], time.Now().Add(-ages[n])
+		n++
+		return v, q, ts
+	})
+	var outs []float64
+	c.RunSensor(ctx, time.Millisecond, s, func(v float64) {
+		outs = append(outs, v)
+		if len(outs) == len(readings) {
+			cancel()
+		}
+	}, policy)
+	return outs
+}
+
+func TestPIDController_RunSensor(t *testing.T) {
+	readings := []float64{8, 100, 100, 9}
+	qualities := []Quality{QualityGood, QualityBad, QualityGood, QualityGood}
+	ages := []time.Duration{0, 0, time.Hour, 0}
+
+	var unusable int
+	c := NewPIDController(1, 0, 0).Set(10)
+	outs := runScripted(t, c, SensorPolicy{MaxAge: time.Minute, OnUnusable: func(float64, Quality, time.Duration) { unusable++ }}, readings, qualities, ages)
+	if want := []float64{2, 2, 2, 1}; !floatsEqual(outs, want) {
+		t.Errorf("hold: outputs %v, want %v", outs, want)
+	}
+	if unusable != 2 || c.Paused() {
+		t.Errorf("unusable %d, paused %v", unusable, c.Paused())
+	}
+
+	c = NewPIDController(1, 0, 0).Set(10)
+	outs = runScripted(t, c, SensorPolicy{MaxAge: time.Minute, Action: SensorFailsafe, Failsafe: -1}, readings, qualities, ages)
+	if want := []float64{2, -1, -1, 1}; !floatsEqual(outs, want) {
+		t.Errorf("failsafe: outputs %v, want %v", outs, want)
+	}
+
+	c = NewPIDController(1, 0, 0).Set(10)
+	outs = runScripted(t, c, SensorPolicy{MaxAge: time.Minute, Action: SensorSubstitute, Substitute: func() float64 { return 5 }}, readings, qualities, ages)
+	if want := []float64{2, 5, 5, 1}; !floatsEqual(outs, want) {
+		t.Errorf("substitute: outputs %v, want %v", outs, want)
+	}
+
+	// a pause of the caller is kept
+	c = NewPIDController(1, 0, 0).Set(10).Pause()
+	outs = runScripted(t, c, SensorPolicy{}, readings[:2], qualities[:2], ages[:2])
+	if want := []float64{0, 0}; !floatsEqual(outs, want) || !c.Paused() {
+		t.Errorf("paused: outputs %v, paused %v", outs, c.Paused())
+	}
+}
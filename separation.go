@@ -0,0 +1,23 @@
+package pidctrl
+
+import "math"
+
+// SetIntegralSeparation enables conditional integration: the integral only
+// accumulates while the absolute error is below band, which prevents windup
+// during large setpoint changes while keeping zero steady-state error near
+// the setpoint. Outside of the band the integral is frozen. A band of 0 (the
+// default) always integrates.
+func (c *PIDController) SetIntegralSeparation(band float64) *PIDController {
+	c.iBand = math.Abs(band)
+	return c
+}
+
+// IntegralSeparation returns the conditional integration band.
+func (c *PIDController) IntegralSeparation() float64 {
+	return c.iBand
+}
+
+// separated reports whether integration is suspended for err.
+func (c *PIDController) separated(err float64) bool {
+	return c.iBand > 0 && math.Abs(err) >= c.iBand
+}
@@ -0,0 +1,19 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIntegralSeparation(t *testing.T) {
+	c := NewPIDController(0, 1, 0).SetIntegralSeparation(5).Set(10)
+	if out := c.UpdateDuration(0, time.Second); out != 0 {
+		t.Errorf("integrated outside of the band: %v", out)
+	}
+	if out := c.UpdateDuration(8, time.Second); out != 2 {
+		t.Errorf("integral inside of the band %v != 2", out)
+	}
+	if out := c.UpdateDuration(-10, time.Second); out != 2 {
+		t.Errorf("integral not frozen outside of the band: %v", out)
+	}
+}
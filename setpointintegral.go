@@ -0,0 +1,62 @@
+package pidctrl
+
+import "math"
+
+// SetpointIntegral selects what happens to the integral when the setpoint
+// changes by more than the threshold of SetSetpointIntegral.
+type SetpointIntegral int
+
+const (
+	// SetpointIntegralKeep keeps the integral, the default.
+	SetpointIntegralKeep SetpointIntegral = iota
+	// SetpointIntegralZero resets the integral to zero.
+	SetpointIntegralZero
+	// SetpointIntegralRescale scales the integral by the ratio of the new to
+	// the old setpoint, for processes whose steady-state output is
+	// proportional to the setpoint. The integral is reset to zero if the old
+	// setpoint was zero.
+	SetpointIntegralRescale
+)
+
+// SetSetpointIntegral selects how Set treats the integral of a running
+// controller when the setpoint changes by more than threshold, so that
+// large setpoint steps don't drag along an integral accumulated for the old
+// setpoint. Smaller changes always keep the integral.
+func (c *PIDController) SetSetpointIntegral(policy SetpointIntegral, threshold float64) *PIDController {
+	c.spIntegral, c.spThreshold = policy, math.Abs(threshold)
+	return c
+}
+
+// SetpointIntegral returns the integral policy on setpoint changes and its
+// threshold.
+func (c *PIDController) SetpointIntegral() (SetpointIntegral, float64) {
+	return c.spIntegral, c.spThreshold
+}
+
+// applySetpointIntegral applies the integral policy to a setpoint change
+// from old to new.
+func (c *PIDController) applySetpointIntegral(old, new float64) {
+	if !c.started || math.Abs(new-old) <= c.spThreshold {
+		return
+	}
+	switch c.spIntegral {
+	case SetpointIntegralZero:
+		c.integral = 0
+	case SetpointIntegralRescale:
+		if old == 0 {
+			c.integral = 0
+		} else {
+			c.integral *= new / old
+		}
+		c.clampIntegral()
+	}
+}
+
+// SetSetpointIntegral selects how Set treats the integral on large setpoint
+// changes.
+func (s *SafePIDController) SetSetpointIntegral(policy SetpointIntegral, threshold float64) *SafePIDController {
+	s.mu.Lock()
+	s.c.SetSetpointIntegral(policy, threshold)
+	s.mu.Unlock()
+	return s
+}
@@ -0,0 +1,36 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPIDController_SetpointIntegral(t *testing.T) {
+	for _, tt := range []struct {
+		policy   SetpointIntegral
+		setpoint float64
+		want     float64
+	}{
+		{SetpointIntegralKeep, 40, 10},
+		{SetpointIntegralZero, 40, 0},
+		{SetpointIntegralZero, 15, 10}, // below the threshold
+		{SetpointIntegralRescale, 40, 20},
+		{SetpointIntegralRescale, 0, 0},
+	} {
+		c := NewPIDController(0, 1, 0).SetOutputLimits(0, 100).Set(20).SetSetpointIntegral(tt.policy, 10)
+		c.UpdateDuration(10, time.Second)
+		c.Set(tt.setpoint)
+		if got := c.Integral(); got != tt.want {
+			t.Errorf("%v to %v: integral %v, want %v", tt.policy, tt.setpoint, got, tt.want)
+		}
+	}
+	c := NewPIDController(0, 1, 0).SetSetpointIntegral(SetpointIntegralZero, 0).SetIntegral(5).Set(50)
+	if c.Integral() != 5 {
+		t.Error("integral of a controller not started yet reset")
+	}
+	c.UpdateDuration(0, time.Second)
+	c.Set(0)
+	if c.Integral() != 0 {
+		t.Error("integral kept after the first update")
+	}
+}
@@ -0,0 +1,87 @@
+package pidctrl
+
+import "math"
+
+// Limits are output limits in Settings. Unbounded limits are nil, which
+// encodes as null in JSON.
+type Limits struct {
+	Min *float64 `json:"min"`
+	Max *float64 `json:"max"`
+}
+
+// Settings are the remotely tunable settings of a controller. In changes
+// applied with ApplySettings, nil fields are left alone.
+type Settings struct {
+	Gains        *Gains     `json:"gains,omitempty"`
+	Setpoint     *float64   `json:"setpoint,omitempty"`
+	OutputLimits *Limits    `json:"output_limits,omitempty"`
+	Occupancy    *Occupancy `json:"occupancy,omitempty"`
+	// Mode is the operating mode, encoded by name like "manual".
+	Mode *ControlMode `json:"mode,omitempty"`
+}
+
+// Settings returns the complete settings of the controller.
+func (c *PIDController) Settings() Settings {
+	gains, setpoint, occupancy, mode := c.Gains(), c.target, c.occupancy, c.mode
+	return Settings{
+		Gains:        &gains,
+		Setpoint:     &setpoint,
+		OutputLimits: &Limits{Min: finiteOrNil(c.outMin), Max: finiteOrNil(c.outMax)},
+		Occupancy:    &occupancy,
+		Mode:         &mode,
+	}
+}
+
+// ApplySettings changes the settings that are set in s. Nothing is changed if
+// s is invalid, including mode transitions SetMode does not allow. The mode
+// is changed last.
+func (c *PIDController) ApplySettings(s Settings) error {
+	if s.Mode != nil && *s.Mode != c.mode && !c.mode.allows(*s.Mode) {
+		return ModeTransitionError{From: c.mode, To: *s.Mode}
+	}
+	min, max := c.outMin, c.outMax
+	if s.OutputLimits != nil {
+		min, max = math.Inf(-1), math.Inf(0)
+		if s.OutputLimits.Min != nil {
+			min = *s.OutputLimits.Min
+		}
+		if s.OutputLimits.Max != nil {
+			max = *s.OutputLimits.Max
+		}
+		if min > max {
+			return MinMaxError{min, max}
+		}
+	}
+	if s.Occupancy != nil {
+		if err := c.SetOccupancy(*s.Occupancy); err != nil {
+			return err
+		}
+	}
+	if s.Gains != nil {
+		c.SetGains(*s.Gains)
+	}
+	if s.Setpoint != nil {
+		c.Set(*s.Setpoint)
+	}
+	if s.OutputLimits != nil {
+		c.SetOutputLimits(min, max)
+	}
+	if s.Mode != nil {
+		return c.SetMode(*s.Mode)
+	}
+	return nil
+}
+
+// Settings returns the complete settings of the controller.
+func (s *SafePIDController) Settings() Settings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.c.Settings()
+}
+
+// ApplySettings atomically changes the settings that are set in settings.
+func (s *SafePIDController) ApplySettings(settings Settings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.c.ApplySettings(settings)
+}
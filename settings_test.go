@@ -0,0 +1,58 @@
+package pidctrl
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPIDController_ApplySettings(t *testing.T) {
+	c := NewPIDController(1, 0, 0).Set(10)
+	one, two := 1.0, 2.0
+	if err := c.ApplySettings(Settings{OutputLimits: &Limits{Min: &two, Max: &one}, Setpoint: &one}); err == nil {
+		t.Error("expected error for swapped limits")
+	}
+	if c.Get() != 10 {
+		t.Errorf("rejected settings changed setpoint to %v", c.Get())
+	}
+	if err := c.ApplySettings(Settings{OutputLimits: &Limits{Max: &two}, Gains: &Gains{P: 3}}); err != nil {
+		t.Fatal(err)
+	}
+	s := c.Settings()
+	if *s.Gains != (Gains{P: 3}) || *s.Setpoint != 10 || s.OutputLimits.Min != nil || *s.OutputLimits.Max != 2 {
+		t.Errorf("unexpected settings: %+v", s)
+	}
+	if min, _ := c.OutputLimits(); !math.IsInf(min, -1) {
+		t.Errorf("omitted min limit not unbounded: %v", min)
+	}
+}
+
+func TestPIDController_ApplySettingsMode(t *testing.T) {
+	c := NewPIDController(1, 0, 0).Set(10)
+	c.UpdateDuration(0, time.Second)
+	manual, auto := ModeManual, ModeAuto
+	if err := c.ApplySettings(Settings{Mode: &manual}); err != nil {
+		t.Fatal(err)
+	}
+	if c.Mode() != ModeManual || *c.Settings().Mode != ModeManual {
+		t.Errorf("mode %v not applied", c.Mode())
+	}
+	if c.ManualOutput() != 10 {
+		t.Errorf("manual output %v not initialized bumplessly", c.ManualOutput())
+	}
+	if err := c.SetMode(ModeFailsafe); err != nil {
+		t.Fatal(err)
+	}
+	one := 1.0
+	if err := c.ApplySettings(Settings{Mode: &auto, Setpoint: &one}); err != (ModeTransitionError{From: ModeFailsafe, To: ModeAuto}) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if c.Get() != 10 {
+		t.Errorf("rejected settings changed setpoint to %v", c.Get())
+	}
+	data, err := json.Marshal(Settings{Mode: &manual})
+	if err != nil || string(data) != `{"mode":"manual"}` {
+		t.Errorf("encoding %s %v", data, err)
+	}
+}
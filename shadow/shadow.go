@@ -0,0 +1,127 @@
+// Package shadow maps controller state onto cloud device shadow / device
+// twin documents, so setpoints and tunings can be managed from the cloud.
+//
+// The reported state of a controller is its pidctrl.Settings plus the live
+// process value and output. Desired state changes are pidctrl.Settings, only
+// the fields present in the document are applied. A Format translates
+// between these and the document layout of a particular service; formats for
+// AWS IoT device shadows and Azure IoT Hub device twins are provided. The
+// transport (MQTT, HTTPS, SDK client) is left to the application.
+package shadow
+
+import (
+	"encoding/json"
+
+	"github.com/felixge/pidctrl"
+)
+
+// State is the reported state of a controller.
+type State struct {
+	pidctrl.Settings
+	Value  float64 `json:"value"`
+	Output float64 `json:"output"`
+}
+
+// Format encodes reported state and decodes desired state changes for a
+// particular service.
+type Format interface {
+	// EncodeReported returns the document reporting the given state.
+	EncodeReported(s State) ([]byte, error)
+	// DecodeDesired extracts the desired settings from a document.
+	DecodeDesired(doc []byte) (pidctrl.Settings, error)
+}
+
+// Report returns the current reported state of c.
+func Report(c *pidctrl.SafePIDController) State {
+	var s State
+	c.Do(func(c *pidctrl.PIDController) {
+		s = State{Settings: c.Settings(), Value: c.ProcessValue(), Output: c.Output()}
+	})
+	return s
+}
+
+// EncodeReported returns the document reporting the current state of c in
+// format f.
+func EncodeReported(f Format, c *pidctrl.SafePIDController) ([]byte, error) {
+	return f.EncodeReported(Report(c))
+}
+
+// ApplyDesired decodes a desired state document in format f and applies it
+// to c. Nothing is changed if the document is invalid.
+func ApplyDesired(f Format, c *pidctrl.SafePIDController, doc []byte) error {
+	s, err := f.DecodeDesired(doc)
+	if err != nil {
+		return err
+	}
+	return c.ApplySettings(s)
+}
+
+// AWS is the Format of AWS IoT device shadows. Reported documents are
+// published to the shadow update topic, desired documents can be either
+// full shadow documents or the deltas published on the update/delta topic.
+//
+// see https://docs.aws.amazon.com/iot/latest/developerguide/device-shadow-document.html
+var AWS Format = awsFormat{}
+
+type awsFormat struct{}
+
+func (awsFormat) EncodeReported(s State) ([]byte, error) {
+	var doc struct {
+		State struct {
+			Reported State `json:"reported"`
+		} `json:"state"`
+	}
+	doc.State.Reported = s
+	return json.Marshal(doc)
+}
+
+func (awsFormat) DecodeDesired(data []byte) (pidctrl.Settings, error) {
+	var doc struct {
+		State json.RawMessage `json:"state"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return pidctrl.Settings{}, err
+	}
+	// full documents nest the desired state, deltas carry it directly
+	var full struct {
+		Desired *pidctrl.Settings `json:"desired"`
+	}
+	if err := json.Unmarshal(doc.State, &full); err != nil {
+		return pidctrl.Settings{}, err
+	}
+	if full.Desired != nil {
+		return *full.Desired, nil
+	}
+	var s pidctrl.Settings
+	err := json.Unmarshal(doc.State, &s)
+	return s, err
+}
+
+// Azure is the Format of Azure IoT Hub device twins. Reported documents are
+// reported properties patches, desired documents are desired properties
+// patches; the $version metadata is ignored.
+//
+// see https://learn.microsoft.com/azure/iot-hub/iot-hub-devguide-device-twins
+var Azure Format = azureFormat{}
+
+type azureFormat struct{}
+
+func (azureFormat) EncodeReported(s State) ([]byte, error) {
+	return json.Marshal(s)
+}
+
+func (azureFormat) DecodeDesired(data []byte) (pidctrl.Settings, error) {
+	var doc struct {
+		Properties *struct {
+			Desired *pidctrl.Settings `json:"desired"`
+		} `json:"properties"`
+		pidctrl.Settings
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return pidctrl.Settings{}, err
+	}
+	if doc.Properties != nil && doc.Properties.Desired != nil {
+		return *doc.Properties.Desired, nil
+	}
+	return doc.Settings, nil
+}
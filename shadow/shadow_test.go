@@ -0,0 +1,65 @@
+package shadow
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+func TestAWS(t *testing.T) {
+	c := pidctrl.NewSafePIDController(2, 0, 0).Set(20)
+	c.UpdateDuration(18, time.Second)
+	doc, err := EncodeReported(AWS, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"state":{"reported":{"gains":{"p":2,"i":0,"d":0},"setpoint":20,"output_limits":{"min":null,"max":null},"occupancy":"","mode":"auto","value":18,"output":4}}}`
+	if string(doc) != want {
+		t.Errorf("reported:\n%s\n%s", doc, want)
+	}
+
+	for _, desired := range []string{
+		`{"state":{"desired":{"setpoint":21}},"version":3}`,
+		`{"version":4,"timestamp":1456876800,"state":{"setpoint":21}}`,
+	} {
+		c.Set(0)
+		if err := ApplyDesired(AWS, c, []byte(desired)); err != nil {
+			t.Fatal(err)
+		}
+		if c.Get() != 21 {
+			t.Errorf("%s: setpoint %v != 21", desired, c.Get())
+		}
+	}
+}
+
+func TestAzure(t *testing.T) {
+	c := pidctrl.NewSafePIDController(2, 0, 0)
+	if err := ApplyDesired(Azure, c, []byte(`{"gains":{"p":1,"i":0.5,"d":0},"$version":7}`)); err != nil {
+		t.Fatal(err)
+	}
+	if p, i, _ := c.PID(); p != 1 || i != 0.5 {
+		t.Errorf("gains not applied: %v %v", p, i)
+	}
+	if err := ApplyDesired(Azure, c, []byte(`{"properties":{"desired":{"setpoint":5},"reported":{}}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if c.Get() != 5 {
+		t.Errorf("setpoint %v != 5", c.Get())
+	}
+}
+
+func ExampleApplyDesired() {
+	c := pidctrl.NewSafePIDController(1, 0.1, 0)
+	// payload received on $aws/things/incubator/shadow/update/delta
+	delta := []byte(`{"version":12,"state":{"setpoint":37.5}}`)
+	if err := ApplyDesired(AWS, c, delta); err != nil {
+		fmt.Println(err)
+		return
+	}
+	// publish to $aws/things/incubator/shadow/update
+	doc, _ := EncodeReported(AWS, c)
+	fmt.Println(string(doc))
+	// Output: {"state":{"reported":{"gains":{"p":1,"i":0.1,"d":0},"setpoint":37.5,"output_limits":{"min":null,"max":null},"occupancy":"","mode":"auto","value":0,"output":0}}}
+}
@@ -0,0 +1,106 @@
+package sim
+
+import (
+	"math"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+// Harness runs a controller in closed loop against a plant.
+type Harness struct {
+	Controller *pidctrl.PIDController
+	Plant      Plant
+	Duration   time.Duration
+	// Dt is the sample time, 1s if 0.
+	Dt time.Duration
+	// SettlingBand is the error band around the setpoint, as a fraction of
+	// the step size, that defines the settling time. 0.02 if 0.
+	SettlingBand float64
+	// Recorder optionally captures every update.
+	Recorder *pidctrl.Recorder
+	// Clock is optionally installed as controller clock and advanced in
+	// simulated time, e.g. to timestamp records.
+	Clock *pidctrl.ManualClock
+}
+
+// Result are the performance metrics of a step response. Times are relative
+// to the start of the run. RiseTime and SettlingTime are negative if the
+// value never rose or settled.
+type Result struct {
+	RiseTime         time.Duration // from 10% to 90% of the step
+	Overshoot        float64       // peak beyond the setpoint in percent of the step
+	SettlingTime     time.Duration // time after which the value stays in the settling band
+	IAE              float64       // integral of the absolute error
+	ISE              float64       // integral of the squared error
+	ITAE             float64       // integral of the time-weighted absolute error
+	SteadyStateError float64       // setpoint minus the final value
+	Saturated        float64       // fraction of updates with saturated output
+}
+
+// Run runs the step response from the current plant value to the current
+// setpoint of the controller and returns its metrics.
+func (h *Harness) Run() Result {
+	dt := h.Dt
+	if dt <= 0 {
+		dt = time.Second
+	}
+	band := h.SettlingBand
+	if band <= 0 {
+		band = 0.02
+	}
+	c, p := h.Controller, h.Plant
+	if h.Clock != nil {
+		c.SetClock(h.Clock)
+	}
+	initial, setpoint := p.Value(), c.Get()
+	span := setpoint - initial
+
+	var (
+		r          = Result{RiseTime: -1, SettlingTime: -1}
+		rise10     = time.Duration(-1)
+		peak       float64
+		saturated  int
+		steps      = int(h.Duration / dt)
+		value      = initial
+		outsideEnd time.Duration
+	)
+	for i := 0; i <= steps; i++ {
+		t := time.Duration(i) * dt
+		out := c.UpdateDuration(value, dt)
+		if h.Recorder != nil {
+			h.Recorder.Record(c)
+		}
+		if c.Saturated() {
+			saturated++
+		}
+		err := c.WorkingSetpoint() - value
+		r.IAE += math.Abs(err) * dt.Seconds()
+		r.ISE += err * err * dt.Seconds()
+		r.ITAE += t.Seconds() * math.Abs(err) * dt.Seconds()
+		if span != 0 {
+			progress := (value - initial) / span
+			if rise10 < 0 && progress >= 0.1 {
+				rise10 = t
+			}
+			if r.RiseTime < 0 && rise10 >= 0 && progress >= 0.9 {
+				r.RiseTime = t - rise10
+			}
+			peak = math.Max(peak, progress-1)
+			if math.Abs(setpoint-value) > band*math.Abs(span) {
+				outsideEnd = t + dt
+			}
+		}
+		value = p.Update(out, dt)
+		if h.Clock != nil {
+			h.Clock.Advance(dt)
+		}
+	}
+	if span != 0 && math.Abs(setpoint-value) <= band*math.Abs(span) {
+		r.SettlingTime = outsideEnd
+	}
+	r.Overshoot = peak * 100
+	r.SteadyStateError = setpoint - value
+	r.Saturated = float64(saturated) / float64(steps+1)
+	return r
+}
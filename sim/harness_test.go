@@ -0,0 +1,56 @@
+package sim
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+func TestHarness(t *testing.T) {
+	rec := pidctrl.NewRecorder(1000)
+	h := &Harness{
+		Controller: pidctrl.NewPIDController(2, 0.5, 0).SetOutputLimits(0, 100).Set(10),
+		Plant:      NewSecondOrder(1, 1, 0.7),
+		Duration:   60 * time.Second,
+		Dt:         100 * time.Millisecond,
+		Recorder:   rec,
+		Clock:      pidctrl.NewManualClock(time.Unix(0, 0)),
+	}
+	r := h.Run()
+	if r.RiseTime <= 0 || r.RiseTime > 5*time.Second {
+		t.Errorf("rise time %v", r.RiseTime)
+	}
+	if r.Overshoot <= 0 || r.Overshoot > 50 {
+		t.Errorf("overshoot %v%%", r.Overshoot)
+	}
+	if r.SettlingTime < r.RiseTime || r.SettlingTime > 40*time.Second {
+		t.Errorf("settling time %v", r.SettlingTime)
+	}
+	if math.Abs(r.SteadyStateError) > 0.01 {
+		t.Errorf("steady state error %v", r.SteadyStateError)
+	}
+	if r.IAE <= 0 || r.ISE <= 0 || r.ITAE <= 0 {
+		t.Errorf("error integrals %v %v %v", r.IAE, r.ISE, r.ITAE)
+	}
+	if rec.Len() != 601 {
+		t.Errorf("%d records", rec.Len())
+	}
+	if last := rec.Records()[600].Time; !last.Equal(time.Unix(60, 0)) {
+		t.Errorf("last record at %v", last)
+	}
+}
+
+func TestHarness_NoSettling(t *testing.T) {
+	h := &Harness{
+		Controller: pidctrl.NewPIDController(1, 0, 0).Set(10),
+		Plant:      NewFirstOrder(1, time.Second),
+		Duration:   time.Minute,
+	}
+	r := h.Run()
+	// a P controller leaves a steady state error of half the step
+	if r.SettlingTime != -1 || math.Abs(r.SteadyStateError-5) > 1e-6 {
+		t.Errorf("settling time %v, steady state error %v", r.SettlingTime, r.SteadyStateError)
+	}
+}
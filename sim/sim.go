@@ -0,0 +1,286 @@
+// Package sim provides plant models for closed-loop simulations of pidctrl
+// controllers in tests, examples, tuning tools and the pidsim command.
+package sim
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Plant is a simulated process. Update applies the controller output for a
+// time step and returns the resulting measured process value.
+type Plant interface {
+	Update(input float64, dt time.Duration) float64
+	// Value returns the measured process value after the last update.
+	Value() float64
+}
+
+// FirstOrder is a first order lag: the value approaches Gain times the input
+// with time constant Tau.
+type FirstOrder struct {
+	Gain  float64
+	Tau   time.Duration
+	value float64
+}
+
+// NewFirstOrder returns a first order plant starting at 0.
+func NewFirstOrder(gain float64, tau time.Duration) *FirstOrder {
+	return &FirstOrder{Gain: gain, Tau: tau}
+}
+
+// SetValue sets the current process value.
+func (p *FirstOrder) SetValue(v float64) *FirstOrder {
+	p.value = v
+	return p
+}
+
+// Update implements Plant. The step is integrated exactly, so large time
+// steps remain stable.
+func (p *FirstOrder) Update(input float64, dt time.Duration) float64 {
+	if p.Tau <= 0 {
+		p.value = p.Gain * input
+	} else {
+		p.value += (p.Gain*input - p.value) * -math.Expm1(-dt.Seconds()/p.Tau.Seconds())
+	}
+	return p.value
+}
+
+// Value implements Plant.
+func (p *FirstOrder) Value() float64 {
+	return p.value
+}
+
+// Delay delays the input of a plant by a dead time.
+type Delay struct {
+	Plant
+	Delay time.Duration
+
+	now     time.Duration
+	history []sample
+}
+
+type sample struct {
+	t     time.Duration
+	input float64
+}
+
+// NewFOPDT returns a first order plus dead time plant, the most common model
+// of industrial processes.
+func NewFOPDT(gain float64, tau, delay time.Duration) *Delay {
+	return &Delay{Plant: NewFirstOrder(gain, tau), Delay: delay}
+}
+
+// Update implements Plant. Inputs applied before the first update count as
+// 0.
+func (p *Delay) Update(input float64, dt time.Duration) float64 {
+	p.history = append(p.history, sample{p.now, input})
+	var delayed float64
+	n := 0
+	for n < len(p.history) && p.history[n].t <= p.now-p.Delay {
+		delayed = p.history[n].input
+		n++
+	}
+	if n > 0 {
+		p.history = p.history[n-1:]
+	}
+	p.now += dt
+	return p.Plant.Update(delayed, dt)
+}
+
+// SecondOrder is a second order system with natural frequency Omega in
+// rad/s and damping ratio Zeta, e.g. a mass-spring-damper or a motor
+// position loop with compliance.
+type SecondOrder struct {
+	Gain  float64
+	Omega float64
+	Zeta  float64
+
+	value, rate float64
+}
+
+// NewSecondOrder returns a second order plant at rest at 0.
+func NewSecondOrder(gain, omega, zeta float64) *SecondOrder {
+	return &SecondOrder{Gain: gain, Omega: omega, Zeta: zeta}
+}
+
+// SetValue sets the current process value and stops any motion.
+func (p *SecondOrder) SetValue(v float64) *SecondOrder {
+	p.value, p.rate = v, 0
+	return p
+}
+
+// Update implements Plant. It integrates with semi-implicit Euler steps of
+// at most a hundredth of the natural period.
+func (p *SecondOrder) Update(input float64, dt time.Duration) float64 {
+	h := dt.Seconds()
+	n := 1
+	if p.Omega > 0 {
+		n = int(math.Ceil(h * p.Omega * 100 / (2 * math.Pi)))
+	}
+	if n < 1 {
+		n = 1
+	}
+	h /= float64(n)
+	for i := 0; i < n; i++ {
+		accel := p.Omega*p.Omega*(p.Gain*input-p.value) - 2*p.Zeta*p.Omega*p.rate
+		p.rate += accel * h
+		p.value += p.rate * h
+	}
+	return p.value
+}
+
+// Value implements Plant.
+func (p *SecondOrder) Value() float64 {
+	return p.value
+}
+
+// Integrator is a plant whose value changes at Gain times the input, e.g. a
+// tank level fed by a pump or a motor position driven by velocity.
+type Integrator struct {
+	Gain  float64
+	value float64
+}
+
+// NewIntegrator returns an integrating plant starting at 0.
+func NewIntegrator(gain float64) *Integrator {
+	return &Integrator{Gain: gain}
+}
+
+// SetValue sets the current process value.
+func (p *Integrator) SetValue(v float64) *Integrator {
+	p.value = v
+	return p
+}
+
+// Update implements Plant.
+func (p *Integrator) Update(input float64, dt time.Duration) float64 {
+	p.value += p.Gain * input * dt.Seconds()
+	return p.value
+}
+
+// Value implements Plant.
+func (p *Integrator) Value() float64 {
+	return p.value
+}
+
+// Noise adds Gaussian measurement noise with standard deviation StdDev to
+// the value of a plant. The noise does not affect the plant state.
+type Noise struct {
+	Plant
+	StdDev float64
+	Rand   *rand.Rand
+
+	value float64
+}
+
+// NewNoise wraps a plant with measurement noise from a source seeded with
+// seed.
+func NewNoise(p Plant, stddev float64, seed int64) *Noise {
+	return &Noise{Plant: p, StdDev: stddev, Rand: rand.New(rand.NewSource(seed)), value: p.Value()}
+}
+
+// Update implements Plant.
+func (p *Noise) Update(input float64, dt time.Duration) float64 {
+	p.value = p.Plant.Update(input, dt) + p.Rand.NormFloat64()*p.StdDev
+	return p.value
+}
+
+// Value implements Plant.
+func (p *Noise) Value() float64 {
+	return p.value
+}
+
+// Quantizer rounds the value of a plant to multiples of Step, like an ADC or
+// an encoder with limited resolution. The plant state is not affected.
+type Quantizer struct {
+	Plant
+	Step float64
+
+	value float64
+}
+
+// NewQuantizer wraps a plant with measurement quantization.
+func NewQuantizer(p Plant, step float64) *Quantizer {
+	q := &Quantizer{Plant: p, Step: step}
+	q.value = q.quantize(p.Value())
+	return q
+}
+
+// Update implements Plant.
+func (p *Quantizer) Update(input float64, dt time.Duration) float64 {
+	p.value = p.quantize(p.Plant.Update(input, dt))
+	return p.value
+}
+
+// Value implements Plant.
+func (p *Quantizer) Value() float64 {
+	return p.value
+}
+
+func (p *Quantizer) quantize(v float64) float64 {
+	if p.Step <= 0 {
+		return v
+	}
+	return math.Round(v/p.Step) * p.Step
+}
+
+// Disturbance adds a load disturbance to the input of a plant. Func returns
+// the disturbance at the time since the first update.
+type Disturbance struct {
+	Plant
+	Func func(t time.Duration) float64
+
+	now time.Duration
+}
+
+// NewDisturbance wraps a plant with a load disturbance.
+func NewDisturbance(p Plant, f func(t time.Duration) float64) *Disturbance {
+	return &Disturbance{Plant: p, Func: f}
+}
+
+// Update implements Plant.
+func (p *Disturbance) Update(input float64, dt time.Duration) float64 {
+	input += p.Func(p.now)
+	p.now += dt
+	return p.Plant.Update(input, dt)
+}
+
+// Step returns a disturbance function stepping from 0 to magnitude at the
+// given time.
+func Step(at time.Duration, magnitude float64) func(time.Duration) float64 {
+	return func(t time.Duration) float64 {
+		if t >= at {
+			return magnitude
+		}
+		return 0
+	}
+}
+
+// Ramp returns a disturbance function ramping linearly from 0 at the given
+// time to magnitude over duration, and staying there.
+func Ramp(at, duration time.Duration, magnitude float64) func(time.Duration) float64 {
+	return func(t time.Duration) float64 {
+		switch {
+		case t < at:
+			return 0
+		case t >= at+duration:
+			return magnitude
+		}
+		return magnitude * float64(t-at) / float64(duration)
+	}
+}
+
+// Sum returns a disturbance function adding the given ones, to schedule
+// several steps and ramps:
+//
+//	sim.Sum(sim.Step(time.Minute, 5), sim.Ramp(3*time.Minute, time.Minute, -5))
+func Sum(fs ...func(time.Duration) float64) func(time.Duration) float64 {
+	return func(t time.Duration) float64 {
+		var sum float64
+		for _, f := range fs {
+			sum += f(t)
+		}
+		return sum
+	}
+}
@@ -0,0 +1,137 @@
+package sim
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+func TestFirstOrder(t *testing.T) {
+	p := NewFirstOrder(2, 10*time.Second)
+	if v := p.Update(1, 10*time.Second); math.Abs(v-2*(1-math.Exp(-1))) > 1e-12 {
+		t.Errorf("after one time constant: %v", v)
+	}
+	for i := 0; i < 100; i++ {
+		p.Update(1, 10*time.Second)
+	}
+	if v := p.Value(); math.Abs(v-2) > 1e-9 {
+		t.Errorf("steady state %v != 2", v)
+	}
+}
+
+func TestFOPDT(t *testing.T) {
+	p := NewFOPDT(1, 0, 3*time.Second)
+	var values []float64
+	for i := 0; i < 5; i++ {
+		values = append(values, p.Update(float64(i+1), time.Second))
+	}
+	if want := []float64{0, 0, 0, 1, 2}; !equal(values, want) {
+		t.Errorf("delayed values %v != %v", values, want)
+	}
+}
+
+func TestSecondOrder(t *testing.T) {
+	p := NewSecondOrder(1, 1, 0.2)
+	peak := 0.0
+	for i := 0; i < 600; i++ {
+		peak = math.Max(peak, p.Update(1, 100*time.Millisecond))
+	}
+	// the overshoot of an underdamped system is exp(-zeta*pi/sqrt(1-zeta^2))
+	if want := 1 + math.Exp(-0.2*math.Pi/math.Sqrt(1-0.04)); math.Abs(peak-want) > 0.01 {
+		t.Errorf("peak %v != %v", peak, want)
+	}
+	if v := p.Value(); math.Abs(v-1) > 0.01 {
+		t.Errorf("did not settle: %v", v)
+	}
+}
+
+func TestIntegrator(t *testing.T) {
+	p := NewIntegrator(0.5).SetValue(1)
+	if v := p.Update(2, 3*time.Second); v != 4 {
+		t.Errorf("%v != 4", v)
+	}
+}
+
+func TestNoiseAndDisturbance(t *testing.T) {
+	p := NewNoise(NewDisturbance(NewIntegrator(1), Step(2*time.Second, -1)), 0.1, 1)
+	var sum float64
+	for i := 0; i < 4; i++ {
+		sum += p.Update(1, time.Second) - float64(min(i+1, 2))
+	}
+	if sum == 0 || math.Abs(sum) > 1 {
+		t.Errorf("unexpected noise sum %v", sum)
+	}
+}
+
+func TestQuantizer(t *testing.T) {
+	p := NewQuantizer(NewIntegrator(1).SetValue(0.3), 0.25)
+	if v := p.Value(); v != 0.25 {
+		t.Errorf("initial value %v", v)
+	}
+	if v := p.Update(0.1, time.Second); v != 0.5 {
+		t.Errorf("quantized value %v", v)
+	}
+	if v := p.Plant.Value(); math.Abs(v-0.4) > 1e-12 {
+		t.Errorf("plant state affected: %v", v)
+	}
+}
+
+func TestRampAndSum(t *testing.T) {
+	f := Sum(Step(time.Second, 1), Ramp(2*time.Second, 4*time.Second, -2))
+	var got []float64
+	for s := 0; s < 8; s++ {
+		got = append(got, f(time.Duration(s)*time.Second))
+	}
+	if want := []float64{0, 1, 1, 0.5, 0, -0.5, -1, -1}; !equal(got, want) {
+		t.Errorf("disturbance %v != %v", got, want)
+	}
+}
+
+func TestDisturbanceRejection(t *testing.T) {
+	// a load step after settling is rejected by the integral, a P controller
+	// is left with an offset
+	for _, tt := range []struct {
+		i      float64
+		offset bool
+	}{{0.2, false}, {0, true}} {
+		c := pidctrl.NewPIDController(2, tt.i, 0).Set(10)
+		var p Plant = NewFirstOrder(1, 5*time.Second)
+		p = NewDisturbance(p, Sum(Step(100*time.Second, -5), Ramp(200*time.Second, 50*time.Second, 3)))
+		p = NewQuantizer(NewNoise(p, 0.01, 1), 0.01)
+		var before float64
+		for s := 0; s < 400; s++ {
+			p.Update(c.UpdateDuration(p.Value(), time.Second), time.Second)
+			if s == 99 {
+				before = p.Value()
+			}
+		}
+		if offset := math.Abs(p.Value() - before); (offset > 0.1) != tt.offset {
+			t.Errorf("i=%v: value %v before and %v after the disturbance", tt.i, before, p.Value())
+		}
+	}
+}
+
+func TestClosedLoop(t *testing.T) {
+	c := pidctrl.NewPIDController(0.8, 0.1, 0).SetOutputLimits(0, 100).Set(50)
+	p := NewFOPDT(1, 20*time.Second, 2*time.Second)
+	for i := 0; i < 600; i++ {
+		p.Update(c.UpdateDuration(p.Value(), time.Second), time.Second)
+	}
+	if v := p.Value(); math.Abs(v-50) > 0.1 {
+		t.Errorf("closed loop did not settle: %v", v)
+	}
+}
+
+func equal(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
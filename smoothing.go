@@ -0,0 +1,36 @@
+package pidctrl
+
+import "time"
+
+// SetOutputSmoothing enables an exponential moving average on the final
+// output with the given time constant, for actuators whose drivers dislike
+// step changes, such as audio-coupled fans or dimmable lighting. The first
+// update after construction or Reset passes through unsmoothed. A time
+// constant of 0 disables smoothing.
+func (c *PIDController) SetOutputSmoothing(tau time.Duration) *PIDController {
+	if tau < 0 {
+		tau = 0
+	}
+	c.smoothing = tau
+	return c
+}
+
+// OutputSmoothing returns the time constant of the output smoothing.
+func (c *PIDController) OutputSmoothing() time.Duration {
+	return c.smoothing
+}
+
+// smooth applies the output smoothing for a step of dt seconds.
+func (c *PIDController) smooth(output, dt float64) float64 {
+	if c.smoothing == 0 {
+		return output
+	}
+	if !c.smoothed {
+		c.smoothed = true
+		c.smoothOut = output
+		return output
+	}
+	alpha := dt / (c.smoothing.Seconds() + dt)
+	c.smoothOut += alpha * (output - c.smoothOut)
+	return c.smoothOut
+}
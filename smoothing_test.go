@@ -0,0 +1,32 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestOutputSmoothing(t *testing.T) {
+	c := NewPIDController(1, 0, 0).SetOutputSmoothing(time.Second)
+	if out := c.UpdateDuration(0, time.Second); out != 0 {
+		t.Errorf("first output %v != 0", out)
+	}
+	c.Set(10)
+	if out := c.UpdateDuration(0, time.Second); out != 5 {
+		t.Errorf("smoothed step %v != 5", out)
+	}
+	if out := c.UpdateDuration(0, 0); out != 5 {
+		t.Errorf("zero duration changed the output to %v", out)
+	}
+	for i := 0; i < 50; i++ {
+		c.UpdateDuration(0, time.Second)
+	}
+	if out := c.Output(); math.Abs(out-10) > 1e-9 {
+		t.Errorf("smoothed output did not converge: %v", out)
+	}
+
+	c.Reset()
+	if out := c.UpdateDuration(0, time.Second); out != 10 {
+		t.Errorf("first output after reset %v != 10", out)
+	}
+}
@@ -0,0 +1,166 @@
+package pidctrl
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// snapshotVersion is the current version of the Snapshot encoding.
+const snapshotVersion = 1
+
+// UnsupportedSnapshotError is returned when restoring a snapshot of an
+// unknown version.
+type UnsupportedSnapshotError struct {
+	Version int
+}
+
+func (e UnsupportedSnapshotError) Error() string {
+	return fmt.Sprintf("unsupported snapshot version %d", e.Version)
+}
+
+// Snapshot is an opaque, versioned copy of the dynamic state of a
+// controller, see PIDController.Snapshot. It can be encoded as JSON.
+type Snapshot struct {
+	version int
+	taken   time.Time
+	state   snapshotState
+}
+
+// snapshotState is the dynamic state of a controller.
+type snapshotState struct {
+	Target       float64   `json:"target"`
+	Setpoint     float64   `json:"setpoint"`
+	Integral     float64   `json:"integral"`
+	PrevValue    float64   `json:"prev_value"`
+	PrevSetpoint float64   `json:"prev_setpoint"`
+	PrevErr      float64   `json:"prev_err"`
+	DFilt        float64   `json:"d_filt"`
+	Output       float64   `json:"output"`
+	SmoothOut    float64   `json:"smooth_out"`
+	Smoothed     bool      `json:"smoothed"`
+	QLevel       float64   `json:"q_level"`
+	Quantized    bool      `json:"quantized"`
+	Pending      int64     `json:"pending"`
+	Started      bool      `json:"started"`
+	Approaching  bool      `json:"approaching"`
+	Negative     bool      `json:"negative"`
+	Saturated    bool      `json:"saturated"`
+	SatDir       float64   `json:"sat_dir"`
+	Windup       bool      `json:"windup"`
+	Terms        Terms     `json:"terms"`
+	LastUpdate   time.Time `json:"last_update"`
+}
+
+// snapshotJSON is the JSON encoding of a Snapshot.
+type snapshotJSON struct {
+	Version int           `json:"version"`
+	Taken   time.Time     `json:"taken"`
+	State   snapshotState `json:"state"`
+}
+
+// Version returns the encoding version of the snapshot.
+func (s Snapshot) Version() int {
+	return s.version
+}
+
+// Taken returns the time the snapshot was taken, according to the clock of
+// the controller.
+func (s Snapshot) Taken() time.Time {
+	return s.taken
+}
+
+// MarshalJSON implements json.Marshaler.
+func (s Snapshot) MarshalJSON() ([]byte, error) {
+	return json.Marshal(snapshotJSON{Version: s.version, Taken: s.taken, State: s.state})
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (s *Snapshot) UnmarshalJSON(data []byte) error {
+	var j snapshotJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	if j.Version != snapshotVersion {
+		return UnsupportedSnapshotError{j.Version}
+	}
+	*s = Snapshot{version: j.Version, taken: j.Taken, state: j.State}
+	return nil
+}
+
+// RestoreMode defines how the time between taking and restoring a snapshot
+// counts for the next Update.
+type RestoreMode int
+
+const (
+	// RestoreRebase ignores the downtime: the next Update sees the time it
+	// would have seen without the restart, as if the controller had been
+	// frozen while it was down. This is the default.
+	RestoreRebase RestoreMode = iota
+	// RestoreElapsed counts the downtime, so the next Update integrates over
+	// it. Combine it with a DtPolicy to bound long outages.
+	RestoreElapsed
+	// RestoreFresh keeps the state, but the next Update starts fresh with a
+	// zero duration, neither integrating nor differentiating.
+	RestoreFresh
+)
+
+// Snapshot returns the dynamic state of the controller: setpoints, the
+// integral, derivative and filter state, the last output and the time of
+// the last update. Configuration such as gains and limits, estimators and
+// the state of alarm detectors are not part of it.
+func (c *PIDController) Snapshot() Snapshot {
+	return Snapshot{
+		version: snapshotVersion,
+		taken:   c.now(),
+		state: snapshotState{
+			Target:       c.target,
+			Setpoint:     c.setpoint,
+			Integral:     c.integral,
+			PrevValue:    c.prevValue,
+			PrevSetpoint: c.prevSetpoint,
+			PrevErr:      c.prevErr,
+			DFilt:        c.dFilt,
+			Output:       c.output,
+			SmoothOut:    c.smoothOut,
+			Smoothed:     c.smoothed,
+			QLevel:       c.qLevel,
+			Quantized:    c.quantized,
+			Pending:      int64(c.pending),
+			Started:      c.started,
+			Approaching:  c.approaching,
+			Negative:     c.negative,
+			Saturated:    c.saturated,
+			SatDir:       c.satDir,
+			Windup:       c.windup,
+			Terms:        c.terms,
+			LastUpdate:   c.lastUpdate,
+		},
+	}
+}
+
+// Restore resumes from a snapshot taken from a controller with the same
+// configuration, with the downtime handled according to mode.
+func (c *PIDController) Restore(s Snapshot, mode RestoreMode) error {
+	if s.version != snapshotVersion {
+		return UnsupportedSnapshotError{s.version}
+	}
+	st := s.state
+	c.target, c.setpoint = st.Target, st.Setpoint
+	c.integral, c.prevValue, c.prevSetpoint, c.prevErr = st.Integral, st.PrevValue, st.PrevSetpoint, st.PrevErr
+	c.dFilt, c.output, c.smoothOut, c.smoothed = st.DFilt, st.Output, st.SmoothOut, st.Smoothed
+	c.qLevel, c.quantized, c.pending, c.started = st.QLevel, st.Quantized, time.Duration(st.Pending), st.Started
+	c.approaching, c.negative = st.Approaching, st.Negative
+	c.saturated, c.satDir, c.windup, c.terms = st.Saturated, st.SatDir, st.Windup, st.Terms
+	c.lastUpdate = st.LastUpdate
+	if c.lastUpdate.IsZero() {
+		return nil
+	}
+	switch mode {
+	case RestoreRebase:
+		c.lastUpdate = c.now().Add(-s.taken.Sub(st.LastUpdate))
+	case RestoreFresh:
+		c.lastUpdate = c.now()
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package pidctrl
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestSnapshot(t *testing.T) {
+	clock := NewManualClock(time.Date(2016, 3, 2, 12, 0, 0, 0, time.UTC))
+	newController := func() *PIDController {
+		return NewPIDController(0.5, 0.25, 0.1).SetOutputLimits(0, 100).SetClock(clock)
+	}
+	c := newController().Set(42)
+	for _, v := range []float64{40, 41, 41.5} {
+		c.Update(v)
+		clock.Advance(time.Second)
+	}
+	data, err := json.Marshal(c.Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Version() != 1 || !s.Taken().Equal(clock.Now()) {
+		t.Errorf("version %d, taken %v", s.Version(), s.Taken())
+	}
+	want := c.Update(41.8)
+
+	for _, test := range []struct {
+		mode RestoreMode
+		dt   time.Duration // duration seen by the first update after the restore
+	}{
+		{RestoreRebase, time.Second},
+		{RestoreElapsed, time.Hour + time.Second},
+		{RestoreFresh, 0},
+	} {
+		saved := clock.Now()
+		clock.Advance(time.Hour)
+		r := newController()
+		if err := r.Restore(s, test.mode); err != nil {
+			t.Fatal(err)
+		}
+		var dt time.Duration
+		r.SetObserver(func(info UpdateInfo) { dt = info.Dt })
+		out := r.Update(41.8)
+		if dt != test.dt {
+			t.Errorf("mode %v: dt %v != %v", test.mode, dt, test.dt)
+		}
+		if test.mode == RestoreRebase && out != want {
+			t.Errorf("rebased controller diverged: %v != %v", out, want)
+		}
+		*clock = *NewManualClock(saved)
+	}
+
+	if err := json.Unmarshal([]byte(`{"version": 99}`), &s); err != (UnsupportedSnapshotError{99}) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := c.Restore(Snapshot{}, RestoreRebase); err == nil {
+		t.Error("expected error for zero snapshot")
+	}
+}
@@ -0,0 +1,84 @@
+package pidctrl
+
+import (
+	"math"
+	"time"
+)
+
+// softStart limits the output for a while after the start.
+type softStart struct {
+	ceiling      float64       // output ceiling while active
+	duration     time.Duration // length of the soft start, 0 for no time limit
+	threshold    float64       // process value ending the soft start
+	useThreshold bool          // threshold set
+	armed        bool          // soft start configured
+	active       bool          // ceiling in effect
+	started      bool          // first update seen
+	elapsed      time.Duration // time since the first update
+	side         float64       // sign of threshold minus value at the start, 0 before
+}
+
+// SetSoftStart limits the output to ceiling for d after the next update,
+// protecting cold heaters and motors from full power while the loop
+// stabilizes. The integral is limited to the ceiling as well, so it does not
+// wind up meanwhile. A d of 0 keeps the ceiling until the threshold set with
+// SetSoftStartThreshold is reached or EndSoftStart is called. Reset starts
+// the soft start again.
+func (c *PIDController) SetSoftStart(ceiling float64, d time.Duration) *PIDController {
+	c.soft = softStart{ceiling: ceiling, duration: d, threshold: c.soft.threshold, useThreshold: c.soft.useThreshold, armed: true, active: true}
+	c.clampIntegral()
+	return c
+}
+
+// SetSoftStartThreshold also ends the soft start once the process value
+// first reaches value, coming from either side.
+func (c *PIDController) SetSoftStartThreshold(value float64) *PIDController {
+	c.soft.threshold, c.soft.useThreshold, c.soft.side = value, true, 0
+	return c
+}
+
+// EndSoftStart lifts the soft start ceiling.
+func (c *PIDController) EndSoftStart() *PIDController {
+	c.soft.active = false
+	return c
+}
+
+// SoftStarting returns true while the soft start ceiling is in effect.
+func (c *PIDController) SoftStarting() bool {
+	return c.soft.active
+}
+
+// restart re-arms the soft start.
+func (s *softStart) restart() {
+	s.active, s.started, s.elapsed, s.side = s.armed, false, 0, 0
+}
+
+// update advances the soft start by an update with the given process value
+// and duration.
+func (s *softStart) update(value float64, duration time.Duration) {
+	if !s.active {
+		return
+	}
+	if s.started {
+		s.elapsed += duration
+	}
+	s.started = true
+	if s.duration > 0 && s.elapsed >= s.duration {
+		s.active = false
+	}
+	if s.useThreshold {
+		side := math.Copysign(1, s.threshold-value)
+		if value == s.threshold || (s.side != 0 && side != s.side) {
+			s.active = false
+		}
+		s.side = side
+	}
+}
+
+// limit returns max lowered to the ceiling while the soft start is active.
+func (s *softStart) limit(max float64) float64 {
+	if s.active {
+		return math.Min(max, s.ceiling)
+	}
+	return max
+}
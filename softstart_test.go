@@ -0,0 +1,53 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPIDController_SoftStart(t *testing.T) {
+	c := NewPIDController(1, 1, 0).SetOutputLimits(0, 100).Set(50).SetSoftStart(20, 3*time.Second)
+	for i, want := range []float64{20, 20, 20, 100} {
+		if out := c.UpdateDuration(0, time.Second); out != want {
+			t.Errorf("update %d: output %v, want %v", i, out, want)
+		}
+		if i < 2 && c.Integral() > 20 {
+			t.Errorf("update %d: integral %v wound up beyond the ceiling", i, c.Integral())
+		}
+	}
+	if c.SoftStarting() {
+		t.Error("soft start still active")
+	}
+	c.Reset()
+	if !c.SoftStarting() || c.UpdateDuration(0, time.Second) != 20 {
+		t.Error("soft start not restarted by reset")
+	}
+	c.EndSoftStart()
+	if c.UpdateDuration(0, time.Second) != 100 {
+		t.Error("soft start not ended")
+	}
+}
+
+func TestPIDController_SoftStartThreshold(t *testing.T) {
+	c := NewPIDController(10, 0, 0).SetOutputLimits(0, 100).Set(50).SetSoftStart(30, 0).SetSoftStartThreshold(20)
+	for i, tt := range []struct {
+		value, want float64
+	}{
+		{0, 30},
+		{15, 30},
+		{25, 100}, // crossed the threshold
+		{10, 100},
+	} {
+		if out := c.UpdateDuration(tt.value, time.Second); out != tt.want {
+			t.Errorf("update %d: output %v, want %v", i, out, tt.want)
+		}
+	}
+	// starting beyond the threshold ends on the first crossing downwards
+	c = NewPIDController(-10, 0, 0).SetOutputLimits(0, 100).Set(0).SetSoftStart(30, 0).SetSoftStartThreshold(20)
+	if c.UpdateDuration(40, time.Second); !c.SoftStarting() {
+		t.Error("soft start ended above the threshold")
+	}
+	if c.UpdateDuration(20, time.Second); c.SoftStarting() {
+		t.Error("soft start not ended at the threshold")
+	}
+}
@@ -0,0 +1,68 @@
+package pidctrl
+
+// Span is the range of a process value or output in engineering units, e.g.
+// 0..500 °C for a temperature transmitter or 0..100 % for a valve.
+type Span struct {
+	Min, Max float64
+}
+
+// Width returns the width of the span.
+func (s Span) Width() float64 {
+	return s.Max - s.Min
+}
+
+// Percent converts a value in engineering units to percent of span.
+func (s Span) Percent(v float64) float64 {
+	return (v - s.Min) / s.Width() * 100
+}
+
+// Value converts percent of span to engineering units.
+func (s Span) Value(percent float64) float64 {
+	return s.Min + percent/100*s.Width()
+}
+
+// SetSpans sets the spans of the process value and output, which enables
+// gains in percent of span with SetPercentGains like on industrial
+// controllers: a proportional gain of 1 moves the output by 1 % of its span
+// per 1 % of process value span of error. The output limits are set to the
+// output span. Setpoints, process values and outputs stay in engineering
+// units; an offset of the output span is taken up by the integral, or by
+// SetBias for loops without integral action. It panics if a span is empty or
+// inverted.
+func (c *PIDController) SetSpans(pv, out Span) *PIDController {
+	for _, s := range []Span{pv, out} {
+		if !(s.Min < s.Max) {
+			panic(MinMaxError{s.Min, s.Max})
+		}
+	}
+	c.pvSpan, c.outSpan = pv, out
+	return c.SetOutputLimits(out.Min, out.Max)
+}
+
+// Spans returns the spans of the process value and output, and false if no
+// spans are set.
+func (c *PIDController) Spans() (pv, out Span, ok bool) {
+	return c.pvSpan, c.outSpan, c.pvSpan != Span{}
+}
+
+// SetPercentGains changes the gains given in percent of span, see SetSpans.
+// Without spans the gains are used as they are.
+func (c *PIDController) SetPercentGains(g Gains) *PIDController {
+	k := c.spanGain()
+	return c.SetPID(g.P*k, g.I*k, g.D*k)
+}
+
+// PercentGains returns the gains in percent of span.
+func (c *PIDController) PercentGains() Gains {
+	k := c.spanGain()
+	return Gains{P: c.p / k, I: c.i / k, D: c.d / k}
+}
+
+// spanGain returns the factor converting gains in percent of span to
+// engineering units.
+func (c *PIDController) spanGain() float64 {
+	if c.pvSpan == (Span{}) {
+		return 1
+	}
+	return c.outSpan.Width() / c.pvSpan.Width()
+}
@@ -0,0 +1,51 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSpan(t *testing.T) {
+	s := Span{Min: 4, Max: 20}
+	if p := s.Percent(12); p != 50 {
+		t.Errorf("percent %v != 50", p)
+	}
+	if v := s.Value(25); v != 8 {
+		t.Errorf("value %v != 8", v)
+	}
+	if w := s.Width(); w != 16 {
+		t.Errorf("width %v != 16", w)
+	}
+}
+
+func TestPIDController_PercentGains(t *testing.T) {
+	c := NewPIDController(0, 0, 0).SetSpans(Span{0, 500}, Span{0, 100}).SetPercentGains(Gains{P: 2, I: 0.1, D: 0})
+	if p, i, _ := c.PID(); p != 0.4 || math.Abs(i-0.02) > 1e-15 {
+		t.Errorf("engineering gains %v %v", p, i)
+	}
+	if min, max := c.OutputLimits(); min != 0 || max != 100 {
+		t.Errorf("output limits %v %v", min, max)
+	}
+	if g := c.PercentGains(); math.Abs(g.P-2) > 1e-12 || math.Abs(g.I-0.1) > 1e-12 {
+		t.Errorf("percent gains %+v", g)
+	}
+	// An error of 5 % of span (25 °C) moves the output by 10 % of span.
+	if out := c.Set(300).UpdateDuration(275, 0); out != 10 {
+		t.Errorf("output %v != 10", out)
+	}
+	if pv, out, ok := c.Spans(); !ok || pv.Max != 500 || out.Max != 100 {
+		t.Errorf("spans %v %v %v", pv, out, ok)
+	}
+	if _, _, ok := NewPIDController(1, 0, 0).SetPercentGains(Gains{P: 3}).Spans(); ok {
+		t.Error("spans reported without SetSpans")
+	}
+}
+
+func TestPIDController_SetSpansPanics(t *testing.T) {
+	defer func() {
+		if _, ok := recover().(MinMaxError); !ok {
+			t.Error("expected MinMaxError")
+		}
+	}()
+	NewPIDController(1, 0, 0).SetSpans(Span{0, 100}, Span{5, 5})
+}
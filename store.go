@@ -0,0 +1,209 @@
+package pidctrl
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Store is a namespaced key-value store persisting profiles, schedules and
+// audit logs. Applications can implement it on top of their own database.
+// Implementations must be safe for concurrent use and return a
+// NotFoundError from Get for missing keys.
+type Store interface {
+	Get(namespace, key string) ([]byte, error)
+	Put(namespace, key string, value []byte) error
+	Delete(namespace, key string) error
+	// List returns the keys of a namespace in sorted order.
+	List(namespace string) ([]string, error)
+}
+
+// Store namespaces used by this package.
+const (
+	NamespaceProfiles  = "profiles"
+	NamespaceSchedules = "schedules"
+	NamespaceAudit     = "audit"
+)
+
+// NotFoundError is returned by a Store for missing keys.
+type NotFoundError struct {
+	Namespace, Key string
+}
+
+func (e NotFoundError) Error() string {
+	return fmt.Sprintf("%s/%s not found", e.Namespace, e.Key)
+}
+
+// MemStore is an in-memory Store.
+type MemStore struct {
+	mu   sync.RWMutex
+	data map[string]map[string][]byte
+}
+
+// NewMemStore returns a new empty MemStore.
+func NewMemStore() *MemStore {
+	return &MemStore{data: map[string]map[string][]byte{}}
+}
+
+// Get implements Store.
+func (s *MemStore) Get(namespace, key string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.data[namespace][key]
+	if !ok {
+		return nil, NotFoundError{namespace, key}
+	}
+	return append([]byte(nil), v...), nil
+}
+
+// Put implements Store.
+func (s *MemStore) Put(namespace, key string, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data[namespace] == nil {
+		s.data[namespace] = map[string][]byte{}
+	}
+	s.data[namespace][key] = append([]byte(nil), value...)
+	return nil
+}
+
+// Delete implements Store.
+func (s *MemStore) Delete(namespace, key string) error {
+	s.mu.Lock()
+	delete(s.data[namespace], key)
+	s.mu.Unlock()
+	return nil
+}
+
+// List implements Store.
+func (s *MemStore) List(namespace string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]string, 0, len(s.data[namespace]))
+	for k := range s.data[namespace] {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// FileStore is a Store keeping each value in a file named
+// <dir>/<namespace>/<key>. Namespaces and keys must be valid file names.
+// Values are replaced atomically.
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore returns a FileStore rooted at dir, which is created on the
+// first Put if necessary.
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{dir: dir}
+}
+
+func (s *FileStore) path(namespace, key string) (string, error) {
+	for _, name := range []string{namespace, key} {
+		if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+			return "", fmt.Errorf("invalid store name %q", name)
+		}
+	}
+	return filepath.Join(s.dir, namespace, key), nil
+}
+
+// Get implements Store.
+func (s *FileStore) Get(namespace, key string) ([]byte, error) {
+	path, err := s.path(namespace, key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, NotFoundError{namespace, key}
+	}
+	return data, err
+}
+
+// Put implements Store.
+func (s *FileStore) Put(namespace, key string, value []byte) error {
+	path, err := s.path(namespace, key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+key+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(value); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// Delete implements Store.
+func (s *FileStore) Delete(namespace, key string) error {
+	path, err := s.path(namespace, key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// List implements Store.
+func (s *FileStore) List(namespace string) ([]string, error) {
+	dir, err := s.path(namespace, "_")
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(filepath.Dir(dir))
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.Type().IsRegular() && !strings.HasPrefix(e.Name(), ".") {
+			keys = append(keys, e.Name())
+		}
+	}
+	return keys, nil
+}
+
+// SaveOccupancyProfiles stores the occupancy profiles of the controller under
+// key in the profiles namespace.
+func (c *PIDController) SaveOccupancyProfiles(s Store, key string) error {
+	data, err := json.Marshal(c.profiles)
+	if err != nil {
+		return err
+	}
+	return s.Put(NamespaceProfiles, key, data)
+}
+
+// LoadOccupancyProfiles installs the occupancy profiles stored under key in
+// the profiles namespace.
+func (c *PIDController) LoadOccupancyProfiles(s Store, key string) error {
+	data, err := s.Get(NamespaceProfiles, key)
+	if err != nil {
+		return err
+	}
+	var profiles map[Occupancy]OccupancyProfile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return err
+	}
+	c.SetOccupancyProfiles(profiles)
+	return nil
+}
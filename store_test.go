@@ -0,0 +1,64 @@
+package pidctrl
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func testStore(t *testing.T, s Store) {
+	if _, err := s.Get("a", "x"); !errors.As(err, new(NotFoundError)) {
+		t.Errorf("expected NotFoundError, got %v", err)
+	}
+	if keys, err := s.List("a"); err != nil || len(keys) != 0 {
+		t.Errorf("empty namespace lists %v, %v", keys, err)
+	}
+	s.Put("a", "y", []byte("2"))
+	s.Put("a", "x", []byte("1"))
+	s.Put("b", "x", []byte("3"))
+	if err := s.Put("a", "x", []byte("4")); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := s.Get("a", "x"); err != nil || string(v) != "4" {
+		t.Errorf("got %q, %v", v, err)
+	}
+	if keys, _ := s.List("a"); !reflect.DeepEqual(keys, []string{"x", "y"}) {
+		t.Errorf("keys %v", keys)
+	}
+	if err := s.Delete("a", "x"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete("a", "x"); err != nil {
+		t.Errorf("deleting a missing key: %v", err)
+	}
+	if keys, _ := s.List("a"); !reflect.DeepEqual(keys, []string{"y"}) {
+		t.Errorf("keys after delete %v", keys)
+	}
+}
+
+func TestMemStore(t *testing.T) {
+	testStore(t, NewMemStore())
+}
+
+func TestFileStore(t *testing.T) {
+	s := NewFileStore(t.TempDir())
+	testStore(t, s)
+	if err := s.Put("a", "../x", nil); err == nil {
+		t.Error("expected error for key with path separator")
+	}
+}
+
+func TestPIDController_OccupancyProfilesStore(t *testing.T) {
+	s := NewMemStore()
+	profiles := map[Occupancy]OccupancyProfile{OccupancyEco: {SetpointOffset: -2, Gains: &Gains{P: 1}}}
+	if err := NewPIDController(0, 0, 0).SetOccupancyProfiles(profiles).SaveOccupancyProfiles(s, "house"); err != nil {
+		t.Fatal(err)
+	}
+	c := NewPIDController(0, 0, 0)
+	if err := c.LoadOccupancyProfiles(s, "house"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(c.OccupancyProfiles(), profiles) {
+		t.Errorf("loaded %v", c.OccupancyProfiles())
+	}
+}
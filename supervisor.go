@@ -0,0 +1,160 @@
+package pidctrl
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// Fault is a set of health signals monitored by a Supervisor.
+type Fault uint8
+
+// Health signals. FaultStale, FaultOscillation, FaultSaturation and
+// FaultRate require the corresponding detection to be enabled on the
+// controller, see SetStaleTimeout, SetOscillationDetection,
+// SetSaturationFault and SetRateAlarm.
+const (
+	FaultStale       Fault = 1 << iota // measurement stale
+	FaultOscillation                   // sustained oscillation
+	FaultSaturation                    // persistent saturation
+	FaultNaN                           // process value or output not finite
+	FaultRate                          // process value rate-of-change alarm
+
+	AllFaults = FaultStale | FaultOscillation | FaultSaturation | FaultNaN | FaultRate
+)
+
+var faultNames = [...]string{"stale", "oscillation", "saturation", "nan", "rate"}
+
+func (f Fault) String() string {
+	if f == 0 {
+		return "none"
+	}
+	var names []string
+	for n, name := range faultNames {
+		if f&(1<<n) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// Supervisor monitors the health signals of a controller and switches the
+// output to a fail-safe value or a fallback controller when it becomes
+// unhealthy. A fault has to persist for TripDelay before the supervisor
+// trips, except for FaultNaN which trips at once, and all faults have to be
+// clear for Recovery before the controller takes over again. Delays are
+// measured from the first update with the changed faults. Non-finite outputs
+// are never passed on, whether FaultNaN is monitored or not.
+//
+// The fallback controller, if any, is updated with every process value and
+// tracks the output of the controller while healthy, so the transfer in
+// either direction is bumpless.
+type Supervisor struct {
+	Controller *PIDController
+	// Fallback takes over from the controller while tripped. nil outputs
+	// FailSafe instead.
+	Fallback *PIDController
+	// FailSafe is the output while tripped without fallback, and while the
+	// fallback output is not finite.
+	FailSafe float64
+	// Monitor selects the faults that trip the supervisor.
+	Monitor   Fault
+	TripDelay time.Duration
+	Recovery  time.Duration
+	// OnTrip is called with the active faults when the supervisor trips and
+	// OnRecover when the controller takes over again. Both may be nil.
+	OnTrip    func(Fault)
+	OnRecover func()
+
+	faults  Fault         // faults of the last update
+	tripped bool          // fail-safe output active
+	pending bool          // faults changed, waiting for delay or recovery
+	timer   time.Duration // time since the faults changed
+}
+
+// NewSupervisor returns a Supervisor monitoring all faults of c and
+// outputting failSafe while tripped.
+func NewSupervisor(c *PIDController, failSafe float64) *Supervisor {
+	return &Supervisor{Controller: c, FailSafe: failSafe, Monitor: AllFaults}
+}
+
+// UpdateDuration updates the controller, and the fallback controller if one
+// is set, and returns the supervised output.
+func (s *Supervisor) UpdateDuration(value float64, duration time.Duration) float64 {
+	c := s.Controller
+	out := c.UpdateDuration(value, duration)
+	fallback := s.FailSafe
+	if s.Fallback != nil {
+		if f := s.Fallback.UpdateDuration(value, duration); !math.IsNaN(f) && !math.IsInf(f, 0) {
+			fallback = f
+		}
+	}
+
+	s.faults = 0
+	if c.Stale() {
+		s.faults |= FaultStale
+	}
+	if c.Oscillating() {
+		s.faults |= FaultOscillation
+	}
+	if c.SaturationFault() {
+		s.faults |= FaultSaturation
+	}
+	if c.RateAlarm() {
+		s.faults |= FaultRate
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) || math.IsNaN(out) || math.IsInf(out, 0) {
+		s.faults |= FaultNaN
+	}
+	faults := s.faults & s.Monitor
+
+	if s.tripped == (faults != 0) {
+		s.timer, s.pending = 0, false
+	} else {
+		if s.pending {
+			s.timer += duration
+		}
+		s.pending = true
+		switch {
+		case !s.tripped && (s.timer >= s.TripDelay || faults&FaultNaN != 0):
+			s.tripped, s.timer, s.pending = true, 0, false
+			if s.OnTrip != nil {
+				s.OnTrip(faults)
+			}
+		case s.tripped && s.timer >= s.Recovery:
+			s.tripped, s.timer, s.pending = false, 0, false
+			if !math.IsNaN(out) {
+				c.track(fallback)
+				out = fallback
+			}
+			if s.OnRecover != nil {
+				s.OnRecover()
+			}
+		}
+	}
+
+	if s.tripped || s.faults&FaultNaN != 0 {
+		return fallback
+	}
+	if s.Fallback != nil {
+		s.Fallback.track(out)
+	}
+	return out
+}
+
+// Tripped returns true while the fail-safe output is active.
+func (s *Supervisor) Tripped() bool {
+	return s.tripped
+}
+
+// Faults returns the health signals raised on the last update, including
+// those not monitored.
+func (s *Supervisor) Faults() Fault {
+	return s.faults
+}
+
+// Reset clears the faults and releases the fail-safe output.
+func (s *Supervisor) Reset() *Supervisor {
+	s.faults, s.tripped, s.pending, s.timer = 0, false, false, 0
+	return s
+}
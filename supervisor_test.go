@@ -0,0 +1,68 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSupervisor_Stale(t *testing.T) {
+	c := NewPIDController(1, 0, 0).Set(10).SetStaleTimeout(2*time.Second, StaleHold, 0, nil)
+	s := NewSupervisor(c, -1)
+	s.TripDelay, s.Recovery = time.Second, 2*time.Second
+	var events []string
+	s.OnTrip = func(f Fault) { events = append(events, "trip "+f.String()) }
+	s.OnRecover = func() { events = append(events, "recover") }
+
+	var outputs []float64
+	for _, v := range []float64{5, 5, 5, 5, 5, 6, 7, 8, 9} {
+		outputs = append(outputs, s.UpdateDuration(v, time.Second))
+	}
+	want := []float64{5, 5, 5, -1, -1, -1, -1, -1, -2}
+	for n := range want {
+		if outputs[n] != want[n] {
+			t.Fatalf("outputs %v != %v", outputs, want)
+		}
+	}
+	if len(events) != 2 || events[0] != "trip stale" || events[1] != "recover" {
+		t.Errorf("unexpected events %v", events)
+	}
+}
+
+func TestSupervisor_NaN(t *testing.T) {
+	s := NewSupervisor(NewPIDController(1, 0, 0).Set(10), 3)
+	s.TripDelay = time.Hour
+	if out := s.UpdateDuration(math.NaN(), time.Second); out != 3 || !s.Tripped() || s.Faults() != FaultNaN {
+		t.Errorf("NaN not caught: out %v tripped %v faults %v", out, s.Tripped(), s.Faults())
+	}
+	s.Monitor = FaultStale
+	s.Reset()
+	if out := s.UpdateDuration(math.Inf(1), time.Second); out != 3 || s.Tripped() {
+		t.Errorf("non-finite output passed through unmonitored: %v", out)
+	}
+}
+
+func TestSupervisor_Fallback(t *testing.T) {
+	c := NewPIDController(2, 0.5, 0).Set(10).SetStaleTimeout(time.Second, StaleHold, 0, nil)
+	s := NewSupervisor(c, 0)
+	s.Fallback = NewPIDController(1, 0.1, 0).Set(10)
+	out := s.UpdateDuration(8, time.Second)
+	if out != 5 || s.Fallback.integral != out-s.Fallback.terms.P {
+		t.Errorf("fallback not tracking: out %v integral %v", out, s.Fallback.integral)
+	}
+	if tripped := s.UpdateDuration(8, time.Second); !s.Tripped() || math.Abs(tripped-(2+3+0.2)) > 1e-9 {
+		t.Errorf("fallback not taking over bumplessly: %v", tripped)
+	}
+	if s.Faults() != FaultStale {
+		t.Errorf("faults %v", s.Faults())
+	}
+}
+
+func TestFault_String(t *testing.T) {
+	if s := (FaultStale | FaultNaN).String(); s != "stale|nan" {
+		t.Errorf("%q", s)
+	}
+	if s := Fault(0).String(); s != "none" {
+		t.Errorf("%q", s)
+	}
+}
@@ -0,0 +1,65 @@
+package pidctrl
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SyncGroup updates a group of controllers in lockstep: in every cycle all
+// inputs are sampled together, then all outputs are computed, and finally
+// all outputs are applied together. Coupled mechanical systems like
+// dual-motor gantries need this so that no axis acts on a sample the other
+// axes have already moved away from.
+type SyncGroup struct {
+	members []syncMember
+}
+
+type syncMember struct {
+	c     *SafePIDController
+	read  func() float64
+	write func(float64)
+}
+
+// NewSyncGroup returns a new empty SyncGroup.
+func NewSyncGroup() *SyncGroup {
+	return &SyncGroup{}
+}
+
+// Add adds a controller with its input and output to the group. Add must not
+// be called concurrently with Step or Run.
+func (g *SyncGroup) Add(c *SafePIDController, read func() float64, write func(float64)) *SyncGroup {
+	g.members = append(g.members, syncMember{c, read, write})
+	return g
+}
+
+// Step runs one cycle with the given duration. Reads and writes of the
+// members run concurrently, and each phase completes for all members before
+// the next one starts, so slow sensors do not skew the sampling instants.
+func (g *SyncGroup) Step(duration time.Duration) {
+	values := make([]float64, len(g.members))
+	g.each(func(i int, m syncMember) { values[i] = m.read() })
+	for i, m := range g.members {
+		values[i] = m.c.UpdateDuration(values[i], duration)
+	}
+	g.each(func(i int, m syncMember) { m.write(values[i]) })
+}
+
+// Run runs Step at the given interval until ctx is cancelled, with the same
+// timing as PIDController.Run. It returns ctx.Err().
+func (g *SyncGroup) Run(ctx context.Context, interval time.Duration) error {
+	return tick(ctx, interval, g.Step)
+}
+
+// each calls f for all members concurrently and waits for all of them.
+func (g *SyncGroup) each(f func(int, syncMember)) {
+	var wg sync.WaitGroup
+	wg.Add(len(g.members))
+	for i, m := range g.members {
+		go func(i int, m syncMember) {
+			defer wg.Done()
+			f(i, m)
+		}(i, m)
+	}
+	wg.Wait()
+}
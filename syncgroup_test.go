@@ -0,0 +1,59 @@
+package pidctrl
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSyncGroup(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []string
+		pos    = [2]float64{0, 5}
+	)
+	log := func(e string) {
+		mu.Lock()
+		events = append(events, e)
+		mu.Unlock()
+	}
+	g := NewSyncGroup()
+	for axis := range pos {
+		axis := axis
+		g.Add(NewSafePIDController(1, 0, 0).Set(10), func() float64 {
+			log("read")
+			return pos[axis]
+		}, func(out float64) {
+			log("write")
+			mu.Lock()
+			pos[axis] += out
+			mu.Unlock()
+		})
+	}
+	g.Step(time.Second)
+	if want := []string{"read", "read", "write", "write"}; !stringsEqual(events, want) {
+		t.Errorf("phases interleaved: %v", events)
+	}
+	if pos != [2]float64{10, 10} {
+		t.Errorf("positions %v", pos)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := g.Run(ctx, time.Millisecond); err != context.DeadlineExceeded {
+		t.Errorf("run: %v", err)
+	}
+}
+
+func stringsEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
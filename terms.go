@@ -0,0 +1,67 @@
+package pidctrl
+
+// Terms holds the contributions of the individual terms to the output of an
+// update, before clamping.
+type Terms struct {
+	P           float64    // proportional term
+	I           float64    // integral term
+	D           float64    // derivative term
+	FeedForward float64    // sum of feed-forward contributions
+	Clamp       ClampCause // term that drove the output into its limit
+}
+
+// ClampCause identifies the term primarily responsible for the output being
+// clamped.
+type ClampCause int
+
+// Clamp causes.
+const (
+	ClampNone ClampCause = iota // output not clamped
+	ClampP
+	ClampI
+	ClampD
+	ClampFeedForward
+)
+
+var clampCauseNames = [...]string{"none", "p", "i", "d", "feedforward"}
+
+func (c ClampCause) String() string {
+	if c < 0 || int(c) >= len(clampCauseNames) {
+		return "unknown"
+	}
+	return clampCauseNames[c]
+}
+
+// clampCause returns the term with the largest contribution in the direction
+// of the limit, 1 for the upper and -1 for the lower one.
+func (t Terms) clampCause(direction float64) ClampCause {
+	cause, largest := ClampNone, 0.0
+	for i, v := range [...]float64{t.P, t.I, t.D, t.FeedForward} {
+		if v*direction > largest {
+			cause, largest = ClampCause(i+1), v*direction
+		}
+	}
+	return cause
+}
+
+// Terms returns the term contributions of the last update.
+func (c *PIDController) Terms() Terms {
+	return c.terms
+}
+
+// Saturated returns true if the output was clamped to the output limits on
+// the last update.
+func (c *PIDController) Saturated() bool {
+	return c.saturated
+}
+
+// Output returns the output of the last update.
+func (c *PIDController) Output() float64 {
+	return c.output
+}
+
+// ProcessValue returns the process value of the last update, after
+// estimation.
+func (c *PIDController) ProcessValue() float64 {
+	return c.prevValue
+}
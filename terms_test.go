@@ -0,0 +1,40 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPIDController_Terms(t *testing.T) {
+	c := NewPIDController(2, 1, 0.5).SetOutputLimits(-10, 10).Set(5)
+	out := c.UpdateDuration(1, time.Second)
+	want := Terms{P: 8, I: 4, D: -0.5, Clamp: ClampP}
+	if got := c.Terms(); got != want {
+		t.Errorf("terms %+v != %+v", got, want)
+	}
+	if out != 10 || !c.Saturated() || c.Output() != out {
+		t.Errorf("expected saturated output 10, got %f (saturated %v)", out, c.Saturated())
+	}
+	c.Set(1)
+	c.UpdateDuration(1, time.Second)
+	if c.Saturated() {
+		t.Error("output still reported as saturated")
+	}
+}
+
+func TestPIDController_ClampCause(t *testing.T) {
+	c := NewPIDController(1, 0, 5).SetOutputLimits(-10, 10).Set(5)
+	c.UpdateDuration(5, 0)
+	if cause := c.Terms().Clamp; cause != ClampNone {
+		t.Errorf("unclamped output reports cause %v", cause)
+	}
+	c.UpdateDuration(2, time.Second) // P 3, D -15
+	if cause := c.Terms().Clamp; cause != ClampD {
+		t.Errorf("cause %v != d", cause)
+	}
+	c.Set(100)
+	c.UpdateDuration(2, time.Second)
+	if cause := c.Terms().Clamp; cause != ClampP || cause.String() != "p" {
+		t.Errorf("cause %v != p", cause)
+	}
+}
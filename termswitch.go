@@ -0,0 +1,55 @@
+package pidctrl
+
+// SetTermsEnabled switches the proportional, integral and derivative terms
+// on or off, e.g. to run a tuned PID controller as P, PI or PD without
+// losing the gains. While the integral term is off, the integral is frozen
+// and excluded from the output. When it is switched back on, the next
+// update initializes the integral so that the output continues from the
+// last one, like a bumpless transfer. Switching the P and D terms takes
+// effect immediately.
+func (c *PIDController) SetTermsEnabled(p, i, d bool) *PIDController {
+	if c.disabled[1] && i && c.started {
+		c.iResume = true
+	}
+	c.disabled = [3]bool{!p, !i, !d}
+	return c
+}
+
+// TermsEnabled returns which of the proportional, integral and derivative
+// terms are enabled.
+func (c *PIDController) TermsEnabled() (p, i, d bool) {
+	return !c.disabled[0], !c.disabled[1], !c.disabled[2]
+}
+
+// enabledGains returns the gains of an update with those of disabled terms
+// zeroed.
+func (c *PIDController) enabledGains(kp, ki, kd float64) (float64, float64, float64) {
+	if c.disabled[0] {
+		kp = 0
+	}
+	if c.disabled[1] {
+		ki = 0
+	}
+	if c.disabled[2] {
+		kd = 0
+	}
+	return kp, ki, kd
+}
+
+// resumeIntegral initializes the integral of a re-enabled integral term from
+// the last output and the other terms of the current update.
+func (c *PIDController) resumeIntegral(p, d float64) {
+	if c.iResume {
+		c.integral = c.output - p - d - c.feedForward()
+		c.iResume = false
+	}
+}
+
+// SetTermsEnabled switches the proportional, integral and derivative terms
+// on or off.
+func (s *SafePIDController) SetTermsEnabled(p, i, d bool) *SafePIDController {
+	s.mu.Lock()
+	s.c.SetTermsEnabled(p, i, d)
+	s.mu.Unlock()
+	return s
+}
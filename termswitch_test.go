@@ -0,0 +1,34 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPIDController_SetTermsEnabled(t *testing.T) {
+	c := NewPIDController(2, 1, 0).Set(10)
+	c.UpdateDuration(0, time.Second) // integral 10
+	c.SetTermsEnabled(true, false, true)
+	if p, i, d := c.TermsEnabled(); !p || i || !d {
+		t.Errorf("TermsEnabled() = %v, %v, %v", p, i, d)
+	}
+	if out := c.UpdateDuration(5, time.Second); out != 10 {
+		t.Errorf("PD output %v, want 10", out)
+	}
+	if c.Integral() != 10 {
+		t.Errorf("integral %v not frozen", c.Integral())
+	}
+	if gains := c.Gains(); gains != (Gains{P: 2, I: 1}) {
+		t.Errorf("gains changed to %+v", gains)
+	}
+	c.SetTermsEnabled(true, true, true)
+	// the integral resumes from the last output, 10 - 2·5, then integrates 5
+	if out := c.UpdateDuration(5, time.Second); math.Abs(out-15) > 1e-9 {
+		t.Errorf("output %v after re-enabling I, want 15", out)
+	}
+	c.SetTermsEnabled(false, true, true)
+	if out := c.UpdateDuration(5, time.Second); math.Abs(out-10) > 1e-9 {
+		t.Errorf("I output %v, want 10", out)
+	}
+}
@@ -0,0 +1,132 @@
+package pidctrl
+
+import (
+	"math"
+	"time"
+)
+
+// TemperatureUnit is the unit of the temperatures of a Thermostat.
+type TemperatureUnit int
+
+// Temperature units.
+const (
+	Celsius TemperatureUnit = iota
+	Fahrenheit
+)
+
+// ConvertTemperature converts a temperature between units.
+func ConvertTemperature(v float64, from, to TemperatureUnit) float64 {
+	if from == to {
+		return v
+	}
+	if to == Fahrenheit {
+		return v*9/5 + 32
+	}
+	return (v - 32) * 5 / 9
+}
+
+// ThermostatMode is the state of a Thermostat.
+type ThermostatMode int
+
+// Thermostat modes.
+const (
+	ThermostatIdle ThermostatMode = iota
+	ThermostatHeating
+	ThermostatCooling
+)
+
+func (m ThermostatMode) String() string {
+	switch m {
+	case ThermostatIdle:
+		return "idle"
+	case ThermostatHeating:
+		return "heating"
+	case ThermostatCooling:
+		return "cooling"
+	}
+	return "unknown"
+}
+
+// Thermostat splits temperature control into a heating and a cooling
+// controller. Heating regulates to the setpoint minus half the deadband,
+// cooling to the setpoint plus half of it, and the thermostat only changes
+// modes when the temperature leaves the deadband on the other side, so the
+// two never fight. The cooling controller is reverse acting. After cooling
+// stopped the compressor stays off for at least MinOffTime.
+type Thermostat struct {
+	Heat *PIDController // nil for cooling only
+	Cool *PIDController // nil for heating only
+	// Deadband between the heating and the cooling setpoint, in Unit.
+	Deadband   float64
+	MinOffTime time.Duration
+	Unit       TemperatureUnit
+
+	setpoint float64
+	mode     ThermostatMode
+	coolOn   bool          // compressor running
+	offFor   time.Duration // time since the compressor stopped
+}
+
+// NewThermostat returns a Thermostat using the given controllers, either of
+// which may be nil. It makes the cooling controller reverse acting.
+func NewThermostat(heat, cool *PIDController, deadband float64) *Thermostat {
+	if cool != nil {
+		cool.SetDirection(Reverse)
+	}
+	return &Thermostat{Heat: heat, Cool: cool, Deadband: deadband, offFor: math.MaxInt64}
+}
+
+// Set changes the setpoint, in Unit.
+func (t *Thermostat) Set(setpoint float64) *Thermostat {
+	t.setpoint = setpoint
+	return t
+}
+
+// SetIn changes the setpoint to a temperature given in unit.
+func (t *Thermostat) SetIn(setpoint float64, unit TemperatureUnit) *Thermostat {
+	return t.Set(ConvertTemperature(setpoint, unit, t.Unit))
+}
+
+// Get returns the setpoint, in Unit.
+func (t *Thermostat) Get() float64 {
+	return t.setpoint
+}
+
+// Mode returns the current mode.
+func (t *Thermostat) Mode() ThermostatMode {
+	return t.mode
+}
+
+// UpdateDuration updates the active controller with the measured temperature
+// and returns the heating and cooling outputs, at most one of which is
+// nonzero.
+func (t *Thermostat) UpdateDuration(temperature float64, duration time.Duration) (heat, cool float64) {
+	low, high := t.setpoint-t.Deadband/2, t.setpoint+t.Deadband/2
+	switch {
+	case temperature < low && t.Heat != nil && t.mode != ThermostatHeating:
+		t.mode = ThermostatHeating
+		t.Heat.Reset()
+	case temperature > high && t.Cool != nil && t.mode != ThermostatCooling:
+		t.mode = ThermostatCooling
+		t.Cool.Reset()
+	}
+	if t.offFor < math.MaxInt64-duration {
+		t.offFor += duration
+	}
+	switch t.mode {
+	case ThermostatHeating:
+		heat = math.Max(0, t.Heat.Set(low).UpdateDuration(temperature, duration))
+	case ThermostatCooling:
+		if !t.coolOn && t.offFor < t.MinOffTime {
+			// compressor protection, keep the integral from winding up
+			t.Cool.Reset()
+			break
+		}
+		cool = math.Max(0, t.Cool.Set(high).UpdateDuration(temperature, duration))
+	}
+	if t.coolOn && cool == 0 {
+		t.offFor = 0
+	}
+	t.coolOn = cool > 0
+	return heat, cool
+}
@@ -0,0 +1,45 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConvertTemperature(t *testing.T) {
+	if v := ConvertTemperature(100, Celsius, Fahrenheit); v != 212 {
+		t.Errorf("100 °C = %v °F", v)
+	}
+	if v := ConvertTemperature(32, Fahrenheit, Celsius); v != 0 {
+		t.Errorf("32 °F = %v °C", v)
+	}
+}
+
+func TestThermostat(t *testing.T) {
+	th := NewThermostat(
+		NewPIDController(10, 0, 0).SetOutputLimits(0, 100),
+		NewPIDController(10, 0, 0).SetOutputLimits(0, 100),
+		2,
+	).Set(21)
+	th.MinOffTime = time.Minute
+	for i, step := range []struct {
+		temperature float64
+		heat, cool  float64
+		mode        ThermostatMode
+	}{
+		{21, 0, 0, ThermostatIdle},
+		{18, 20, 0, ThermostatHeating},
+		{21, 0, 0, ThermostatHeating}, // above the heating setpoint, but inside the deadband
+		{23, 0, 10, ThermostatCooling},
+		{21.5, 0, 0, ThermostatCooling}, // compressor stops
+		{23, 0, 0, ThermostatCooling},   // minimum off time
+		{23, 0, 10, ThermostatCooling},
+	} {
+		heat, cool := th.UpdateDuration(step.temperature, 30*time.Second)
+		if heat != step.heat || cool != step.cool || th.Mode() != step.mode {
+			t.Errorf("step %d: heat %v cool %v %v, want %v %v %v", i, heat, cool, th.Mode(), step.heat, step.cool, step.mode)
+		}
+	}
+	if th.SetIn(68, Fahrenheit).Get() != 20 {
+		t.Errorf("setpoint %v", th.Get())
+	}
+}
@@ -0,0 +1,108 @@
+package pidctrl
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// ErrWindow is returned by NewTimeProportionalOutputE for windows that are
+// not positive.
+var ErrWindow = errors.New("pidctrl: time proportional output window must be positive")
+
+// TimeProportionalOutput converts a 0-100% controller output into on/off
+// cycling of a relay, e.g. for SSR driven heaters: within each window the
+// relay is on for the requested percentage of the window. It is not safe for
+// concurrent use.
+type TimeProportionalOutput struct {
+	window time.Duration
+	minOn  time.Duration
+	minOff time.Duration
+	clock  Clock
+
+	percent    float64   // requested output
+	start      time.Time // start of the current window
+	on         bool      // current relay state
+	lastSwitch time.Time // time of the last state change
+}
+
+// NewTimeProportionalOutput returns a TimeProportionalOutput with the given
+// window. On times shorter than minOn are skipped and off times shorter than
+// minOff are filled, and the relay never changes state again before the
+// respective minimum time has passed, protecting relays and contactors. It
+// panics with ErrWindow if the window is not positive.
+func NewTimeProportionalOutput(window, minOn, minOff time.Duration) *TimeProportionalOutput {
+	t, err := NewTimeProportionalOutputE(window, minOn, minOff)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// NewTimeProportionalOutputE is like NewTimeProportionalOutput, but returns
+// ErrWindow instead of panicking.
+func NewTimeProportionalOutputE(window, minOn, minOff time.Duration) (*TimeProportionalOutput, error) {
+	if window <= 0 {
+		return nil, ErrWindow
+	}
+	return &TimeProportionalOutput{window: window, minOn: minOn, minOff: minOff}, nil
+}
+
+// SetClock changes the time source. Passing nil restores the real clock.
+func (t *TimeProportionalOutput) SetClock(clock Clock) *TimeProportionalOutput {
+	t.clock = clock
+	return t
+}
+
+// Set changes the requested output in percent. Values outside of 0-100 are
+// clamped. The change takes effect within the current window.
+func (t *TimeProportionalOutput) Set(percent float64) *TimeProportionalOutput {
+	t.percent = math.Max(0, math.Min(100, percent))
+	return t
+}
+
+// Get returns the requested output in percent.
+func (t *TimeProportionalOutput) Get() float64 {
+	return t.percent
+}
+
+// OnTime returns the on time per window for the requested output, after
+// applying the minimum on and off times.
+func (t *TimeProportionalOutput) OnTime() time.Duration {
+	on := time.Duration(t.percent / 100 * float64(t.window))
+	if on < t.minOn {
+		return 0
+	} else if t.window-on < t.minOff {
+		return t.window
+	}
+	return on
+}
+
+// On returns whether the relay should be on now. It must be called
+// regularly, at a rate well above the window length.
+func (t *TimeProportionalOutput) On() bool {
+	now := RealClock.Now()
+	if t.clock != nil {
+		now = t.clock.Now()
+	}
+	if t.start.IsZero() {
+		t.start, t.lastSwitch = now, now.Add(-t.window)
+	}
+	if elapsed := now.Sub(t.start); elapsed >= t.window {
+		t.start = t.start.Add(elapsed / t.window * t.window)
+	}
+	on := now.Sub(t.start) < t.OnTime()
+	if on != t.on {
+		held := now.Sub(t.lastSwitch)
+		if t.on && held < t.minOn || !t.on && held < t.minOff {
+			return t.on
+		}
+		t.on, t.lastSwitch = on, now
+	}
+	return t.on
+}
+
+// Update is a shorthand for Set followed by On.
+func (t *TimeProportionalOutput) Update(percent float64) bool {
+	return t.Set(percent).On()
+}
@@ -0,0 +1,70 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeProportionalOutput(t *testing.T) {
+	clock := NewManualClock(time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC))
+	tpo := NewTimeProportionalOutput(10*time.Second, time.Second, time.Second).SetClock(clock)
+
+	var onTime time.Duration
+	for i := 0; i < 300; i++ {
+		if tpo.Update(35) {
+			onTime += 100 * time.Millisecond
+		}
+		clock.Advance(100 * time.Millisecond)
+	}
+	if onTime != 3*3500*time.Millisecond {
+		t.Errorf("on time %v over three windows, expected 10.5s", onTime)
+	}
+
+	for percent, want := range map[float64]time.Duration{
+		5:   0,
+		95:  10 * time.Second,
+		50:  5 * time.Second,
+		150: 10 * time.Second,
+		-10: 0,
+	} {
+		if got := tpo.Set(percent).OnTime(); got != want {
+			t.Errorf("%v%%: on time %v != %v", percent, got, want)
+		}
+	}
+}
+
+func TestTimeProportionalOutput_MinimumTimes(t *testing.T) {
+	clock := NewManualClock(time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC))
+	tpo := NewTimeProportionalOutput(10*time.Second, 2*time.Second, 2*time.Second).SetClock(clock)
+	if !tpo.Update(50) {
+		t.Fatal("relay off at start of window")
+	}
+	clock.Advance(500 * time.Millisecond)
+	// dropping the output mid-window must not cut the on time short
+	if !tpo.Update(0) {
+		t.Error("relay switched off before minimum on time")
+	}
+	clock.Advance(2 * time.Second)
+	if tpo.On() {
+		t.Error("relay still on after minimum on time")
+	}
+}
+
+func TestTimeProportionalOutput_Window(t *testing.T) {
+	for _, window := range []time.Duration{0, -time.Second} {
+		if _, err := NewTimeProportionalOutputE(window, 0, 0); err != ErrWindow {
+			t.Errorf("window %v: unexpected error %v", window, err)
+		}
+		func() {
+			defer func() {
+				if r := recover(); r != ErrWindow {
+					t.Errorf("window %v: unexpected panic %v", window, r)
+				}
+			}()
+			NewTimeProportionalOutput(window, 0, 0)
+		}()
+	}
+	if _, err := NewTimeProportionalOutputE(time.Second, 0, 0); err != nil {
+		t.Error(err)
+	}
+}
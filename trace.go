@@ -0,0 +1,65 @@
+package pidctrl
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"time"
+)
+
+// WriteTraceCSV merges the records of several recorders, keyed by loop name,
+// onto a common time base and writes them to w as one wide CSV table. Every
+// row holds the time of a record of at least one loop; the columns of each
+// loop, named "<loop>.<column>" and ordered by loop name, carry the most
+// recent record of that loop at that time, or are empty before its first
+// record.
+func WriteTraceCSV(w io.Writer, recorders map[string]*Recorder) error {
+	names := make([]string, 0, len(recorders))
+	for name := range recorders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	header := []string{"time"}
+	records := make([][]Record, len(names))
+	var times []time.Time
+	for i, name := range names {
+		for _, col := range csvHeader[1:] {
+			header = append(header, name+"."+col)
+		}
+		records[i] = recorders[name].Records()
+		for _, rec := range records[i] {
+			times = append(times, rec.Time)
+		}
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	next := make([]int, len(names)) // index of the next record per loop
+	empty := make([]string, len(csvHeader)-1)
+	row := make([]string, 0, len(header))
+	for i, t := range times {
+		if i > 0 && t.Equal(times[i-1]) {
+			continue
+		}
+		row = append(row[:0], t.Format(time.RFC3339Nano))
+		for l := range names {
+			for next[l] < len(records[l]) && !records[l][next[l]].Time.After(t) {
+				next[l]++
+			}
+			if next[l] == 0 {
+				row = append(row, empty...)
+			} else {
+				row = append(row, records[l][next[l]-1].csv()[1:]...)
+			}
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,29 @@
+package pidctrl
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestWriteTraceCSV(t *testing.T) {
+	t0 := time.Date(2016, 3, 2, 0, 0, 0, 0, time.UTC)
+	a, b := NewRecorder(10), NewRecorder(10)
+	a.Add(Record{Time: t0, Setpoint: 1, Value: 2, Output: 3})
+	a.Add(Record{Time: t0.Add(2 * time.Second), Setpoint: 1, Value: 4, Output: 5})
+	b.Add(Record{Time: t0.Add(time.Second), Setpoint: 10, Value: 20, Output: 30})
+	b.Add(Record{Time: t0.Add(2 * time.Second), Setpoint: 10, Value: 40, Output: 50})
+
+	var buf bytes.Buffer
+	if err := WriteTraceCSV(&buf, map[string]*Recorder{"b": b, "a": a}); err != nil {
+		t.Fatal(err)
+	}
+	want := `time,a.setpoint,a.value,a.output,a.p,a.i,a.d,a.feedforward,b.setpoint,b.value,b.output,b.p,b.i,b.d,b.feedforward
+2016-03-02T00:00:00Z,1,2,3,0,0,0,0,,,,,,,
+2016-03-02T00:00:01Z,1,2,3,0,0,0,0,10,20,30,0,0,0,0
+2016-03-02T00:00:02Z,1,4,5,0,0,0,0,10,40,50,0,0,0,0
+`
+	if buf.String() != want {
+		t.Errorf("unexpected trace:\n%s\nexpected:\n%s", buf.String(), want)
+	}
+}
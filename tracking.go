@@ -0,0 +1,66 @@
+package pidctrl
+
+// SetTrackingSignal feeds back the value actually driving the actuator when
+// another controller or a selector overrides this one, as in min/max
+// override control. Call it before every update with the current actuator
+// value. While a tracking signal is set, the integral no longer integrates
+// the error but follows the tracking signal minus feed-forward with a first
+// order lag of the integral time P/I (external reset feedback). The output
+// of an overridden controller then stays within its P and D terms of the
+// actuator value, so it takes over without a bump once a selector picks it,
+// and its integral cannot wind up while it is not selected. A controller fed
+// back its own output integrates essentially as usual. Without P or I gain
+// the integral follows the tracking signal immediately.
+func (c *PIDController) SetTrackingSignal(value float64) *PIDController {
+	c.tracking, c.trackValue = true, value
+	return c
+}
+
+// ClearTrackingSignal makes the integral integrate the error again.
+func (c *PIDController) ClearTrackingSignal() *PIDController {
+	c.tracking = false
+	return c
+}
+
+// TrackingSignal returns the tracking signal and whether one is set.
+func (c *PIDController) TrackingSignal() (value float64, ok bool) {
+	return c.trackValue, c.tracking
+}
+
+// trackIntegral moves the integral dt seconds towards the tracking signal.
+func (c *PIDController) trackIntegral(kp, ki, dt float64) {
+	target := c.trackValue - c.feedForward()
+	if kp == 0 || ki == 0 {
+		c.integral = target
+		return
+	}
+	alpha := dt * ki / kp
+	if alpha > 1 || alpha < 0 {
+		alpha = 1
+	}
+	c.integral += alpha * (target - c.integral)
+}
+
+// track adjusts the integral so that the last update would have produced
+// output, for bumpless transfer of controllers whose output is overridden,
+// e.g. by a selector.
+func (c *PIDController) track(output float64) {
+	c.integral = output - c.terms.P - c.terms.D - c.terms.FeedForward
+	c.clampIntegral()
+}
+
+// SetTrackingSignal feeds back the value actually driving the actuator.
+func (s *SafePIDController) SetTrackingSignal(value float64) *SafePIDController {
+	s.mu.Lock()
+	s.c.SetTrackingSignal(value)
+	s.mu.Unlock()
+	return s
+}
+
+// ClearTrackingSignal makes the integral integrate the error again.
+func (s *SafePIDController) ClearTrackingSignal() *SafePIDController {
+	s.mu.Lock()
+	s.c.ClearTrackingSignal()
+	s.mu.Unlock()
+	return s
+}
@@ -0,0 +1,61 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// TestPIDController_TrackingSignal runs a min selector for a flow loop with
+// a pressure override: the flow controller demands full output, while the
+// pressure controller limits it.
+func TestPIDController_TrackingSignal(t *testing.T) {
+	flow := NewPIDController(2, 1, 0).SetOutputLimits(0, 100).Set(50)
+	pressure := NewPIDController(4, 2, 0).SetOutputLimits(0, 100).Set(10)
+	var out, prev float64
+	for i := 0; i < 1000; i++ {
+		prev = out
+		flow.SetTrackingSignal(out)
+		pressure.SetTrackingSignal(out)
+		of := flow.UpdateDuration(out/4, 100*time.Millisecond)
+		op := pressure.UpdateDuration(out/6, 100*time.Millisecond)
+		out = math.Min(of, op)
+	}
+	if math.Abs(out-60) > 0.5 {
+		t.Errorf("pressure override not settled at 60: %v", out)
+	}
+	// The flow controller is overridden with a constant error of 35, so its
+	// output stays within its P term of the actuator value instead of
+	// winding up to the limit.
+	if i := flow.Integral(); math.Abs(i-out) > 0.5 {
+		t.Errorf("flow integral %v not tracking %v", i, out)
+	}
+	if v, ok := flow.TrackingSignal(); !ok || v != prev {
+		t.Errorf("tracking signal %v %v", v, ok)
+	}
+	flow.ClearTrackingSignal()
+	if _, ok := flow.TrackingSignal(); ok {
+		t.Error("tracking signal not cleared")
+	}
+}
+
+func TestPIDController_TrackingSignalOwnOutput(t *testing.T) {
+	c := NewPIDController(2, 1, 0).SetOutputLimits(0, 100).Set(10)
+	ref := NewPIDController(2, 1, 0).SetOutputLimits(0, 100).Set(10)
+	out := 0.0
+	for i := 0; i < 100; i++ {
+		c.SetTrackingSignal(out)
+		out = c.UpdateDuration(float64(i)/10, 100*time.Millisecond)
+		ref.UpdateDuration(float64(i)/10, 100*time.Millisecond)
+	}
+	if math.Abs(c.Integral()-ref.Integral()) > 0.1*ref.Integral() {
+		t.Errorf("integral %v differs from %v", c.Integral(), ref.Integral())
+	}
+}
+
+func TestPIDController_TrackingSignalImmediate(t *testing.T) {
+	c := NewPIDController(0, 1, 0).SetTrackingSignal(42)
+	if out := c.UpdateDuration(0, time.Second); out != 42 {
+		t.Errorf("output %v != 42", out)
+	}
+}
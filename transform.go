@@ -0,0 +1,47 @@
+package pidctrl
+
+import "math"
+
+// SetErrorTransform installs a nonlinear transform of the error applied
+// before the proportional and integral terms, for processes whose gain
+// depends strongly on the operating point, like pH neutralization with its
+// steep titration curve around pH 7. The transformed error is what
+// observers, logging and alarms see. Pass nil to remove it.
+func (c *PIDController) SetErrorTransform(f func(err float64) float64) *PIDController {
+	c.errTransform = f
+	return c
+}
+
+// PiecewiseErrorGain returns an error transform with gain inside for errors
+// within band and gain outside beyond it. The transform is continuous at the
+// band edges. This is known as gap gain in level control. A low inside gain
+// tames the high process gain of pH loops near neutrality, while the outside
+// gain keeps large upsets responsive.
+func PiecewiseErrorGain(band, inside, outside float64) func(float64) float64 {
+	band = math.Abs(band)
+	return func(err float64) float64 {
+		if abs := math.Abs(err); abs > band {
+			return math.Copysign(band*inside+(abs-band)*outside, err)
+		}
+		return err * inside
+	}
+}
+
+// LogErrorTransform returns an error transform that compresses large errors
+// logarithmically, sign(e)·scale·ln(1+|e|/scale). Errors small compared to
+// scale pass nearly unchanged.
+func LogErrorTransform(scale float64) func(float64) float64 {
+	return func(err float64) float64 {
+		return math.Copysign(scale*math.Log1p(math.Abs(err)/scale), err)
+	}
+}
+
+// ErrorSquaredTransform returns the error transform of error-squared PID,
+// e·|e|/scale. The effective gain grows linearly with the error and equals
+// the configured gains at an error of scale, which keeps surge tank level
+// loops gentle near the setpoint and aggressive far from it.
+func ErrorSquaredTransform(scale float64) func(float64) float64 {
+	return func(err float64) float64 {
+		return err * math.Abs(err) / scale
+	}
+}
@@ -0,0 +1,52 @@
+package pidctrl
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestErrorTransform(t *testing.T) {
+	c := NewPIDController(1, 0, 0).SetErrorTransform(PiecewiseErrorGain(1, 0.1, 1)).Set(7)
+	for value, want := range map[float64]float64{
+		6.5: 0.05,
+		7.5: -0.05,
+		4:   2.1,
+		10:  -2.1,
+	} {
+		if out := c.UpdateDuration(value, time.Second); math.Abs(out-want) > 1e-12 {
+			t.Errorf("pH %v: output %v != %v", value, out, want)
+		}
+	}
+	c.SetErrorTransform(nil)
+	if out := c.UpdateDuration(4, time.Second); out != 3 {
+		t.Errorf("without transform: %v != 3", out)
+	}
+}
+
+func TestLogErrorTransform(t *testing.T) {
+	f := LogErrorTransform(1)
+	if v := f(math.E - 1); math.Abs(v-1) > 1e-12 {
+		t.Errorf("%v != 1", v)
+	}
+	if v := f(-(math.E - 1)); math.Abs(v+1) > 1e-12 {
+		t.Errorf("%v != -1", v)
+	}
+	if v := f(1e-6); math.Abs(v-1e-6) > 1e-12 {
+		t.Errorf("small errors changed: %v", v)
+	}
+}
+
+func TestErrorSquaredTransform(t *testing.T) {
+	c := NewPIDController(1, 0, 0).SetErrorTransform(ErrorSquaredTransform(2)).Set(10)
+	for value, want := range map[float64]float64{
+		9:  0.5,
+		11: -0.5,
+		8:  2,
+		4:  18,
+	} {
+		if out := c.UpdateDuration(value, time.Second); out != want {
+			t.Errorf("value %v: output %v != %v", value, out, want)
+		}
+	}
+}
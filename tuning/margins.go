@@ -0,0 +1,121 @@
+package tuning
+
+import (
+	"math"
+	"math/cmplx"
+
+	"github.com/felixge/pidctrl"
+)
+
+// FrequencyResponse is the response of a linear system at the angular
+// frequency w in rad/s.
+type FrequencyResponse func(w float64) complex128
+
+// Response returns the frequency response of the model.
+func (m FOPDT) Response(w float64) complex128 {
+	s := complex(0, w)
+	return complex(m.Gain, 0) * cmplx.Exp(-s*complex(m.DeadTime.Seconds(), 0)) / (s*complex(m.Tau.Seconds(), 0) + 1)
+}
+
+// Response returns the frequency response of the model.
+func (m SOPDT) Response(w float64) complex128 {
+	s := complex(0, w)
+	return complex(m.Gain, 0) * cmplx.Exp(-s*complex(m.DeadTime.Seconds(), 0)) /
+		((s*complex(m.Tau1.Seconds(), 0) + 1) * (s*complex(m.Tau2.Seconds(), 0) + 1))
+}
+
+// PIDResponse returns the frequency response of an ideal parallel PID
+// controller with the given gains, P + I/s + D·s.
+func PIDResponse(g pidctrl.Gains) FrequencyResponse {
+	return func(w float64) complex128 {
+		s := complex(0, w)
+		return complex(g.P, 0) + complex(g.I, 0)/s + complex(g.D, 0)*s
+	}
+}
+
+// Margins are the stability margins of a control loop.
+type Margins struct {
+	GainMargin     float64 // factor the loop gain may grow by, +Inf without phase crossover
+	PhaseMargin    float64 // degrees, +Inf without gain crossover
+	GainCrossover  float64 // rad/s where the open-loop gain is 1, 0 if none
+	PhaseCrossover float64 // rad/s where the open-loop phase is -180°, 0 if none
+	Bandwidth      float64 // rad/s where the closed-loop gain falls 3 dB below its DC gain, 0 if none
+}
+
+// Stable reports whether both margins are positive, which for open-loop
+// stable plants means the closed loop is stable.
+func (m Margins) Stable() bool {
+	return m.GainMargin > 1 && m.PhaseMargin > 0
+}
+
+// frequency sweep of the margin analysis
+const (
+	minFrequency     = 1e-6
+	maxFrequency     = 1e6
+	pointsPerDecade  = 200
+	refineIterations = 60
+)
+
+// LoopMargins returns the margins of the loop of a PID controller with
+// gains g and the plant. They are found on a logarithmic frequency sweep
+// from 1e-6 to 1e6 rad/s and refined by bisection.
+func LoopMargins(g pidctrl.Gains, plant FrequencyResponse) Margins {
+	c := PIDResponse(g)
+	open := func(w float64) complex128 { return c(w) * plant(w) }
+	m := Margins{GainMargin: math.Inf(1), PhaseMargin: math.Inf(1)}
+
+	ratio := math.Pow(10, 1.0/pointsPerDecade)
+	w0, l0 := minFrequency, open(minFrequency)
+	phase0 := cmplx.Phase(l0)
+	dc := cmplx.Abs(l0 / (1 + l0))
+	closed := func(w float64) float64 { l := open(w); return cmplx.Abs(l/(1+l)) - dc*math.Sqrt2/2 }
+	for w1 := w0 * ratio; w1 <= maxFrequency; w0, w1 = w1, w1*ratio {
+		l1 := open(w1)
+		phase1 := phase0 + wrap(cmplx.Phase(l1)-cmplx.Phase(l0))
+		if m.GainCrossover == 0 && (cmplx.Abs(l0)-1)*(cmplx.Abs(l1)-1) <= 0 && cmplx.Abs(l0) != cmplx.Abs(l1) {
+			m.GainCrossover = bisect(w0, w1, func(w float64) float64 { return cmplx.Abs(open(w)) - 1 })
+			m.PhaseMargin = 180 + degrees(phase0+wrap(cmplx.Phase(open(m.GainCrossover))-cmplx.Phase(l0)))
+		}
+		if m.PhaseCrossover == 0 && (phase0+math.Pi)*(phase1+math.Pi) <= 0 && phase0 != phase1 {
+			m.PhaseCrossover = bisect(w0, w1, func(w float64) float64 {
+				return phase0 + wrap(cmplx.Phase(open(w))-cmplx.Phase(l0)) + math.Pi
+			})
+			m.GainMargin = 1 / cmplx.Abs(open(m.PhaseCrossover))
+		}
+		if m.Bandwidth == 0 && closed(w0) >= 0 && closed(w1) < 0 {
+			m.Bandwidth = bisect(w0, w1, closed)
+		}
+		l0, phase0 = l1, phase1
+	}
+	return m
+}
+
+// bisect returns the root of f between a and b, which must bracket it, on a
+// logarithmic scale.
+func bisect(a, b float64, f func(float64) float64) float64 {
+	fa := f(a)
+	for i := 0; i < refineIterations; i++ {
+		mid := math.Sqrt(a * b)
+		if fm := f(mid); (fm < 0) == (fa < 0) {
+			a, fa = mid, fm
+		} else {
+			b = mid
+		}
+	}
+	return math.Sqrt(a * b)
+}
+
+// wrap returns the angle a wrapped to (-π, π].
+func wrap(a float64) float64 {
+	for a > math.Pi {
+		a -= 2 * math.Pi
+	}
+	for a <= -math.Pi {
+		a += 2 * math.Pi
+	}
+	return a
+}
+
+func degrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
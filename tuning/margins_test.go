@@ -0,0 +1,60 @@
+package tuning
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+func TestLoopMargins(t *testing.T) {
+	// integrator with one second of dead time under P control
+	plant := func(w float64) complex128 {
+		s := complex(0, w)
+		return cmplx.Exp(-s) / s
+	}
+	m := LoopMargins(pidctrl.Gains{P: 1}, plant)
+	if math.Abs(m.GainCrossover-1) > 1e-6 || math.Abs(m.PhaseMargin-(90-180/math.Pi)) > 1e-4 {
+		t.Errorf("gain crossover %v, phase margin %v", m.GainCrossover, m.PhaseMargin)
+	}
+	if math.Abs(m.PhaseCrossover-math.Pi/2) > 1e-6 || math.Abs(m.GainMargin-math.Pi/2) > 1e-6 {
+		t.Errorf("phase crossover %v, gain margin %v", m.PhaseCrossover, m.GainMargin)
+	}
+	if !m.Stable() || m.Bandwidth == 0 {
+		t.Errorf("margins %+v", m)
+	}
+
+	if m := LoopMargins(pidctrl.Gains{P: 2}, plant); m.Stable() {
+		t.Errorf("gain above the gain margin is stable: %+v", m)
+	}
+}
+
+func TestLoopMargins_NoDeadTime(t *testing.T) {
+	m := LoopMargins(pidctrl.Gains{P: 1}, FOPDT{Gain: 1, Tau: time.Second}.Response)
+	// |L| = 1/√(1+w²) never reaches 1 and the phase stays above -90°
+	if !math.IsInf(m.GainMargin, 1) || !math.IsInf(m.PhaseMargin, 1) || !m.Stable() {
+		t.Errorf("margins %+v", m)
+	}
+	// T = 1/(s+2) falls 3 dB below its DC gain at w = 2
+	if math.Abs(m.Bandwidth-2) > 1e-6 {
+		t.Errorf("bandwidth %v", m.Bandwidth)
+	}
+}
+
+func TestLoopMargins_Tuning(t *testing.T) {
+	model := FOPDT{Gain: 2, Tau: 10 * time.Second, DeadTime: 2 * time.Second}
+	for _, v := range Variants {
+		g, err := model.Tune(SIMC, PI, v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m := LoopMargins(g, model.Response); !m.Stable() || m.PhaseMargin < 30 {
+			t.Errorf("SIMC %v: margins %+v", v, m)
+		}
+	}
+	if m := LoopMargins(pidctrl.Gains{P: 1}, SOPDT{Gain: 1, Tau1: time.Second, Tau2: time.Second}.Response); !m.Stable() {
+		t.Errorf("SOPDT margins %+v", m)
+	}
+}
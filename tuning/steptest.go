@@ -0,0 +1,163 @@
+package tuning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrNoResponse is returned by FitStep for responses without a usable
+// change of the process value.
+var ErrNoResponse = errors.New("tuning: step response too small to fit")
+
+// AbortError is returned by StepTest.Run and UltimateTest.Run when the
+// process value left the abort limits.
+type AbortError struct {
+	Value float64
+	At    time.Duration // time since the start of the test
+}
+
+func (e AbortError) Error() string {
+	return fmt.Sprintf("tuning: test aborted at %v, process value %v outside the limits", e.At, e.Value)
+}
+
+// Sample is a recorded point of a step response.
+type Sample struct {
+	At     time.Duration // time since the start of the test
+	Output float64
+	Value  float64
+}
+
+// StepTest applies an open-loop output step to a live plant through the
+// same read and write functions used with PIDController.Run and fits a
+// FOPDT model to the response. The output holds Initial for Settle to
+// record the baseline, then Initial+Step until Timeout. Whatever the
+// outcome, the output is set back to Initial at the end.
+type StepTest struct {
+	Read     func() float64
+	Write    func(float64)
+	Interval time.Duration
+
+	Initial, Step float64
+	// OutputMin and OutputMax bound the output; a step beyond them is
+	// rejected before anything is written.
+	OutputMin, OutputMax float64
+	// AbortMin and AbortMax stop the test once the process value leaves
+	// them, e.g. before a heater overheats.
+	AbortMin, AbortMax float64
+
+	Settle  time.Duration
+	Timeout time.Duration
+}
+
+// NewStepTest returns a StepTest with unbounded outputs and abort limits.
+func NewStepTest(read func() float64, write func(float64), interval time.Duration, initial, step float64, settle, timeout time.Duration) *StepTest {
+	return &StepTest{
+		Read: read, Write: write, Interval: interval,
+		Initial: initial, Step: step,
+		OutputMin: math.Inf(-1), OutputMax: math.Inf(1),
+		AbortMin: math.Inf(-1), AbortMax: math.Inf(1),
+		Settle: settle, Timeout: timeout,
+	}
+}
+
+// StepResult is the outcome of a step test.
+type StepResult struct {
+	Model   FOPDT
+	Samples []Sample
+}
+
+// Run performs the step test until Timeout or ctx is cancelled and returns
+// the recorded samples, with the fitted model unless the test failed.
+func (s *StepTest) Run(ctx context.Context) (StepResult, error) {
+	var r StepResult
+	for _, out := range []float64{s.Initial, s.Initial + s.Step} {
+		if out < s.OutputMin || out > s.OutputMax {
+			return r, fmt.Errorf("tuning: step test output %v outside [%v, %v]", out, s.OutputMin, s.OutputMax)
+		}
+	}
+	defer s.Write(s.Initial)
+	s.Write(s.Initial)
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for at := time.Duration(0); at <= s.Settle+s.Timeout; at += s.Interval {
+		out := s.Initial
+		if at >= s.Settle {
+			out += s.Step
+		}
+		v := s.Read()
+		r.Samples = append(r.Samples, Sample{At: at, Output: out, Value: v})
+		if v < s.AbortMin || v > s.AbortMax || math.IsNaN(v) {
+			return r, AbortError{Value: v, At: at}
+		}
+		s.Write(out)
+		select {
+		case <-ctx.Done():
+			return r, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	var err error
+	r.Model, err = FitStep(r.Samples, s.Settle, s.Step)
+	return r, err
+}
+
+// FitStep fits a FOPDT model to the response to an output step of size step
+// applied at the given time, using Smith's two point method on the times
+// the response reaches 28.3% and 63.2% of its final change. The baseline is
+// the mean value before the step, the final value the mean of the last
+// tenth of the samples.
+func FitStep(samples []Sample, at time.Duration, step float64) (FOPDT, error) {
+	var (
+		base, final float64
+		nb, nf      int
+	)
+	tail := samples[len(samples)-len(samples)/10:]
+	for _, p := range samples {
+		if p.At < at {
+			base += p.Value
+			nb++
+		}
+	}
+	for _, p := range tail {
+		final += p.Value
+		nf++
+	}
+	if nb == 0 || nf == 0 || step == 0 {
+		return FOPDT{}, ErrNoResponse
+	}
+	base, final = base/float64(nb), final/float64(nf)
+	change := final - base
+	if change == 0 {
+		return FOPDT{}, ErrNoResponse
+	}
+	t28, ok28 := crossing(samples, at, base+0.283*change, change > 0)
+	t63, ok63 := crossing(samples, at, base+0.632*change, change > 0)
+	if !ok28 || !ok63 || t63 <= t28 {
+		return FOPDT{}, ErrNoResponse
+	}
+	tau := 1.5 * (t63 - t28).Seconds()
+	dead := math.Max(0, (t63-at).Seconds()-tau)
+	return FOPDT{Gain: change / step, Tau: seconds(tau), DeadTime: seconds(dead)}, nil
+}
+
+// crossing returns the time after at when the samples first reach level,
+// interpolated linearly between samples.
+func crossing(samples []Sample, at time.Duration, level float64, rising bool) (time.Duration, bool) {
+	for i := 1; i < len(samples); i++ {
+		a, b := samples[i-1], samples[i]
+		if b.At < at {
+			continue
+		}
+		if (rising && b.Value >= level) || (!rising && b.Value <= level) {
+			if b.Value == a.Value || a.At < at {
+				return b.At, true
+			}
+			f := (level - a.Value) / (b.Value - a.Value)
+			return a.At + time.Duration(f*float64(b.At-a.At)), true
+		}
+	}
+	return 0, false
+}
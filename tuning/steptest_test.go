@@ -0,0 +1,88 @@
+package tuning
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+// fopdtPlant simulates a FOPDT model with a fixed step.
+type fopdtPlant struct {
+	model FOPDT
+	dt    time.Duration
+	y     float64
+	queue []float64
+	input float64
+}
+
+func (p *fopdtPlant) read() float64 {
+	n := int(p.model.DeadTime / p.dt)
+	p.queue = append(p.queue, p.input)
+	u := p.queue[0]
+	if len(p.queue) > n {
+		p.queue = p.queue[1:]
+	}
+	p.y += (p.model.Gain*u - p.y) * p.dt.Seconds() / p.model.Tau.Seconds()
+	return p.y
+}
+
+func (p *fopdtPlant) write(u float64) { p.input = u }
+
+func TestFitStep(t *testing.T) {
+	model := FOPDT{Gain: 2, Tau: 10 * time.Second, DeadTime: 3 * time.Second}
+	const dt = 10 * time.Millisecond
+	p := &fopdtPlant{model: model, dt: dt}
+	var samples []Sample
+	for at := time.Duration(0); at < 100*time.Second; at += dt {
+		if at >= 5*time.Second {
+			p.write(4)
+		}
+		samples = append(samples, Sample{At: at, Output: p.input, Value: p.read()})
+	}
+	m, err := FitStep(samples, 5*time.Second, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(m.Gain-2) > 0.01 || math.Abs(m.Tau.Seconds()-10) > 0.2 || math.Abs(m.DeadTime.Seconds()-3) > 0.2 {
+		t.Errorf("fitted %+v", m)
+	}
+	if _, err := FitStep(samples[:10], 5*time.Second, 4); err != ErrNoResponse {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestStepTest(t *testing.T) {
+	// a fast plant, time-scaled so the test runs in below a second
+	model := FOPDT{Gain: 2, Tau: 100 * time.Millisecond, DeadTime: 20 * time.Millisecond}
+	const dt = time.Millisecond
+	p := &fopdtPlant{model: model, dt: dt}
+	s := NewStepTest(p.read, p.write, dt, 0, 1, 50*time.Millisecond, 700*time.Millisecond)
+	r, err := s.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(r.Model.Gain-2) > 0.05 || math.Abs(r.Model.Tau.Seconds()-0.1) > 0.01 {
+		t.Errorf("fitted %+v", r.Model)
+	}
+	if p.input != 0 {
+		t.Errorf("output not restored: %v", p.input)
+	}
+
+	p = &fopdtPlant{model: model, dt: dt}
+	s = NewStepTest(p.read, p.write, dt, 0, 1, 10*time.Millisecond, time.Second)
+	s.AbortMax = 1
+	if _, err := s.Run(context.Background()); err == nil {
+		t.Error("expected abort")
+	} else if _, ok := err.(AbortError); !ok {
+		t.Errorf("unexpected error %v", err)
+	}
+	if p.input != 0 {
+		t.Errorf("output not restored after abort: %v", p.input)
+	}
+
+	s.OutputMax = 0.5
+	if _, err := s.Run(context.Background()); err == nil {
+		t.Error("expected error for step beyond the output limits")
+	}
+}
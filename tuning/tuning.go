@@ -0,0 +1,317 @@
+// Package tuning suggests PID gains from process models using the classic
+// tuning rules: Ziegler-Nichols, Cohen-Coon, IMC (lambda), SIMC and AMIGO,
+// and Takahashi's modification of Ziegler-Nichols for digital control.
+//
+// Gains are returned in the parallel form used by pidctrl, with integral and
+// derivative gains per second. The TuneSampled and SuggestSampled variants
+// account for the sample period of the controller. StepTest fits a model to the step response
+// of a live plant, and UltimateTest finds the ultimate gain and period for
+// the Ziegler-Nichols closed-loop rule.
+package tuning
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+// ErrInvalidModel is returned for models without a usable gain or time
+// constant.
+var ErrInvalidModel = errors.New("tuning: model gain and time constant must be nonzero and positive")
+
+// ErrNoDeadTime is returned by rules that depend on the dead time for models
+// without dead time.
+var ErrNoDeadTime = errors.New("tuning: rule requires a positive dead time")
+
+// FOPDT is a first order plus dead time process model.
+type FOPDT struct {
+	Gain     float64       // steady-state change of the process value per unit of output
+	Tau      time.Duration // time constant
+	DeadTime time.Duration
+}
+
+// SOPDT is a second order plus dead time process model with the time
+// constants Tau1 ≥ Tau2.
+type SOPDT struct {
+	Gain       float64
+	Tau1, Tau2 time.Duration
+	DeadTime   time.Duration
+}
+
+// FOPDT approximates the model by a first order one with Skogestad's half
+// rule: half of the smaller time constant is added to the larger one and
+// half to the dead time.
+func (m SOPDT) FOPDT() FOPDT {
+	tau1, tau2 := m.Tau1, m.Tau2
+	if tau2 > tau1 {
+		tau1, tau2 = tau2, tau1
+	}
+	return FOPDT{Gain: m.Gain, Tau: tau1 + tau2/2, DeadTime: m.DeadTime + tau2/2}
+}
+
+// Rule is a tuning rule.
+type Rule int
+
+// Tuning rules.
+const (
+	ZieglerNichols Rule = iota // Ziegler-Nichols reaction curve
+	CohenCoon
+	IMC // internal model control, also known as lambda tuning
+	SIMC
+	AMIGO
+	Takahashi // Ziegler-Nichols modified for discrete sample periods
+)
+
+var ruleNames = [...]string{"ziegler-nichols", "cohen-coon", "imc", "simc", "amigo", "takahashi"}
+
+// Rules lists all tuning rules.
+var Rules = []Rule{ZieglerNichols, CohenCoon, IMC, SIMC, AMIGO, Takahashi}
+
+func (r Rule) String() string {
+	if r < 0 || int(r) >= len(ruleNames) {
+		return "unknown"
+	}
+	return ruleNames[r]
+}
+
+// ParseRule returns the rule with the given name, as returned by String.
+func ParseRule(name string) (Rule, error) {
+	for i, n := range ruleNames {
+		if strings.EqualFold(n, name) {
+			return Rule(i), nil
+		}
+	}
+	return 0, fmt.Errorf("tuning: unknown rule %q", name)
+}
+
+// Kind selects the controller type a rule is applied for.
+type Kind int
+
+// Controller kinds.
+const (
+	PI Kind = iota
+	PID
+)
+
+func (k Kind) String() string {
+	if k == PID {
+		return "pid"
+	}
+	return "pi"
+}
+
+// Variant selects between aggressive and conservative variants of a rule.
+// For the model based IMC and SIMC rules it selects the closed-loop time
+// constant λ: half, once or three times the larger of the dead time and a
+// tenth of the time constant. For the other rules the proportional gain is
+// scaled by 1.2, 1 or 0.5, with the published rule as the normal variant.
+type Variant int
+
+// Rule variants.
+const (
+	Normal Variant = iota
+	Aggressive
+	Conservative
+)
+
+var variantNames = [...]string{"normal", "aggressive", "conservative"}
+
+// Variants lists all rule variants.
+var Variants = []Variant{Aggressive, Normal, Conservative}
+
+func (v Variant) String() string {
+	if v < 0 || int(v) >= len(variantNames) {
+		return "unknown"
+	}
+	return variantNames[v]
+}
+
+// ParseVariant returns the variant with the given name, as returned by
+// String.
+func ParseVariant(name string) (Variant, error) {
+	for i, n := range variantNames {
+		if strings.EqualFold(n, name) {
+			return Variant(i), nil
+		}
+	}
+	return 0, fmt.Errorf("tuning: unknown variant %q", name)
+}
+
+// Suggestion is the result of a rule applied to a model.
+type Suggestion struct {
+	Rule    Rule
+	Kind    Kind
+	Variant Variant
+	Gains   pidctrl.Gains
+}
+
+// Tune returns the gains of the rule for the model. The Takahashi rule is
+// applied for continuous control, where it differs from Ziegler-Nichols only
+// in the derivative gain; see TuneSampled.
+func (m FOPDT) Tune(rule Rule, kind Kind, variant Variant) (pidctrl.Gains, error) {
+	return m.TuneSampled(rule, kind, variant, 0)
+}
+
+// TuneSampled returns the gains of the rule for the model controlled with
+// the given sample period, e.g. the controller's SampleTime. The Takahashi
+// rule accounts for the period itself; for the other rules the zero-order
+// hold of the output is modelled as half a period of additional dead time.
+func (m FOPDT) TuneSampled(rule Rule, kind Kind, variant Variant, period time.Duration) (pidctrl.Gains, error) {
+	k, t, l := m.Gain, m.Tau.Seconds(), m.DeadTime.Seconds()
+	if k == 0 || math.IsNaN(k) || !(t > 0) || l < 0 {
+		return pidctrl.Gains{}, ErrInvalidModel
+	}
+	h := math.Max(0, period.Seconds())
+	if rule == Takahashi {
+		return takahashi(k, t, l, h, kind, variant)
+	}
+	l += h / 2
+	if l == 0 && (rule == ZieglerNichols || rule == CohenCoon || rule == AMIGO) {
+		return pidctrl.Gains{}, ErrNoDeadTime
+	}
+	lambda := math.Max(l, t/10) * [...]float64{Normal: 1, Aggressive: 0.5, Conservative: 3}[variant]
+	scale := [...]float64{Normal: 1, Aggressive: 1.2, Conservative: 0.5}[variant]
+	var kp, ti, td float64
+	switch rule {
+	case ZieglerNichols:
+		if kind == PID {
+			kp, ti, td = 1.2*t/(k*l), 2*l, l/2
+		} else {
+			kp, ti = 0.9*t/(k*l), l/0.3
+		}
+	case CohenCoon:
+		r := l / t
+		if kind == PID {
+			kp, ti, td = t/(k*l)*(4.0/3+r/4), l*(32+6*r)/(13+8*r), 4*l/(11+2*r)
+		} else {
+			kp, ti = t/(k*l)*(0.9+r/12), l*(30+3*r)/(9+20*r)
+		}
+	case IMC:
+		scale = 1
+		if kind == PID {
+			kp, ti, td = (t+l/2)/(k*(lambda+l/2)), t+l/2, t*l/(2*t+l)
+		} else {
+			kp, ti = t/(k*(lambda+l)), t
+		}
+	case SIMC:
+		// the FOPDT rule only has a PI form, see SOPDT.Tune for PID
+		scale = 1
+		kp, ti = t/(k*(lambda+l)), math.Min(t, 4*(lambda+l))
+	case AMIGO:
+		if kind == PID {
+			kp, ti, td = (0.2+0.45*t/l)/k, (0.4*l+0.8*t)/(l+0.1*t)*l, 0.5*l*t/(0.3*l+t)
+		} else {
+			kp = 0.15/k + (0.35-l*t/((l+t)*(l+t)))*t/(k*l)
+			ti = 0.35*l + 13*l*t*t/(t*t+12*l*t+7*l*l)
+		}
+	default:
+		return pidctrl.Gains{}, fmt.Errorf("tuning: unknown rule %v", rule)
+	}
+	return pidctrl.StandardGains(kp*scale, seconds(ti), seconds(td)), nil
+}
+
+// Tune returns the gains of the rule for the model. SIMC PID uses the
+// series form, with the derivative time cancelling the second time constant;
+// all other rules are applied to the half rule approximation of the model.
+func (m SOPDT) Tune(rule Rule, kind Kind, variant Variant) (pidctrl.Gains, error) {
+	return m.TuneSampled(rule, kind, variant, 0)
+}
+
+// TuneSampled returns the gains of the rule for the model controlled with
+// the given sample period, see FOPDT.TuneSampled.
+func (m SOPDT) TuneSampled(rule Rule, kind Kind, variant Variant, period time.Duration) (pidctrl.Gains, error) {
+	if rule != SIMC || kind != PID {
+		return m.FOPDT().TuneSampled(rule, kind, variant, period)
+	}
+	if period > 0 {
+		m.DeadTime += period / 2
+	}
+	tau1, tau2 := m.Tau1.Seconds(), m.Tau2.Seconds()
+	if tau2 > tau1 {
+		tau1, tau2 = tau2, tau1
+	}
+	k, l := m.Gain, m.DeadTime.Seconds()
+	if k == 0 || math.IsNaN(k) || !(tau1 > 0) || l < 0 {
+		return pidctrl.Gains{}, ErrInvalidModel
+	}
+	lambda := math.Max(l, tau1/10) * [...]float64{Normal: 1, Aggressive: 0.5, Conservative: 3}[variant]
+	kc, ti := tau1/(k*(lambda+l)), math.Min(tau1, 4*(lambda+l))
+	return pidctrl.SeriesGains(kc, seconds(ti), seconds(tau2)), nil
+}
+
+// Suggest returns the suggestions of all rules, kinds and variants for the
+// model, skipping rules that are not applicable.
+func (m FOPDT) Suggest() []Suggestion {
+	return suggest(m.Tune)
+}
+
+// SuggestSampled is like Suggest for the given sample period, see
+// TuneSampled.
+func (m FOPDT) SuggestSampled(period time.Duration) []Suggestion {
+	return suggest(func(r Rule, k Kind, v Variant) (pidctrl.Gains, error) {
+		return m.TuneSampled(r, k, v, period)
+	})
+}
+
+// Suggest returns the suggestions of all rules, kinds and variants for the
+// model, skipping rules that are not applicable.
+func (m SOPDT) Suggest() []Suggestion {
+	return suggest(m.Tune)
+}
+
+// SuggestSampled is like Suggest for the given sample period, see
+// TuneSampled.
+func (m SOPDT) SuggestSampled(period time.Duration) []Suggestion {
+	return suggest(func(r Rule, k Kind, v Variant) (pidctrl.Gains, error) {
+		return m.TuneSampled(r, k, v, period)
+	})
+}
+
+func suggest(tune func(Rule, Kind, Variant) (pidctrl.Gains, error)) []Suggestion {
+	var s []Suggestion
+	for _, r := range Rules {
+		for _, k := range []Kind{PI, PID} {
+			for _, v := range Variants {
+				if g, err := tune(r, k, v); err == nil {
+					s = append(s, Suggestion{Rule: r, Kind: k, Variant: v, Gains: g})
+				}
+			}
+		}
+	}
+	return s
+}
+
+// takahashi returns the gains of Takahashi's reaction curve rule for a
+// model with gain k, time constant t and dead time l, in seconds, sampled
+// with period h. The published velocity form gains per sample are converted
+// to gains per second. For h = 0 the proportional and integral gains are
+// those of Ziegler-Nichols.
+func takahashi(k, t, l, h float64, kind Kind, variant Variant) (pidctrl.Gains, error) {
+	lh := l + h/2
+	if lh == 0 {
+		return pidctrl.Gains{}, ErrNoDeadTime
+	}
+	scale := [...]float64{Normal: 1, Aggressive: 1.2, Conservative: 0.5}[variant]
+	var g pidctrl.Gains
+	if kind == PID {
+		g = pidctrl.Gains{
+			P: 1.2*t/(k*(l+h)) - 0.3*t*h/(k*lh*lh),
+			I: 0.6 * t / (k * lh * lh),
+			D: 0.5 * t / k,
+		}
+	} else {
+		g = pidctrl.Gains{
+			P: 0.9*t/(k*lh) - 0.135*t*h/(k*lh*lh),
+			I: 0.27 * t / (k * lh * lh),
+		}
+	}
+	return pidctrl.Gains{P: g.P * scale, I: g.I * scale, D: g.D * scale}, nil
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Round(s * float64(time.Second)))
+}
@@ -0,0 +1,145 @@
+package tuning
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-6*math.Max(1, math.Abs(b))
+}
+
+func TestFOPDT_Tune(t *testing.T) {
+	m := FOPDT{Gain: 2, Tau: 10 * time.Second, DeadTime: 2 * time.Second}
+	for _, test := range []struct {
+		rule    Rule
+		kind    Kind
+		variant Variant
+		kp      float64
+		ti, td  float64 // seconds
+	}{
+		{ZieglerNichols, PI, Normal, 2.25, 2 / 0.3, 0},
+		{ZieglerNichols, PID, Normal, 3, 4, 1},
+		{ZieglerNichols, PID, Conservative, 1.5, 4, 1},
+		{CohenCoon, PI, Normal, 2.25 + 1.0/24, 2 * 30.6 / 13, 0},
+		{IMC, PI, Normal, 10.0 / 8, 10, 0},
+		{IMC, PID, Normal, 11.0 / 6, 11, 20.0 / 22},
+		{SIMC, PI, Normal, 10.0 / 8, 10, 0},
+		{SIMC, PI, Aggressive, 10.0 / 6, 10, 0},
+		{SIMC, PI, Conservative, 10.0 / 16, 10, 0},
+		{AMIGO, PID, Normal, (0.2 + 0.45*5) / 2, (0.8 + 8) / 3 * 2, 10.0 / 10.6},
+	} {
+		g, err := m.Tune(test.rule, test.kind, test.variant)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := pidctrl.StandardGains(test.kp, seconds(test.ti), seconds(test.td))
+		if !approx(g.P, want.P) || !approx(g.I, want.I) || !approx(g.D, want.D) {
+			t.Errorf("%v %v %v: %+v, want %+v", test.rule, test.kind, test.variant, g, want)
+		}
+	}
+}
+
+func TestFOPDT_TuneInvalid(t *testing.T) {
+	if _, err := (FOPDT{Tau: time.Second}).Tune(IMC, PI, Normal); err != ErrInvalidModel {
+		t.Errorf("unexpected error %v", err)
+	}
+	m := FOPDT{Gain: 1, Tau: time.Second}
+	if _, err := m.Tune(ZieglerNichols, PI, Normal); err != ErrNoDeadTime {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := m.Tune(SIMC, PI, Normal); err != nil {
+		t.Errorf("SIMC without dead time: %v", err)
+	}
+	// only the dead time free rules remain
+	if n := len(m.Suggest()); n != 2*2*3 {
+		t.Errorf("%d suggestions", n)
+	}
+}
+
+func TestSOPDT(t *testing.T) {
+	m := SOPDT{Gain: 1, Tau1: 10 * time.Second, Tau2: 4 * time.Second, DeadTime: time.Second}
+	if f := m.FOPDT(); f.Tau != 12*time.Second || f.DeadTime != 3*time.Second {
+		t.Errorf("half rule %+v", f)
+	}
+	g, err := m.Tune(SIMC, PID, Normal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Kc = 10/(1+1), τI = min(10, 8), τD = 4 in series form
+	if want := pidctrl.SeriesGains(5, 8*time.Second, 4*time.Second); !approx(g.P, want.P) || !approx(g.I, want.I) || !approx(g.D, want.D) {
+		t.Errorf("SIMC PID %+v, want %+v", g, want)
+	}
+	if len(m.Suggest()) != len(Rules)*2*3 {
+		t.Errorf("%d suggestions", len(m.Suggest()))
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, r := range Rules {
+		if p, err := ParseRule(r.String()); err != nil || p != r {
+			t.Errorf("%v: %v %v", r, p, err)
+		}
+	}
+	if v, err := ParseVariant("Conservative"); err != nil || v != Conservative {
+		t.Errorf("%v %v", v, err)
+	}
+	if _, err := ParseRule("magic"); err == nil {
+		t.Error("expected error for unknown rule")
+	}
+}
+
+func TestFOPDT_Takahashi(t *testing.T) {
+	m := FOPDT{Gain: 2, Tau: 10 * time.Second, DeadTime: time.Second}
+	zn, err := m.Tune(ZieglerNichols, PID, Normal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := m.Tune(Takahashi, PID, Normal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !approx(g.P, zn.P) || !approx(g.I, zn.I) {
+		t.Errorf("continuous Takahashi %+v, want Ziegler-Nichols P and I %+v", g, zn)
+	}
+	// L + h/2 = 1.5: Kp = 1.2·10/(2·2) - 0.3·10·1/(2·2.25), Ki = 0.6·10/(2·2.25), Kd = 0.5·10/2
+	g, err = m.TuneSampled(Takahashi, PID, Normal, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (pidctrl.Gains{P: 3 - 2.0/3, I: 4.0 / 3, D: 2.5}); !approx(g.P, want.P) || !approx(g.I, want.I) || !approx(g.D, want.D) {
+		t.Errorf("sampled Takahashi PID %+v, want %+v", g, want)
+	}
+	// Kp = 0.9·10/(2·1.5) - 0.135·10·1/(2·2.25), Ki = 0.27·10/(2·2.25)
+	g, err = m.TuneSampled(Takahashi, PI, Normal, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (pidctrl.Gains{P: 3 - 0.3, I: 0.6}); !approx(g.P, want.P) || !approx(g.I, want.I) || g.D != 0 {
+		t.Errorf("sampled Takahashi PI %+v, want %+v", g, want)
+	}
+	if _, err := (FOPDT{Gain: 1, Tau: time.Second}).TuneSampled(Takahashi, PI, Normal, time.Second); err != nil {
+		t.Errorf("Takahashi without dead time but with a sample period: %v", err)
+	}
+}
+
+func TestFOPDT_TuneSampled(t *testing.T) {
+	m := FOPDT{Gain: 1, Tau: 10 * time.Second, DeadTime: time.Second}
+	g, err := m.TuneSampled(ZieglerNichols, PI, Normal, 2*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := FOPDT{Gain: 1, Tau: 10 * time.Second, DeadTime: 2 * time.Second}.Tune(ZieglerNichols, PI, Normal)
+	if g != want {
+		t.Errorf("sampled %+v, want the gains for half a period more dead time %+v", g, want)
+	}
+	if _, err := (FOPDT{Gain: 1, Tau: time.Second}).TuneSampled(ZieglerNichols, PI, Normal, time.Second); err != nil {
+		t.Errorf("sample period not counted as dead time: %v", err)
+	}
+	if n := len(m.SuggestSampled(time.Second)); n != len(Rules)*2*3 {
+		t.Errorf("%d suggestions", n)
+	}
+}
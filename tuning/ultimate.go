@@ -0,0 +1,189 @@
+package tuning
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+// ErrNoOscillation is returned by UltimateTest.Run when the loop did not
+// oscillate up to the maximum gain.
+var ErrNoOscillation = errors.New("tuning: no sustained oscillation up to the maximum gain")
+
+// Ultimate is the ultimate gain and period of a loop, at which a purely
+// proportional controller keeps the loop oscillating.
+type Ultimate struct {
+	Gain   float64
+	Period time.Duration
+}
+
+// Tune returns the Ziegler-Nichols closed-loop gains. The variant scales
+// the proportional gain like for the other fixed rules, see Variant.
+func (u Ultimate) Tune(kind Kind, variant Variant) (pidctrl.Gains, error) {
+	ku, tu := u.Gain, u.Period.Seconds()
+	if !(ku > 0) || !(tu > 0) {
+		return pidctrl.Gains{}, ErrInvalidModel
+	}
+	scale := [...]float64{Normal: 1, Aggressive: 1.2, Conservative: 0.5}[variant]
+	if kind == PID {
+		return pidctrl.StandardGains(0.6*ku*scale, seconds(tu/2), seconds(tu/8)), nil
+	}
+	return pidctrl.StandardGains(0.45*ku*scale, seconds(tu/1.2), 0), nil
+}
+
+// TuneSampled returns Takahashi's closed-loop gains for control with the
+// given sample period, which equal the Ziegler-Nichols gains of Tune for a
+// period of 0.
+func (u Ultimate) TuneSampled(kind Kind, variant Variant, period time.Duration) (pidctrl.Gains, error) {
+	if period <= 0 {
+		return u.Tune(kind, variant)
+	}
+	ku, tu, h := u.Gain, u.Period.Seconds(), period.Seconds()
+	if !(ku > 0) || !(tu > 0) {
+		return pidctrl.Gains{}, ErrInvalidModel
+	}
+	scale := [...]float64{Normal: 1, Aggressive: 1.2, Conservative: 0.5}[variant]
+	if kind == PID {
+		return pidctrl.Gains{P: 0.6 * ku * (1 - h/tu) * scale, I: 1.2 * ku / tu * scale, D: 3 * ku * tu / 40 * scale}, nil
+	}
+	return pidctrl.Gains{P: (0.45*ku - 0.27*ku*h/tu) * scale, I: 0.54 * ku / tu * scale}, nil
+}
+
+// UltimateTest finds the ultimate gain of a live loop without relay
+// switching: the controller runs purely proportional and its gain is raised
+// by Factor every Dwell until the process value oscillates with constant
+// amplitude. At the start of each gain step the setpoint is bumped by Bump,
+// alternating in direction, to excite the loop. The gains and setpoint of
+// the controller are restored at the end, whatever the outcome; the
+// integral is kept as operating point of the proportional test.
+type UltimateTest struct {
+	Controller *pidctrl.PIDController
+	Read       func() float64
+	Write      func(float64)
+	Interval   time.Duration
+
+	StartGain, MaxGain float64
+	// Factor is the factor by which the gain is raised, e.g. 1.2.
+	Factor float64
+	Dwell  time.Duration
+	Bump   float64
+	// MinAmplitude is the smallest peak deviation counted as oscillation,
+	// to ignore measurement noise.
+	MinAmplitude float64
+	// AbortMin and AbortMax stop the test once the process value leaves
+	// them.
+	AbortMin, AbortMax float64
+
+	// Progress is called with each new gain. It may be nil.
+	Progress func(gain float64)
+}
+
+// NewUltimateTest returns an UltimateTest raising the gain by 20% up to 100
+// times the start gain, with unbounded abort limits.
+func NewUltimateTest(c *pidctrl.PIDController, read func() float64, write func(float64), interval time.Duration, startGain, bump float64, dwell time.Duration) *UltimateTest {
+	return &UltimateTest{
+		Controller: c, Read: read, Write: write, Interval: interval,
+		StartGain: startGain, MaxGain: 100 * startGain, Factor: 1.2,
+		Dwell: dwell, Bump: bump,
+		AbortMin: math.Inf(-1), AbortMax: math.Inf(1),
+	}
+}
+
+// UltimateResult is the outcome of an ultimate gain test.
+type UltimateResult struct {
+	Ultimate Ultimate
+	Samples  []Sample
+}
+
+// Run performs the test until sustained oscillation is found, the gain
+// exceeds MaxGain or ctx is cancelled.
+func (t *UltimateTest) Run(ctx context.Context) (UltimateResult, error) {
+	var r UltimateResult
+	c := t.Controller
+	gains, setpoint := c.Gains(), c.Get()
+	defer func() { c.SetGains(gains).Set(setpoint) }()
+	ticker := time.NewTicker(t.Interval)
+	defer ticker.Stop()
+	at := time.Duration(0)
+	bump := t.Bump
+	for kp := t.StartGain; kp <= t.MaxGain; kp *= t.Factor {
+		if t.Progress != nil {
+			t.Progress(kp)
+		}
+		c.SetPID(kp, 0, 0).Set(setpoint + bump)
+		bump = -bump
+		start := len(r.Samples)
+		for end := at + t.Dwell; at < end; at += t.Interval {
+			v := t.Read()
+			out := c.UpdateDuration(v, t.Interval)
+			r.Samples = append(r.Samples, Sample{At: at, Output: out, Value: v})
+			if v < t.AbortMin || v > t.AbortMax || math.IsNaN(v) {
+				return r, AbortError{Value: v, At: at}
+			}
+			t.Write(out)
+			select {
+			case <-ctx.Done():
+				return r, ctx.Err()
+			case <-ticker.C:
+			}
+		}
+		if period, ok := sustained(r.Samples[start:], t.MinAmplitude); ok {
+			r.Ultimate = Ultimate{Gain: kp, Period: period}
+			return r, nil
+		}
+	}
+	return r, ErrNoOscillation
+}
+
+// sustained checks the second half of the samples for an oscillation of
+// constant or growing amplitude and returns its period. The cycles are
+// separated by the upward crossings of the mean and measured between their
+// maxima, with the amplitude as half their peak-to-peak value. At least three
+// full cycles are required, with periods within ±20% of each other and the
+// last amplitude at least 90% of the first.
+func sustained(samples []Sample, minAmplitude float64) (time.Duration, bool) {
+	samples = samples[len(samples)/2:]
+	if len(samples) < 2 {
+		return 0, false
+	}
+	var mean float64
+	for _, s := range samples {
+		mean += s.Value
+	}
+	mean /= float64(len(samples))
+	var (
+		maxima     []time.Duration
+		amplitudes []float64
+		started    bool
+		hi, lo     Sample
+	)
+	for i := 1; i < len(samples); i++ {
+		s := samples[i]
+		if samples[i-1].Value < mean && s.Value >= mean {
+			if started {
+				maxima = append(maxima, hi.At)
+				amplitudes = append(amplitudes, (hi.Value-lo.Value)/2)
+			}
+			started, hi, lo = true, s, s
+		}
+		if s.Value > hi.Value {
+			hi = s
+		}
+		if s.Value < lo.Value {
+			lo = s
+		}
+	}
+	if len(maxima) < 4 || amplitudes[0] < minAmplitude || amplitudes[len(amplitudes)-1] < 0.9*amplitudes[0] {
+		return 0, false
+	}
+	period := (maxima[len(maxima)-1] - maxima[0]) / time.Duration(len(maxima)-1)
+	for i := 1; i < len(maxima); i++ {
+		if d := maxima[i] - maxima[i-1]; math.Abs(float64(d-period)) > 0.2*float64(period) {
+			return 0, false
+		}
+	}
+	return period, true
+}
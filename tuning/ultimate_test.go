@@ -0,0 +1,86 @@
+package tuning
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/felixge/pidctrl"
+)
+
+func TestUltimate_Tune(t *testing.T) {
+	u := Ultimate{Gain: 10, Period: 4 * time.Second}
+	g, err := u.Tune(PID, Normal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := pidctrl.StandardGains(6, 2*time.Second, 500*time.Millisecond); !approx(g.P, want.P) || !approx(g.I, want.I) || !approx(g.D, want.D) {
+		t.Errorf("%+v != %+v", g, want)
+	}
+	if g, _ := u.Tune(PI, Conservative); !approx(g.P, 2.25) || g.D != 0 {
+		t.Errorf("conservative PI %+v", g)
+	}
+	if _, err := (Ultimate{}).Tune(PI, Normal); err != ErrInvalidModel {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestSustained(t *testing.T) {
+	var constant, decaying []Sample
+	for at := time.Duration(0); at < 20*time.Second; at += 10 * time.Millisecond {
+		x := 2 * math.Pi * at.Seconds() / 1.5
+		constant = append(constant, Sample{At: at, Value: 5 + math.Sin(x)})
+		decaying = append(decaying, Sample{At: at, Value: 5 + math.Exp(-at.Seconds()/2)*math.Sin(x)})
+	}
+	if period, ok := sustained(constant, 0.1); !ok || math.Abs(period.Seconds()-1.5) > 0.01 {
+		t.Errorf("constant oscillation: %v %v", period, ok)
+	}
+	if _, ok := sustained(decaying, 0.001); ok {
+		t.Error("decaying oscillation reported as sustained")
+	}
+	if _, ok := sustained(constant, 2); ok {
+		t.Error("oscillation below the minimum amplitude reported")
+	}
+}
+
+func TestUltimateTest(t *testing.T) {
+	// a fast plant, time-scaled so the test runs in about two seconds; the
+	// ultimate gain of the continuous model is 8.5 at a period of 37 ms
+	model := FOPDT{Gain: 1, Tau: 50 * time.Millisecond, DeadTime: 10 * time.Millisecond}
+	const dt = time.Millisecond
+	p := &fopdtPlant{model: model, dt: dt}
+	c := pidctrl.NewPIDController(1, 0.5, 0).Set(1)
+	var gains []float64
+	u := NewUltimateTest(c, p.read, p.write, dt, 5, 0.2, 500*time.Millisecond)
+	u.MinAmplitude = 0.01
+	u.Progress = func(g float64) { gains = append(gains, g) }
+	r, err := u.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Ultimate.Gain < 7 || r.Ultimate.Gain > 10.5 || math.Abs(r.Ultimate.Period.Seconds()-0.037) > 0.006 {
+		t.Errorf("unexpected result %+v", r.Ultimate)
+	}
+	if len(gains) == 0 || gains[len(gains)-1] != r.Ultimate.Gain {
+		t.Errorf("progress %v", gains)
+	}
+	if p, i, _ := c.PID(); p != 1 || i != 0.5 || c.Get() != 1 {
+		t.Errorf("controller not restored: %v %v %v", p, i, c.Get())
+	}
+}
+
+func TestUltimate_TuneSampled(t *testing.T) {
+	u := Ultimate{Gain: 10, Period: 4 * time.Second}
+	g, err := u.TuneSampled(PID, Normal, 400*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Kp = 0.6·10·(1-0.1), Ki = 1.2·10/4, Kd = 3·10·4/40
+	if want := (pidctrl.Gains{P: 5.4, I: 3, D: 3}); !approx(g.P, want.P) || !approx(g.I, want.I) || !approx(g.D, want.D) {
+		t.Errorf("%+v != %+v", g, want)
+	}
+	if g, _ := u.TuneSampled(PI, Normal, 0); !approx(g.P, 4.5) || !approx(g.I, 1.35) {
+		t.Errorf("unsampled PI %+v, want Ziegler-Nichols", g)
+	}
+}
@@ -0,0 +1,142 @@
+package pidctrl
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Severity classifies an Issue.
+type Severity int
+
+const (
+	// Warning marks a configuration that works but probably not as
+	// intended.
+	Warning Severity = iota
+	// Invalid marks a configuration the controller cannot run sensibly
+	// with.
+	Invalid
+)
+
+func (s Severity) String() string {
+	if s == Invalid {
+		return "invalid"
+	}
+	return "warning"
+}
+
+// Issue is a configuration problem found by Validate.
+type Issue struct {
+	Severity Severity
+	Field    string // the setting at fault, e.g. "integral_limits"
+	Message  string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.Severity, i.Field, i.Message)
+}
+
+// Issues is the result of Validate.
+type Issues []Issue
+
+// Err returns an error listing the invalid issues, or nil if there are none.
+func (is Issues) Err() error {
+	var msgs []string
+	for _, i := range is {
+		if i.Severity == Invalid {
+			msgs = append(msgs, i.Field+": "+i.Message)
+		}
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid configuration: %s", strings.Join(msgs, "; "))
+}
+
+// Validate cross-checks the configuration of the controller for physically
+// impossible or inconsistent settings that the individual setters cannot
+// detect on their own. Loop.Start refuses to start a controller with
+// invalid issues.
+func (c *PIDController) Validate() Issues {
+	var is Issues
+	add := func(s Severity, field, format string, args ...interface{}) {
+		is = append(is, Issue{s, field, fmt.Sprintf(format, args...)})
+	}
+	for _, g := range []struct {
+		name string
+		v    float64
+	}{{"p", c.p}, {"i", c.i}, {"d", c.d}, {"setpoint", c.target}, {"setpoint_ramp", c.rampRate}} {
+		if math.IsNaN(g.v) || math.IsInf(g.v, 0) {
+			add(Invalid, g.name, "%v is not finite", g.v)
+		}
+	}
+	if c.p*c.i < 0 || c.p*c.d < 0 || c.i*c.d < 0 {
+		add(Warning, "gains", "mixed signs p=%v i=%v d=%v, the terms work against each other", c.p, c.i, c.d)
+	}
+	iMin, iMax := c.IntegralLimits()
+	if iMin > 0 || iMax < 0 {
+		add(Invalid, "integral_limits", "[%v, %v] does not contain 0", iMin, iMax)
+	}
+	if c.iLimits && (c.iMin < c.outMin || c.iMax > c.outMax) {
+		add(Warning, "integral_limits", "[%v, %v] exceed the output limits [%v, %v]", c.iMin, c.iMax, c.outMin, c.outMax)
+	}
+	if c.outExp != 0 && (math.IsInf(c.outMin, 0) || math.IsInf(c.outMax, 0)) {
+		add(Warning, "output_exponent", "has no effect without finite output limits")
+	}
+	if c.i == 0 {
+		if c.iBand > 0 {
+			add(Warning, "integral_separation", "has no effect without integral gain")
+		}
+		if c.leak > 0 {
+			add(Warning, "integral_leak", "has no effect without integral gain")
+		}
+	}
+	if c.band > 0 && c.approach == [3]float64{} {
+		add(Warning, "approach_gains", "band %v set but all approach gains are 0", c.band)
+	}
+	if n := c.nudge; n.Min != 0 || n.Max != 0 {
+		if n.Min > n.Max {
+			add(Invalid, "nudge_bounds", "min %v is greater than max %v", n.Min, n.Max)
+		} else if c.target < n.Min || c.target > n.Max {
+			add(Warning, "setpoint", "%v outside of the nudge bounds [%v, %v]", c.target, n.Min, n.Max)
+		}
+		if c.rampRate > n.Max-n.Min && n.Max > n.Min {
+			add(Warning, "setpoint_ramp", "%v per second traverses the whole setpoint range [%v, %v] in less than a second", c.rampRate, n.Min, n.Max)
+		}
+	}
+	if q := c.quantum; q > 0 && !math.IsInf(c.outMin, 0) && !math.IsInf(c.outMax, 0) {
+		if q > c.outMax-c.outMin {
+			add(Warning, "output_quantization", "step %v exceeds the output range [%v, %v]", q, c.outMin, c.outMax)
+		} else if offGrid(c.outMin, q) || offGrid(c.outMax, q) {
+			add(Warning, "output_quantization", "output limits [%v, %v] are not multiples of the step %v, clamped outputs fall between levels", c.outMin, c.outMax, q)
+		}
+	}
+	// with a sample time, the output is computed once the accumulated
+	// update intervals reach it
+	step, stepName := c.interval, "update interval"
+	if c.sampleTime > step {
+		step, stepName = c.sampleTime, "sample time"
+		if c.interval > 0 && c.sampleTime%c.interval != 0 {
+			step, stepName = (c.sampleTime/c.interval+1)*c.interval, "computation period"
+		}
+	}
+	if tf := c.disc.DerivativeFilter; tf > 0 && step > 0 {
+		if c.disc.Derivative == DerivativeTustin && 2*tf < step {
+			add(Invalid, "derivative_filter", "%v is shorter than half the %s %v, the Tustin filter alternates sign", tf, stepName, step)
+		} else if tf < step {
+			add(Warning, "derivative_filter", "%v is shorter than the %s %v and barely filters", tf, stepName, step)
+		}
+	}
+	if c.sampleTime > 0 && c.interval > 0 && c.sampleTime%c.interval != 0 {
+		add(Warning, "sample_time", "%v is not a multiple of the update interval %v, the output is computed every %v", c.sampleTime, c.interval, (c.sampleTime/c.interval+1)*c.interval)
+	}
+	if c.stale.timeout > 0 && c.stale.action == StaleFailsafe && (c.stale.failsafe < c.outMin || c.stale.failsafe > c.outMax) {
+		add(Warning, "stale_timeout", "failsafe output %v outside of the output limits [%v, %v]", c.stale.failsafe, c.outMin, c.outMax)
+	}
+	return is
+}
+
+// offGrid returns true if v is not a multiple of step.
+func offGrid(v, step float64) bool {
+	return math.Abs(math.Remainder(v, step)) > 1e-9*step
+}
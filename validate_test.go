@@ -0,0 +1,119 @@
+package pidctrl
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	if is := NewPIDController(1, 0.1, 0.01).SetOutputLimits(0, 100).Validate(); len(is) != 0 {
+		t.Errorf("unexpected issues %v", is)
+	}
+	c := NewPIDController(1, -0.1, 0).SetOutputLimits(0, 100).SetIntegralLimits(10, 200).
+		SetOutputExponent(2).SetIntegralLeak(time.Second)
+	c.SetStaleTimeout(time.Second, StaleFailsafe, -5, nil)
+	c.SetNudgeOptions(NudgeOptions{Min: 30, Max: 20})
+	fields := map[string]Severity{}
+	for _, i := range c.Validate() {
+		if i.Severity >= fields[i.Field] {
+			fields[i.Field] = i.Severity
+		}
+	}
+	for field, want := range map[string]Severity{
+		"gains":           Warning,
+		"integral_limits": Invalid,
+		"nudge_bounds":    Invalid,
+		"stale_timeout":   Warning,
+	} {
+		if got, ok := fields[field]; !ok || got != want {
+			t.Errorf("%s: got %v, %v, want %v", field, got, ok, want)
+		}
+	}
+	if err := c.Validate().Err(); err == nil {
+		t.Error("expected error")
+	}
+	if is := NewPIDController(math.NaN(), 0, 0).Validate(); is.Err() == nil {
+		t.Error("NaN gain not rejected")
+	}
+}
+
+func TestValidate_DerivativeFilter(t *testing.T) {
+	for _, test := range []struct {
+		method DerivativeMethod
+		filter time.Duration
+		issues Issues
+	}{
+		{DerivativeTustin, time.Second, nil},
+		{DerivativeBackward, 40 * time.Millisecond, Issues{{Warning, "derivative_filter", "40ms is shorter than the update interval 100ms and barely filters"}}},
+		{DerivativeTustin, 80 * time.Millisecond, Issues{{Warning, "derivative_filter", "80ms is shorter than the update interval 100ms and barely filters"}}},
+		{DerivativeTustin, 40 * time.Millisecond, Issues{{Invalid, "derivative_filter", "40ms is shorter than half the update interval 100ms, the Tustin filter alternates sign"}}},
+	} {
+		c := NewPIDController(1, 0, 0.1).SetInterval(100 * time.Millisecond).
+			SetDiscretization(Discretization{Derivative: test.method, DerivativeFilter: test.filter})
+		if is := c.Validate(); !reflect.DeepEqual(is, test.issues) {
+			t.Errorf("%v %v: %v, want %v", test.method, test.filter, is, test.issues)
+		}
+	}
+	c := NewPIDController(1, 0, 0.1).SetDiscretization(Discretization{DerivativeFilter: time.Millisecond})
+	if is := c.Validate(); len(is) != 0 {
+		t.Errorf("issues without an update interval %v", is)
+	}
+}
+
+func TestValidate_SampleTime(t *testing.T) {
+	c := NewPIDController(1, 0, 0.1).SetSampleTime(100 * time.Millisecond).
+		SetDiscretization(Discretization{Derivative: DerivativeTustin, DerivativeFilter: 40 * time.Millisecond})
+	want := Issues{{Invalid, "derivative_filter", "40ms is shorter than half the sample time 100ms, the Tustin filter alternates sign"}}
+	if is := c.Validate(); !reflect.DeepEqual(is, want) {
+		t.Errorf("%v, want %v", is, want)
+	}
+	// the sample time, not the faster interval, limits the filter
+	c.SetInterval(10 * time.Millisecond)
+	if is := c.Validate(); !reflect.DeepEqual(is, want) {
+		t.Errorf("with interval: %v, want %v", is, want)
+	}
+	c.SetInterval(30 * time.Millisecond).SetDiscretization(Discretization{DerivativeFilter: 110 * time.Millisecond})
+	want = Issues{
+		{Warning, "derivative_filter", "110ms is shorter than the computation period 120ms and barely filters"},
+		{Warning, "sample_time", "100ms is not a multiple of the update interval 30ms, the output is computed every 120ms"},
+	}
+	if is := c.Validate(); !reflect.DeepEqual(is, want) {
+		t.Errorf("%v, want %v", is, want)
+	}
+}
+
+func TestValidate_Quantization(t *testing.T) {
+	for _, test := range []struct {
+		step, min, max float64
+		issues         Issues
+	}{
+		{25, 0, 100, nil},
+		{0.1, -0.5, 0.7, nil},
+		{30, 0, 100, Issues{{Warning, "output_quantization", "output limits [0, 100] are not multiples of the step 30, clamped outputs fall between levels"}}},
+		{150, 0, 100, Issues{{Warning, "output_quantization", "step 150 exceeds the output range [0, 100]"}}},
+	} {
+		c := NewPIDController(1, 0, 0).SetOutputLimits(test.min, test.max).SetOutputQuantization(test.step, 0)
+		if is := c.Validate(); !reflect.DeepEqual(is, test.issues) {
+			t.Errorf("step %v in [%v, %v]: %v, want %v", test.step, test.min, test.max, is, test.issues)
+		}
+	}
+	if is := NewPIDController(1, 0, 0).SetOutputQuantization(30, 0).Validate(); len(is) != 0 {
+		t.Errorf("issues without output limits %v", is)
+	}
+}
+
+func TestLoop_StartValidates(t *testing.T) {
+	l := &Loop{
+		Controller: NewSafePIDControllerFrom(NewPIDController(math.Inf(1), 0, 0)),
+		Interval:   time.Millisecond,
+		Read:       func() float64 { return 0 },
+		Write:      func(float64) {},
+	}
+	if err := l.Start(context.Background()); err == nil {
+		l.Stop(context.Background())
+		t.Error("loop with invalid configuration started")
+	}
+}
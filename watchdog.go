@@ -0,0 +1,122 @@
+package pidctrl
+
+import (
+	"sync"
+	"time"
+)
+
+// StaleAction selects the output of a controller with a stale measurement.
+type StaleAction int
+
+const (
+	// StaleHold freezes the output at its last value.
+	StaleHold StaleAction = iota
+	// StaleFailsafe outputs the configured failsafe value, e.g. 0.
+	StaleFailsafe
+)
+
+// staleWatch detects a process value that stopped changing.
+type staleWatch struct {
+	timeout  time.Duration // 0 disables detection
+	action   StaleAction
+	failsafe float64
+	callback func(bool)    // optional, called when the state changes
+	since    time.Duration // time without a change of the value
+	last     float64       // last raw process value
+	seen     bool          // last is valid
+	active   bool          // measurement stale
+}
+
+// SetStaleTimeout enables detection of a stale measurement: once the raw
+// process value passed to UpdateDuration did not change at all for timeout,
+// as happens when a sensor driver returns its last reading after a dropout,
+// the measurement counts as stale. While stale the integral is frozen and
+// the output is held or replaced by failsafe, depending on action. f is
+// called with true when the measurement becomes stale and with false when it
+// changes again; it may be nil. A timeout of 0 disables detection.
+//
+// Use a Watchdog to detect that updates stopped altogether.
+func (c *PIDController) SetStaleTimeout(timeout time.Duration, action StaleAction, failsafe float64, f func(stale bool)) *PIDController {
+	c.stale = staleWatch{timeout: timeout, action: action, failsafe: failsafe, callback: f}
+	return c
+}
+
+// Stale returns true while the measurement is stale.
+func (c *PIDController) Stale() bool {
+	return c.stale.active
+}
+
+// update feeds a raw process value into the detector and reports whether
+// the measurement is stale.
+func (s *staleWatch) update(value float64, duration time.Duration) bool {
+	if s.timeout == 0 {
+		return false
+	}
+	if s.seen && value == s.last {
+		s.since += duration
+	} else {
+		s.since = 0
+	}
+	s.last, s.seen = value, true
+	if stale := s.since >= s.timeout; stale != s.active {
+		s.active = stale
+		if s.callback != nil {
+			s.callback(stale)
+		}
+	}
+	return s.active
+}
+
+// output returns the output while stale given the previous output.
+func (s *staleWatch) output(prev float64) float64 {
+	if s.action == StaleFailsafe {
+		return s.failsafe
+	}
+	return prev
+}
+
+// Watchdog calls a function when it was not kicked for a timeout, to detect
+// control loops that stopped updating, e.g. because the goroutine reading
+// the sensor hangs. Kick it from the loop or from an observer:
+//
+//	w := pidctrl.NewWatchdog(5*time.Second, failsafe)
+//	c.SetObserver(func(pidctrl.UpdateInfo) { w.Kick() })
+type Watchdog struct {
+	mu      sync.Mutex
+	timer   *time.Timer
+	timeout time.Duration
+	expired bool
+}
+
+// NewWatchdog returns a running Watchdog calling f in its own goroutine
+// when it is not kicked within timeout. f is called once per expiry.
+func NewWatchdog(timeout time.Duration, f func()) *Watchdog {
+	w := &Watchdog{timeout: timeout}
+	w.timer = time.AfterFunc(timeout, func() {
+		w.mu.Lock()
+		w.expired = true
+		w.mu.Unlock()
+		f()
+	})
+	return w
+}
+
+// Kick restarts the timeout.
+func (w *Watchdog) Kick() {
+	w.mu.Lock()
+	w.expired = false
+	w.mu.Unlock()
+	w.timer.Reset(w.timeout)
+}
+
+// Expired returns true if the timeout expired since the last kick.
+func (w *Watchdog) Expired() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.expired
+}
+
+// Stop stops the watchdog.
+func (w *Watchdog) Stop() {
+	w.timer.Stop()
+}
@@ -0,0 +1,66 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStaleTimeout(t *testing.T) {
+	var events []bool
+	c := NewPIDController(1, 1, 0).Set(10).SetStaleTimeout(3*time.Second, StaleHold, 0, func(stale bool) {
+		events = append(events, stale)
+	})
+	c.UpdateDuration(5, time.Second)
+	var outputs []float64
+	for i := 0; i < 10; i++ {
+		outputs = append(outputs, c.UpdateDuration(6, time.Second))
+	}
+	// stale once unchanged for 3s, holding the output of the update before
+	for i := 3; i < len(outputs); i++ {
+		if outputs[i] != outputs[2] {
+			t.Fatalf("held output changed: %v", outputs)
+		}
+	}
+	if !c.Stale() {
+		t.Fatal("measurement not stale")
+	}
+	integral := c.Terms().I
+	c.UpdateDuration(6, time.Second)
+	if c.Terms().I != integral {
+		t.Error("integral not frozen while stale")
+	}
+	c.UpdateDuration(6.5, time.Second)
+	if c.Stale() {
+		t.Error("still stale after the value changed")
+	}
+	if len(events) != 2 || !events[0] || events[1] {
+		t.Errorf("events %v", events)
+	}
+
+	c = NewPIDController(1, 0, 0).Set(10).SetStaleTimeout(time.Second, StaleFailsafe, -1, nil)
+	c.UpdateDuration(5, time.Second)
+	if out := c.UpdateDuration(5, time.Second); out != -1 {
+		t.Errorf("failsafe output %v != -1", out)
+	}
+}
+
+func TestWatchdog(t *testing.T) {
+	fired := make(chan struct{}, 1)
+	w := NewWatchdog(20*time.Millisecond, func() { fired <- struct{}{} })
+	defer w.Stop()
+	for i := 0; i < 5; i++ {
+		time.Sleep(5 * time.Millisecond)
+		w.Kick()
+	}
+	if w.Expired() {
+		t.Fatal("expired while kicked")
+	}
+	select {
+	case <-fired:
+	case <-time.After(time.Second):
+		t.Fatal("watchdog did not fire")
+	}
+	if !w.Expired() {
+		t.Error("not expired after firing")
+	}
+}
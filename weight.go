@@ -0,0 +1,60 @@
+package pidctrl
+
+import "math"
+
+// SetDerivativeSetpointWeight sets how much of the setpoint change enters
+// the derivative term: 0 (the default) differentiates the measurement only,
+// avoiding derivative kick on setpoint changes, 1 differentiates the full
+// error. Values in between give partial setpoint derivative action, which
+// improves tracking of moving setpoints in servo applications.
+func (c *PIDController) SetDerivativeSetpointWeight(weight float64) *PIDController {
+	c.dWeight = math.Max(0, math.Min(1, weight))
+	return c
+}
+
+// DerivativeSetpointWeight returns the setpoint weight of the derivative
+// term.
+func (c *PIDController) DerivativeSetpointWeight() float64 {
+	return c.dWeight
+}
+
+// SetProportionalSetpointWeight sets the setpoint weight b of the
+// proportional term, which becomes Kp·(b·setpoint - value): 1 (the default)
+// acts on the full error, 0 on the measurement only, so that setpoint
+// changes move the output through the integral alone instead of kicking it.
+func (c *PIDController) SetProportionalSetpointWeight(weight float64) *PIDController {
+	c.pWeight = math.Max(0, math.Min(1, weight))
+	return c
+}
+
+// ProportionalSetpointWeight returns the setpoint weight of the
+// proportional term.
+func (c *PIDController) ProportionalSetpointWeight() float64 {
+	return c.pWeight
+}
+
+// Structure selects which terms act on the error and which on the
+// measurement only.
+type Structure int
+
+// Controller structures.
+const (
+	StructurePID       Structure = iota // P, I and D on the error
+	StructurePIOnError                  // PI-D: P and I on the error, D on the measurement (the default)
+	StructureIOnError                   // I-PD: I on the error, P and D on the measurement
+)
+
+// SetStructure sets the proportional and derivative setpoint weights to the
+// given structure. I-PD avoids the proportional kick on setpoint steps
+// without a setpoint ramp.
+func (c *PIDController) SetStructure(s Structure) *PIDController {
+	switch s {
+	case StructurePID:
+		c.pWeight, c.dWeight = 1, 1
+	case StructurePIOnError:
+		c.pWeight, c.dWeight = 1, 0
+	case StructureIOnError:
+		c.pWeight, c.dWeight = 0, 0
+	}
+	return c
+}
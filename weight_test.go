@@ -0,0 +1,50 @@
+package pidctrl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDerivativeSetpointWeight(t *testing.T) {
+	for _, test := range []struct {
+		weight, output float64
+	}{
+		{0, 0},
+		{0.5, 5},
+		{1, 10},
+	} {
+		c := NewPIDController(0, 0, 1).SetDerivativeSetpointWeight(test.weight)
+		c.UpdateDuration(0, time.Second)
+		c.Set(10)
+		if out := c.UpdateDuration(0, time.Second); out != test.output {
+			t.Errorf("weight %v: output %v != %v", test.weight, out, test.output)
+		}
+	}
+}
+
+func TestStructure(t *testing.T) {
+	for _, test := range []struct {
+		s      Structure
+		output float64 // output right after a setpoint step from 0 to 10
+	}{
+		{StructurePID, 20 + 10 + 10},
+		{StructurePIOnError, 20 + 10},
+		{StructureIOnError, 10},
+	} {
+		c := NewPIDController(2, 1, 1).SetStructure(test.s)
+		c.UpdateDuration(0, time.Second)
+		c.Set(10)
+		if out := c.UpdateDuration(0, time.Second); out != test.output {
+			t.Errorf("structure %v: output %v != %v", test.s, out, test.output)
+		}
+	}
+	// I-PD still removes the steady-state error
+	c := NewPIDController(0.5, 0.5, 0).SetStructure(StructureIOnError).Set(10)
+	value := 0.0
+	for i := 0; i < 200; i++ {
+		value += (c.UpdateDuration(value, time.Second) - value) * 0.5
+	}
+	if value < 9.99 || value > 10.01 {
+		t.Errorf("I-PD settled at %v", value)
+	}
+}